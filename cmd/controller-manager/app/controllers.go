@@ -34,6 +34,9 @@ import (
 	iamv1alpha2 "kubesphere.io/api/iam/v1alpha2"
 
 	"kubesphere.io/kubesphere/cmd/controller-manager/app/options"
+	"kubesphere.io/kubesphere/pkg/apiserver/auditing"
+	auditv1alpha1 "kubesphere.io/kubesphere/pkg/apiserver/auditing/v1alpha1"
+	"kubesphere.io/kubesphere/pkg/controller/accessrequest"
 	"kubesphere.io/kubesphere/pkg/controller/alerting"
 	"kubesphere.io/kubesphere/pkg/controller/application"
 	"kubesphere.io/kubesphere/pkg/controller/certificatesigningrequest"
@@ -109,6 +112,7 @@ var allControllers = []string{
 	"globalrolebinding",
 	"groupbinding",
 	"group",
+	"accessrequest",
 	"notification",
 	"pvcworkloadrestarter",
 	"rulegroup",
@@ -469,6 +473,17 @@ func addAllControllers(mgr manager.Manager, client k8s.Client, informerFactory i
 		addController(mgr, "group", groupController)
 	}
 
+	// "accessrequest" controller
+	if cmOptions.IsControllerEnabled("accessrequest") {
+		accessRequestReconciler := &accessrequest.Reconciler{}
+		if cmOptions.AuditingOptions != nil && cmOptions.AuditingOptions.Enable {
+			auditingEvents := make(chan *auditv1alpha1.Event, auditing.DefaultCacheCapacity)
+			auditing.NewBackend(cmOptions.AuditingOptions, auditingEvents, stopCh)
+			accessRequestReconciler.AuditingEvents = auditingEvents
+		}
+		addControllerWithSetup(mgr, "accessrequest", accessRequestReconciler)
+	}
+
 	// "cluster" controller
 	if cmOptions.IsControllerEnabled("cluster") {
 		if cmOptions.MultiClusterOptions.Enable {
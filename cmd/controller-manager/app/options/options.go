@@ -32,6 +32,7 @@ import (
 	"kubesphere.io/kubesphere/pkg/apiserver/authentication"
 	controllerconfig "kubesphere.io/kubesphere/pkg/apiserver/config"
 	"kubesphere.io/kubesphere/pkg/simple/client/alerting"
+	"kubesphere.io/kubesphere/pkg/simple/client/auditing"
 	"kubesphere.io/kubesphere/pkg/simple/client/devops/jenkins"
 	"kubesphere.io/kubesphere/pkg/simple/client/gateway"
 	"kubesphere.io/kubesphere/pkg/simple/client/k8s"
@@ -55,6 +56,7 @@ type KubeSphereControllerManagerOptions struct {
 	GatewayOptions        *gateway.Options
 	MonitoringOptions     *prometheus.Options
 	AlertingOptions       *alerting.Options
+	AuditingOptions       *auditing.Options
 	LeaderElect           bool
 	LeaderElection        *leaderelection.LeaderElectionConfig
 	WebhookCertDir        string
@@ -94,6 +96,7 @@ func NewKubeSphereControllerManagerOptions() *KubeSphereControllerManagerOptions
 		AuthenticationOptions: authentication.NewOptions(),
 		GatewayOptions:        gateway.NewGatewayOptions(),
 		AlertingOptions:       alerting.NewAlertingOptions(),
+		AuditingOptions:       auditing.NewAuditingOptions(),
 		LeaderElection: &leaderelection.LeaderElectionConfig{
 			LeaseDuration: 30 * time.Second,
 			RenewDeadline: 15 * time.Second,
@@ -121,6 +124,7 @@ func (s *KubeSphereControllerManagerOptions) Flags(allControllerNameSelectors []
 	s.ServiceMeshOptions.AddFlags(fss.FlagSet("servicemesh"), s.ServiceMeshOptions)
 	s.GatewayOptions.AddFlags(fss.FlagSet("gateway"), s.GatewayOptions)
 	s.AlertingOptions.AddFlags(fss.FlagSet("alerting"), s.AlertingOptions)
+	s.AuditingOptions.AddFlags(fss.FlagSet("auditing"), s.AuditingOptions)
 	fs := fss.FlagSet("leaderelection")
 	s.bindLeaderElectionFlags(s.LeaderElection, fs)
 
@@ -238,4 +242,5 @@ func (s *KubeSphereControllerManagerOptions) MergeConfig(cfg *controllerconfig.C
 	s.GatewayOptions = cfg.GatewayOptions
 	s.MonitoringOptions = cfg.MonitoringOptions
 	s.AlertingOptions = cfg.AlertingOptions
+	s.AuditingOptions = cfg.AuditingOptions
 }
@@ -67,6 +67,7 @@ func NewControllerManagerCommand() *cobra.Command {
 			GatewayOptions:        conf.GatewayOptions,
 			MonitoringOptions:     conf.MonitoringOptions,
 			AlertingOptions:       conf.AlertingOptions,
+			AuditingOptions:       conf.AuditingOptions,
 			LeaderElection:        s.LeaderElection,
 			LeaderElect:           s.LeaderElect,
 			WebhookCertDir:        s.WebhookCertDir,
@@ -49,7 +49,9 @@ import (
 	"kubesphere.io/kubesphere/pkg/simple/client/devops/jenkins"
 	eventsclient "kubesphere.io/kubesphere/pkg/simple/client/events/elasticsearch"
 	"kubesphere.io/kubesphere/pkg/simple/client/k8s"
+	"kubesphere.io/kubesphere/pkg/simple/client/logging"
 	esclient "kubesphere.io/kubesphere/pkg/simple/client/logging/elasticsearch"
+	lokiclient "kubesphere.io/kubesphere/pkg/simple/client/logging/loki"
 	"kubesphere.io/kubesphere/pkg/simple/client/monitoring/metricsserver"
 	"kubesphere.io/kubesphere/pkg/simple/client/monitoring/prometheus"
 	"kubesphere.io/kubesphere/pkg/simple/client/sonarqube"
@@ -131,8 +133,15 @@ func (s *ServerRunOptions) NewAPIServer(stopCh <-chan struct{}) (*apiserver.APIS
 	apiServer.MetricsClient = metricsserver.NewMetricsClient(kubernetesClient.Kubernetes(), s.KubernetesOptions)
 
 	if s.LoggingOptions.Host != "" {
-		if apiServer.LoggingClient, err = esclient.NewClient(s.LoggingOptions); err != nil {
-			return nil, fmt.Errorf("failed to connect to elasticsearch, please check elasticsearch status, error: %v", err)
+		switch s.LoggingOptions.Backend {
+		case logging.BackendLoki:
+			if apiServer.LoggingClient, err = lokiclient.NewClient(s.LoggingOptions); err != nil {
+				return nil, fmt.Errorf("failed to connect to loki, please check loki status, error: %v", err)
+			}
+		default:
+			if apiServer.LoggingClient, err = esclient.NewClient(s.LoggingOptions); err != nil {
+				return nil, fmt.Errorf("failed to connect to elasticsearch, please check elasticsearch status, error: %v", err)
+			}
 		}
 	}
 
@@ -216,7 +225,7 @@ func (s *ServerRunOptions) NewAPIServer(stopCh <-chan struct{}) (*apiserver.APIS
 		klog.Fatalf("unable to create controller runtime client: %v", err)
 	}
 
-	apiServer.Issuer, err = token.NewIssuer(s.AuthenticationOptions)
+	apiServer.Issuer, err = token.NewIssuerWithKeyCache(s.AuthenticationOptions, apiServer.CacheClient)
 	if err != nil {
 		klog.Fatalf("unable to create issuer: %v", err)
 	}
@@ -24,6 +24,16 @@ import (
 type ListResult struct {
 	Items      []interface{} `json:"items"`
 	TotalItems int           `json:"totalItems"`
+	// Continue is set if there are more items, pass it as the continue parameter to fetch the next page
+	Continue string `json:"continue,omitempty"`
+}
+
+// AggregatedListResult is the list result merged from multiple clusters
+type AggregatedListResult struct {
+	Items      []interface{} `json:"items"`
+	TotalItems int           `json:"totalItems"`
+	// Failures are the errors of the clusters failed to list, keyed by the cluster names
+	Failures map[string]string `json:"failures,omitempty"`
 }
 
 type ResourceQuota struct {
@@ -58,7 +58,9 @@ import (
 	"kubesphere.io/kubesphere/pkg/apiserver/authorization/authorizerfactory"
 	"kubesphere.io/kubesphere/pkg/apiserver/authorization/path"
 	"kubesphere.io/kubesphere/pkg/apiserver/authorization/rbac"
+	"kubesphere.io/kubesphere/pkg/apiserver/authorization/scope"
 	unionauthorizer "kubesphere.io/kubesphere/pkg/apiserver/authorization/union"
+	"kubesphere.io/kubesphere/pkg/apiserver/authorization/webhook"
 	apiserverconfig "kubesphere.io/kubesphere/pkg/apiserver/config"
 	"kubesphere.io/kubesphere/pkg/apiserver/filters"
 	"kubesphere.io/kubesphere/pkg/apiserver/request"
@@ -165,6 +167,9 @@ type APIServer struct {
 	ClusterClient clusterclient.ClusterClients
 
 	OpenpitrixClient openpitrix.Interface
+
+	// authorizer of the requests, shared with the APIs authorizing the objects they return
+	authorizer authorizer.Authorizer
 }
 
 func (s *APIServer) PrepareRun(stopCh <-chan struct{}) error {
@@ -175,9 +180,14 @@ func (s *APIServer) PrepareRun(stopCh <-chan struct{}) error {
 	s.container.RecoverHandler(func(panicReason interface{}, httpWriter http.ResponseWriter) {
 		logStackOnRecover(panicReason, httpWriter)
 	})
+	var err error
+	if s.authorizer, err = s.buildAuthorizer(); err != nil {
+		return err
+	}
 	s.installDynamicResourceAPI()
 	s.installKubeSphereAPIs(stopCh)
 	s.installMetricsAPI()
+	token.RunKeyRotation(s.Issuer, stopCh)
 	s.installHealthz()
 
 	for _, ws := range s.container.RegisteredWebServices() {
@@ -185,7 +195,9 @@ func (s *APIServer) PrepareRun(stopCh <-chan struct{}) error {
 	}
 
 	s.Server.Handler = s.container
-	s.buildHandlerChain(stopCh)
+	if err := s.buildHandlerChain(stopCh); err != nil {
+		return err
+	}
 
 	return nil
 }
@@ -211,11 +223,13 @@ func (s *APIServer) installMetricsAPI() {
 //
 //	any attempt to list objects using listers will get empty results.
 func (s *APIServer) installKubeSphereAPIs(stopCh <-chan struct{}) {
+	passwordPolicy, err := auth.NewPasswordPolicy(s.Config.AuthenticationOptions.PasswordPolicy)
+	urlruntime.Must(err)
 	imOperator := im.NewOperator(s.KubernetesClient.KubeSphere(),
 		user.New(s.InformerFactory.KubeSphereSharedInformerFactory(),
 			s.InformerFactory.KubernetesSharedInformerFactory()),
 		loginrecord.New(s.InformerFactory.KubeSphereSharedInformerFactory()),
-		s.Config.AuthenticationOptions)
+		s.Config.AuthenticationOptions, passwordPolicy)
 	amOperator := am.NewOperator(s.KubernetesClient.KubeSphere(),
 		s.KubernetesClient.Kubernetes(),
 		s.InformerFactory,
@@ -223,7 +237,7 @@ func (s *APIServer) installKubeSphereAPIs(stopCh <-chan struct{}) {
 	rbacAuthorizer := rbac.NewRBACAuthorizer(amOperator)
 
 	urlruntime.Must(configv1alpha2.AddToContainer(s.container, s.Config))
-	urlruntime.Must(resourcev1alpha3.AddToContainer(s.container, s.InformerFactory, s.RuntimeCache))
+	urlruntime.Must(resourcev1alpha3.AddToContainer(s.container, s.InformerFactory, s.RuntimeCache, s.authorizer))
 	urlruntime.Must(monitoringv1alpha3.AddToContainer(s.container, s.KubernetesClient.Kubernetes(), s.MonitoringClient, s.MetricsClient, s.InformerFactory, s.OpenpitrixClient, s.RuntimeClient))
 	urlruntime.Must(meteringv1alpha1.AddToContainer(s.container, s.KubernetesClient.Kubernetes(), s.MonitoringClient, s.InformerFactory, s.RuntimeCache, s.Config.MeteringOptions, s.OpenpitrixClient, s.RuntimeClient))
 	urlruntime.Must(openpitrixv1.AddToContainer(s.container, s.InformerFactory, s.KubernetesClient.KubeSphere(), s.Config.OpenPitrixOptions, s.OpenpitrixClient))
@@ -243,16 +257,24 @@ func (s *APIServer) installKubeSphereAPIs(stopCh <-chan struct{}) {
 		s.Config.MultiClusterOptions.ProxyPublishService,
 		s.Config.MultiClusterOptions.ProxyPublishAddress,
 		s.Config.MultiClusterOptions.AgentImage))
+	sessionOperator := auth.NewSessionOperator(s.CacheClient)
+	multiFactorAuthenticator := auth.NewMultiFactorAuthenticator(s.KubernetesClient.KubeSphere(), s.CacheClient, s.Config.AuthenticationOptions)
 	urlruntime.Must(iamapi.AddToContainer(s.container, imOperator, amOperator,
 		group.New(s.InformerFactory, s.KubernetesClient.KubeSphere(), s.KubernetesClient.Kubernetes()),
-		rbacAuthorizer))
+		rbacAuthorizer, multiFactorAuthenticator, auth.NewAccessTokenOperator(s.CacheClient, s.Issuer), sessionOperator, s.Issuer, rbacAuthorizer,
+		am.NewAccessRequestOperator(s.RuntimeClient, amOperator)))
 
 	userLister := s.InformerFactory.KubeSphereSharedInformerFactory().Iam().V1alpha2().Users().Lister()
+	globalRoleBindingLister := s.InformerFactory.KubeSphereSharedInformerFactory().Iam().V1alpha2().GlobalRoleBindings().Lister()
 	urlruntime.Must(oauth.AddToContainer(s.container, imOperator,
 		auth.NewTokenOperator(s.CacheClient, s.Issuer, s.Config.AuthenticationOptions),
-		auth.NewPasswordAuthenticator(s.KubernetesClient.KubeSphere(), userLister, s.Config.AuthenticationOptions),
-		auth.NewOAuthAuthenticator(s.KubernetesClient.KubeSphere(), userLister, s.Config.AuthenticationOptions),
+		auth.NewPasswordAuthenticator(s.KubernetesClient.KubeSphere(), userLister, globalRoleBindingLister, s.Config.AuthenticationOptions),
+		auth.NewOAuthAuthenticator(s.KubernetesClient.KubeSphere(), userLister, s.CacheClient, s.Config.AuthenticationOptions),
+		multiFactorAuthenticator,
+		auth.NewDeviceAuthorizer(s.CacheClient),
+		sessionOperator,
 		auth.NewLoginRecorder(s.KubernetesClient.KubeSphere(), userLister),
+		auth.NewLoginLimiter(s.CacheClient, s.Config.AuthenticationOptions.LoginLimiter),
 		s.Config.AuthenticationOptions))
 	urlruntime.Must(servicemeshv1alpha2.AddToContainer(s.Config.ServiceMeshOptions, s.container, s.KubernetesClient.Kubernetes(), s.CacheClient))
 	urlruntime.Must(networkv1alpha2.AddToContainer(s.container, s.Config.NetworkOptions.WeaveScopeHost))
@@ -304,7 +326,7 @@ func (s *APIServer) Run(ctx context.Context) (err error) {
 	return err
 }
 
-func (s *APIServer) buildHandlerChain(stopCh <-chan struct{}) {
+func (s *APIServer) buildHandlerChain(stopCh <-chan struct{}) error {
 	requestInfoResolver := &request.RequestInfoFactory{
 		APIPrefixes:          sets.New("api", "apis", "kapis", "kapi"),
 		GrouplessAPIPrefixes: sets.New("api", "kapi"),
@@ -312,6 +334,9 @@ func (s *APIServer) buildHandlerChain(stopCh <-chan struct{}) {
 			iamv1alpha2.Resource(iamv1alpha2.ResourcesPluralUser),
 			iamv1alpha2.Resource(iamv1alpha2.ResourcesPluralGlobalRole),
 			iamv1alpha2.Resource(iamv1alpha2.ResourcesPluralGlobalRoleBinding),
+			iamv1alpha2.Resource("signingkeys"),
+			iamv1alpha2.Resource("resourceaccessreviews"),
+			iamv1alpha2.Resource(iamv1alpha2.ResourcesPluralAccessRequest),
 			tenantv1alpha1.Resource(tenantv1alpha1.ResourcePluralWorkspace),
 			tenantv1alpha2.Resource(tenantv1alpha1.ResourcePluralWorkspace),
 			tenantv1alpha2.Resource(clusterv1alpha1.ResourcesPluralCluster),
@@ -340,23 +365,7 @@ func (s *APIServer) buildHandlerChain(stopCh <-chan struct{}) {
 			audit.NewAuditing(s.InformerFactory, s.Config.AuditingOptions, stopCh))
 	}
 
-	var authorizers authorizer.Authorizer
-
-	switch s.Config.AuthorizationOptions.Mode {
-	case authorization.AlwaysAllow:
-		authorizers = authorizerfactory.NewAlwaysAllowAuthorizer()
-	case authorization.AlwaysDeny:
-		authorizers = authorizerfactory.NewAlwaysDenyAuthorizer()
-	default:
-		fallthrough
-	case authorization.RBAC:
-		excludedPaths := []string{"/oauth/*", "/kapis/config.kubesphere.io/*", "/kapis/version", "/kapis/metrics", "/healthz"}
-		pathAuthorizer, _ := path.NewAuthorizer(excludedPaths)
-		amOperator := am.NewReadOnlyOperator(s.InformerFactory, s.DevopsClient)
-		authorizers = unionauthorizer.New(pathAuthorizer, rbac.NewRBACAuthorizer(amOperator))
-	}
-
-	handler = filters.WithAuthorization(handler, authorizers)
+	handler = filters.WithAuthorization(handler, s.authorizer)
 	if s.Config.MultiClusterOptions.Enable {
 		handler = filters.WithMulticluster(handler, s.ClusterClient)
 	}
@@ -369,14 +378,61 @@ func (s *APIServer) buildHandlerChain(stopCh <-chan struct{}) {
 		basictoken.New(basic.NewBasicAuthenticator(auth.NewPasswordAuthenticator(
 			s.KubernetesClient.KubeSphere(),
 			userLister,
+			s.InformerFactory.KubeSphereSharedInformerFactory().Iam().V1alpha2().GlobalRoleBindings().Lister(),
 			s.Config.AuthenticationOptions),
-			loginRecorder)),
+			loginRecorder,
+			auth.NewLoginLimiter(s.CacheClient, s.Config.AuthenticationOptions.LoginLimiter))),
 		bearertoken.New(jwt.NewTokenAuthenticator(
 			auth.NewTokenOperator(s.CacheClient, s.Issuer, s.Config.AuthenticationOptions),
 			userLister)))
 	handler = filters.WithAuthentication(handler, authn)
 	handler = filters.WithRequestInfo(handler, requestInfoResolver)
 	s.Server.Handler = handler
+	return nil
+}
+
+// buildAuthorizer builds the authorizer chain of the configured authorization mode
+func (s *APIServer) buildAuthorizer() (authorizer.Authorizer, error) {
+	var authorizers authorizer.Authorizer
+
+	switch s.Config.AuthorizationOptions.Mode {
+	case authorization.AlwaysAllow:
+		authorizers = authorizerfactory.NewAlwaysAllowAuthorizer()
+	case authorization.AlwaysDeny:
+		authorizers = authorizerfactory.NewAlwaysDenyAuthorizer()
+	case authorization.Webhook:
+		// the external policy is consulted before RBAC, so that it is able to deny the requests allowed by RBAC
+		webhookAuthorizer, err := webhook.NewAuthorizer(s.Config.AuthorizationOptions.Webhook)
+		if err != nil {
+			return nil, err
+		}
+		authorizers = unionauthorizer.New(s.pathAuthorizer(), webhookAuthorizer, s.rbacAuthorizer())
+	default:
+		fallthrough
+	case authorization.RBAC:
+		authorizers = unionauthorizer.New(s.pathAuthorizer(), s.rbacAuthorizer())
+	}
+
+	// the scopes of personal access tokens are enforced regardless of the authorization mode
+	namespaceLister := s.InformerFactory.KubernetesSharedInformerFactory().Core().V1().Namespaces().Lister()
+	authorizers = unionauthorizer.New(scope.NewAuthorizer(namespaceLister), authorizers)
+
+	return authorizers, nil
+}
+
+func (s *APIServer) pathAuthorizer() authorizer.Authorizer {
+	excludedPaths := []string{"/oauth/*", "/kapis/config.kubesphere.io/*", "/kapis/version", "/kapis/metrics", "/healthz",
+		// everyone is allowed to review the rules of their own
+		"/kapis/iam.kubesphere.io/v1alpha2/selfsubjectrulesreviews",
+		// the search hits are filtered by the authorizers of the handler chain, see buildAuthorizer
+		"/kapis/resources.kubesphere.io/v1alpha3/search"}
+	pathAuthorizer, _ := path.NewAuthorizer(excludedPaths)
+	return pathAuthorizer
+}
+
+func (s *APIServer) rbacAuthorizer() authorizer.Authorizer {
+	amOperator := am.NewReadOnlyOperator(s.InformerFactory, s.DevopsClient)
+	return rbac.NewRBACAuthorizer(amOperator)
 }
 
 func isResourceExists(apiResources []v1.APIResource, resource schema.GroupVersionResource) bool {
@@ -17,13 +17,14 @@ limitations under the License.
 package auditing
 
 import (
-	"bytes"
 	"context"
-	"crypto/tls"
 	"encoding/json"
-	"net/http"
+	"fmt"
+	"os"
+	"path/filepath"
 	"time"
 
+	"k8s.io/apiserver/pkg/apis/audit"
 	"k8s.io/klog/v2"
 
 	"kubesphere.io/kubesphere/pkg/apiserver/auditing/v1alpha1"
@@ -37,24 +38,47 @@ const (
 	DefaultBatchSize     = 100
 	DefaultBatchInterval = time.Second * 3
 	WebhookURL           = "https://kube-auditing-webhook-svc.kubesphere-logging-system.svc:6443/audit/webhook/event"
+	DefaultSinkName      = "webhook"
+	DefaultSpoolMaxSize  = 1 << 30
+	DefaultSpoolMaxAge   = time.Hour * 24 * 7
+)
+
+// ServiceCAFile is the in-cluster service CA verifying the certificate of the auditing webhook by default
+const ServiceCAFile = "/var/run/secrets/kubernetes.io/serviceaccount/ca.crt"
+
+var (
+	// RetryInitialInterval is the interval before the first retry of sending spooled events,
+	// it doubles after each failure up to RetryMaxInterval.
+	RetryInitialInterval = time.Second
+	RetryMaxInterval     = time.Minute
 )
 
 type Backend struct {
-	url                string
 	senderCh           chan interface{}
 	cache              chan *v1alpha1.Event
-	client             http.Client
 	sendTimeout        time.Duration
 	getSenderTimeout   time.Duration
 	eventBatchSize     int
 	eventBatchInterval time.Duration
 	stopCh             <-chan struct{}
+	outputs            []*output
+	// chain links the events by hash in the order they are dequeued, so that the events dropped
+	// before reaching the backend are not chain gaps, nil if hash chaining is disabled.
+	chain *hashChain
+}
+
+// output delivers the events to a sink independently of the other sinks.
+type output struct {
+	name  string
+	sink  Sink
+	level audit.Level
+	// spool persists the events until they are delivered, nil if the spool is disabled.
+	spool *spool
 }
 
 func NewBackend(opts *options.Options, cache chan *v1alpha1.Event, stopCh <-chan struct{}) *Backend {
 
 	b := Backend{
-		url:                opts.WebhookUrl,
 		getSenderTimeout:   GetSenderTimeout,
 		cache:              cache,
 		sendTimeout:        SendTimeout,
@@ -63,10 +87,6 @@ func NewBackend(opts *options.Options, cache chan *v1alpha1.Event, stopCh <-chan
 		stopCh:             stopCh,
 	}
 
-	if len(b.url) == 0 {
-		b.url = WebhookURL
-	}
-
 	if b.eventBatchInterval == 0 {
 		b.eventBatchInterval = DefaultBatchInterval
 	}
@@ -81,13 +101,49 @@ func NewBackend(opts *options.Options, cache chan *v1alpha1.Event, stopCh <-chan
 	}
 	b.senderCh = make(chan interface{}, sendersNum)
 
-	b.client = http.Client{
-		Transport: &http.Transport{
-			TLSClientConfig: &tls.Config{
-				InsecureSkipVerify: true,
-			},
-		},
-		Timeout: b.sendTimeout,
+	if opts.HashChainEnabled {
+		chain, err := newHashChain(opts.CheckpointSigningKeyFile, opts.CheckpointInterval)
+		if err != nil {
+			klog.Errorf("create auditing hash chain error, %s, events will not be chained", err)
+		} else {
+			b.chain = chain
+		}
+	}
+
+	sinks := opts.Sinks
+	if len(sinks) == 0 {
+		sinks = []options.SinkOptions{defaultSink(opts)}
+	}
+	for _, sinkOpts := range sinks {
+		sink, err := NewSink(sinkOpts)
+		if err != nil {
+			klog.Errorf("create auditing sink %s error, %s", sinkOpts.Name, err)
+			continue
+		}
+		o := &output{name: sinkOpts.Name, sink: sink, level: sinkLevel(sinkOpts)}
+
+		if len(opts.SpoolDir) > 0 {
+			// the default sink spools into the spool directory itself
+			dir := opts.SpoolDir
+			if len(opts.Sinks) > 0 {
+				dir = filepath.Join(opts.SpoolDir, sinkOpts.Name)
+			}
+			maxSize, maxAge := opts.SpoolMaxSize, opts.SpoolMaxAge
+			if maxSize == 0 {
+				maxSize = DefaultSpoolMaxSize
+			}
+			if maxAge == 0 {
+				maxAge = DefaultSpoolMaxAge
+			}
+			s, err := newSpool(o.name, dir, maxSize, maxAge)
+			if err != nil {
+				klog.Errorf("open auditing spool %s error, %s, events will not be spooled", dir, err)
+			} else {
+				o.spool = s
+				go b.replay(o)
+			}
+		}
+		b.outputs = append(b.outputs, o)
 	}
 
 	go b.worker()
@@ -95,6 +151,29 @@ func NewBackend(opts *options.Options, cache chan *v1alpha1.Event, stopCh <-chan
 	return &b
 }
 
+// defaultSink posts the events to the auditing webhook, its certificate is verified by the configured CA,
+// or the in-cluster service CA if it exists.
+func defaultSink(opts *options.Options) options.SinkOptions {
+	url := opts.WebhookUrl
+	if len(url) == 0 {
+		url = WebhookURL
+	}
+	caFile := opts.WebhookCAFile
+	if len(caFile) == 0 {
+		if _, err := os.Stat(ServiceCAFile); err == nil {
+			caFile = ServiceCAFile
+		}
+	}
+	return options.SinkOptions{
+		Name: DefaultSinkName,
+		Type: options.SinkTypeWebhook,
+		Webhook: &options.WebhookSinkOptions{
+			URL: url,
+			TLS: &options.TLSOptions{CAFile: caFile, InsecureSkipVerify: opts.WebhookInsecureSkipVerify},
+		},
+	}
+}
+
 func (b *Backend) worker() {
 
 	for {
@@ -103,11 +182,25 @@ func (b *Backend) worker() {
 			break
 		}
 
-		if len(events.Items) == 0 {
-			continue
+		for _, o := range b.outputs {
+			events := applyLevel(events, o.level)
+			if len(events.Items) == 0 {
+				continue
+			}
+
+			if o.spool != nil {
+				b.spoolEvents(o, events)
+				continue
+			}
+
+			go b.sendEvents(o, events)
 		}
+	}
 
-		go b.sendEvents(events)
+	for _, o := range b.outputs {
+		if err := o.sink.Close(); err != nil {
+			klog.Errorf("close auditing sink %s error, %s", o.name, err)
+		}
 	}
 }
 
@@ -123,6 +216,11 @@ func (b *Backend) getEvents() *v1alpha1.EventList {
 			if event == nil {
 				break
 			}
+			if b.chain != nil {
+				if err := b.chain.link(event); err != nil {
+					klog.Errorf("link audit event %s error, %s", event.AuditID, err)
+				}
+			}
 			events.Items = append(events.Items, *event)
 			if len(events.Items) >= b.eventBatchSize {
 				return events
@@ -135,51 +233,37 @@ func (b *Backend) getEvents() *v1alpha1.EventList {
 	}
 }
 
-func (b *Backend) sendEvents(events *v1alpha1.EventList) {
+func (b *Backend) sendEvents(o *output, events *v1alpha1.EventList) {
 
 	ctx, cancel := context.WithTimeout(context.Background(), b.sendTimeout)
 	defer cancel()
 
-	stopCh := make(chan struct{})
+	errCh := make(chan error, 1)
 	skipReturnSender := false
 
 	send := func() {
-		ctx, cancel := context.WithTimeout(context.Background(), b.getSenderTimeout)
+		getSenderCtx, cancel := context.WithTimeout(context.Background(), b.getSenderTimeout)
 		defer cancel()
 
 		select {
-		case <-ctx.Done():
+		case <-getSenderCtx.Done():
 			klog.Error("Get auditing event sender timeout")
 			skipReturnSender = true
+			errCh <- fmt.Errorf("get sender timeout")
 			return
 		case b.senderCh <- struct{}{}:
 		}
 
 		start := time.Now()
 		defer func() {
-			stopCh <- struct{}{}
-			klog.V(8).Infof("send %d auditing logs used %d", len(events.Items), time.Since(start).Milliseconds())
+			klog.V(8).Infof("send %d auditing logs to %s used %d", len(events.Items), o.name, time.Since(start).Milliseconds())
 		}()
 
-		bs, err := b.eventToBytes(events)
-		if err != nil {
-			klog.Errorf("json marshal error, %s", err)
-			return
-		}
-
-		klog.V(8).Infof("%s", string(bs))
-
-		response, err := b.client.Post(b.url, "application/json", bytes.NewBuffer(bs))
+		err := o.sink.Send(ctx, events)
 		if err != nil {
-			klog.Errorf("send audit events error, %s", err)
-			return
-		}
-		defer response.Body.Close()
-
-		if response.StatusCode != http.StatusOK {
-			klog.Errorf("send audit events error[%d]", response.StatusCode)
-			return
+			klog.Errorf("send audit events to %s error, %s", o.name, err)
 		}
+		errCh <- err
 	}
 
 	go send()
@@ -192,12 +276,91 @@ func (b *Backend) sendEvents(events *v1alpha1.EventList) {
 
 	select {
 	case <-ctx.Done():
-		klog.Error("send audit events timeout")
-	case <-stopCh:
+		klog.Errorf("send audit events to %s timeout", o.name)
+		eventsDropped.WithLabelValues(o.name, dropReasonSendFailed).Add(float64(len(events.Items)))
+	case err := <-errCh:
+		if err != nil && isRejected(err) {
+			eventsDropped.WithLabelValues(o.name, dropReasonRejected).Add(float64(len(events.Items)))
+		} else if err != nil {
+			eventsDropped.WithLabelValues(o.name, dropReasonSendFailed).Add(float64(len(events.Items)))
+		} else {
+			eventsSent.WithLabelValues(o.name).Add(float64(len(events.Items)))
+		}
+	}
+}
+
+// spoolEvents writes the events into the spool of the output, they are sent by replay.
+func (b *Backend) spoolEvents(o *output, events *v1alpha1.EventList) {
+
+	bs, err := eventToBytes(events)
+	if err != nil {
+		klog.Errorf("json marshal error, %s", err)
+		eventsDropped.WithLabelValues(o.name, dropReasonSendFailed).Add(float64(len(events.Items)))
+		return
+	}
+
+	if err := o.spool.append(len(events.Items), bs); err != nil {
+		klog.Errorf("spool audit events for %s error, %s", o.name, err)
+		eventsDropped.WithLabelValues(o.name, dropReasonSpoolError).Add(float64(len(events.Items)))
+	}
+}
+
+// replay sends the spooled events one batch at a time in the order they were spooled,
+// a batch is removed from the spool only after the sink accepted it.
+func (b *Backend) replay(o *output) {
+
+	interval := RetryInitialInterval
+	for {
+		seg, bs := o.spool.peek()
+		if seg == nil {
+			select {
+			case <-o.spool.notify:
+				continue
+			case <-b.stopCh:
+				return
+			}
+		}
+
+		events := &v1alpha1.EventList{}
+		if err := json.Unmarshal(bs, events); err != nil {
+			klog.Errorf("decode spooled audit events for %s error, %s", o.name, err)
+			o.spool.remove(seg)
+			eventsDropped.WithLabelValues(o.name, dropReasonSendFailed).Add(float64(seg.events))
+			continue
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), b.sendTimeout)
+		err := o.sink.Send(ctx, events)
+		cancel()
+		if err != nil && isRejected(err) {
+			// the rejected batch would block the following ones forever
+			klog.Errorf("send spooled audit events to %s error, %s, dropped", o.name, err)
+			interval = RetryInitialInterval
+			o.spool.remove(seg)
+			eventsDropped.WithLabelValues(o.name, dropReasonRejected).Add(float64(seg.events))
+			continue
+		}
+		if err != nil {
+			klog.Errorf("send spooled audit events to %s error, %s, retry after %s", o.name, err, interval)
+			select {
+			case <-time.After(interval):
+			case <-b.stopCh:
+				return
+			}
+			interval *= 2
+			if interval > RetryMaxInterval {
+				interval = RetryMaxInterval
+			}
+			continue
+		}
+
+		interval = RetryInitialInterval
+		o.spool.remove(seg)
+		eventsSent.WithLabelValues(o.name).Add(float64(seg.events))
 	}
 }
 
-func (b *Backend) eventToBytes(event *v1alpha1.EventList) ([]byte, error) {
+func eventToBytes(event *v1alpha1.EventList) ([]byte, error) {
 
 	bs, err := json.Marshal(event)
 	if err != nil {
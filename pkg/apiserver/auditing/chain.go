@@ -0,0 +1,334 @@
+/*
+Copyright 2022 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auditing
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/util/keyutil"
+
+	auditv1alpha1 "kubesphere.io/kubesphere/pkg/apiserver/auditing/v1alpha1"
+)
+
+const DefaultCheckpointInterval = time.Minute
+
+// hashChain links the events emitted by the server, each event carries a sequence number
+// and the hash of the previous event, so that modified or deleted events can be detected.
+type hashChain struct {
+	id                 string
+	signer             crypto.Signer
+	checkpointInterval time.Duration
+
+	mutex          sync.Mutex
+	sequence       uint64
+	previousHash   string
+	lastCheckpoint time.Time
+}
+
+func newHashChain(signingKeyFile string, checkpointInterval time.Duration) (*hashChain, error) {
+	c := &hashChain{
+		id:                 uuid.New().String(),
+		checkpointInterval: checkpointInterval,
+	}
+	if c.checkpointInterval == 0 {
+		c.checkpointInterval = DefaultCheckpointInterval
+	}
+	if signingKeyFile != "" {
+		signer, err := LoadCheckpointSigningKey(signingKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		c.signer = signer
+	}
+	return c, nil
+}
+
+// LoadCheckpointSigningKey loads the PEM encoded RSA or ECDSA private key signing the checkpoints.
+func LoadCheckpointSigningKey(file string) (crypto.Signer, error) {
+	key, err := keyutil.PrivateKeyFromFile(file)
+	if err != nil {
+		return nil, err
+	}
+	switch key := key.(type) {
+	case *rsa.PrivateKey:
+		return key, nil
+	case *ecdsa.PrivateKey:
+		return key, nil
+	default:
+		return nil, fmt.Errorf("unsupported checkpoint signing key type %T", key)
+	}
+}
+
+// link appends the event to the chain, signing a checkpoint if the checkpoint interval elapsed.
+func (c *hashChain) link(e *auditv1alpha1.Event) error {
+	chain := &auditv1alpha1.Chain{
+		ID:                 c.id,
+		RequestObjectHash:  objectHash(e.RequestObject),
+		ResponseObjectHash: objectHash(e.ResponseObject),
+	}
+	e.Chain = chain
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	chain.Sequence = c.sequence + 1
+	chain.PreviousHash = c.previousHash
+	hash, err := EventHash(e)
+	if err != nil {
+		e.Chain = nil
+		return err
+	}
+	c.sequence = chain.Sequence
+	c.previousHash = hash
+
+	now := time.Now()
+	if c.signer != nil && now.Sub(c.lastCheckpoint) >= c.checkpointInterval {
+		checkpoint := &auditv1alpha1.Checkpoint{Hash: hash, Timestamp: metav1.NewTime(now)}
+		checkpoint.Signature, err = sign(c.signer, checkpointPayload(chain, checkpoint))
+		if err != nil {
+			return err
+		}
+		chain.Checkpoint = checkpoint
+		c.lastCheckpoint = now
+	}
+	return nil
+}
+
+// EventHash returns the hash of the event which the next event in the chain refers to.
+// It covers neither the level nor the request and response objects, which are stripped
+// by sinks of lower level, the objects are covered by their hashes in the chain instead.
+func EventHash(e *auditv1alpha1.Event) (string, error) {
+	event := *e
+	event.Level = ""
+	event.RequestObject = nil
+	event.ResponseObject = nil
+	if e.Chain != nil {
+		chain := *e.Chain
+		chain.Checkpoint = nil
+		event.Chain = &chain
+	}
+	bs, err := json.Marshal(&event)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(bs)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// objectHash hashes the compacted object, as it is compacted when the event is encoded.
+func objectHash(object *runtime.Unknown) string {
+	if object == nil || len(object.Raw) == 0 {
+		return ""
+	}
+	raw := object.Raw
+	buf := &bytes.Buffer{}
+	if err := json.Compact(buf, raw); err == nil {
+		raw = buf.Bytes()
+	}
+	sum := sha256.Sum256(raw)
+	return hex.EncodeToString(sum[:])
+}
+
+func checkpointPayload(chain *auditv1alpha1.Chain, checkpoint *auditv1alpha1.Checkpoint) []byte {
+	return []byte(fmt.Sprintf("%s\n%d\n%s\n%s", chain.ID, chain.Sequence, checkpoint.Hash,
+		checkpoint.Timestamp.UTC().Format(time.RFC3339)))
+}
+
+func sign(signer crypto.Signer, payload []byte) ([]byte, error) {
+	digest := sha256.Sum256(payload)
+	return signer.Sign(rand.Reader, digest[:], crypto.SHA256)
+}
+
+func verifySignature(keys []interface{}, payload, signature []byte) bool {
+	digest := sha256.Sum256(payload)
+	for _, key := range keys {
+		switch key := key.(type) {
+		case *rsa.PublicKey:
+			if rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature) == nil {
+				return true
+			}
+		case *ecdsa.PublicKey:
+			if ecdsa.VerifyASN1(key, digest[:], signature) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ReadEvents reads an exported stream of auditing events,
+// which is a sequence of JSON encoded events or event lists.
+func ReadEvents(r io.Reader) ([]auditv1alpha1.Event, error) {
+	var events []auditv1alpha1.Event
+	decoder := json.NewDecoder(r)
+	for {
+		var raw json.RawMessage
+		if err := decoder.Decode(&raw); err == io.EOF {
+			return events, nil
+		} else if err != nil {
+			return nil, err
+		}
+
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(raw, &fields); err != nil {
+			return nil, err
+		}
+		if _, ok := fields["Items"]; ok {
+			list := &auditv1alpha1.EventList{}
+			if err := json.Unmarshal(raw, list); err != nil {
+				return nil, err
+			}
+			events = append(events, list.Items...)
+			continue
+		}
+		event := auditv1alpha1.Event{}
+		if err := json.Unmarshal(raw, &event); err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+}
+
+// ChainReport is the result of verifying the hash chains in a stream of events.
+type ChainReport struct {
+	Chains []ChainSummary
+	// Unchained is the number of events without hash chain.
+	Unchained int
+	Problems  []ChainProblem
+}
+
+type ChainSummary struct {
+	ID            string
+	FirstSequence uint64
+	LastSequence  uint64
+	Events        int
+	Checkpoints   int
+	// LastCheckpoint is the sequence of the last verified checkpoint,
+	// deleting the events after it can't be detected.
+	LastCheckpoint uint64
+}
+
+type ChainProblem struct {
+	Chain    string
+	Sequence uint64
+	Reason   string
+}
+
+func (p ChainProblem) String() string {
+	return fmt.Sprintf("chain %s event %d: %s", p.Chain, p.Sequence, p.Reason)
+}
+
+// VerifyChains checks the hash chains in the events, reporting the gaps between the events,
+// the modified events and the invalid checkpoints. The checkpoint signatures are not verified
+// if no public key is given. The events may be in any order, duplicates are ignored.
+func VerifyChains(events []auditv1alpha1.Event, publicKeys []interface{}) *ChainReport {
+	report := &ChainReport{}
+	chains := make(map[string][]*auditv1alpha1.Event)
+	for i := range events {
+		if events[i].Chain == nil {
+			report.Unchained++
+			continue
+		}
+		chains[events[i].Chain.ID] = append(chains[events[i].Chain.ID], &events[i])
+	}
+
+	ids := make([]string, 0, len(chains))
+	for id := range chains {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	for _, id := range ids {
+		chain := chains[id]
+		sort.SliceStable(chain, func(i, j int) bool {
+			return chain[i].Chain.Sequence < chain[j].Chain.Sequence
+		})
+		summary := ChainSummary{ID: id, FirstSequence: chain[0].Chain.Sequence}
+		problem := func(sequence uint64, format string, args ...interface{}) {
+			report.Problems = append(report.Problems, ChainProblem{Chain: id, Sequence: sequence, Reason: fmt.Sprintf(format, args...)})
+		}
+
+		var previous *auditv1alpha1.Event
+		var previousHash string
+		for _, e := range chain {
+			sequence := e.Chain.Sequence
+			hash, err := EventHash(e)
+			if err != nil {
+				problem(sequence, "failed to hash event, %s", err)
+				continue
+			}
+
+			if previous != nil && previous.Chain.Sequence == sequence {
+				if hash != previousHash {
+					problem(sequence, "conflicting events with the same sequence")
+				}
+				continue
+			}
+
+			summary.Events++
+			if e.RequestObject != nil && objectHash(e.RequestObject) != e.Chain.RequestObjectHash {
+				problem(sequence, "request object was modified")
+			}
+			if e.ResponseObject != nil && objectHash(e.ResponseObject) != e.Chain.ResponseObjectHash {
+				problem(sequence, "response object was modified")
+			}
+
+			switch {
+			case previous == nil:
+				if sequence == 1 && e.Chain.PreviousHash != "" {
+					problem(sequence, "first event of the chain refers to a previous event")
+				}
+			case sequence != previous.Chain.Sequence+1:
+				problem(sequence, "events %d to %d are missing", previous.Chain.Sequence+1, sequence-1)
+			case e.Chain.PreviousHash != previousHash:
+				problem(sequence, "previous event %d was modified", previous.Chain.Sequence)
+			}
+
+			if checkpoint := e.Chain.Checkpoint; checkpoint != nil {
+				summary.Checkpoints++
+				switch {
+				case checkpoint.Hash != hash:
+					problem(sequence, "event carrying a checkpoint was modified")
+				case len(publicKeys) > 0 && !verifySignature(publicKeys, checkpointPayload(e.Chain, checkpoint), checkpoint.Signature):
+					problem(sequence, "invalid checkpoint signature")
+				default:
+					summary.LastCheckpoint = sequence
+				}
+			}
+
+			previous, previousHash = e, hash
+			summary.LastSequence = sequence
+		}
+		report.Chains = append(report.Chains, summary)
+	}
+	return report
+}
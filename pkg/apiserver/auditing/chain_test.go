@@ -0,0 +1,226 @@
+/*
+Copyright 2022 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auditing
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/apiserver/pkg/apis/audit"
+	"k8s.io/client-go/util/keyutil"
+
+	auditv1alpha1 "kubesphere.io/kubesphere/pkg/apiserver/auditing/v1alpha1"
+	options "kubesphere.io/kubesphere/pkg/simple/client/auditing"
+)
+
+func TestHashChain(t *testing.T) {
+	keyData, err := keyutil.MakeEllipticPrivateKeyPEM()
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyFile := filepath.Join(t.TempDir(), "key.pem")
+	if err := os.WriteFile(keyFile, keyData, 0600); err != nil {
+		t.Fatal(err)
+	}
+	chain, err := newHashChain(keyFile, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	privateKey, err := keyutil.ParsePrivateKeyPEM(keyData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	publicKeys := []interface{}{&privateKey.(*ecdsa.PrivateKey).PublicKey}
+
+	// the events are exported as an event list by a sink stripping the response objects
+	exported := &auditv1alpha1.EventList{}
+	for _, name := range []string{"a", "b", "c", "d"} {
+		e := newTestEvents().Items[1]
+		e.ObjectRef = &audit.ObjectReference{Name: name}
+		e.RequestObject = &runtime.Unknown{Raw: []byte(`{ "name": "` + name + `" }`)}
+		if err := chain.link(&e); err != nil {
+			t.Fatal(err)
+		}
+		exported.Items = append(exported.Items, e)
+	}
+	exported = applyLevel(exported, audit.LevelRequest)
+	data, err := json.Marshal(exported)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		description      string
+		tamper           func(events []auditv1alpha1.Event) []auditv1alpha1.Event
+		publicKeys       []interface{}
+		expectedProblems []string
+	}{
+		{
+			description: "intact",
+			tamper: func(events []auditv1alpha1.Event) []auditv1alpha1.Event {
+				return events
+			},
+			publicKeys: publicKeys,
+		},
+		{
+			description: "reordered and duplicated",
+			tamper: func(events []auditv1alpha1.Event) []auditv1alpha1.Event {
+				return []auditv1alpha1.Event{events[3], events[1], events[0], events[2], events[1]}
+			},
+			publicKeys: publicKeys,
+		},
+		{
+			description: "modified",
+			tamper: func(events []auditv1alpha1.Event) []auditv1alpha1.Event {
+				events[1].User.Username = "admin"
+				return events
+			},
+			expectedProblems: []string{"event 3: previous event 2 was modified"},
+		},
+		{
+			description: "modified request object",
+			tamper: func(events []auditv1alpha1.Event) []auditv1alpha1.Event {
+				events[2].RequestObject = &runtime.Unknown{Raw: []byte(`{"name":"x"}`)}
+				return events
+			},
+			expectedProblems: []string{"event 3: request object was modified"},
+		},
+		{
+			description: "deleted",
+			tamper: func(events []auditv1alpha1.Event) []auditv1alpha1.Event {
+				return append(events[:1], events[3:]...)
+			},
+			expectedProblems: []string{"event 4: events 2 to 3 are missing"},
+		},
+		{
+			description: "signed by another key",
+			tamper: func(events []auditv1alpha1.Event) []auditv1alpha1.Event {
+				return events
+			},
+			publicKeys: func() []interface{} {
+				other, _ := keyutil.MakeEllipticPrivateKeyPEM()
+				key, _ := keyutil.ParsePrivateKeyPEM(other)
+				return []interface{}{&key.(*ecdsa.PrivateKey).PublicKey}
+			}(),
+			expectedProblems: []string{"event 1: invalid checkpoint signature"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			events, err := ReadEvents(bytes.NewReader(data))
+			if err != nil {
+				t.Fatal(err)
+			}
+			report := VerifyChains(test.tamper(events), test.publicKeys)
+			if len(report.Chains) != 1 {
+				t.Fatalf("expected 1 chain, got %d", len(report.Chains))
+			}
+			var problems []string
+			for _, problem := range report.Problems {
+				problems = append(problems, problem.String()[len("chain "+chain.id+" "):])
+			}
+			if diff := cmp.Diff(problems, test.expectedProblems); diff != "" {
+				t.Errorf("%T differ (-got, +want): %s", test.expectedProblems, diff)
+			}
+		})
+	}
+}
+
+func TestReadEvents(t *testing.T) {
+	stream := `{"Items":[{"auditID":"1"},{"auditID":"2"}]}
+{"auditID":"3"}
+{"Items":[{"auditID":"4"}]}`
+	events, err := ReadEvents(bytes.NewBufferString(stream))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []string
+	for _, e := range events {
+		ids = append(ids, string(e.AuditID))
+	}
+	if diff := cmp.Diff(ids, []string{"1", "2", "3", "4"}); diff != "" {
+		t.Errorf("%T differ (-got, +want): %s", ids, diff)
+	}
+}
+
+func TestBackendChain(t *testing.T) {
+	var mutex sync.Mutex
+	var received []uint64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, _ := io.ReadAll(req.Body)
+		events := &auditv1alpha1.EventList{}
+		if err := json.Unmarshal(body, events); err != nil {
+			t.Error(err)
+		}
+		mutex.Lock()
+		defer mutex.Unlock()
+		for _, event := range events.Items {
+			if event.Chain == nil {
+				t.Errorf("expected event %s to be chained", event.AuditID)
+				continue
+			}
+			received = append(received, event.Chain.Sequence)
+		}
+	}))
+	defer server.Close()
+
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	cache := make(chan *auditv1alpha1.Event)
+	opts := &options.Options{
+		WebhookUrl:         server.URL,
+		EventBatchSize:     1,
+		EventBatchInterval: time.Millisecond,
+		SpoolDir:           t.TempDir(),
+		HashChainEnabled:   true,
+	}
+	NewBackend(opts, cache, stopCh)
+
+	// the events are linked once they reach the backend, so the events never queued leave no gaps
+	for _, id := range []string{"1", "2", "3"} {
+		event := &auditv1alpha1.Event{}
+		event.AuditID = types.UID(id)
+		cache <- event
+	}
+
+	expected := []uint64{1, 2, 3}
+	err := wait.PollImmediate(10*time.Millisecond, 5*time.Second, func() (bool, error) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		return len(received) == len(expected), nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(received, expected); diff != "" {
+		t.Errorf("%T differ (-got, +want): %s", received, diff)
+	}
+}
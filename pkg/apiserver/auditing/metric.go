@@ -0,0 +1,62 @@
+/*
+Copyright 2022 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auditing
+
+import (
+	compbasemetrics "k8s.io/component-base/metrics"
+
+	"kubesphere.io/kubesphere/pkg/utils/metrics"
+)
+
+const (
+	dropReasonSendFailed = "send_failed"
+	dropReasonSpoolFull  = "spool_full"
+	dropReasonSpoolError = "spool_error"
+	dropReasonExpired    = "expired"
+	dropReasonRejected   = "rejected"
+)
+
+var (
+	eventsQueued = compbasemetrics.NewGaugeVec(
+		&compbasemetrics.GaugeOpts{
+			Name:           "ks_auditing_events_queued",
+			Help:           "Number of auditing events waiting in the spool broken out for each sink.",
+			StabilityLevel: compbasemetrics.ALPHA,
+		},
+		[]string{"sink"},
+	)
+	eventsDropped = compbasemetrics.NewCounterVec(
+		&compbasemetrics.CounterOpts{
+			Name:           "ks_auditing_events_dropped_total",
+			Help:           "Counter of auditing events that were never delivered broken out for each sink and reason.",
+			StabilityLevel: compbasemetrics.ALPHA,
+		},
+		[]string{"sink", "reason"},
+	)
+	eventsSent = compbasemetrics.NewCounterVec(
+		&compbasemetrics.CounterOpts{
+			Name:           "ks_auditing_events_sent_total",
+			Help:           "Counter of auditing events delivered broken out for each sink.",
+			StabilityLevel: compbasemetrics.ALPHA,
+		},
+		[]string{"sink"},
+	)
+)
+
+func init() {
+	metrics.MustRegister(eventsQueued, eventsDropped, eventsSent)
+}
@@ -0,0 +1,308 @@
+/*
+Copyright 2022 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auditing
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/klog/v2"
+	auditingv1alpha1 "kubesphere.io/api/auditing/v1alpha1"
+
+	auditv1alpha1 "kubesphere.io/kubesphere/pkg/apiserver/auditing/v1alpha1"
+)
+
+const (
+	redactedMask       = "***"
+	redactedHashPrefix = "sha256:"
+	lastAppliedConfig  = "['kubectl.kubernetes.io/last-applied-configuration']"
+)
+
+// DefaultRedactionRules are always applied before the rules of the webhook.
+var DefaultRedactionRules = []auditingv1alpha1.RedactionRule{
+	{
+		Resources: []string{"secrets"},
+		Paths: []string{
+			".data", ".stringData", ".metadata.annotations" + lastAppliedConfig,
+			".items[*].data", ".items[*].stringData", ".items[*].metadata.annotations" + lastAppliedConfig,
+		},
+	},
+	{
+		Resources: []string{"users"},
+		Paths:     []string{".spec.password", ".items[*].spec.password", ".password", ".currentPassword"},
+	},
+	{
+		// the kubeconfig of the user is responded in plain text
+		Resources: []string{"users/kubeconfig"},
+		Paths:     []string{"$"},
+	},
+	{
+		Resources: []string{"clusters"},
+		Paths:     []string{".spec.connection.kubeconfig", ".items[*].spec.connection.kubeconfig", ".kubeconfig"},
+	},
+	{
+		// the kubeconfigs of the users are stored in the configmaps
+		Resources: []string{"configmaps"},
+		Paths:     []string{".data.config", ".items[*].data.config"},
+	},
+	{
+		// credentials in any object, e.g. the responses of the OAuth token endpoint and kubeconfigs
+		Paths: []string{
+			"..password", "..token", "..bearerToken", "..access_token", "..refresh_token", "..id_token",
+			"..client_secret", "..clientSecret", "..client-key-data",
+		},
+	},
+}
+
+type pathSegmentKind int
+
+const (
+	// .key or ['key']
+	segmentChild pathSegmentKind = iota
+	// ..key
+	segmentDescendant
+	// .* or [*]
+	segmentWildcard
+	// [0]
+	segmentIndex
+)
+
+type pathSegment struct {
+	kind  pathSegmentKind
+	key   string
+	index int
+}
+
+type redactionRule struct {
+	resources sets.Set[string]
+	verbs     sets.Set[string]
+	paths     [][]pathSegment
+	method    auditingv1alpha1.RedactionMethod
+}
+
+// redactor replaces the sensitive fields of the request and response objects.
+type redactor struct {
+	rules []redactionRule
+}
+
+func newRedactor(rules []auditingv1alpha1.RedactionRule) *redactor {
+	r := &redactor{}
+	for _, rule := range append(append([]auditingv1alpha1.RedactionRule{}, DefaultRedactionRules...), rules...) {
+		compiled := redactionRule{
+			resources: sets.New(rule.Resources...),
+			verbs:     sets.New(rule.Verbs...),
+			method:    rule.Method,
+		}
+		for _, path := range rule.Paths {
+			segments, err := parsePath(path)
+			if err != nil {
+				klog.Warningf("ignore invalid redaction path %s, %s", path, err)
+				continue
+			}
+			compiled.paths = append(compiled.paths, segments)
+		}
+		r.rules = append(r.rules, compiled)
+	}
+	return r
+}
+
+// redact redacts the request and response objects of the event, the objects which are not
+// JSON can't be redacted, they are dropped if any rule applies to the event.
+func (r *redactor) redact(e *auditv1alpha1.Event) {
+	var rules []redactionRule
+	for _, rule := range r.rules {
+		if rule.matches(e) {
+			rules = append(rules, rule)
+		}
+	}
+	if len(rules) == 0 {
+		return
+	}
+	e.RequestObject = redactObject(e.RequestObject, rules)
+	e.ResponseObject = redactObject(e.ResponseObject, rules)
+}
+
+func (r *redactionRule) matches(e *auditv1alpha1.Event) bool {
+	if r.verbs.Len() > 0 && !r.verbs.Has(e.Verb) {
+		return false
+	}
+	if r.resources.Len() == 0 {
+		return true
+	}
+	if e.ObjectRef == nil {
+		return false
+	}
+	// the resource matches all of its subresources, resource/subresource matches the subresource only
+	return r.resources.Has(e.ObjectRef.Resource) ||
+		(e.ObjectRef.Subresource != "" && r.resources.Has(e.ObjectRef.Resource+"/"+e.ObjectRef.Subresource))
+}
+
+func redactObject(object *runtime.Unknown, rules []redactionRule) *runtime.Unknown {
+	if object == nil || len(object.Raw) == 0 {
+		return object
+	}
+	// the object which is not JSON can be redacted as a whole
+	for _, rule := range rules {
+		for _, path := range rule.paths {
+			if len(path) > 0 {
+				continue
+			}
+			value := interface{}(redactedMask)
+			if rule.method == auditingv1alpha1.RedactionMethodHash {
+				value = hashValue(string(object.Raw))
+			}
+			raw, _ := json.Marshal(value)
+			return &runtime.Unknown{Raw: raw}
+		}
+	}
+	var data interface{}
+	if err := json.Unmarshal(object.Raw, &data); err != nil {
+		return nil
+	}
+	for _, rule := range rules {
+		replace := func(interface{}) interface{} {
+			return redactedMask
+		}
+		if rule.method == auditingv1alpha1.RedactionMethodHash {
+			replace = hashValue
+		}
+		for _, path := range rule.paths {
+			data = redactPath(data, path, replace)
+		}
+	}
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return nil
+	}
+	return &runtime.Unknown{Raw: raw}
+}
+
+func hashValue(value interface{}) interface{} {
+	bs, _ := json.Marshal(value)
+	sum := sha256.Sum256(bs)
+	return redactedHashPrefix + hex.EncodeToString(sum[:])
+}
+
+// redactPath replaces the values matching the path in the node, returning the node,
+// the node itself is replaced by the empty path.
+func redactPath(node interface{}, path []pathSegment, replace func(interface{}) interface{}) interface{} {
+	if len(path) == 0 {
+		return replace(node)
+	}
+	current, rest := path[0], path[1:]
+	switch n := node.(type) {
+	case map[string]interface{}:
+		for key, value := range n {
+			switch {
+			case current.kind == segmentWildcard,
+				current.kind == segmentChild && current.key == key:
+				n[key] = redactPath(value, rest, replace)
+			case current.kind == segmentDescendant:
+				if current.key == key {
+					n[key] = redactPath(value, rest, replace)
+				} else {
+					n[key] = redactPath(value, path, replace)
+				}
+			}
+		}
+	case []interface{}:
+		for i, value := range n {
+			switch {
+			case current.kind == segmentWildcard,
+				current.kind == segmentIndex && current.index == i:
+				n[i] = redactPath(value, rest, replace)
+			case current.kind == segmentDescendant:
+				n[i] = redactPath(value, path, replace)
+			}
+		}
+	}
+	return node
+}
+
+// parsePath parses the subset of JSONPath supported by the redaction rules,
+// e.g. {.items[*].metadata.annotations['kubectl.kubernetes.io/last-applied-configuration']} or ..token,
+// $ is the whole object and parsed as the empty path.
+func parsePath(path string) ([]pathSegment, error) {
+	path = strings.TrimSpace(path)
+	if strings.HasPrefix(path, "{") && strings.HasSuffix(path, "}") {
+		path = path[1 : len(path)-1]
+	}
+	if path == "$" {
+		return []pathSegment{}, nil
+	}
+	path = strings.TrimPrefix(path, "$")
+
+	var segments []pathSegment
+	for len(path) > 0 {
+		switch {
+		case strings.HasPrefix(path, ".."):
+			key, remaining := readKey(path[2:])
+			if key == "" {
+				return nil, fmt.Errorf("missing key after ..")
+			}
+			segments = append(segments, pathSegment{kind: segmentDescendant, key: key})
+			path = remaining
+		case strings.HasPrefix(path, "."):
+			key, remaining := readKey(path[1:])
+			switch key {
+			case "":
+				return nil, fmt.Errorf("missing key after .")
+			case "*":
+				segments = append(segments, pathSegment{kind: segmentWildcard})
+			default:
+				segments = append(segments, pathSegment{kind: segmentChild, key: key})
+			}
+			path = remaining
+		case strings.HasPrefix(path, "["):
+			end := strings.Index(path, "]")
+			if end < 0 {
+				return nil, fmt.Errorf("missing ]")
+			}
+			selector := path[1:end]
+			path = path[end+1:]
+			if selector == "*" {
+				segments = append(segments, pathSegment{kind: segmentWildcard})
+			} else if len(selector) >= 2 && (selector[0] == '\'' || selector[0] == '"') && selector[len(selector)-1] == selector[0] {
+				segments = append(segments, pathSegment{kind: segmentChild, key: selector[1 : len(selector)-1]})
+			} else if index, err := strconv.Atoi(selector); err == nil && index >= 0 {
+				segments = append(segments, pathSegment{kind: segmentIndex, index: index})
+			} else {
+				return nil, fmt.Errorf("invalid selector [%s]", selector)
+			}
+		default:
+			return nil, fmt.Errorf("unexpected %q", path)
+		}
+	}
+	if len(segments) == 0 {
+		return nil, fmt.Errorf("empty path")
+	}
+	return segments, nil
+}
+
+func readKey(path string) (string, string) {
+	end := strings.IndexAny(path, ".[")
+	if end < 0 {
+		return path, ""
+	}
+	return path[:end], path[end:]
+}
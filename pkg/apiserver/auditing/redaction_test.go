@@ -0,0 +1,252 @@
+/*
+Copyright 2022 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auditing
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8srequest "k8s.io/apiserver/pkg/endpoints/request"
+	fakek8s "k8s.io/client-go/kubernetes/fake"
+	auditingv1alpha1 "kubesphere.io/api/auditing/v1alpha1"
+
+	auditv1alpha1 "kubesphere.io/kubesphere/pkg/apiserver/auditing/v1alpha1"
+	"kubesphere.io/kubesphere/pkg/apiserver/request"
+	"kubesphere.io/kubesphere/pkg/client/clientset/versioned/fake"
+	"kubesphere.io/kubesphere/pkg/informers"
+)
+
+func TestParsePath(t *testing.T) {
+	tests := []struct {
+		path     string
+		expected []pathSegment
+		invalid  bool
+	}{
+		{
+			path:     "{.data}",
+			expected: []pathSegment{{kind: segmentChild, key: "data"}},
+		},
+		{
+			path: "$.items[*].metadata.annotations['kubectl.kubernetes.io/last-applied-configuration']",
+			expected: []pathSegment{
+				{kind: segmentChild, key: "items"},
+				{kind: segmentWildcard},
+				{kind: segmentChild, key: "metadata"},
+				{kind: segmentChild, key: "annotations"},
+				{kind: segmentChild, key: "kubectl.kubernetes.io/last-applied-configuration"},
+			},
+		},
+		{
+			path:     "..token",
+			expected: []pathSegment{{kind: segmentDescendant, key: "token"}},
+		},
+		{
+			path:     ".spec.*[1]",
+			expected: []pathSegment{{kind: segmentChild, key: "spec"}, {kind: segmentWildcard}, {kind: segmentIndex, index: 1}},
+		},
+		{
+			path:     "$",
+			expected: []pathSegment{},
+		},
+		{path: "", invalid: true},
+		{path: "data", invalid: true},
+		{path: ".items[", invalid: true},
+		{path: ".items[x]", invalid: true},
+	}
+	for _, test := range tests {
+		segments, err := parsePath(test.path)
+		if test.invalid {
+			if err == nil {
+				t.Errorf("expected path %q to be invalid", test.path)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("unexpected error parsing %q, %s", test.path, err)
+			continue
+		}
+		if diff := cmp.Diff(segments, test.expected, cmp.AllowUnexported(pathSegment{})); diff != "" {
+			t.Errorf("%s: %T differ (-got, +want): %s", test.path, test.expected, diff)
+		}
+	}
+}
+
+func TestRedaction(t *testing.T) {
+	webhook := &auditingv1alpha1.Webhook{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: DefaultWebhook,
+		},
+		Spec: auditingv1alpha1.WebhookSpec{
+			AuditLevel: auditingv1alpha1.Level("RequestResponse"),
+			RedactionRules: []auditingv1alpha1.RedactionRule{
+				{
+					Resources: []string{"configmaps"},
+					Verbs:     []string{"create"},
+					Paths:     []string{".data.apiKey"},
+					Method:    auditingv1alpha1.RedactionMethodHash,
+				},
+			},
+		},
+	}
+	ksClient := fake.NewSimpleClientset()
+	k8sClient := fakek8s.NewSimpleClientset()
+	fakeInformerFactory := informers.NewInformerFactories(k8sClient, ksClient, nil, nil, nil, nil)
+	if err := fakeInformerFactory.KubeSphereSharedInformerFactory().Auditing().V1alpha1().Webhooks().Informer().GetIndexer().Add(webhook); err != nil {
+		t.Fatal(err)
+	}
+	a := &auditing{
+		webhookLister: fakeInformerFactory.KubeSphereSharedInformerFactory().Auditing().V1alpha1().Webhooks().Lister(),
+		cache:         make(chan *auditv1alpha1.Event, 1),
+	}
+
+	tests := []struct {
+		description  string
+		path         string
+		verb         string
+		resource     string
+		subresource  string
+		requestBody  string
+		responseBody string
+		sensitive    []string
+		expected     []string
+	}{
+		{
+			description:  "secret",
+			path:         "/api/v1/namespaces/default/secrets",
+			verb:         "create",
+			resource:     "secrets",
+			requestBody:  `{"kind":"Secret","metadata":{"name":"db","annotations":{"kubectl.kubernetes.io/last-applied-configuration":"{\"stringData\":{\"password\":\"hunter2\"}}"}},"stringData":{"password":"hunter2"}}`,
+			responseBody: `{"kind":"Secret","metadata":{"name":"db"},"data":{"password":"aHVudGVyMg=="}}`,
+			sensitive:    []string{"hunter2", "aHVudGVyMg=="},
+			expected:     []string{`"name":"db"`, `"stringData":"***"`, `"data":"***"`},
+		},
+		{
+			description:  "secret list",
+			path:         "/api/v1/namespaces/default/secrets",
+			verb:         "list",
+			resource:     "secrets",
+			responseBody: `{"kind":"SecretList","items":[{"metadata":{"name":"db"},"data":{"password":"aHVudGVyMg=="}}]}`,
+			sensitive:    []string{"aHVudGVyMg=="},
+			expected:     []string{`"name":"db"`},
+		},
+		{
+			description: "user password",
+			path:        "/kapis/iam.kubesphere.io/v1alpha2/users/admin/password",
+			verb:        "update",
+			resource:    "users",
+			requestBody: `{"currentPassword":"P@88w0rd","password":"n3wP@88w0rd"}`,
+			sensitive:   []string{"P@88w0rd", "n3wP@88w0rd"},
+		},
+		{
+			description:  "oauth token",
+			path:         "/oauth/token",
+			verb:         "post",
+			requestBody:  `grant_type=password&username=admin&password=P@88w0rd`,
+			responseBody: `{"access_token":"eyJhbGciOiJIUzI1NiJ9.YWNjZXNz","refresh_token":"eyJhbGciOiJIUzI1NiJ9.cmVmcmVzaA","token_type":"Bearer"}`,
+			sensitive:    []string{"P@88w0rd", "eyJhbGciOiJIUzI1NiJ9"},
+			expected:     []string{`"token_type":"Bearer"`},
+		},
+		{
+			description:  "user kubeconfig",
+			path:         "/kapis/resources.kubesphere.io/v1alpha2/users/admin/kubeconfig",
+			verb:         "get",
+			resource:     "users",
+			subresource:  "kubeconfig",
+			responseBody: "apiVersion: v1\nusers:\n- name: admin\n  user:\n    token: eyJhbGciOiJIUzI1NiJ9.YWNjZXNz\n",
+			sensitive:    []string{"eyJhbGciOiJIUzI1NiJ9"},
+			expected:     []string{`"ResponseObject":"***"`},
+		},
+		{
+			description:  "cluster kubeconfig",
+			path:         "/apis/cluster.kubesphere.io/v1alpha1/clusters/member",
+			verb:         "update",
+			resource:     "clusters",
+			requestBody:  `{"metadata":{"name":"member"},"spec":{"connection":{"type":"direct","kubeconfig":"YXBpVmVyc2lvbjogdjE="}}}`,
+			responseBody: `{"metadata":{"name":"member"},"spec":{"connection":{"type":"direct","kubeconfig":"YXBpVmVyc2lvbjogdjE="}}}`,
+			sensitive:    []string{"YXBpVmVyc2lvbjogdjE="},
+			expected:     []string{`"type":"direct"`, `"kubeconfig":"***"`},
+		},
+		{
+			description:  "kubeconfig configmap",
+			path:         "/api/v1/namespaces/kubesphere-controls-system/configmaps",
+			verb:         "list",
+			resource:     "configmaps",
+			responseBody: `{"kind":"ConfigMapList","items":[{"metadata":{"name":"kubeconfig-admin"},"data":{"config":"apiVersion: v1\nusers:\n- name: admin\n  user:\n    client-key-data: LS0tLS1CRUdJTg=="}}]}`,
+			sensitive:    []string{"LS0tLS1CRUdJTg=="},
+			expected:     []string{`"name":"kubeconfig-admin"`, `"config":"***"`},
+		},
+		{
+			description: "custom rule",
+			path:        "/api/v1/namespaces/default/configmaps",
+			verb:        "create",
+			resource:    "configmaps",
+			requestBody: `{"data":{"apiKey":"c2VjcmV0","region":"us"}}`,
+			sensitive:   []string{"c2VjcmV0"},
+			expected:    []string{`"apiKey":"sha256:`, `"region":"us"`},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, test.path, strings.NewReader(test.requestBody))
+			info := &request.RequestInfo{
+				RequestInfo: &k8srequest.RequestInfo{
+					IsResourceRequest: test.resource != "",
+					Path:              test.path,
+					Verb:              test.verb,
+					Resource:          test.resource,
+					Subresource:       test.subresource,
+				},
+			}
+			e := a.LogRequestObject(req, info)
+			if body, _ := io.ReadAll(req.Body); string(body) != test.requestBody {
+				t.Errorf("expected the request body to be preserved for the handler, got %s", body)
+			}
+
+			recorder := httptest.NewRecorder()
+			resp := NewResponseCapture(recorder)
+			resp.Write([]byte(test.responseBody))
+			a.LogResponseObject(e, resp)
+			if recorder.Body.String() != test.responseBody {
+				t.Errorf("expected the response body to be preserved for the client, got %s", recorder.Body.String())
+			}
+
+			// the event is what reaches the backend
+			bs, err := json.Marshal(<-a.cache)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, sensitive := range test.sensitive {
+				if bytes.Contains(bs, []byte(sensitive)) {
+					t.Errorf("sensitive value %s reached the backend: %s", sensitive, bs)
+				}
+			}
+			for _, expected := range test.expected {
+				if !bytes.Contains(bs, []byte(expected)) {
+					t.Errorf("expected %s in the event: %s", expected, bs)
+				}
+			}
+		})
+	}
+}
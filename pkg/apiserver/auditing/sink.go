@@ -0,0 +1,118 @@
+/*
+Copyright 2022 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auditing
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+
+	"k8s.io/apiserver/pkg/apis/audit"
+
+	"kubesphere.io/kubesphere/pkg/apiserver/auditing/v1alpha1"
+	options "kubesphere.io/kubesphere/pkg/simple/client/auditing"
+)
+
+// Sink is an output auditing events are delivered to.
+type Sink interface {
+	// Send delivers a batch of events, the batch is retried as a whole if it fails and the spool is enabled.
+	Send(ctx context.Context, events *v1alpha1.EventList) error
+	Close() error
+}
+
+// rejectedError means the sink rejected the events, sending the same batch again would fail as well.
+type rejectedError struct {
+	error
+}
+
+func isRejected(err error) bool {
+	var rejected *rejectedError
+	return errors.As(err, &rejected)
+}
+
+// NewSink creates the sink described by the options.
+func NewSink(opts options.SinkOptions) (Sink, error) {
+	switch {
+	case opts.Type == options.SinkTypeFile && opts.File != nil:
+		return newFileSink(opts.File), nil
+	case opts.Type == options.SinkTypeSyslog && opts.Syslog != nil:
+		return newSyslogSink(opts.Syslog)
+	case opts.Type == options.SinkTypeWebhook && opts.Webhook != nil:
+		return newWebhookSink(opts.Webhook)
+	default:
+		return nil, fmt.Errorf("unsupported auditing sink type %s", opts.Type)
+	}
+}
+
+func sinkLevel(opts options.SinkOptions) audit.Level {
+	if opts.Level == "" {
+		return audit.LevelRequestResponse
+	}
+	return audit.Level(opts.Level)
+}
+
+// applyLevel returns a copy of the events stripped down to the given level,
+// the events are shared by all the sinks so they must not be modified in place.
+func applyLevel(events *v1alpha1.EventList, level audit.Level) *v1alpha1.EventList {
+	result := &v1alpha1.EventList{}
+	if level == audit.LevelNone {
+		return result
+	}
+	for _, event := range events.Items {
+		if event.Level.GreaterOrEqual(level) {
+			if level.Less(audit.LevelRequest) {
+				event.RequestObject = nil
+			}
+			if level.Less(audit.LevelRequestResponse) {
+				event.ResponseObject = nil
+			}
+			event.Level = level
+		}
+		result.Items = append(result.Items, event)
+	}
+	return result
+}
+
+func newTLSConfig(opts *options.TLSOptions) (*tls.Config, error) {
+	config := &tls.Config{MinVersion: tls.VersionTLS12}
+	if opts == nil {
+		return config, nil
+	}
+	config.InsecureSkipVerify = opts.InsecureSkipVerify
+	if opts.CAFile != "" {
+		data, err := os.ReadFile(opts.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(data) {
+			return nil, fmt.Errorf("no valid certificate found in %s", opts.CAFile)
+		}
+		config.RootCAs = pool
+	}
+	if opts.CertFile != "" || opts.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(opts.CertFile, opts.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+	return config, nil
+}
@@ -0,0 +1,74 @@
+/*
+Copyright 2022 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auditing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"sync"
+
+	"gopkg.in/natefinch/lumberjack.v2"
+
+	"kubesphere.io/kubesphere/pkg/apiserver/auditing/v1alpha1"
+	options "kubesphere.io/kubesphere/pkg/simple/client/auditing"
+)
+
+const defaultFileSinkMaxSize = 100
+
+// fileSink writes the events as JSON lines to a file rotated by size.
+type fileSink struct {
+	mutex  sync.Mutex
+	writer *lumberjack.Logger
+}
+
+func newFileSink(opts *options.FileSinkOptions) *fileSink {
+	maxSize := opts.MaxSize
+	if maxSize == 0 {
+		maxSize = defaultFileSinkMaxSize
+	}
+	return &fileSink{
+		writer: &lumberjack.Logger{
+			Filename:   opts.Path,
+			MaxSize:    maxSize,
+			MaxBackups: opts.MaxBackups,
+			MaxAge:     opts.MaxAge,
+			Compress:   opts.Compress,
+		},
+	}
+}
+
+func (f *fileSink) Send(_ context.Context, events *v1alpha1.EventList) error {
+	buf := &bytes.Buffer{}
+	encoder := json.NewEncoder(buf)
+	for i := range events.Items {
+		if err := encoder.Encode(&events.Items[i]); err != nil {
+			return err
+		}
+	}
+
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	_, err := f.writer.Write(buf.Bytes())
+	return err
+}
+
+func (f *fileSink) Close() error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return f.writer.Close()
+}
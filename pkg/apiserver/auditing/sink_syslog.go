@@ -0,0 +1,159 @@
+/*
+Copyright 2022 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auditing
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"kubesphere.io/kubesphere/pkg/apiserver/auditing/v1alpha1"
+	options "kubesphere.io/kubesphere/pkg/simple/client/auditing"
+)
+
+const (
+	syslogFacilityLogAudit = 13
+	syslogSeverityInfo     = 6
+	syslogDefaultAppName   = "kubesphere"
+	syslogMsgID            = "audit"
+	syslogNilValue         = "-"
+	syslogTimestampFormat  = "2006-01-02T15:04:05.000000Z07:00"
+)
+
+// syslogSink sends each event as a RFC 5424 message over TCP or TLS,
+// the messages are framed by octet counting as described in RFC 6587.
+type syslogSink struct {
+	address   string
+	tlsConfig *tls.Config
+	facility  int
+	hostname  string
+	appName   string
+
+	mutex sync.Mutex
+	conn  net.Conn
+}
+
+func newSyslogSink(opts *options.SyslogSinkOptions) (*syslogSink, error) {
+	s := &syslogSink{
+		address:  opts.Address,
+		facility: syslogFacilityLogAudit,
+		appName:  opts.AppName,
+	}
+	if opts.Facility != nil {
+		s.facility = *opts.Facility
+	}
+	if s.appName == "" {
+		s.appName = syslogDefaultAppName
+	}
+	if hostname, err := os.Hostname(); err == nil && hostname != "" {
+		s.hostname = hostname
+	} else {
+		s.hostname = syslogNilValue
+	}
+	if opts.Network == "tls" {
+		config, err := newTLSConfig(opts.TLS)
+		if err != nil {
+			return nil, err
+		}
+		if config.ServerName == "" {
+			config.ServerName, _, _ = net.SplitHostPort(opts.Address)
+		}
+		s.tlsConfig = config
+	}
+	return s, nil
+}
+
+func (s *syslogSink) Send(ctx context.Context, events *v1alpha1.EventList) error {
+	buf := &bytes.Buffer{}
+	for i := range events.Items {
+		message, err := s.format(&events.Items[i])
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(buf, "%d %s", len(message), message)
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.conn == nil {
+		conn, err := s.dial(ctx)
+		if err != nil {
+			return err
+		}
+		s.conn = conn
+	}
+	deadline, _ := ctx.Deadline()
+	if err := s.conn.SetWriteDeadline(deadline); err != nil {
+		return err
+	}
+	if _, err := s.conn.Write(buf.Bytes()); err != nil {
+		// the connection is reestablished by the next batch
+		s.conn.Close()
+		s.conn = nil
+		return err
+	}
+	return nil
+}
+
+func (s *syslogSink) dial(ctx context.Context) (net.Conn, error) {
+	if s.tlsConfig != nil {
+		dialer := &tls.Dialer{Config: s.tlsConfig}
+		return dialer.DialContext(ctx, "tcp", s.address)
+	}
+	dialer := &net.Dialer{}
+	return dialer.DialContext(ctx, "tcp", s.address)
+}
+
+// format renders the event as a RFC 5424 message with the JSON encoded event as the message body.
+func (s *syslogSink) format(event *v1alpha1.Event) ([]byte, error) {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return nil, err
+	}
+	timestamp := event.RequestReceivedTimestamp.Time
+	if timestamp.IsZero() {
+		timestamp = time.Now()
+	}
+	header := fmt.Sprintf("<%d>1 %s %s %s %s %s %s ",
+		s.facility*8+syslogSeverityInfo,
+		timestamp.UTC().Format(syslogTimestampFormat),
+		s.hostname,
+		s.appName,
+		syslogNilValue,
+		syslogMsgID,
+		syslogNilValue,
+	)
+	return append([]byte(header), body...), nil
+}
+
+func (s *syslogSink) Close() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.conn == nil {
+		return nil
+	}
+	err := s.conn.Close()
+	s.conn = nil
+	return err
+}
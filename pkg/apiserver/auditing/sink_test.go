@@ -0,0 +1,231 @@
+/*
+Copyright 2022 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auditing
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apiserver/pkg/apis/audit"
+
+	"kubesphere.io/kubesphere/pkg/apiserver/auditing/v1alpha1"
+	options "kubesphere.io/kubesphere/pkg/simple/client/auditing"
+)
+
+func newTestEvents() *v1alpha1.EventList {
+	events := &v1alpha1.EventList{}
+	for i, level := range []audit.Level{audit.LevelMetadata, audit.LevelRequestResponse} {
+		event := v1alpha1.Event{}
+		event.AuditID = types.UID(fmt.Sprintf("id-%d", i))
+		event.Level = level
+		event.RequestReceivedTimestamp = metav1.NewMicroTime(time.Date(2022, 3, 4, 5, 6, 7, 8000, time.UTC))
+		if level == audit.LevelRequestResponse {
+			event.RequestObject = &runtime.Unknown{Raw: []byte(`{"kind":"Request"}`)}
+			event.ResponseObject = &runtime.Unknown{Raw: []byte(`{"kind":"Response"}`)}
+		}
+		events.Items = append(events.Items, event)
+	}
+	return events
+}
+
+func TestApplyLevel(t *testing.T) {
+	events := newTestEvents()
+
+	if result := applyLevel(events, audit.LevelNone); len(result.Items) != 0 {
+		t.Errorf("expected no events at level None, got %d", len(result.Items))
+	}
+
+	result := applyLevel(events, audit.LevelRequest)
+	if len(result.Items) != 2 {
+		t.Fatalf("expected 2 events, got %d", len(result.Items))
+	}
+	if result.Items[0].Level != audit.LevelMetadata {
+		t.Errorf("expected the level of a less detailed event to be kept, got %s", result.Items[0].Level)
+	}
+	stripped := result.Items[1]
+	if stripped.Level != audit.LevelRequest || stripped.RequestObject == nil || stripped.ResponseObject != nil {
+		t.Errorf("expected the event to be stripped down to level Request, got %+v", stripped)
+	}
+	if events.Items[1].ResponseObject == nil {
+		t.Errorf("expected the original events not to be modified")
+	}
+}
+
+func TestFileSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	sink, err := NewSink(options.SinkOptions{Type: options.SinkTypeFile, File: &options.FileSinkOptions{Path: path}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		if err := sink.Send(context.Background(), newTestEvents()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := sink.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []string
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		event := &v1alpha1.Event{}
+		if err := json.Unmarshal([]byte(line), event); err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, string(event.AuditID))
+	}
+	if diff := cmp.Diff(ids, []string{"id-0", "id-1", "id-0", "id-1"}); diff != "" {
+		t.Errorf("%T differ (-got, +want): %s", ids, diff)
+	}
+}
+
+func TestSyslogSink(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+
+	messages := make(chan string, 2)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		reader := bufio.NewReader(conn)
+		for {
+			var length int
+			if _, err := fmt.Fscanf(reader, "%d ", &length); err != nil {
+				return
+			}
+			message := make([]byte, length)
+			if _, err := io.ReadFull(reader, message); err != nil {
+				return
+			}
+			messages <- string(message)
+		}
+	}()
+
+	facility := 4
+	sink, err := NewSink(options.SinkOptions{
+		Type:   options.SinkTypeSyslog,
+		Syslog: &options.SyslogSinkOptions{Address: listener.Addr().String(), Facility: &facility, AppName: "test"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sink.Close()
+	sink.(*syslogSink).hostname = "host"
+	if err := sink.Send(context.Background(), newTestEvents()); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 2; i++ {
+		select {
+		case message := <-messages:
+			prefix := "<38>1 2022-03-04T05:06:07.000008Z host test - audit - {"
+			if !strings.HasPrefix(message, prefix) {
+				t.Errorf("expected message prefix %q, got %q", prefix, message)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("timeout waiting for syslog message")
+		}
+	}
+}
+
+func TestWebhookSinkKafkaFormat(t *testing.T) {
+	var contentType string
+	var body kafkaRecords
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		contentType = req.Header.Get("Content-Type")
+		if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+			t.Error(err)
+		}
+	}))
+	defer server.Close()
+
+	sink, err := NewSink(options.SinkOptions{
+		Type:    options.SinkTypeWebhook,
+		Webhook: &options.WebhookSinkOptions{URL: server.URL, Format: options.WebhookFormatKafka},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sink.Send(context.Background(), newTestEvents()); err != nil {
+		t.Fatal(err)
+	}
+	if contentType != kafkaContentType {
+		t.Errorf("expected content type %s, got %s", kafkaContentType, contentType)
+	}
+	if len(body.Records) != 2 || body.Records[1].Value.AuditID != "id-1" {
+		t.Errorf("unexpected records %+v", body.Records)
+	}
+}
+
+func TestDefaultSinkTLS(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {}))
+	defer server.Close()
+	caFile := filepath.Join(t.TempDir(), "ca.crt")
+	ca := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	if err := os.WriteFile(caFile, ca, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		description string
+		opts        *options.Options
+		expectError bool
+	}{
+		{"untrusted certificate", &options.Options{WebhookUrl: server.URL}, true},
+		{"trusted by the CA", &options.Options{WebhookUrl: server.URL, WebhookCAFile: caFile}, false},
+		{"verification skipped", &options.Options{WebhookUrl: server.URL, WebhookInsecureSkipVerify: true}, false},
+	}
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			sink, err := NewSink(defaultSink(test.opts))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer sink.Close()
+			err = sink.Send(context.Background(), newTestEvents())
+			if (err != nil) != test.expectError {
+				t.Errorf("expected error %v, got %v", test.expectError, err)
+			}
+		})
+	}
+}
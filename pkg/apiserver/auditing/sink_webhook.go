@@ -0,0 +1,110 @@
+/*
+Copyright 2022 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auditing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"kubesphere.io/kubesphere/pkg/apiserver/auditing/v1alpha1"
+	options "kubesphere.io/kubesphere/pkg/simple/client/auditing"
+)
+
+const kafkaContentType = "application/vnd.kafka.json.v2+json"
+
+// webhookSink posts the events to an HTTP endpoint.
+type webhookSink struct {
+	url    string
+	format string
+	client *http.Client
+}
+
+// kafkaRecords is the request body of producing messages through the Kafka REST proxy.
+type kafkaRecords struct {
+	Records []kafkaRecord `json:"records"`
+}
+
+type kafkaRecord struct {
+	Value *v1alpha1.Event `json:"value"`
+}
+
+func newWebhookSink(opts *options.WebhookSinkOptions) (*webhookSink, error) {
+	config, err := newTLSConfig(opts.TLS)
+	if err != nil {
+		return nil, err
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = config
+
+	timeout := opts.Timeout
+	if timeout == 0 {
+		timeout = SendTimeout
+	}
+	return &webhookSink{
+		url:    opts.URL,
+		format: opts.Format,
+		client: &http.Client{Transport: transport, Timeout: timeout},
+	}, nil
+}
+
+func (w *webhookSink) Send(ctx context.Context, events *v1alpha1.EventList) error {
+	contentType := "application/json"
+	var bs []byte
+	var err error
+	if w.format == options.WebhookFormatKafka {
+		contentType = kafkaContentType
+		records := kafkaRecords{}
+		for i := range events.Items {
+			records.Records = append(records.Records, kafkaRecord{Value: &events.Items[i]})
+		}
+		bs, err = json.Marshal(records)
+	} else {
+		bs, err = eventToBytes(events)
+	}
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewBuffer(bs))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+	response, err := w.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	// the client errors other than timeouts and throttling are not transient
+	if response.StatusCode >= http.StatusBadRequest && response.StatusCode < http.StatusInternalServerError &&
+		response.StatusCode != http.StatusRequestTimeout && response.StatusCode != http.StatusTooManyRequests {
+		return &rejectedError{fmt.Errorf("events rejected with status code %d", response.StatusCode)}
+	}
+	if response.StatusCode < http.StatusOK || response.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("unexpected status code %d", response.StatusCode)
+	}
+	return nil
+}
+
+func (w *webhookSink) Close() error {
+	w.client.CloseIdleConnections()
+	return nil
+}
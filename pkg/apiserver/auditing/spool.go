@@ -0,0 +1,253 @@
+/*
+Copyright 2022 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auditing
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s.io/klog/v2"
+)
+
+const (
+	spoolFileSuffix = ".json"
+	spoolTempSuffix = ".tmp"
+)
+
+// segment is a batch of auditing events persisted in the spool.
+type segment struct {
+	path    string
+	seq     uint64
+	events  int
+	size    int64
+	created time.Time
+}
+
+// spool is a write-ahead queue of auditing event batches, one file per batch.
+// The files are named after a monotonic sequence number so that the batches
+// are replayed in the order they were written, even after a restart.
+type spool struct {
+	// name of the sink the events are spooled for
+	name     string
+	dir      string
+	maxBytes int64
+	maxAge   time.Duration
+
+	mutex    sync.Mutex
+	segments []*segment
+	size     int64
+	seq      uint64
+	// notify is signaled when a batch is appended.
+	notify chan struct{}
+}
+
+func newSpool(name, dir string, maxBytes int64, maxAge time.Duration) (*spool, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &spool{name: name, dir: dir, maxBytes: maxBytes, maxAge: maxAge, notify: make(chan struct{}, 1)}
+	for _, entry := range entries {
+		path := filepath.Join(dir, entry.Name())
+		if entry.IsDir() {
+			continue
+		}
+		// leftover of an interrupted write, the batch was never acknowledged
+		if strings.HasSuffix(entry.Name(), spoolTempSuffix) {
+			os.Remove(path)
+			continue
+		}
+		seg, err := parseSegment(path)
+		if err != nil {
+			klog.Warningf("ignore unknown file %s in auditing spool, %s", path, err)
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			klog.Error(err)
+			return nil, err
+		}
+		seg.size = info.Size()
+		s.segments = append(s.segments, seg)
+		s.size += seg.size
+		eventsQueued.WithLabelValues(s.name).Add(float64(seg.events))
+	}
+
+	sort.Slice(s.segments, func(i, j int) bool {
+		return s.segments[i].seq < s.segments[j].seq
+	})
+	if len(s.segments) > 0 {
+		s.seq = s.segments[len(s.segments)-1].seq
+		klog.Infof("replaying %d auditing event batches from spool %s", len(s.segments), dir)
+	}
+	return s, nil
+}
+
+func segmentName(seq uint64, created time.Time, events int) string {
+	return fmt.Sprintf("%020d-%019d-%d%s", seq, created.UnixNano(), events, spoolFileSuffix)
+}
+
+func parseSegment(path string) (*segment, error) {
+	name := filepath.Base(path)
+	if !strings.HasSuffix(name, spoolFileSuffix) {
+		return nil, fmt.Errorf("invalid spool file name")
+	}
+	var seq uint64
+	var created int64
+	var events int
+	if _, err := fmt.Sscanf(strings.TrimSuffix(name, spoolFileSuffix), "%d-%d-%d", &seq, &created, &events); err != nil {
+		return nil, err
+	}
+	return &segment{path: path, seq: seq, events: events, created: time.Unix(0, created)}, nil
+}
+
+// append persists a batch of events, the batch is durable once append returns.
+func (s *spool) append(events int, data []byte) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	now := time.Now()
+	seg := &segment{
+		path:    filepath.Join(s.dir, segmentName(s.seq+1, now, events)),
+		seq:     s.seq + 1,
+		events:  events,
+		size:    int64(len(data)),
+		created: now,
+	}
+	if err := writeFileSync(seg.path, data); err != nil {
+		return err
+	}
+
+	s.seq = seg.seq
+	s.segments = append(s.segments, seg)
+	s.size += seg.size
+	eventsQueued.WithLabelValues(s.name).Add(float64(events))
+	s.trim(now)
+
+	select {
+	case s.notify <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+func writeFileSync(path string, data []byte) error {
+	tmp := path + spoolTempSuffix
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err = f.Write(data); err == nil {
+		err = f.Sync()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if err = os.Rename(tmp, path); err != nil {
+		return err
+	}
+	// the rename is only durable once the directory is synced
+	if err = syncDir(filepath.Dir(path)); err != nil {
+		os.Remove(path)
+		return err
+	}
+	return nil
+}
+
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	err = d.Sync()
+	if closeErr := d.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// trim drops the oldest batches exceeding the size or age limit, the caller must hold the lock.
+func (s *spool) trim(now time.Time) {
+	for len(s.segments) > 0 {
+		seg := s.segments[0]
+		var reason string
+		if s.maxAge > 0 && now.Sub(seg.created) > s.maxAge {
+			reason = dropReasonExpired
+		} else if s.maxBytes > 0 && s.size > s.maxBytes {
+			reason = dropReasonSpoolFull
+		} else {
+			return
+		}
+		klog.Warningf("drop %d auditing events from spool of %s, %s", seg.events, s.name, reason)
+		s.pop()
+		eventsDropped.WithLabelValues(s.name, reason).Add(float64(seg.events))
+	}
+}
+
+// pop removes the oldest batch, the caller must hold the lock.
+func (s *spool) pop() {
+	seg := s.segments[0]
+	s.segments = s.segments[1:]
+	s.size -= seg.size
+	eventsQueued.WithLabelValues(s.name).Add(-float64(seg.events))
+	if err := os.Remove(seg.path); err != nil && !os.IsNotExist(err) {
+		klog.Errorf("remove auditing spool file %s error, %s", seg.path, err)
+	}
+}
+
+// peek returns the oldest batch and its content, or nil if the spool is empty.
+func (s *spool) peek() (*segment, []byte) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.trim(time.Now())
+	for len(s.segments) > 0 {
+		seg := s.segments[0]
+		data, err := os.ReadFile(seg.path)
+		if err == nil {
+			return seg, data
+		}
+		klog.Errorf("read auditing spool file %s error, %s", seg.path, err)
+		s.pop()
+		eventsDropped.WithLabelValues(s.name, dropReasonSendFailed).Add(float64(seg.events))
+	}
+	return nil, nil
+}
+
+// remove acknowledges a batch returned by peek.
+func (s *spool) remove(seg *segment) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	// the batch may have been trimmed while it was being sent
+	if len(s.segments) > 0 && s.segments[0] == seg {
+		s.pop()
+	}
+}
@@ -0,0 +1,214 @@
+/*
+Copyright 2022 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auditing
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+
+	"kubesphere.io/kubesphere/pkg/apiserver/auditing/v1alpha1"
+	options "kubesphere.io/kubesphere/pkg/simple/client/auditing"
+)
+
+func TestSpool(t *testing.T) {
+	dir := t.TempDir()
+	s, err := newSpool("test", dir, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, batch := range []string{"a", "b", "c"} {
+		if err := s.append(1, []byte(batch)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	seg, data := s.peek()
+	if string(data) != "a" {
+		t.Fatalf("expected the oldest batch a, got %s", data)
+	}
+	s.remove(seg)
+
+	// an interrupted write is discarded on restart
+	if err := os.WriteFile(dir+"/interrupted"+spoolTempSuffix, []byte("d"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	s, err = newSpool("test", dir, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.append(1, []byte("d")); err != nil {
+		t.Fatal(err)
+	}
+	var replayed []string
+	for seg, data := s.peek(); seg != nil; seg, data = s.peek() {
+		replayed = append(replayed, string(data))
+		s.remove(seg)
+	}
+	if diff := cmp.Diff(replayed, []string{"b", "c", "d"}); diff != "" {
+		t.Errorf("%T differ (-got, +want): %s", replayed, diff)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("expected empty spool directory, got %d files", len(entries))
+	}
+}
+
+func TestSpoolLimits(t *testing.T) {
+	s, err := newSpool("test", t.TempDir(), 4, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, batch := range []string{"aa", "bb", "cc"} {
+		if err := s.append(1, []byte(batch)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if seg, data := s.peek(); string(data) != "bb" || seg.seq != 2 {
+		t.Errorf("expected the oldest batch to be dropped when the spool is full, got %s", data)
+	}
+
+	s, err = newSpool("test", t.TempDir(), 0, time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.append(1, []byte("a")); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(2 * time.Millisecond)
+	if seg, _ := s.peek(); seg != nil {
+		t.Errorf("expected expired batch to be dropped, got %s", seg.path)
+	}
+}
+
+func TestBackendReplay(t *testing.T) {
+	var mutex sync.Mutex
+	var received []string
+	failures := 2
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		if failures > 0 {
+			failures--
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		body, _ := io.ReadAll(req.Body)
+		events := &v1alpha1.EventList{}
+		if err := json.Unmarshal(body, events); err != nil {
+			t.Error(err)
+		}
+		for _, event := range events.Items {
+			received = append(received, string(event.AuditID))
+		}
+	}))
+	defer server.Close()
+
+	RetryInitialInterval = time.Millisecond
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	cache := make(chan *v1alpha1.Event)
+	opts := &options.Options{
+		WebhookUrl:         server.URL,
+		EventBatchSize:     1,
+		EventBatchInterval: time.Millisecond,
+		SpoolDir:           t.TempDir(),
+	}
+	NewBackend(opts, cache, stopCh)
+
+	expected := []string{"1", "2", "3", "4"}
+	for _, id := range expected {
+		event := &v1alpha1.Event{}
+		event.AuditID = types.UID(id)
+		cache <- event
+	}
+
+	err := wait.PollImmediate(10*time.Millisecond, 5*time.Second, func() (bool, error) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		return len(received) == len(expected), nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(received, expected); diff != "" {
+		t.Errorf("%T differ (-got, +want): %s", received, diff)
+	}
+}
+
+func TestBackendReplayRejected(t *testing.T) {
+	var mutex sync.Mutex
+	var received []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, _ := io.ReadAll(req.Body)
+		events := &v1alpha1.EventList{}
+		if err := json.Unmarshal(body, events); err != nil {
+			t.Error(err)
+		}
+		mutex.Lock()
+		defer mutex.Unlock()
+		for _, event := range events.Items {
+			if event.AuditID == "2" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			received = append(received, string(event.AuditID))
+		}
+	}))
+	defer server.Close()
+
+	// the rejected batch is not retried
+	retryInterval := RetryInitialInterval
+	RetryInitialInterval = time.Hour
+	defer func() { RetryInitialInterval = retryInterval }()
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	cache := make(chan *v1alpha1.Event)
+	opts := &options.Options{
+		WebhookUrl:         server.URL,
+		EventBatchSize:     1,
+		EventBatchInterval: time.Millisecond,
+		SpoolDir:           t.TempDir(),
+	}
+	NewBackend(opts, cache, stopCh)
+
+	for _, id := range []string{"1", "2", "3"} {
+		event := &v1alpha1.Event{}
+		event.AuditID = types.UID(id)
+		cache <- event
+	}
+
+	expected := []string{"1", "3"}
+	err := wait.PollImmediate(10*time.Millisecond, 5*time.Second, func() (bool, error) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		return len(received) == len(expected), nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(received, expected); diff != "" {
+		t.Errorf("%T differ (-got, +want): %s", received, diff)
+	}
+}
@@ -24,6 +24,7 @@ import (
 	"io"
 	"net"
 	"net/http"
+	"sync"
 	"time"
 
 	"github.com/google/uuid"
@@ -33,6 +34,7 @@ import (
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apiserver/pkg/apis/audit"
 	"k8s.io/klog/v2"
+	auditingv1alpha1 "kubesphere.io/api/auditing/v1alpha1"
 	devopsv1alpha3 "kubesphere.io/api/devops/v1alpha3"
 	"kubesphere.io/api/iam/v1alpha2"
 
@@ -65,6 +67,11 @@ type auditing struct {
 	devopsGetter  v1alpha3.Interface
 	cache         chan *auditv1alpha1.Event
 	backend       *Backend
+
+	redactorMutex sync.Mutex
+	redactor      *redactor
+	// the resource version of the webhook the redactor is built from
+	redactorVersion string
 }
 
 func NewAuditing(informers informers.InformerFactory, opts *options.Options, stopCh <-chan struct{}) Auditing {
@@ -89,6 +96,24 @@ func (a *auditing) getAuditLevel() audit.Level {
 	return (audit.Level)(wh.Spec.AuditLevel)
 }
 
+// getRedactor returns the redactor of the rules in the webhook, it is rebuilt when the webhook changes.
+func (a *auditing) getRedactor() *redactor {
+	var version string
+	var rules []auditingv1alpha1.RedactionRule
+	if wh, err := a.webhookLister.Get(DefaultWebhook); err == nil {
+		version = wh.ResourceVersion
+		rules = wh.Spec.RedactionRules
+	}
+
+	a.redactorMutex.Lock()
+	defer a.redactorMutex.Unlock()
+	if a.redactor == nil || a.redactorVersion != version {
+		a.redactor = newRedactor(rules)
+		a.redactorVersion = version
+	}
+	return a.redactor
+}
+
 func (a *auditing) Enabled() bool {
 
 	level := a.getAuditLevel()
@@ -251,10 +276,12 @@ func (a *auditing) LogResponseObject(e *auditv1alpha1.Event, resp *ResponseCaptu
 
 	e.StageTimestamp = metav1.NowMicro()
 	e.ResponseStatus = &metav1.Status{Code: int32(resp.StatusCode())}
-	if e.Level.GreaterOrEqual(audit.LevelRequestResponse) {
+	// the truncated body is not a valid object
+	if e.Level.GreaterOrEqual(audit.LevelRequestResponse) && resp.body != nil && !resp.truncated {
 		e.ResponseObject = &runtime.Unknown{Raw: resp.Bytes()}
 	}
 
+	a.getRedactor().redact(e)
 	a.cacheEvent(*e)
 }
 
@@ -269,11 +296,15 @@ func (a *auditing) cacheEvent(e auditv1alpha1.Event) {
 	}
 }
 
+// the response body larger than the limit is not captured
+const maxCapturedResponseSize = 1 << 20
+
 type ResponseCapture struct {
 	http.ResponseWriter
 	wroteHeader bool
 	status      int
 	body        *bytes.Buffer
+	truncated   bool
 }
 
 func NewResponseCapture(w http.ResponseWriter) *ResponseCapture {
@@ -284,6 +315,15 @@ func NewResponseCapture(w http.ResponseWriter) *ResponseCapture {
 	}
 }
 
+// NewStreamResponseCapture captures the status of the streamed response only, such as the watch,
+// since the body is written until the connection is closed.
+func NewStreamResponseCapture(w http.ResponseWriter) *ResponseCapture {
+	return &ResponseCapture{
+		ResponseWriter: w,
+		wroteHeader:    false,
+	}
+}
+
 func (c *ResponseCapture) Header() http.Header {
 	return c.ResponseWriter.Header()
 }
@@ -291,7 +331,14 @@ func (c *ResponseCapture) Header() http.Header {
 func (c *ResponseCapture) Write(data []byte) (int, error) {
 
 	c.WriteHeader(http.StatusOK)
-	c.body.Write(data)
+	if c.body != nil && !c.truncated {
+		if c.body.Len()+len(data) > maxCapturedResponseSize {
+			c.truncated = true
+			c.body.Reset()
+		} else {
+			c.body.Write(data)
+		}
+	}
 	return c.ResponseWriter.Write(data)
 }
 
@@ -304,6 +351,9 @@ func (c *ResponseCapture) WriteHeader(statusCode int) {
 }
 
 func (c *ResponseCapture) Bytes() []byte {
+	if c.body == nil {
+		return nil
+	}
 	return c.body.Bytes()
 }
 
@@ -322,6 +372,14 @@ func (c *ResponseCapture) Hijack() (net.Conn, *bufio.ReadWriter, error) {
 	return hijacker.Hijack()
 }
 
+// Flush implements the http.Flusher interface, so the streamed responses
+// are flushed to the client if the underlying http.ResponseWriter supports it.
+func (c *ResponseCapture) Flush() {
+	if flusher, ok := c.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
 // CloseNotify is part of http.CloseNotifier interface
 func (c *ResponseCapture) CloseNotify() <-chan bool {
 	//nolint:staticcheck
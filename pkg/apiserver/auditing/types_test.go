@@ -17,6 +17,7 @@ limitations under the License.
 package auditing
 
 import (
+	"bytes"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
@@ -349,3 +350,28 @@ func TestResponseCapture_Write(t *testing.T) {
 	assert.EqualValues(t, body, resp.Bytes())
 	assert.EqualValues(t, body, record.Body.Bytes())
 }
+
+func TestResponseCapture_WriteLimit(t *testing.T) {
+	record := httptest.NewRecorder()
+	resp := NewResponseCapture(record)
+	body := bytes.Repeat([]byte("a"), maxCapturedResponseSize)
+	if _, err := resp.Write(body); err != nil {
+		t.Fatal(err)
+	}
+	assert.Len(t, resp.Bytes(), maxCapturedResponseSize)
+
+	// the body exceeding the limit is dropped
+	if _, err := resp.Write([]byte("a")); err != nil {
+		t.Fatal(err)
+	}
+	assert.Empty(t, resp.Bytes())
+	assert.True(t, resp.truncated)
+	assert.Len(t, record.Body.Bytes(), maxCapturedResponseSize+1)
+
+	stream := NewStreamResponseCapture(httptest.NewRecorder())
+	if _, err := stream.Write(body); err != nil {
+		t.Fatal(err)
+	}
+	assert.Empty(t, stream.Bytes())
+	assert.EqualValues(t, http.StatusOK, stream.StatusCode())
+}
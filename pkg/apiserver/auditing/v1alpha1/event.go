@@ -30,10 +30,37 @@ type Event struct {
 	Cluster string
 	// Message send to user.
 	Message string
+	// Chain links the event to the previous event emitted by the same server, nil if hash chaining is disabled.
+	Chain *Chain `json:",omitempty"`
 
 	audit.Event
 }
 
+// Chain is the position of an event in a hash chain.
+type Chain struct {
+	// ID of the chain, each server emits a chain of its own.
+	ID string `json:"id"`
+	// Sequence of the event in the chain, starting from 1.
+	Sequence uint64 `json:"sequence"`
+	// PreviousHash is the hash of the previous event in the chain, empty for the first event.
+	PreviousHash string `json:"previousHash,omitempty"`
+	// The hashes of the request and response objects, the event hash covers them instead of the objects,
+	// so that the chain can still be verified after the objects were stripped by a sink of lower level.
+	RequestObjectHash  string `json:"requestObjectHash,omitempty"`
+	ResponseObjectHash string `json:"responseObjectHash,omitempty"`
+	// Checkpoint is set on the events signed periodically.
+	Checkpoint *Checkpoint `json:"checkpoint,omitempty"`
+}
+
+// Checkpoint is a signature of the chain up to the event carrying it.
+type Checkpoint struct {
+	// Hash of the event carrying the checkpoint.
+	Hash      string  `json:"hash"`
+	Timestamp v1.Time `json:"timestamp"`
+	// Signature of the chain id, sequence, hash and timestamp.
+	Signature []byte `json:"signature"`
+}
+
 type EventList struct {
 	Items []Event
 }
@@ -39,34 +39,58 @@ import (
 type basicAuthenticator struct {
 	authenticator auth.PasswordAuthenticator
 	loginRecorder auth.LoginRecorder
+	loginLimiter  auth.LoginLimiter
 }
 
-func NewBasicAuthenticator(authenticator auth.PasswordAuthenticator, loginRecorder auth.LoginRecorder) basictoken.Password {
+func NewBasicAuthenticator(authenticator auth.PasswordAuthenticator, loginRecorder auth.LoginRecorder, loginLimiter auth.LoginLimiter) basictoken.Password {
 	return &basicAuthenticator{
 		authenticator: authenticator,
 		loginRecorder: loginRecorder,
+		loginLimiter:  loginLimiter,
 	}
 }
 
 func (t *basicAuthenticator) AuthenticatePassword(ctx context.Context, username, password string) (*authenticator.Response, bool, error) {
+	var sourceIP, userAgent string
+	if requestInfo, ok := request.RequestInfoFrom(ctx); ok {
+		sourceIP = requestInfo.SourceIP
+		userAgent = requestInfo.UserAgent
+	}
+	if t.loginLimiter != nil {
+		limit, err := t.loginLimiter.Attempt(username, sourceIP)
+		if err != nil {
+			return nil, false, err
+		}
+		if limit.RetryAfter > 0 {
+			return nil, false, auth.LoginThrottledError
+		}
+	}
 	authenticated, provider, err := t.authenticator.Authenticate(ctx, "", username, password)
 	if err != nil {
-		if t.loginRecorder != nil && err == auth.IncorrectPasswordError {
-			var sourceIP, userAgent string
-			if requestInfo, ok := request.RequestInfoFrom(ctx); ok {
-				sourceIP = requestInfo.SourceIP
-				userAgent = requestInfo.UserAgent
+		if err == auth.IncorrectPasswordError {
+			if t.loginRecorder != nil {
+				if _, err := t.loginRecorder.RecordLogin(username, iamv1alpha2.Password, provider, sourceIP, userAgent, false, err); err != nil {
+					klog.Errorf("Failed to record unsuccessful login attempt for user %s, error: %v", username, err)
+				}
 			}
-			if err := t.loginRecorder.RecordLogin(username, iamv1alpha2.Password, provider, sourceIP, userAgent, err); err != nil {
-				klog.Errorf("Failed to record unsuccessful login attempt for user %s, error: %v", username, err)
+			if t.loginLimiter != nil {
+				if _, err := t.loginLimiter.RecordFailure(username, sourceIP); err != nil {
+					klog.Errorf("Failed to record unsuccessful login attempt for user %s, error: %v", username, err)
+				}
 			}
 		}
 		return nil, false, err
 	}
+	if t.loginLimiter != nil {
+		if err = t.loginLimiter.RecordSuccess(username, sourceIP); err != nil {
+			klog.Errorf("Failed to reset the login limit of user %s, error: %v", username, err)
+		}
+	}
 	return &authenticator.Response{
 		User: &user.DefaultInfo{
 			Name:   authenticated.GetName(),
 			Groups: append(authenticated.GetGroups(), user.AllAuthenticated),
+			Extra:  authenticated.GetExtra(),
 		},
 	}, true, nil
 }
@@ -18,6 +18,7 @@ package jwt
 
 import (
 	"context"
+	"fmt"
 
 	"k8s.io/apiserver/pkg/authentication/authenticator"
 	"k8s.io/apiserver/pkg/authentication/user"
@@ -25,6 +26,7 @@ import (
 
 	iamv1alpha2 "kubesphere.io/api/iam/v1alpha2"
 
+	authtoken "kubesphere.io/kubesphere/pkg/apiserver/authentication/token"
 	"kubesphere.io/kubesphere/pkg/models/auth"
 
 	iamv1alpha2listers "kubesphere.io/kubesphere/pkg/client/listers/iam/v1alpha2"
@@ -54,6 +56,10 @@ func (t *tokenAuthenticator) AuthenticateToken(ctx context.Context, token string
 		return nil, false, err
 	}
 
+	if verified.TokenType == authtoken.MultiFactorChallenge {
+		return nil, false, fmt.Errorf("multi-factor authentication is not completed")
+	}
+
 	if verified.User.GetName() == iamv1alpha2.PreRegistrationUser {
 		return &authenticator.Response{
 			User: verified.User,
@@ -73,6 +79,28 @@ func (t *tokenAuthenticator) AuthenticateToken(ctx context.Context, token string
 		User: &user.DefaultInfo{
 			Name:   userInfo.GetName(),
 			Groups: append(userInfo.Spec.Groups, user.AllAuthenticated),
+			Extra:  extra(userInfo, verified.User.GetExtra()),
 		},
 	}, true, nil
 }
+
+// extra returns the extra information of the user which will be enforced by the authorizer
+func extra(userInfo *iamv1alpha2.User, tokenExtra map[string][]string) map[string][]string {
+	// the state is checked on every request, so the restriction is lifted once the password is changed
+	return auth.WithPasswordExpiredExtra(userInfo, accessTokenExtra(tokenExtra))
+}
+
+// accessTokenExtra returns the scopes of the personal access token which will be enforced by the authorizer
+func accessTokenExtra(extra map[string][]string) map[string][]string {
+	if len(extra[iamv1alpha2.ExtraAccessToken]) == 0 {
+		return nil
+	}
+	result := make(map[string][]string)
+	for _, key := range []string{iamv1alpha2.ExtraAccessToken, iamv1alpha2.ExtraAccessTokenVerbs,
+		iamv1alpha2.ExtraAccessTokenAPIGroups, iamv1alpha2.ExtraAccessTokenWorkspaces} {
+		if values, ok := extra[key]; ok {
+			result[key] = values
+		}
+	}
+	return result
+}
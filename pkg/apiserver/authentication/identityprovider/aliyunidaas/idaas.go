@@ -120,6 +120,13 @@ func (a idaasIdentity) GetEmail() string {
 	return a.Email
 }
 
+func (a idaasIdentity) GetGroups() []string {
+	if a.OuName == "" {
+		return nil
+	}
+	return []string{a.OuName}
+}
+
 func (a *aliyunIDaaS) IdentityExchangeCallback(req *http.Request) (identityprovider.Identity, error) {
 	// OAuth2 callback, see also https://tools.ietf.org/html/rfc6749#section-4.1.2
 	code := req.URL.Query().Get("code")
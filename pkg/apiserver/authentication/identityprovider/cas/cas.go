@@ -59,6 +59,10 @@ func (c casIdentity) GetEmail() string {
 	return ""
 }
 
+func (c casIdentity) GetGroups() []string {
+	return nil
+}
+
 func (f casProviderFactory) Type() string {
 	return "CASIdentityProvider"
 }
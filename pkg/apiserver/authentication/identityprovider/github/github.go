@@ -20,6 +20,7 @@ import (
 	"context"
 	"crypto/tls"
 	"encoding/json"
+	"fmt"
 	"io"
 	"net/http"
 	"time"
@@ -33,6 +34,7 @@ import (
 
 const (
 	userInfoURL = "https://api.github.com/user"
+	orgsURL     = "https://api.github.com/user/orgs"
 	authURL     = "https://github.com/login/oauth/authorize"
 	tokenURL    = "https://github.com/login/oauth/access_token"
 )
@@ -64,6 +66,10 @@ type github struct {
 	// Scope specifies optional requested permissions.
 	Scopes []string `json:"scopes" yaml:"scopes"`
 
+	// FetchGroups loads the organizations the user belongs to as groups,
+	// the read:org scope is required.
+	FetchGroups bool `json:"fetchGroups" yaml:"fetchGroups"`
+
 	Config *oauth2.Config `json:"-" yaml:"-"`
 }
 
@@ -73,6 +79,7 @@ type endpoint struct {
 	AuthURL     string `json:"authURL" yaml:"authURL"`
 	TokenURL    string `json:"tokenURL" yaml:"tokenURL"`
 	UserInfoURL string `json:"userInfoURL" yaml:"userInfoURL"`
+	OrgsURL     string `json:"orgsURL" yaml:"orgsURL"`
 }
 
 type githubIdentity struct {
@@ -112,6 +119,11 @@ type githubIdentity struct {
 	OwnedPrivateRepos int       `json:"owned_private_repos"`
 	DiskUsage         int       `json:"disk_usage"`
 	Collaborators     int       `json:"collaborators"`
+	Orgs              []string  `json:"-"`
+}
+
+type githubOrg struct {
+	Login string `json:"login"`
 }
 
 type ldapProviderFactory struct {
@@ -136,11 +148,15 @@ func (g *ldapProviderFactory) Create(opts options.DynamicOptions) (identityprovi
 	if github.Endpoint.UserInfoURL == "" {
 		github.Endpoint.UserInfoURL = userInfoURL
 	}
+	if github.Endpoint.OrgsURL == "" {
+		github.Endpoint.OrgsURL = orgsURL
+	}
 	// fixed options
 	opts["endpoint"] = options.DynamicOptions{
 		"authURL":     github.Endpoint.AuthURL,
 		"tokenURL":    github.Endpoint.TokenURL,
 		"userInfoURL": github.Endpoint.UserInfoURL,
+		"orgsURL":     github.Endpoint.OrgsURL,
 	}
 	github.Config = &oauth2.Config{
 		ClientID:     github.ClientID,
@@ -167,6 +183,10 @@ func (g githubIdentity) GetEmail() string {
 	return g.Email
 }
 
+func (g githubIdentity) GetGroups() []string {
+	return g.Orgs
+}
+
 func (g *github) IdentityExchangeCallback(req *http.Request) (identityprovider.Identity, error) {
 	// OAuth2 callback, see also https://tools.ietf.org/html/rfc6749#section-4.1.2
 	code := req.URL.Query().Get("code")
@@ -185,7 +205,8 @@ func (g *github) IdentityExchangeCallback(req *http.Request) (identityprovider.I
 	if err != nil {
 		return nil, err
 	}
-	resp, err := oauth2.NewClient(ctx, oauth2.StaticTokenSource(token)).Get(g.Endpoint.UserInfoURL)
+	client := oauth2.NewClient(ctx, oauth2.StaticTokenSource(token))
+	resp, err := client.Get(g.Endpoint.UserInfoURL)
 	if err != nil {
 		return nil, err
 	}
@@ -202,5 +223,35 @@ func (g *github) IdentityExchangeCallback(req *http.Request) (identityprovider.I
 		return nil, err
 	}
 
+	if g.FetchGroups {
+		if githubIdentity.Orgs, err = g.fetchOrgs(client); err != nil {
+			return nil, err
+		}
+	}
+
 	return githubIdentity, nil
 }
+
+// fetchOrgs returns the login names of the organizations the user belongs to
+func (g *github) fetchOrgs(client *http.Client) ([]string, error) {
+	resp, err := client.Get(g.Endpoint.OrgsURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("github: failed to fetch organizations, status code: %d", resp.StatusCode)
+	}
+
+	var orgs []githubOrg
+	if err = json.NewDecoder(resp.Body).Decode(&orgs); err != nil {
+		return nil, err
+	}
+
+	groups := make([]string, 0, len(orgs))
+	for _, org := range orgs {
+		groups = append(groups, org.Login)
+	}
+	return groups, nil
+}
@@ -47,7 +47,7 @@ func TestGithub(t *testing.T) {
 
 var _ = BeforeSuite(func(done Done) {
 	githubServer = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		var data map[string]interface{}
+		var data interface{}
 		switch r.RequestURI {
 		case "/login/oauth/access_token":
 			data = map[string]interface{}{
@@ -60,6 +60,11 @@ var _ = BeforeSuite(func(done Done) {
 				"login": "test",
 				"email": "test@kubesphere.io",
 			}
+		case "/user/orgs":
+			data = []map[string]interface{}{
+				{"login": "kubesphere"},
+				{"login": "kubesphere-sigs"},
+			}
 		default:
 			w.WriteHeader(http.StatusInternalServerError)
 			w.Write([]byte("not implemented"))
@@ -103,6 +108,7 @@ scopes:
 					AuthURL:     authURL,
 					TokenURL:    tokenURL,
 					UserInfoURL: userInfoURL,
+					OrgsURL:     orgsURL,
 				},
 				RedirectURL: "https://ks-console.kubesphere-system.svc/oauth/redirect/github",
 				Scopes:      []string{"user"},
@@ -125,10 +131,12 @@ scopes:
 				"clientSecret":       "2b70536f79ec8d2939863509d05e2a71c268b9af",
 				"redirectURL":        "https://ks-console.kubesphere-system.svc/oauth/redirect/github",
 				"insecureSkipVerify": true,
+				"fetchGroups":        true,
 				"endpoint": options.DynamicOptions{
 					"authURL":     fmt.Sprintf("%s/login/oauth/authorize", githubServer.URL),
 					"tokenURL":    fmt.Sprintf("%s/login/oauth/access_token", githubServer.URL),
 					"userInfoURL": fmt.Sprintf("%s/user", githubServer.URL),
+					"orgsURL":     fmt.Sprintf("%s/user/orgs", githubServer.URL),
 				},
 			}
 			factory := ldapProviderFactory{}
@@ -139,10 +147,12 @@ scopes:
 				"clientSecret":       "2b70536f79ec8d2939863509d05e2a71c268b9af",
 				"redirectURL":        "https://ks-console.kubesphere-system.svc/oauth/redirect/github",
 				"insecureSkipVerify": true,
+				"fetchGroups":        true,
 				"endpoint": options.DynamicOptions{
 					"authURL":     fmt.Sprintf("%s/login/oauth/authorize", githubServer.URL),
 					"tokenURL":    fmt.Sprintf("%s/login/oauth/access_token", githubServer.URL),
 					"userInfoURL": fmt.Sprintf("%s/user", githubServer.URL),
+					"orgsURL":     fmt.Sprintf("%s/user/orgs", githubServer.URL),
 				},
 			}
 			Expect(config).Should(Equal(expected))
@@ -155,6 +165,7 @@ scopes:
 			Expect(identity.GetUserID()).Should(Equal("test"))
 			Expect(identity.GetUsername()).Should(Equal("test"))
 			Expect(identity.GetEmail()).Should(Equal("test@kubesphere.io"))
+			Expect(identity.GetGroups()).Should(Equal([]string{"kubesphere", "kubesphere-sigs"}))
 		})
 	})
 })
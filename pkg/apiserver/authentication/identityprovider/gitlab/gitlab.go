@@ -20,6 +20,7 @@ import (
 	"context"
 	"crypto/tls"
 	"encoding/json"
+	"fmt"
 	"io"
 	"net/http"
 	"strconv"
@@ -33,6 +34,7 @@ import (
 
 const (
 	userInfoURL = "https://gitlab.com/api/v4/user"
+	groupsURL   = "https://gitlab.com/api/v4/groups?min_access_level=10"
 	authURL     = "https://gitlab.com/oauth/authorize"
 	tokenURL    = "https://gitlab.com/oauth/token"
 )
@@ -64,6 +66,10 @@ type gitlab struct {
 	// Scope specifies optional requested permissions.
 	Scopes []string `json:"scopes" yaml:"scopes"`
 
+	// FetchGroups loads the groups the user is a member of,
+	// the read_api scope is required.
+	FetchGroups bool `json:"fetchGroups" yaml:"fetchGroups"`
+
 	Config *oauth2.Config `json:"-" yaml:"-"`
 }
 
@@ -73,16 +79,22 @@ type endpoint struct {
 	AuthURL     string `json:"authURL" yaml:"authURL"`
 	TokenURL    string `json:"tokenURL" yaml:"tokenURL"`
 	UserInfoURL string `json:"userInfoURL" yaml:"userInfoURL"`
+	GroupsURL   string `json:"groupsURL" yaml:"groupsURL"`
 }
 
 type gitlabIdentity struct {
-	ID        int64  `json:"id"`
-	Username  string `json:"username"`
-	Email     string `json:"email"`
-	Name      string `json:"name"`
-	State     string `json:"state"`
-	AvatarURL string `json:"avatar_url"`
-	WebURL    string `json:"web_url"`
+	ID        int64    `json:"id"`
+	Username  string   `json:"username"`
+	Email     string   `json:"email"`
+	Name      string   `json:"name"`
+	State     string   `json:"state"`
+	AvatarURL string   `json:"avatar_url"`
+	WebURL    string   `json:"web_url"`
+	Groups    []string `json:"-"`
+}
+
+type gitlabGroup struct {
+	FullPath string `json:"full_path"`
 }
 
 type gitlabProviderFactory struct {
@@ -107,11 +119,15 @@ func (g *gitlabProviderFactory) Create(opts options.DynamicOptions) (identitypro
 	if gitlab.Endpoint.UserInfoURL == "" {
 		gitlab.Endpoint.UserInfoURL = userInfoURL
 	}
+	if gitlab.Endpoint.GroupsURL == "" {
+		gitlab.Endpoint.GroupsURL = groupsURL
+	}
 	// fixed options
 	opts["endpoint"] = options.DynamicOptions{
 		"authURL":     gitlab.Endpoint.AuthURL,
 		"tokenURL":    gitlab.Endpoint.TokenURL,
 		"userInfoURL": gitlab.Endpoint.UserInfoURL,
+		"groupsURL":   gitlab.Endpoint.GroupsURL,
 	}
 	gitlab.Config = &oauth2.Config{
 		ClientID:     gitlab.ClientID,
@@ -138,6 +154,10 @@ func (g gitlabIdentity) GetEmail() string {
 	return g.Email
 }
 
+func (g gitlabIdentity) GetGroups() []string {
+	return g.Groups
+}
+
 func (g *gitlab) IdentityExchangeCallback(req *http.Request) (identityprovider.Identity, error) {
 	// OAuth2 callback, see also https://tools.ietf.org/html/rfc6749#section-4.1.2
 	code := req.URL.Query().Get("code")
@@ -156,7 +176,8 @@ func (g *gitlab) IdentityExchangeCallback(req *http.Request) (identityprovider.I
 	if err != nil {
 		return nil, err
 	}
-	resp, err := oauth2.NewClient(ctx, oauth2.StaticTokenSource(token)).Get(g.Endpoint.UserInfoURL)
+	client := oauth2.NewClient(ctx, oauth2.StaticTokenSource(token))
+	resp, err := client.Get(g.Endpoint.UserInfoURL)
 	if err != nil {
 		return nil, err
 	}
@@ -173,5 +194,35 @@ func (g *gitlab) IdentityExchangeCallback(req *http.Request) (identityprovider.I
 		return nil, err
 	}
 
+	if g.FetchGroups {
+		if gitlabIdentity.Groups, err = g.fetchGroups(client); err != nil {
+			return nil, err
+		}
+	}
+
 	return gitlabIdentity, nil
 }
+
+// fetchGroups returns the full path of the groups the user is a member of
+func (g *gitlab) fetchGroups(client *http.Client) ([]string, error) {
+	resp, err := client.Get(g.Endpoint.GroupsURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("gitlab: failed to fetch groups, status code: %d", resp.StatusCode)
+	}
+
+	var gitlabGroups []gitlabGroup
+	if err = json.NewDecoder(resp.Body).Decode(&gitlabGroups); err != nil {
+		return nil, err
+	}
+
+	groups := make([]string, 0, len(gitlabGroups))
+	for _, group := range gitlabGroups {
+		groups = append(groups, group.FullPath)
+	}
+	return groups, nil
+}
@@ -48,6 +48,7 @@ scopes:
 					AuthURL:     "https://gitlab.com/oauth/authorize",
 					TokenURL:    "https://gitlab.com/oauth/token",
 					UserInfoURL: "https://gitlab.com/api/v4/user",
+					GroupsURL:   "https://gitlab.com/api/v4/groups?min_access_level=10",
 				},
 				RedirectURL: "https://ks-console.kubesphere-system.svc/oauth/redirect/gitlab",
 				Scopes:      []string{"read"},
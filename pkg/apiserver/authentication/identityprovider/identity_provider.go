@@ -43,6 +43,9 @@ type Identity interface {
 	GetUsername() string
 	// GetEmail optional
 	GetEmail() string
+	// GetGroups optional
+	// The groups the End-User belongs to at the Issuer.
+	GetGroups() []string
 }
 
 // SetupWithOptions will verify the configuration and initialize the identityProviders
@@ -53,6 +53,10 @@ func (e emptyIdentity) GetEmail() string {
 	return "test@test.com"
 }
 
+func (e emptyIdentity) GetGroups() []string {
+	return nil
+}
+
 func (e emptyOAuthProvider) IdentityExchangeCallback(req *http.Request) (Identity, error) {
 	return emptyIdentity{}, nil
 }
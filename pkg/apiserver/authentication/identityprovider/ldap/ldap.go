@@ -36,6 +36,7 @@ import (
 const (
 	ldapIdentityProvider = "LDAPIdentityProvider"
 	defaultReadTimeout   = 15000
+	defaultGroupNameAttr = "cn"
 )
 
 func init() {
@@ -72,6 +73,8 @@ type ldapProvider struct {
 	UserMemberAttribute string `json:"userMemberAttribute,omitempty" yaml:"userMemberAttribute"`
 	// Attribute on a group object storing the information for primary group membership.
 	GroupMemberAttribute string `json:"groupMemberAttribute,omitempty" yaml:"groupMemberAttribute"`
+	// Attribute on a group object used as the group name. Default to cn.
+	GroupNameAttribute string `json:"groupNameAttribute,omitempty" yaml:"groupNameAttribute"`
 	// The following three fields are direct mappings of attributes on the user entry.
 	// login attribute used for comparing user entries.
 	LoginAttribute string `json:"loginAttribute" yaml:"loginAttribute"`
@@ -99,6 +102,7 @@ func (l *ldapProviderFactory) Create(opts options.DynamicOptions) (identityprovi
 type ldapIdentity struct {
 	Username string
 	Email    string
+	Groups   []string
 }
 
 func (l *ldapIdentity) GetUserID() string {
@@ -113,6 +117,10 @@ func (l *ldapIdentity) GetEmail() string {
 	return l.Email
 }
 
+func (l *ldapIdentity) GetGroups() []string {
+	return l.Groups
+}
+
 func (l ldapProvider) Authenticate(username string, password string) (identityprovider.Identity, error) {
 	conn, err := l.newConn()
 	if err != nil {
@@ -132,6 +140,10 @@ func (l ldapProvider) Authenticate(username string, password string) (identitypr
 	if l.UserSearchFilter != "" {
 		filter = fmt.Sprintf("(&%s%s)", filter, l.UserSearchFilter)
 	}
+	attributes := []string{l.LoginAttribute, l.MailAttribute}
+	if l.UserMemberAttribute != "" {
+		attributes = append(attributes, l.UserMemberAttribute)
+	}
 	result, err := conn.Search(&ldap.SearchRequest{
 		BaseDN:       l.UserSearchBase,
 		Scope:        ldap.ScopeWholeSubtree,
@@ -140,7 +152,7 @@ func (l ldapProvider) Authenticate(username string, password string) (identitypr
 		TimeLimit:    0,
 		TypesOnly:    false,
 		Filter:       filter,
-		Attributes:   []string{l.LoginAttribute, l.MailAttribute},
+		Attributes:   attributes,
 	})
 	if err != nil {
 		klog.Error(err)
@@ -167,12 +179,78 @@ func (l ldapProvider) Authenticate(username string, password string) (identitypr
 	}
 	email := entry.GetAttributeValue(l.MailAttribute)
 	uid := entry.GetAttributeValue(l.LoginAttribute)
+	groups, err := l.searchGroups(conn, entry)
+	if err != nil {
+		klog.Error(err)
+		return nil, err
+	}
 	return &ldapIdentity{
 		Username: uid,
 		Email:    email,
+		Groups:   groups,
 	}, nil
 }
 
+// searchGroups returns the names of the groups the user entry is a member of.
+// The UserMemberAttribute on the user entry takes precedence, otherwise groups
+// under the GroupSearchBase are searched by the GroupMemberAttribute.
+func (l ldapProvider) searchGroups(conn *ldap.Conn, entry *ldap.Entry) ([]string, error) {
+	if l.UserMemberAttribute != "" {
+		groups := make([]string, 0)
+		for _, groupDN := range entry.GetAttributeValues(l.UserMemberAttribute) {
+			if name := groupNameFromDN(groupDN); name != "" {
+				groups = append(groups, name)
+			}
+		}
+		return groups, nil
+	}
+
+	if l.GroupSearchBase == "" || l.GroupMemberAttribute == "" {
+		return nil, nil
+	}
+
+	// the user may not have the permission to search groups
+	if err := conn.Bind(l.ManagerDN, l.ManagerPassword); err != nil {
+		return nil, err
+	}
+
+	groupNameAttribute := l.GroupNameAttribute
+	if groupNameAttribute == "" {
+		groupNameAttribute = defaultGroupNameAttr
+	}
+	filter := fmt.Sprintf("(%s=%s)", l.GroupMemberAttribute, ldap.EscapeFilter(entry.DN))
+	if l.GroupSearchFilter != "" {
+		filter = fmt.Sprintf("(&%s%s)", filter, l.GroupSearchFilter)
+	}
+	result, err := conn.Search(&ldap.SearchRequest{
+		BaseDN:       l.GroupSearchBase,
+		Scope:        ldap.ScopeWholeSubtree,
+		DerefAliases: ldap.NeverDerefAliases,
+		Filter:       filter,
+		Attributes:   []string{groupNameAttribute},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	groups := make([]string, 0, len(result.Entries))
+	for _, group := range result.Entries {
+		if name := group.GetAttributeValue(groupNameAttribute); name != "" {
+			groups = append(groups, name)
+		}
+	}
+	return groups, nil
+}
+
+// groupNameFromDN returns the value of the first RDN, e.g. "developers" for "cn=developers,ou=groups,dc=example,dc=org"
+func groupNameFromDN(groupDN string) string {
+	dn, err := ldap.ParseDN(groupDN)
+	if err != nil || len(dn.RDNs) == 0 || len(dn.RDNs[0].Attributes) == 0 {
+		return ""
+	}
+	return dn.RDNs[0].Attributes[0].Value
+}
+
 func (l *ldapProvider) newConn() (*ldap.Conn, error) {
 	if !l.StartTLS {
 		return ldap.Dial("tcp", l.Host)
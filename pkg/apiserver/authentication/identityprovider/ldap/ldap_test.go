@@ -89,3 +89,20 @@ func TestLdapProvider_Authenticate(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+func TestGroupNameFromDN(t *testing.T) {
+	tests := []struct {
+		dn   string
+		want string
+	}{
+		{dn: "cn=developers,ou=groups,dc=example,dc=org", want: "developers"},
+		{dn: "CN=Domain Admins,CN=Users,DC=example,DC=org", want: "Domain Admins"},
+		{dn: "", want: ""},
+		{dn: "invalid", want: ""},
+	}
+	for _, tt := range tests {
+		if got := groupNameFromDN(tt.dn); got != tt.want {
+			t.Errorf("groupNameFromDN(%q) = %q, want %q", tt.dn, got, tt.want)
+		}
+	}
+}
@@ -18,6 +18,7 @@ package identityprovider
 
 import (
 	"net/http"
+	"time"
 
 	"kubesphere.io/kubesphere/pkg/server/options"
 )
@@ -27,6 +28,29 @@ type OAuthProvider interface {
 	IdentityExchangeCallback(req *http.Request) (Identity, error)
 }
 
+// SAMLProvider is an OAuthProvider that exchanges the identity by SAML 2.0,
+// the SAMLResponse is posted to the callback URL by the identity provider.
+type SAMLProvider interface {
+	OAuthProvider
+	// AuthnRequestURL returns the ID of a new AuthnRequest and the URL that redirect the user agent
+	// to the identity provider with it
+	AuthnRequestURL(relayState string) (string, string, error)
+	// ServiceProviderMetadata returns the SAML metadata of the service provider
+	ServiceProviderMetadata() ([]byte, error)
+}
+
+// SAMLAssertion is the identity exchanged from a SAML assertion, the assertion must be accepted only once
+// and in response to an outstanding AuthnRequest, so that a captured response can't be replayed.
+type SAMLAssertion interface {
+	Identity
+	// GetAssertionID returns the ID of the assertion
+	GetAssertionID() string
+	// GetInResponseTo returns the ID of the AuthnRequest which the assertion is issued in response to
+	GetInResponseTo() string
+	// GetNotOnOrAfter returns the time from which the assertion is rejected as expired
+	GetNotOnOrAfter() time.Time
+}
+
 type OAuthProviderFactory interface {
 	// Type unique type of the provider
 	Type() string
@@ -76,6 +76,9 @@ type oidcProvider struct {
 	// Configurable key which contains the preferred username claims
 	PreferredUsernameKey string `json:"preferredUsernameKey" yaml:"preferredUsernameKey"`
 
+	// Configurable key which contains the groups claims
+	GroupsKey string `json:"groupsKey" yaml:"groupsKey"`
+
 	Provider     *oidc.Provider        `json:"-" yaml:"-"`
 	OAuth2Config *oauth2.Config        `json:"-" yaml:"-"`
 	Verifier     *oidc.IDTokenVerifier `json:"-" yaml:"-"`
@@ -111,6 +114,8 @@ type oidcIdentity struct {
 	// Its value MUST conform to the RFC 5322 [RFC5322] addr-spec syntax.
 	// The RP MUST NOT rely upon this value being unique.
 	Email string `json:"email"`
+	// Groups the End-User belongs to, taken from the groups claims.
+	Groups []string `json:"groups,omitempty"`
 }
 
 func (o oidcIdentity) GetUserID() string {
@@ -125,6 +130,10 @@ func (o oidcIdentity) GetEmail() string {
 	return o.Email
 }
 
+func (o oidcIdentity) GetGroups() []string {
+	return o.Groups
+}
+
 type oidcProviderFactory struct {
 }
 
@@ -283,9 +292,37 @@ func (o *oidcProvider) IdentityExchangeCallback(req *http.Request) (identityprov
 		preferredUsername, _ = claims["name"].(string)
 	}
 
+	groupsKey := "groups"
+	if o.GroupsKey != "" {
+		groupsKey = o.GroupsKey
+	}
+
 	return &oidcIdentity{
 		Sub:               subject,
 		PreferredUsername: preferredUsername,
 		Email:             email,
+		Groups:            groupsClaim(claims[groupsKey]),
 	}, nil
 }
+
+// groupsClaim converts the groups claim to a string slice,
+// both a JSON array and a single string value are accepted.
+func groupsClaim(claim interface{}) []string {
+	switch value := claim.(type) {
+	case string:
+		if value != "" {
+			return []string{value}
+		}
+	case []string:
+		return value
+	case []interface{}:
+		groups := make([]string, 0, len(value))
+		for _, item := range value {
+			if group, ok := item.(string); ok && group != "" {
+				groups = append(groups, group)
+			}
+		}
+		return groups
+	}
+	return nil
+}
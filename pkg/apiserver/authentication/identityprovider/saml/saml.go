@@ -0,0 +1,552 @@
+/*
+Copyright 2022 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package saml
+
+import (
+	"bytes"
+	"compress/flate"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/beevik/etree"
+	"github.com/mitchellh/mapstructure"
+	dsig "github.com/russellhaering/goxmldsig"
+
+	"kubesphere.io/kubesphere/pkg/apiserver/authentication/identityprovider"
+	"kubesphere.io/kubesphere/pkg/server/options"
+)
+
+const (
+	samlIdentityProvider = "SAMLIdentityProvider"
+
+	protocolNamespace  = "urn:oasis:names:tc:SAML:2.0:protocol"
+	assertionNamespace = "urn:oasis:names:tc:SAML:2.0:assertion"
+	metadataNamespace  = "urn:oasis:names:tc:SAML:2.0:metadata"
+
+	httpPostBinding     = "urn:oasis:names:tc:SAML:2.0:bindings:HTTP-POST"
+	httpRedirectBinding = "urn:oasis:names:tc:SAML:2.0:bindings:HTTP-Redirect"
+	statusSuccess       = "urn:oasis:names:tc:SAML:2.0:status:Success"
+	bearerConfirmation  = "urn:oasis:names:tc:SAML:2.0:cm:bearer"
+
+	defaultNameIDFormat = "urn:oasis:names:tc:SAML:1.1:nameid-format:unspecified"
+	// allowedClockSkew tolerates the clock difference between the service provider and the identity provider
+	allowedClockSkew = 3 * time.Minute
+)
+
+func init() {
+	identityprovider.RegisterOAuthProvider(&samlProviderFactory{})
+}
+
+type samlProvider struct {
+	// EntityID is the unique identifier of KubeSphere as a service provider,
+	// default to the AssertionConsumerServiceURL.
+	EntityID string `json:"entityID" yaml:"entityID"`
+
+	// AssertionConsumerServiceURL is the URL where the identity provider posts the SAMLResponse to.
+	// e.g. https://ks-console.kubesphere-system.svc/oauth/redirect/saml
+	AssertionConsumerServiceURL string `json:"assertionConsumerServiceURL" yaml:"assertionConsumerServiceURL"`
+
+	// SSOURL is the single sign-on service URL of the identity provider, HTTP-Redirect binding is used.
+	SSOURL string `json:"ssoURL" yaml:"ssoURL"`
+
+	// IdPEntityID is the expected issuer of the assertions, the issuer is not verified if empty.
+	IdPEntityID string `json:"idpEntityID" yaml:"idpEntityID"`
+
+	// Path to the certificate file used by the identity provider to sign responses.
+	IdPCertificate string `json:"idpCertificate,omitempty" yaml:"idpCertificate"`
+
+	// The identity provider certificate can also be provided inline. Base64 encoded PEM file.
+	IdPCertificateData string `json:"idpCertificateData,omitempty" yaml:"idpCertificateData"`
+
+	// Path to the certificate file of the service provider, published in the metadata.
+	Certificate string `json:"certificate,omitempty" yaml:"certificate"`
+
+	// The service provider certificate can also be provided inline. Base64 encoded PEM file.
+	CertificateData string `json:"certificateData,omitempty" yaml:"certificateData"`
+
+	// Path to the private key file used to sign the AuthnRequests.
+	PrivateKey string `json:"-" yaml:"privateKey"`
+
+	// The service provider private key can also be provided inline. Base64 encoded PEM file.
+	PrivateKeyData string `json:"-" yaml:"privateKeyData"`
+
+	// NameIDFormat requested in the AuthnRequests.
+	NameIDFormat string `json:"nameIDFormat,omitempty" yaml:"nameIDFormat"`
+
+	// Attribute used as the username, default to the NameID of the subject.
+	UsernameAttribute string `json:"usernameAttribute,omitempty" yaml:"usernameAttribute"`
+
+	// Attribute which contains the email.
+	EmailAttribute string `json:"emailAttribute,omitempty" yaml:"emailAttribute"`
+
+	// Attribute which contains the groups.
+	GroupsAttribute string `json:"groupsAttribute,omitempty" yaml:"groupsAttribute"`
+
+	idpCertificates []*x509.Certificate
+	keyStore        dsig.X509KeyStore
+	clock           *dsig.Clock
+}
+
+type samlIdentity struct {
+	NameID       string
+	Username     string
+	Email        string
+	Groups       []string
+	AssertionID  string
+	InResponseTo string
+	NotOnOrAfter time.Time
+}
+
+func (s samlIdentity) GetUserID() string {
+	return s.NameID
+}
+
+func (s samlIdentity) GetUsername() string {
+	if s.Username != "" {
+		return s.Username
+	}
+	return s.NameID
+}
+
+func (s samlIdentity) GetEmail() string {
+	return s.Email
+}
+
+func (s samlIdentity) GetGroups() []string {
+	return s.Groups
+}
+
+func (s samlIdentity) GetAssertionID() string {
+	return s.AssertionID
+}
+
+func (s samlIdentity) GetInResponseTo() string {
+	return s.InResponseTo
+}
+
+func (s samlIdentity) GetNotOnOrAfter() time.Time {
+	return s.NotOnOrAfter
+}
+
+type samlProviderFactory struct {
+}
+
+func (f *samlProviderFactory) Type() string {
+	return samlIdentityProvider
+}
+
+func (f *samlProviderFactory) Create(opts options.DynamicOptions) (identityprovider.OAuthProvider, error) {
+	var provider samlProvider
+	if err := mapstructure.Decode(opts, &provider); err != nil {
+		return nil, err
+	}
+	if provider.AssertionConsumerServiceURL == "" {
+		return nil, errors.New("saml: assertionConsumerServiceURL is required")
+	}
+	if provider.SSOURL == "" {
+		return nil, errors.New("saml: ssoURL is required")
+	}
+	if provider.EntityID == "" {
+		provider.EntityID = provider.AssertionConsumerServiceURL
+	}
+	if provider.NameIDFormat == "" {
+		provider.NameIDFormat = defaultNameIDFormat
+	}
+
+	idpCertificateData, err := readPEM(provider.IdPCertificate, provider.IdPCertificateData)
+	if err != nil {
+		return nil, err
+	}
+	if idpCertificateData == nil {
+		return nil, errors.New("saml: the certificate of identity provider is required")
+	}
+	if provider.idpCertificates, err = parseCertificates(idpCertificateData); err != nil {
+		return nil, err
+	}
+
+	certificateData, err := readPEM(provider.Certificate, provider.CertificateData)
+	if err != nil {
+		return nil, err
+	}
+	privateKeyData, err := readPEM(provider.PrivateKey, provider.PrivateKeyData)
+	if err != nil {
+		return nil, err
+	}
+	// the AuthnRequests will not be signed without the key pair
+	if certificateData != nil && privateKeyData != nil {
+		keyPair, err := tls.X509KeyPair(certificateData, privateKeyData)
+		if err != nil {
+			return nil, fmt.Errorf("saml: failed to load key pair: %v", err)
+		}
+		provider.keyStore = dsig.TLSCertKeyStore(keyPair)
+	}
+	provider.clock = dsig.NewRealClock()
+	return &provider, nil
+}
+
+// readPEM reads the PEM data from the file, or decode the base64 encoded inline data
+func readPEM(file, data string) ([]byte, error) {
+	if file != "" {
+		return os.ReadFile(file)
+	}
+	if data != "" {
+		return base64.StdEncoding.DecodeString(data)
+	}
+	return nil, nil
+}
+
+func parseCertificates(data []byte) ([]*x509.Certificate, error) {
+	var certificates []*x509.Certificate
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+		certificate, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("saml: failed to parse certificate: %v", err)
+		}
+		certificates = append(certificates, certificate)
+	}
+	if len(certificates) == 0 {
+		return nil, errors.New("saml: no certificate found")
+	}
+	return certificates, nil
+}
+
+// AuthnRequestURL returns the URL that redirect the user agent to the identity provider with an AuthnRequest,
+// see also 3.4 HTTP Redirect Binding of https://docs.oasis-open.org/security/saml/v2.0/saml-bindings-2.0-os.pdf
+func (s *samlProvider) AuthnRequestURL(relayState string) (string, string, error) {
+	requestID := newID()
+	data, err := s.newAuthnRequest(requestID).WriteToBytes()
+	if err != nil {
+		return "", "", err
+	}
+	var buf bytes.Buffer
+	writer, _ := flate.NewWriter(&buf, flate.DefaultCompression)
+	if _, err = writer.Write(data); err != nil {
+		return "", "", err
+	}
+	if err = writer.Close(); err != nil {
+		return "", "", err
+	}
+
+	ssoURL, err := url.Parse(s.SSOURL)
+	if err != nil {
+		return "", "", err
+	}
+
+	// the signature is computed over the query string in the exact order of SAMLRequest, RelayState and SigAlg
+	query := "SAMLRequest=" + url.QueryEscape(base64.StdEncoding.EncodeToString(buf.Bytes()))
+	if relayState != "" {
+		query += "&RelayState=" + url.QueryEscape(relayState)
+	}
+	if s.keyStore != nil {
+		signingContext := dsig.NewDefaultSigningContext(s.keyStore)
+		query += "&SigAlg=" + url.QueryEscape(signingContext.GetSignatureMethodIdentifier())
+		signature, err := signingContext.SignString(query)
+		if err != nil {
+			return "", "", err
+		}
+		query += "&Signature=" + url.QueryEscape(base64.StdEncoding.EncodeToString(signature))
+	}
+
+	if ssoURL.RawQuery != "" {
+		query = ssoURL.RawQuery + "&" + query
+	}
+	ssoURL.RawQuery = query
+	return requestID, ssoURL.String(), nil
+}
+
+func (s *samlProvider) newAuthnRequest(requestID string) *etree.Document {
+	doc := etree.NewDocument()
+	authnRequest := doc.CreateElement("samlp:AuthnRequest")
+	authnRequest.CreateAttr("xmlns:samlp", protocolNamespace)
+	authnRequest.CreateAttr("xmlns:saml", assertionNamespace)
+	authnRequest.CreateAttr("ID", requestID)
+	authnRequest.CreateAttr("Version", "2.0")
+	authnRequest.CreateAttr("IssueInstant", s.clock.Now().UTC().Format(time.RFC3339))
+	authnRequest.CreateAttr("Destination", s.SSOURL)
+	authnRequest.CreateAttr("ProtocolBinding", httpPostBinding)
+	authnRequest.CreateAttr("AssertionConsumerServiceURL", s.AssertionConsumerServiceURL)
+	authnRequest.CreateElement("saml:Issuer").SetText(s.EntityID)
+	nameIDPolicy := authnRequest.CreateElement("samlp:NameIDPolicy")
+	nameIDPolicy.CreateAttr("Format", s.NameIDFormat)
+	nameIDPolicy.CreateAttr("AllowCreate", "true")
+	return doc
+}
+
+// ServiceProviderMetadata returns the SAML metadata of KubeSphere as a service provider,
+// see also https://docs.oasis-open.org/security/saml/v2.0/saml-metadata-2.0-os.pdf
+func (s *samlProvider) ServiceProviderMetadata() ([]byte, error) {
+	doc := etree.NewDocument()
+	doc.CreateProcInst("xml", `version="1.0" encoding="UTF-8"`)
+	entityDescriptor := doc.CreateElement("md:EntityDescriptor")
+	entityDescriptor.CreateAttr("xmlns:md", metadataNamespace)
+	entityDescriptor.CreateAttr("entityID", s.EntityID)
+
+	spSSODescriptor := entityDescriptor.CreateElement("md:SPSSODescriptor")
+	spSSODescriptor.CreateAttr("AuthnRequestsSigned", fmt.Sprint(s.keyStore != nil))
+	spSSODescriptor.CreateAttr("WantAssertionsSigned", "true")
+	spSSODescriptor.CreateAttr("protocolSupportEnumeration", protocolNamespace)
+
+	if s.keyStore != nil {
+		_, certificate, err := s.keyStore.GetKeyPair()
+		if err != nil {
+			return nil, err
+		}
+		keyDescriptor := spSSODescriptor.CreateElement("md:KeyDescriptor")
+		keyDescriptor.CreateAttr("use", "signing")
+		keyInfo := keyDescriptor.CreateElement("ds:KeyInfo")
+		keyInfo.CreateAttr("xmlns:ds", dsig.Namespace)
+		keyInfo.CreateElement("ds:X509Data").
+			CreateElement("ds:X509Certificate").
+			SetText(base64.StdEncoding.EncodeToString(certificate))
+	}
+
+	spSSODescriptor.CreateElement("md:NameIDFormat").SetText(s.NameIDFormat)
+	assertionConsumerService := spSSODescriptor.CreateElement("md:AssertionConsumerService")
+	assertionConsumerService.CreateAttr("Binding", httpPostBinding)
+	assertionConsumerService.CreateAttr("Location", s.AssertionConsumerServiceURL)
+	assertionConsumerService.CreateAttr("index", "0")
+
+	doc.Indent(2)
+	return doc.WriteToBytes()
+}
+
+func (s *samlProvider) IdentityExchangeCallback(req *http.Request) (identityprovider.Identity, error) {
+	// the SAMLResponse is posted to the assertion consumer service with HTTP POST binding,
+	// see also 3.5 HTTP POST Binding of https://docs.oasis-open.org/security/saml/v2.0/saml-bindings-2.0-os.pdf
+	encoded := req.FormValue("SAMLResponse")
+	if encoded == "" {
+		return nil, errors.New("saml: SAMLResponse is required")
+	}
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("saml: failed to decode SAMLResponse: %v", err)
+	}
+	doc := etree.NewDocument()
+	if err = doc.ReadFromBytes(data); err != nil {
+		return nil, fmt.Errorf("saml: failed to parse SAMLResponse: %v", err)
+	}
+	response := doc.Root()
+	if response == nil || response.Tag != "Response" {
+		return nil, errors.New("saml: invalid SAMLResponse")
+	}
+
+	assertion, err := s.validateResponse(response)
+	if err != nil {
+		return nil, err
+	}
+	return s.identityFromAssertion(assertion)
+}
+
+// validateResponse verifies the signature and status of the response, returns the verified assertion.
+// Only the elements returned by the signature validation are trusted, which prevents the signature wrapping attacks.
+func (s *samlProvider) validateResponse(response *etree.Element) (*etree.Element, error) {
+	validationContext := dsig.NewDefaultValidationContext(&dsig.MemoryX509CertificateStore{Roots: s.idpCertificates})
+	validationContext.Clock = s.clock
+
+	responseSigned := response.SelectElement("Signature") != nil
+	if responseSigned {
+		verified, err := validationContext.Validate(response)
+		if err != nil {
+			return nil, fmt.Errorf("saml: failed to verify response signature: %v", err)
+		}
+		response = verified
+	}
+
+	if destination := response.SelectAttrValue("Destination", ""); destination != "" &&
+		destination != s.AssertionConsumerServiceURL {
+		return nil, fmt.Errorf("saml: unexpected destination %s", destination)
+	}
+
+	statusCode := response.FindElement("./Status/StatusCode")
+	if statusCode == nil || statusCode.SelectAttrValue("Value", "") != statusSuccess {
+		return nil, errors.New("saml: the identity provider responded with a non-success status")
+	}
+
+	if response.SelectElement("EncryptedAssertion") != nil {
+		return nil, errors.New("saml: encrypted assertions are not supported")
+	}
+	assertions := response.SelectElements("Assertion")
+	if len(assertions) != 1 {
+		return nil, fmt.Errorf("saml: expected exactly one assertion, got %d", len(assertions))
+	}
+	assertion := assertions[0]
+
+	if assertion.SelectElement("Signature") != nil {
+		verified, err := validationContext.Validate(assertion)
+		if err != nil {
+			return nil, fmt.Errorf("saml: failed to verify assertion signature: %v", err)
+		}
+		assertion = verified
+	} else if !responseSigned {
+		return nil, errors.New("saml: neither the response nor the assertion is signed")
+	}
+
+	if err := s.validateAssertion(assertion); err != nil {
+		return nil, err
+	}
+	if inResponseTo := response.SelectAttrValue("InResponseTo", ""); inResponseTo != "" &&
+		inResponseTo != bearerConfirmationData(assertion).SelectAttrValue("InResponseTo", "") {
+		return nil, errors.New("saml: InResponseTo of the response does not match the assertion")
+	}
+	return assertion, nil
+}
+
+func (s *samlProvider) validateAssertion(assertion *etree.Element) error {
+	now := s.clock.Now()
+
+	if s.IdPEntityID != "" {
+		issuer := assertion.SelectElement("Issuer")
+		if issuer == nil || strings.TrimSpace(issuer.Text()) != s.IdPEntityID {
+			return errors.New("saml: unexpected issuer of the assertion")
+		}
+	}
+
+	if conditions := assertion.SelectElement("Conditions"); conditions != nil {
+		if err := validateTimeRange(conditions, now); err != nil {
+			return err
+		}
+		for _, audienceRestriction := range conditions.SelectElements("AudienceRestriction") {
+			matched := false
+			for _, audience := range audienceRestriction.SelectElements("Audience") {
+				if strings.TrimSpace(audience.Text()) == s.EntityID {
+					matched = true
+				}
+			}
+			if !matched {
+				return errors.New("saml: the assertion is not intended for this service provider")
+			}
+		}
+	}
+
+	if assertion.SelectAttrValue("ID", "") == "" {
+		return errors.New("saml: missing ID of the assertion")
+	}
+
+	// the unsolicited responses are not accepted, see also 4.1.4.2 of
+	// https://docs.oasis-open.org/security/saml/v2.0/saml-profiles-2.0-os.pdf
+	data := bearerConfirmationData(assertion)
+	if data == nil {
+		return errors.New("saml: missing bearer subject confirmation")
+	}
+	if data.SelectAttrValue("InResponseTo", "") == "" {
+		return errors.New("saml: missing InResponseTo of the subject confirmation")
+	}
+	if data.SelectAttrValue("NotOnOrAfter", "") == "" {
+		return errors.New("saml: missing NotOnOrAfter of the subject confirmation")
+	}
+	if recipient := data.SelectAttrValue("Recipient", ""); recipient != "" && recipient != s.AssertionConsumerServiceURL {
+		return fmt.Errorf("saml: unexpected recipient %s", recipient)
+	}
+	return validateTimeRange(data, now)
+}
+
+// bearerConfirmationData returns the SubjectConfirmationData of the bearer subject confirmation
+func bearerConfirmationData(assertion *etree.Element) *etree.Element {
+	for _, subjectConfirmation := range assertion.FindElements("./Subject/SubjectConfirmation") {
+		if subjectConfirmation.SelectAttrValue("Method", "") == bearerConfirmation {
+			return subjectConfirmation.SelectElement("SubjectConfirmationData")
+		}
+	}
+	return nil
+}
+
+func validateTimeRange(el *etree.Element, now time.Time) error {
+	if notBefore := el.SelectAttrValue("NotBefore", ""); notBefore != "" {
+		t, err := time.Parse(time.RFC3339, notBefore)
+		if err != nil {
+			return fmt.Errorf("saml: invalid NotBefore: %v", err)
+		}
+		if now.Add(allowedClockSkew).Before(t) {
+			return errors.New("saml: the assertion is not yet valid")
+		}
+	}
+	if notOnOrAfter := el.SelectAttrValue("NotOnOrAfter", ""); notOnOrAfter != "" {
+		t, err := time.Parse(time.RFC3339, notOnOrAfter)
+		if err != nil {
+			return fmt.Errorf("saml: invalid NotOnOrAfter: %v", err)
+		}
+		if !now.Add(-allowedClockSkew).Before(t) {
+			return errors.New("saml: the assertion has expired")
+		}
+	}
+	return nil
+}
+
+func (s *samlProvider) identityFromAssertion(assertion *etree.Element) (identityprovider.Identity, error) {
+	nameID := assertion.FindElement("./Subject/NameID")
+	if nameID == nil || strings.TrimSpace(nameID.Text()) == "" {
+		return nil, errors.New("saml: missing NameID of the subject")
+	}
+
+	attributes := make(map[string][]string)
+	for _, statement := range assertion.SelectElements("AttributeStatement") {
+		for _, attribute := range statement.SelectElements("Attribute") {
+			name := attribute.SelectAttrValue("Name", "")
+			for _, value := range attribute.SelectElements("AttributeValue") {
+				attributes[name] = append(attributes[name], strings.TrimSpace(value.Text()))
+			}
+		}
+	}
+	first := func(name string) string {
+		if values := attributes[name]; name != "" && len(values) > 0 {
+			return values[0]
+		}
+		return ""
+	}
+
+	data := bearerConfirmationData(assertion)
+	notOnOrAfter, err := time.Parse(time.RFC3339, data.SelectAttrValue("NotOnOrAfter", ""))
+	if err != nil {
+		return nil, fmt.Errorf("saml: invalid NotOnOrAfter: %v", err)
+	}
+
+	identity := samlIdentity{
+		NameID:       strings.TrimSpace(nameID.Text()),
+		Username:     first(s.UsernameAttribute),
+		Email:        first(s.EmailAttribute),
+		AssertionID:  assertion.SelectAttrValue("ID", ""),
+		InResponseTo: data.SelectAttrValue("InResponseTo", ""),
+		NotOnOrAfter: notOnOrAfter.Add(allowedClockSkew),
+	}
+	if s.GroupsAttribute != "" {
+		identity.Groups = attributes[s.GroupsAttribute]
+	}
+	return identity, nil
+}
+
+// newID returns a random identifier, which must not start with a number according to xsd:ID
+func newID() string {
+	buf := make([]byte, 20)
+	_, _ = rand.Read(buf)
+	return "id-" + hex.EncodeToString(buf)
+}
@@ -0,0 +1,322 @@
+/*
+Copyright 2022 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package saml
+
+import (
+	"bytes"
+	"compress/flate"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/beevik/etree"
+	dsig "github.com/russellhaering/goxmldsig"
+
+	"kubesphere.io/kubesphere/pkg/apiserver/authentication/identityprovider"
+	"kubesphere.io/kubesphere/pkg/server/options"
+)
+
+const (
+	acsURL      = "https://ks-console.kubesphere-system.svc/oauth/redirect/saml"
+	ssoURL      = "https://idp.example.org/sso"
+	idpEntityID = "https://idp.example.org/metadata"
+	requestID   = "id-request-1"
+)
+
+func newKeyPair(t *testing.T) (certPEM, keyPEM []byte) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "kubesphere"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(24 * time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certPEM = pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM = pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	return certPEM, keyPEM
+}
+
+func newProvider(t *testing.T, idpCert, spCert, spKey []byte) *samlProvider {
+	opts := options.DynamicOptions{
+		"assertionConsumerServiceURL": acsURL,
+		"ssoURL":                      ssoURL,
+		"idpEntityID":                 idpEntityID,
+		"idpCertificateData":          base64.StdEncoding.EncodeToString(idpCert),
+		"certificateData":             base64.StdEncoding.EncodeToString(spCert),
+		"privateKeyData":              base64.StdEncoding.EncodeToString(spKey),
+		"usernameAttribute":           "uid",
+		"emailAttribute":              "mail",
+		"groupsAttribute":             "memberOf",
+	}
+	provider, err := new(samlProviderFactory).Create(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return provider.(*samlProvider)
+}
+
+func newAssertion(nameID string, notOnOrAfter time.Time) *etree.Element {
+	assertion := etree.NewElement("saml:Assertion")
+	assertion.CreateAttr("xmlns:saml", assertionNamespace)
+	assertion.CreateAttr("ID", "assertion-1")
+	assertion.CreateAttr("Version", "2.0")
+	assertion.CreateElement("saml:Issuer").SetText(idpEntityID)
+	subject := assertion.CreateElement("saml:Subject")
+	subject.CreateElement("saml:NameID").SetText(nameID)
+	subjectConfirmation := subject.CreateElement("saml:SubjectConfirmation")
+	subjectConfirmation.CreateAttr("Method", bearerConfirmation)
+	data := subjectConfirmation.CreateElement("saml:SubjectConfirmationData")
+	data.CreateAttr("Recipient", acsURL)
+	data.CreateAttr("InResponseTo", requestID)
+	data.CreateAttr("NotOnOrAfter", notOnOrAfter.UTC().Format(time.RFC3339))
+	conditions := assertion.CreateElement("saml:Conditions")
+	conditions.CreateAttr("NotOnOrAfter", notOnOrAfter.UTC().Format(time.RFC3339))
+	conditions.CreateElement("saml:AudienceRestriction").CreateElement("saml:Audience").SetText(acsURL)
+	statement := assertion.CreateElement("saml:AttributeStatement")
+	for name, values := range map[string][]string{
+		"uid":      {"jdoe"},
+		"mail":     {"jdoe@example.org"},
+		"memberOf": {"developers", "operators"},
+	} {
+		attribute := statement.CreateElement("saml:Attribute")
+		attribute.CreateAttr("Name", name)
+		for _, value := range values {
+			attribute.CreateElement("saml:AttributeValue").SetText(value)
+		}
+	}
+	return assertion
+}
+
+func newResponse(assertion *etree.Element) *etree.Document {
+	doc := etree.NewDocument()
+	response := doc.CreateElement("samlp:Response")
+	response.CreateAttr("xmlns:samlp", protocolNamespace)
+	response.CreateAttr("ID", "response-1")
+	response.CreateAttr("Version", "2.0")
+	response.CreateAttr("Destination", acsURL)
+	response.CreateElement("samlp:Status").CreateElement("samlp:StatusCode").CreateAttr("Value", statusSuccess)
+	response.AddChild(assertion)
+	return doc
+}
+
+func sign(t *testing.T, el *etree.Element, certPEM, keyPEM []byte) *etree.Element {
+	keyPair, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signed, err := dsig.NewDefaultSigningContext(dsig.TLSCertKeyStore(keyPair)).SignEnveloped(el)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed
+}
+
+func postRequest(t *testing.T, doc *etree.Document) *http.Request {
+	data, err := doc.WriteToBytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	form := url.Values{"SAMLResponse": {base64.StdEncoding.EncodeToString(data)}}
+	req, err := http.NewRequest(http.MethodPost, acsURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return req
+}
+
+func TestIdentityExchangeCallback(t *testing.T) {
+	idpCert, idpKey := newKeyPair(t)
+	spCert, spKey := newKeyPair(t)
+	otherCert, otherKey := newKeyPair(t)
+	provider := newProvider(t, idpCert, spCert, spKey)
+
+	t.Run("signed assertion", func(t *testing.T) {
+		notOnOrAfter := time.Now().Add(5 * time.Minute).Truncate(time.Second)
+		assertion := sign(t, newAssertion("jdoe@example.org", notOnOrAfter), idpCert, idpKey)
+		identity, err := provider.IdentityExchangeCallback(postRequest(t, newResponse(assertion)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if identity.GetUserID() != "jdoe@example.org" || identity.GetUsername() != "jdoe" ||
+			identity.GetEmail() != "jdoe@example.org" || len(identity.GetGroups()) != 2 {
+			t.Errorf("unexpected identity %#v", identity)
+		}
+		samlAssertion, ok := identity.(identityprovider.SAMLAssertion)
+		if !ok || samlAssertion.GetAssertionID() != "assertion-1" || samlAssertion.GetInResponseTo() != requestID ||
+			!samlAssertion.GetNotOnOrAfter().Equal(notOnOrAfter.Add(allowedClockSkew)) {
+			t.Errorf("unexpected assertion %#v", identity)
+		}
+	})
+
+	t.Run("signed response", func(t *testing.T) {
+		response := newResponse(newAssertion("jdoe@example.org", time.Now().Add(5*time.Minute)))
+		response.SetRoot(sign(t, response.Root(), idpCert, idpKey))
+		if _, err := provider.IdentityExchangeCallback(postRequest(t, response)); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	tests := []struct {
+		name     string
+		response func() *etree.Document
+	}{
+		{
+			name: "unsigned",
+			response: func() *etree.Document {
+				return newResponse(newAssertion("jdoe@example.org", time.Now().Add(5*time.Minute)))
+			},
+		},
+		{
+			name: "signed by unknown key",
+			response: func() *etree.Document {
+				return newResponse(sign(t, newAssertion("jdoe@example.org", time.Now().Add(5*time.Minute)), otherCert, otherKey))
+			},
+		},
+		{
+			name: "modified after signed",
+			response: func() *etree.Document {
+				assertion := sign(t, newAssertion("jdoe@example.org", time.Now().Add(5*time.Minute)), idpCert, idpKey)
+				assertion.FindElement("./Subject/NameID").SetText("admin")
+				return newResponse(assertion)
+			},
+		},
+		{
+			name: "unsolicited",
+			response: func() *etree.Document {
+				assertion := newAssertion("jdoe@example.org", time.Now().Add(5*time.Minute))
+				assertion.FindElement("./Subject/SubjectConfirmation/SubjectConfirmationData").RemoveAttr("InResponseTo")
+				return newResponse(sign(t, assertion, idpCert, idpKey))
+			},
+		},
+		{
+			name: "response to another request",
+			response: func() *etree.Document {
+				response := newResponse(sign(t, newAssertion("jdoe@example.org", time.Now().Add(5*time.Minute)), idpCert, idpKey))
+				response.Root().CreateAttr("InResponseTo", "id-request-2")
+				return response
+			},
+		},
+		{
+			name: "expired",
+			response: func() *etree.Document {
+				return newResponse(sign(t, newAssertion("jdoe@example.org", time.Now().Add(-time.Hour)), idpCert, idpKey))
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if identity, err := provider.IdentityExchangeCallback(postRequest(t, tt.response())); err == nil {
+				t.Errorf("IdentityExchangeCallback() should fail, got %#v", identity)
+			}
+		})
+	}
+}
+
+func TestAuthnRequestURL(t *testing.T) {
+	idpCert, _ := newKeyPair(t)
+	spCert, spKey := newKeyPair(t)
+	provider := newProvider(t, idpCert, spCert, spKey)
+
+	id, redirectURL, err := provider.AuthnRequestURL("state")
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, err := url.Parse(redirectURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.Query().Get("RelayState") != "state" || u.Query().Get("SigAlg") != dsig.RSASHA256SignatureMethod {
+		t.Errorf("unexpected query %s", u.RawQuery)
+	}
+
+	data, err := base64.StdEncoding.DecodeString(u.Query().Get("SAMLRequest"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	inflated, err := io.ReadAll(flate.NewReader(bytes.NewReader(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc := etree.NewDocument()
+	if err = doc.ReadFromBytes(inflated); err != nil {
+		t.Fatal(err)
+	}
+	if doc.Root().SelectAttrValue("AssertionConsumerServiceURL", "") != acsURL || doc.Root().SelectAttrValue("ID", "") != id {
+		t.Errorf("unexpected AuthnRequest %s", inflated)
+	}
+
+	signed := u.RawQuery[:strings.Index(u.RawQuery, "&Signature=")]
+	signature, err := base64.StdEncoding.DecodeString(u.Query().Get("Signature"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, _ := pem.Decode(spCert)
+	certificate, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	digest := sha256.Sum256([]byte(signed))
+	if err = rsa.VerifyPKCS1v15(certificate.PublicKey.(*rsa.PublicKey), crypto.SHA256, digest[:], signature); err != nil {
+		t.Errorf("failed to verify AuthnRequest signature: %v", err)
+	}
+}
+
+func TestServiceProviderMetadata(t *testing.T) {
+	idpCert, _ := newKeyPair(t)
+	spCert, spKey := newKeyPair(t)
+	provider := newProvider(t, idpCert, spCert, spKey)
+
+	metadata, err := provider.ServiceProviderMetadata()
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc := etree.NewDocument()
+	if err = doc.ReadFromBytes(metadata); err != nil {
+		t.Fatal(err)
+	}
+	if doc.Root().SelectAttrValue("entityID", "") != acsURL {
+		t.Errorf("unexpected entityID in metadata %s", metadata)
+	}
+	acs := doc.FindElement("//AssertionConsumerService")
+	if acs == nil || acs.SelectAttrValue("Location", "") != acsURL {
+		t.Errorf("unexpected AssertionConsumerService in metadata %s", metadata)
+	}
+	if doc.FindElement("//X509Certificate") == nil {
+		t.Errorf("missing signing certificate in metadata %s", metadata)
+	}
+}
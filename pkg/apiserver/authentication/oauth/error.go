@@ -74,6 +74,30 @@ var (
 	// Error HTTP status code cannot be returned to the client
 	// via an HTTP redirect.)
 	ErrorServerError = Error{Type: "server_error"}
+
+	// ErrorMultiFactorRequired The password is verified, but the user must pass the TOTP challenge.
+	// The client should exchange the mfa_token along with the passcode for tokens by the mfa_otp grant.
+	ErrorMultiFactorRequired = Error{Type: "mfa_required"}
+
+	// ErrorMultiFactorEnrollmentRequired The same as ErrorMultiFactorRequired,
+	// except that the user must enroll with the mfa_token before the mfa_otp grant.
+	ErrorMultiFactorEnrollmentRequired = Error{Type: "mfa_enrollment_required"}
+
+	// The following error types are defined in https://datatracker.ietf.org/doc/html/rfc8628#section-3.5
+
+	// ErrorAuthorizationPending The authorization request is still pending as
+	// the end user hasn't yet completed the user-interaction steps.
+	ErrorAuthorizationPending = Error{Type: "authorization_pending"}
+
+	// ErrorSlowDown A variant of "authorization_pending", the authorization request is still pending
+	// and polling should continue, but the interval MUST be increased by 5 seconds for this and all subsequent requests.
+	ErrorSlowDown = Error{Type: "slow_down"}
+
+	// ErrorAccessDenied The authorization request was denied.
+	ErrorAccessDenied = Error{Type: "access_denied"}
+
+	// ErrorExpiredToken The "device_code" has expired, and the device authorization session has concluded.
+	ErrorExpiredToken = Error{Type: "expired_token"}
 )
 
 func NewInvalidRequest(error error) Error {
@@ -122,6 +146,12 @@ type Error struct {
 	// Values for the "error_description" parameter MUST NOT include
 	// characters outside the set %x20-21 / %x23-5B / %x5D-7E.
 	Description string `json:"error_description,omitempty"`
+	// MultiFactorToken is the short-lived challenge returned with ErrorMultiFactorRequired
+	// and ErrorMultiFactorEnrollmentRequired.
+	MultiFactorToken string `json:"mfa_token,omitempty"`
+	// CaptchaRequired signals the client to present a CAPTCHA along with the next login attempt,
+	// it is returned when too many login attempts failed for the username or from the source IP.
+	CaptchaRequired bool `json:"captcha_required,omitempty"`
 }
 
 func (e Error) Error() string {
@@ -89,6 +89,11 @@ type Options struct {
 	// - X: Tokens time out if there is no activity
 	// The current minimum allowed value for X is 5 minutes
 	AccessTokenInactivityTimeout time.Duration `json:"accessTokenInactivityTimeout" yaml:"accessTokenInactivityTimeout"`
+
+	// SigningKeyRotationPeriod controls how often the key used to sign the id token is rotated.
+	// The retired keys are still published and used for verification until the tokens signed by them expire.
+	// 0 means the keys are only rotated through the API or by changing the sign key.
+	SigningKeyRotationPeriod time.Duration `json:"signingKeyRotationPeriod,omitempty" yaml:"signingKeyRotationPeriod,omitempty"`
 }
 
 type IdentityProviderOptions struct {
@@ -114,6 +119,24 @@ type IdentityProviderOptions struct {
 
 	// The options of identify provider
 	Provider options.DynamicOptions `json:"provider" yaml:"provider"`
+
+	// GroupMappings maps the groups of the identity to KubeSphere groups.
+	// The group memberships are reconciled each time the user logs in successfully,
+	// groups without a mapping are ignored.
+	GroupMappings []GroupMapping `json:"groupMappings,omitempty" yaml:"groupMappings,omitempty"`
+}
+
+type GroupMapping struct {
+	// The group name returned by the identity provider,
+	// e.g. the groups claim of OIDC, the organization of GitHub or the cn of LDAP group.
+	Claim string `json:"claim" yaml:"claim"`
+
+	// The name of the KubeSphere group.
+	Group string `json:"group" yaml:"group"`
+
+	// The workspace the group belongs to, if specified,
+	// the group will be created when it does not exist.
+	Workspace string `json:"workspace,omitempty" yaml:"workspace,omitempty"`
 }
 
 type Token struct {
@@ -137,6 +160,64 @@ type Token struct {
 	ExpiresIn int `json:"expires_in,omitempty"`
 }
 
+// DeviceAuthorizationResponse is the response of the device authorization endpoint,
+// for more details: https://datatracker.ietf.org/doc/html/rfc8628#section-3.2
+type DeviceAuthorizationResponse struct {
+	// DeviceCode is the device verification code used to poll the token endpoint.
+	DeviceCode string `json:"device_code"`
+
+	// UserCode is the end-user verification code.
+	UserCode string `json:"user_code"`
+
+	// VerificationURI is the end-user verification URI on the authorization server.
+	VerificationURI string `json:"verification_uri"`
+
+	// VerificationURIComplete is the verification URI that includes the user code.
+	VerificationURIComplete string `json:"verification_uri_complete,omitempty"`
+
+	// ExpiresIn is the lifetime in seconds of the device code and user code.
+	ExpiresIn int `json:"expires_in"`
+
+	// Interval is the minimum amount of time in seconds that the client
+	// should wait between polling requests to the token endpoint.
+	Interval int `json:"interval,omitempty"`
+}
+
+// TokenIntrospection is the response of the token introspection endpoint,
+// for more details: https://datatracker.ietf.org/doc/html/rfc7662#section-2.2
+type TokenIntrospection struct {
+	// Active indicates whether the token is currently active,
+	// the other fields are omitted if the token is inactive.
+	Active bool `json:"active"`
+
+	// Scope is a space-separated list of scopes associated with the token.
+	Scope string `json:"scope,omitempty"`
+
+	// Username is the human-readable identifier for the resource owner who authorized the token.
+	Username string `json:"username,omitempty"`
+
+	// TokenType is the type of the token.
+	TokenType string `json:"token_type,omitempty"`
+
+	// ExpiresAt is the timestamp indicating when the token will expire.
+	ExpiresAt int64 `json:"exp,omitempty"`
+
+	// IssuedAt is the timestamp indicating when the token was issued.
+	IssuedAt int64 `json:"iat,omitempty"`
+
+	// Subject is the subject of the token.
+	Subject string `json:"sub,omitempty"`
+
+	// Audience is the intended audience of the token.
+	Audience []string `json:"aud,omitempty"`
+
+	// Issuer is the issuer of the token.
+	Issuer string `json:"iss,omitempty"`
+
+	// ID is the identifier of the token.
+	ID string `json:"jti,omitempty"`
+}
+
 type Client struct {
 	// The name of the OAuth client is used as the client_id parameter when making requests to <master>/oauth/authorize
 	// and <master>/oauth/token.
@@ -31,6 +31,7 @@ import (
 	_ "kubesphere.io/kubesphere/pkg/apiserver/authentication/identityprovider/gitlab"
 	_ "kubesphere.io/kubesphere/pkg/apiserver/authentication/identityprovider/ldap"
 	_ "kubesphere.io/kubesphere/pkg/apiserver/authentication/identityprovider/oidc"
+	_ "kubesphere.io/kubesphere/pkg/apiserver/authentication/identityprovider/saml"
 	"kubesphere.io/kubesphere/pkg/apiserver/authentication/oauth"
 )
 
@@ -61,6 +62,93 @@ type Options struct {
 	OAuthOptions *oauth.Options `json:"oauthOptions" yaml:"oauthOptions"`
 	// KubectlImage is the image address we use to create kubectl pod for users who have admin access to the cluster.
 	KubectlImage string `json:"kubectlImage" yaml:"kubectlImage"`
+	// MultiFactorAuthOptions defines the policy of multi-factor authentication for kubesphere accounts
+	MultiFactorAuthOptions *MultiFactorAuthOptions `json:"multiFactorAuthOptions,omitempty" yaml:"multiFactorAuthOptions,omitempty"`
+	// PasswordPolicy defines the requirements of the passwords of kubesphere accounts
+	PasswordPolicy *PasswordPolicyOptions `json:"passwordPolicy,omitempty" yaml:"passwordPolicy,omitempty"`
+	// LoginLimiter throttles the failed login attempts by username and by source IP
+	LoginLimiter *LoginLimiterOptions `json:"loginLimiter,omitempty" yaml:"loginLimiter,omitempty"`
+}
+
+type MultiFactorAuthOptions struct {
+	// Users bound to any of the GlobalRoles are required to pass the TOTP challenge when they log in with password,
+	// users who have not enrolled yet must enroll during the login. For example,
+	//   RequiredGlobalRoles: ["platform-admin"]
+	// Users who have enrolled voluntarily are always challenged.
+	RequiredGlobalRoles []string `json:"requiredGlobalRoles,omitempty" yaml:"requiredGlobalRoles,omitempty"`
+	// Issuer is the name displayed by the authenticator apps, default to KubeSphere.
+	Issuer string `json:"issuer,omitempty" yaml:"issuer,omitempty"`
+	// ChallengeMaxAge control the lifetime of the challenge issued after the password is verified, default to 5m.
+	ChallengeMaxAge time.Duration `json:"challengeMaxAge,omitempty" yaml:"challengeMaxAge,omitempty"`
+}
+
+type PasswordPolicyOptions struct {
+	// MinLength is the minimum length of the password, default to 8.
+	MinLength int `json:"minLength,omitempty" yaml:"minLength,omitempty"`
+	// The character classes which the password must contain, by default the password must contain
+	// at least one lowercase letter, one uppercase letter and one digit.
+	RequireLowercase bool `json:"requireLowercase" yaml:"requireLowercase"`
+	RequireUppercase bool `json:"requireUppercase" yaml:"requireUppercase"`
+	RequireDigit     bool `json:"requireDigit" yaml:"requireDigit"`
+	RequireSymbol    bool `json:"requireSymbol" yaml:"requireSymbol"`
+	// DictionaryFile is the path of a local file which contains the forbidden passwords, one per line.
+	// A line is either a common password in plain text (case-insensitive), or the SHA-1 hash of a breached password
+	// in hex, optionally followed by ":<count>" as the Pwned Passwords downloads do.
+	DictionaryFile string `json:"dictionaryFile,omitempty" yaml:"dictionaryFile,omitempty"`
+	// HistorySize prevents the reuse of the last N passwords, 0 means the reuse is allowed.
+	HistorySize int `json:"historySize,omitempty" yaml:"historySize,omitempty"`
+	// MaxAge is the maximum age of the password, the user is forced to change the password on next login
+	// once it expires, 0 means the password never expires.
+	MaxAge time.Duration `json:"maxAge,omitempty" yaml:"maxAge,omitempty"`
+}
+
+type LoginLimiterOptions struct {
+	// Window is the sliding period before each login attempt in which the failed attempts are counted, default to 15m.
+	Window time.Duration `json:"window,omitempty" yaml:"window,omitempty"`
+	// Once the failed login attempts of a username or from a source IP reach the limit in the window,
+	// the next attempt is delayed, and the delay doubles with every further failure. For example,
+	//   UsernameMaxFailures: 5
+	//   BaseBackoff: 1s
+	//   MaxBackoff: 15m
+	// The 5th failure of a user delays the next attempt by 1s, the 6th by 2s, the 7th by 4s, and so on up to 15m.
+	// Unlike AuthenticateRateLimiterMaxTries, the account is never locked, the correct password is accepted once the delay elapses.
+	UsernameMaxFailures int `json:"usernameMaxFailures,omitempty" yaml:"usernameMaxFailures,omitempty"`
+	// SourceIPMaxFailures limits the failed attempts across all the usernames from the same source IP,
+	// which throttles the password spraying.
+	SourceIPMaxFailures int           `json:"sourceIPMaxFailures,omitempty" yaml:"sourceIPMaxFailures,omitempty"`
+	BaseBackoff         time.Duration `json:"baseBackoff,omitempty" yaml:"baseBackoff,omitempty"`
+	MaxBackoff          time.Duration `json:"maxBackoff,omitempty" yaml:"maxBackoff,omitempty"`
+	// CaptchaThreshold is the number of failed attempts of a username or from a source IP after which
+	// the clients are signaled to present a CAPTCHA, 0 means the CAPTCHA is never required.
+	CaptchaThreshold int `json:"captchaThreshold,omitempty" yaml:"captchaThreshold,omitempty"`
+}
+
+func NewLoginLimiterOptions() *LoginLimiterOptions {
+	return &LoginLimiterOptions{
+		Window:              15 * time.Minute,
+		UsernameMaxFailures: 5,
+		SourceIPMaxFailures: 20,
+		BaseBackoff:         time.Second,
+		MaxBackoff:          15 * time.Minute,
+		CaptchaThreshold:    3,
+	}
+}
+
+func NewPasswordPolicyOptions() *PasswordPolicyOptions {
+	return &PasswordPolicyOptions{
+		MinLength:        8,
+		RequireLowercase: true,
+		RequireUppercase: true,
+		RequireDigit:     true,
+	}
+}
+
+func NewMultiFactorAuthOptions() *MultiFactorAuthOptions {
+	return &MultiFactorAuthOptions{
+		RequiredGlobalRoles: []string{},
+		Issuer:              "KubeSphere",
+		ChallengeMaxAge:     5 * time.Minute,
+	}
 }
 
 func NewOptions() *Options {
@@ -74,6 +162,9 @@ func NewOptions() *Options {
 		MultipleLogin:                   false,
 		JwtSecret:                       "",
 		KubectlImage:                    "kubesphere/kubectl:v1.0.0",
+		MultiFactorAuthOptions:          NewMultiFactorAuthOptions(),
+		PasswordPolicy:                  NewPasswordPolicyOptions(),
+		LoginLimiter:                    NewLoginLimiterOptions(),
 	}
 }
 
@@ -85,6 +176,11 @@ func (options *Options) Validate() []error {
 	if options.AuthenticateRateLimiterMaxTries > options.LoginHistoryMaximumEntries {
 		errs = append(errs, errors.New("authenticateRateLimiterMaxTries MUST not be greater than loginHistoryMaximumEntries"))
 	}
+	if options.PasswordPolicy != nil {
+		if options.PasswordPolicy.MinLength < 0 || options.PasswordPolicy.HistorySize < 0 || options.PasswordPolicy.MaxAge < 0 {
+			errs = append(errs, errors.New("minLength, historySize and maxAge of the password policy MUST not be negative"))
+		}
+	}
 	if err := identityprovider.SetupWithOptions(options.OAuthOptions.IdentityProviders); err != nil {
 		errs = append(errs, err)
 	}
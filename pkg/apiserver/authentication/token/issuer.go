@@ -26,6 +26,7 @@ import (
 	"fmt"
 	"hash/fnv"
 	"os"
+	"sync"
 	"time"
 
 	"github.com/golang-jwt/jwt/v4"
@@ -34,6 +35,7 @@ import (
 	"k8s.io/klog/v2"
 
 	"kubesphere.io/kubesphere/pkg/apiserver/authentication"
+	"kubesphere.io/kubesphere/pkg/simple/client/cache"
 )
 
 const (
@@ -46,6 +48,10 @@ const (
 	headerAlgorithm   string = "alg"
 )
 
+// MultiFactorChallenge is issued after the password is verified, it can only be exchanged for
+// the access token along with the TOTP passcode and must not be accepted as a bearer token.
+const MultiFactorChallenge Type = "mfa_challenge"
+
 type Type string
 
 type IssueRequest struct {
@@ -63,6 +69,9 @@ type VerifiedResponse struct {
 type Keys struct {
 	SigningKey    *jose.JSONWebKey
 	SigningKeyPub *jose.JSONWebKey
+	// VerificationKeys are the public keys of the active and the retired signing keys,
+	// the retired keys are kept until the tokens signed by them expire.
+	VerificationKeys []*jose.JSONWebKey
 }
 
 // Issuer issues token to user, tokens are required to perform mutating requests to resources
@@ -75,6 +84,9 @@ type Issuer interface {
 
 	// Keys hold encryption and signing keys.
 	Keys() *Keys
+
+	// RotateKeys generates a new signing key, the previous signing keys are still valid for verification.
+	RotateKeys() error
 }
 
 type Claims struct {
@@ -86,6 +98,8 @@ type Claims struct {
 	Username string `json:"username,omitempty"`
 	// Extra contains the additional information
 	Extra map[string][]string `json:"extra,omitempty"`
+	// SessionID identifies the login session which the token is issued in
+	SessionID string `json:"sid,omitempty"`
 
 	// Used for issuing authorization code
 	// Scopes can be used to request that specific sets of information be made available as Claim Values.
@@ -112,10 +126,20 @@ type issuer struct {
 	name string
 	// signing access_token and refresh_token
 	secret []byte
-	// signing id_token
-	signKey *Keys
 	// Token verification maximum time difference
 	maximumClockSkew time.Duration
+	// signing id_token, the first one is the active signing key, and the others are verify-only
+	keys  []*signingKey
+	mutex sync.RWMutex
+	// the id of the last activated configured sign key
+	configuredKeyID string
+	// the last time the signing keys are loaded from the cache
+	keysLoadedAt time.Time
+	// the retired signing keys are removed after the retention
+	keyRetention      time.Duration
+	keyRotationPeriod time.Duration
+	// optional, share the signing keys among the replicas
+	keyCache cache.Interface
 }
 
 func (s *issuer) IssueTo(request *IssueRequest) (string, error) {
@@ -134,6 +158,12 @@ func (s *issuer) IssueTo(request *IssueRequest) (string, error) {
 	if len(request.Audience) > 0 {
 		claims.Audience = request.Audience
 	}
+	if request.ID != "" {
+		claims.ID = request.ID
+	}
+	if request.SessionID != "" {
+		claims.SessionID = request.SessionID
+	}
 	if request.Name != "" {
 		claims.Name = request.Name
 	}
@@ -159,9 +189,12 @@ func (s *issuer) IssueTo(request *IssueRequest) (string, error) {
 	var token string
 	var err error
 	if request.TokenType == IDToken {
+		s.mutex.RLock()
+		signKey := s.keys[0]
+		s.mutex.RUnlock()
 		t := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
-		t.Header[headerKeyID] = s.signKey.SigningKey.KeyID
-		token, err = t.SignedString(s.signKey.SigningKey.Key)
+		t.Header[headerKeyID] = signKey.KeyID
+		token, err = t.SignedString(signKey.key)
 	} else {
 		token, err = jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(s.secret)
 	}
@@ -211,7 +244,17 @@ func (s *issuer) Verify(token string) (*VerifiedResponse, error) {
 }
 
 func (s *issuer) Keys() *Keys {
-	return s.signKey
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	keys := &Keys{
+		SigningKey:       s.keys[0].jwk(),
+		SigningKeyPub:    s.keys[0].publicJWK(),
+		VerificationKeys: make([]*jose.JSONWebKey, 0, len(s.keys)),
+	}
+	for _, k := range s.keys {
+		keys.VerificationKeys = append(keys.VerificationKeys, k.publicJWK())
+	}
+	return keys
 }
 
 func (s *issuer) keyFunc(token *jwt.Token) (i interface{}, err error) {
@@ -220,7 +263,8 @@ func (s *issuer) keyFunc(token *jwt.Token) (i interface{}, err error) {
 	case jwt.SigningMethodHS256.Alg():
 		return s.secret, nil
 	case jwt.SigningMethodRS256.Alg():
-		return s.signKey.SigningKey.Key, nil
+		keyID, _ := token.Header[headerKeyID].(string)
+		return s.verificationKey(keyID)
 	default:
 		return nil, fmt.Errorf("unexpect signature algorithm %v", token.Header[headerAlgorithm])
 	}
@@ -253,8 +297,8 @@ func generatePrivateKeyData() ([]byte, error) {
 	return pemData, nil
 }
 
-func loadSignKey(options *authentication.Options) (*rsa.PrivateKey, string, error) {
-	var signKey *rsa.PrivateKey
+// loadSignKey returns the configured sign key data, and whether it is generated automatically
+func loadSignKey(options *authentication.Options) ([]byte, bool, error) {
 	var signKeyData []byte
 	var err error
 
@@ -262,61 +306,89 @@ func loadSignKey(options *authentication.Options) (*rsa.PrivateKey, string, erro
 		signKeyData, err = os.ReadFile(options.OAuthOptions.SignKey)
 		if err != nil {
 			klog.Errorf("issuer: failed to read private key file %s: %v", options.OAuthOptions.SignKey, err)
-			return nil, "", err
+			return nil, false, err
 		}
 	} else if options.OAuthOptions.SignKeyData != "" {
 		signKeyData, err = base64.StdEncoding.DecodeString(options.OAuthOptions.SignKeyData)
 		if err != nil {
 			klog.Errorf("issuer: failed to decode sign key data: %s", err)
-			return nil, "", err
-		}
-	}
-
-	// automatically generate private key
-	if len(signKeyData) == 0 {
-		signKeyData, err = generatePrivateKeyData()
-		if err != nil {
-			klog.Errorf("issuer: failed to generate private key: %v", err)
-			return nil, "", err
+			return nil, false, err
 		}
 	}
 
 	if len(signKeyData) > 0 {
-		signKey, err = loadPrivateKey(signKeyData)
-		if err != nil {
-			klog.Errorf("issuer: failed to load private key from data: %v", err)
-		}
+		return signKeyData, false, nil
 	}
 
-	keyID := fmt.Sprint(fnv32a(signKeyData))
-	return signKey, keyID, nil
+	// automatically generate private key
+	signKeyData, err = generatePrivateKeyData()
+	if err != nil {
+		klog.Errorf("issuer: failed to generate private key: %v", err)
+		return nil, false, err
+	}
+	return signKeyData, true, nil
 }
 
 func NewIssuer(options *authentication.Options) (Issuer, error) {
-	// TODO(hongming) automatically rotates keys
-	signKey, keyID, err := loadSignKey(options)
+	return NewIssuerWithKeyCache(options, nil)
+}
+
+// NewIssuerWithKeyCache returns an issuer which shares the signing keys with the other replicas through the cache.
+// If the configured sign key is newly configured, it will be activated and the previous one becomes verify-only,
+// so that the keys can also be rotated by changing the configuration. A configured sign key which has been rotated
+// will not be activated again.
+func NewIssuerWithKeyCache(options *authentication.Options, keyCache cache.Interface) (Issuer, error) {
+	signKeyData, generated, err := loadSignKey(options)
 	if err != nil {
 		return nil, err
 	}
-	return &issuer{
-		name:             options.OAuthOptions.Issuer,
-		secret:           []byte(options.JwtSecret),
-		maximumClockSkew: options.MaximumClockSkew,
-		signKey: &Keys{
-			SigningKey: &jose.JSONWebKey{
-				Key:       signKey,
-				KeyID:     keyID,
-				Algorithm: jwt.SigningMethodRS256.Alg(),
-				Use:       "sig",
-			},
-			SigningKeyPub: &jose.JSONWebKey{
-				Key:       signKey.Public(),
-				KeyID:     keyID,
-				Algorithm: jwt.SigningMethodRS256.Alg(),
-				Use:       "sig",
-			},
-		},
-	}, nil
+	signKey, err := newSigningKey(signKeyData, fmt.Sprint(fnv32a(signKeyData)), time.Now())
+	if err != nil {
+		klog.Errorf("issuer: failed to load private key from data: %v", err)
+		return nil, err
+	}
+
+	s := &issuer{
+		name:              options.OAuthOptions.Issuer,
+		secret:            []byte(options.JwtSecret),
+		maximumClockSkew:  options.MaximumClockSkew,
+		keyCache:          keyCache,
+		keyRotationPeriod: options.OAuthOptions.SigningKeyRotationPeriod,
+		// the id_token has the longest lifetime
+		keyRetention: options.OAuthOptions.AccessTokenMaxAge + options.OAuthOptions.AccessTokenInactivityTimeout,
+	}
+	// the tokens never expire, the retired keys are removed after the default retention
+	if options.OAuthOptions.AccessTokenMaxAge == 0 || s.keyRetention <= 0 {
+		s.keyRetention = defaultKeyRetention
+	}
+
+	// the issuer is not shared yet, the lock only guards the keys shared with the other replicas
+	unlock, err := s.lockSharedKeys()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	if err = s.loadKeysLocked(); err != nil {
+		if !errors.Is(err, errUndecryptableKeys) {
+			klog.Errorf("issuer: failed to load signing keys: %v", err)
+			return nil, err
+		}
+		// the jwt secret is changed, the tokens signed by the previous keys can not be verified anyway
+		klog.Warningf("issuer: the signing keys will be replaced: %v", err)
+	}
+	// the generated key is only used when no key is shared by the other replicas,
+	// and the configured key is only activated when it is newly configured
+	if len(s.keys) > 0 && (generated || signKey.KeyID == s.configuredKeyID || s.hasKeyLocked(signKey.KeyID)) {
+		return s, nil
+	}
+	if !generated {
+		s.configuredKeyID = signKey.KeyID
+	}
+	if err = s.activateKeyLocked(signKey); err != nil {
+		klog.Errorf("issuer: failed to save signing keys: %v", err)
+		return nil, err
+	}
+	return s, nil
 }
 
 // fnv32a hashes using fnv32a algorithm
@@ -17,7 +17,9 @@ package token
 
 import (
 	"encoding/base64"
+	"fmt"
 	"reflect"
+	"sync"
 	"testing"
 	"time"
 
@@ -28,6 +30,7 @@ import (
 
 	"kubesphere.io/kubesphere/pkg/apiserver/authentication"
 	"kubesphere.io/kubesphere/pkg/apiserver/authentication/oauth"
+	"kubesphere.io/kubesphere/pkg/simple/client/cache"
 )
 
 const privateKeyData = `
@@ -75,32 +78,34 @@ func TestNewIssuer(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	signKey, keyID, err := loadSignKey(options)
+	signKey, err := loadPrivateKey([]byte(privateKeyData))
 	if err != nil {
 		t.Fatal(err)
 	}
+	keyID := fmt.Sprint(fnv32a([]byte(privateKeyData)))
 
-	want := &issuer{
-		name:             options.OAuthOptions.Issuer,
-		secret:           []byte(options.JwtSecret),
-		maximumClockSkew: options.MaximumClockSkew,
-		signKey: &Keys{
-			SigningKey: &jose.JSONWebKey{
-				Key:       signKey,
-				KeyID:     keyID,
-				Algorithm: jwt.SigningMethodRS256.Alg(),
-				Use:       "sig",
-			},
-			SigningKeyPub: &jose.JSONWebKey{
-				Key:       signKey.Public(),
-				KeyID:     keyID,
-				Algorithm: jwt.SigningMethodRS256.Alg(),
-				Use:       "sig",
-			},
+	want := &Keys{
+		SigningKey: &jose.JSONWebKey{
+			Key:       signKey,
+			KeyID:     keyID,
+			Algorithm: jwt.SigningMethodRS256.Alg(),
+			Use:       "sig",
+		},
+		SigningKeyPub: &jose.JSONWebKey{
+			Key:       signKey.Public(),
+			KeyID:     keyID,
+			Algorithm: jwt.SigningMethodRS256.Alg(),
+			Use:       "sig",
 		},
+		VerificationKeys: []*jose.JSONWebKey{{
+			Key:       signKey.Public(),
+			KeyID:     keyID,
+			Algorithm: jwt.SigningMethodRS256.Alg(),
+			Use:       "sig",
+		}},
 	}
-	if !reflect.DeepEqual(got, want) {
-		t.Errorf("NewIssuer() got = %v, want %v", got, want)
+	if !reflect.DeepEqual(got.Keys(), want) {
+		t.Errorf("NewIssuer() got = %v, want %v", got.Keys(), want)
 		return
 	}
 }
@@ -119,12 +124,118 @@ func TestNewIssuerGenerateSignKey(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	iss := got.(*issuer)
-	assert.NotNil(t, iss.signKey)
-	assert.NotNil(t, iss.signKey.SigningKey)
-	assert.NotNil(t, iss.signKey.SigningKeyPub)
-	assert.NotNil(t, iss.signKey.SigningKey.KeyID)
-	assert.NotNil(t, iss.signKey.SigningKeyPub.KeyID)
+	keys := got.Keys()
+	assert.NotNil(t, keys)
+	assert.NotNil(t, keys.SigningKey)
+	assert.NotNil(t, keys.SigningKeyPub)
+	assert.NotNil(t, keys.SigningKey.KeyID)
+	assert.NotNil(t, keys.SigningKeyPub.KeyID)
+}
+
+func TestIssuerRotateKeys(t *testing.T) {
+	options := authentication.NewOptions()
+	options.OAuthOptions.SignKeyData = base64.StdEncoding.EncodeToString([]byte(privateKeyData))
+	keyCache, _ := cache.NewInMemoryCache(nil, nil)
+	s, err := NewIssuerWithKeyCache(options, keyCache)
+	if err != nil {
+		t.Fatal(err)
+	}
+	idToken, err := s.IssueTo(&IssueRequest{User: &user.DefaultInfo{Name: "admin"}, Claims: Claims{TokenType: IDToken}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	previousKeyID := s.Keys().SigningKey.KeyID
+
+	if err = s.RotateKeys(); err != nil {
+		t.Fatal(err)
+	}
+	keys := s.Keys()
+	assert.NotEqual(t, previousKeyID, keys.SigningKey.KeyID)
+	assert.Len(t, keys.VerificationKeys, 2)
+	// the tokens signed by the retired key are still valid
+	if _, err = s.Verify(idToken); err != nil {
+		t.Fatal(err)
+	}
+
+	// the replica which shares the cache verifies the token signed by the new key
+	replica, err := NewIssuerWithKeyCache(options, keyCache)
+	if err != nil {
+		t.Fatal(err)
+	}
+	idToken, err = s.IssueTo(&IssueRequest{User: &user.DefaultInfo{Name: "admin"}, Claims: Claims{TokenType: IDToken}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = replica.Verify(idToken); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, keys.SigningKey.KeyID, replica.Keys().SigningKey.KeyID)
+
+	// the configured sign key is changed
+	options.OAuthOptions.SignKeyData = ""
+	options.OAuthOptions.SignKey = ""
+	generated, err := NewIssuerWithKeyCache(options, keyCache)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, keys.SigningKey.KeyID, generated.Keys().SigningKey.KeyID)
+}
+
+func TestIssuerRotateKeysConcurrently(t *testing.T) {
+	options := authentication.NewOptions()
+	keyCache, _ := cache.NewInMemoryCache(nil, nil)
+	var replicas []*issuer
+	for i := 0; i < 2; i++ {
+		replica, err := NewIssuerWithKeyCache(options, keyCache)
+		if err != nil {
+			t.Fatal(err)
+		}
+		replicas = append(replicas, replica.(*issuer))
+	}
+
+	// none of the concurrent rotations is lost
+	var wg sync.WaitGroup
+	for _, replica := range replicas {
+		for i := 0; i < 3; i++ {
+			wg.Add(1)
+			go func(replica *issuer) {
+				defer wg.Done()
+				if err := replica.RotateKeys(); err != nil {
+					t.Error(err)
+				}
+			}(replica)
+		}
+	}
+	wg.Wait()
+	restarted, err := NewIssuerWithKeyCache(options, keyCache)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Len(t, restarted.Keys().VerificationKeys, 7)
+
+	// the due rotation is only made by one of the replicas
+	s := restarted.(*issuer)
+	s.mutex.Lock()
+	s.keys[0].CreatedAt = time.Now().Add(-2 * time.Hour)
+	err = s.saveKeysLocked()
+	s.mutex.Unlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, replica := range replicas {
+		replica.keyRotationPeriod = time.Hour
+		wg.Add(1)
+		go func(replica *issuer) {
+			defer wg.Done()
+			replica.rotateKeysIfDue()
+		}(replica)
+	}
+	wg.Wait()
+	for _, replica := range replicas {
+		replica.reloadKeys()
+		assert.Len(t, replica.Keys().VerificationKeys, 8)
+		assert.Equal(t, replicas[0].Keys().SigningKey.KeyID, replica.Keys().SigningKey.KeyID)
+	}
 }
 
 func Test_issuer_IssueTo(t *testing.T) {
@@ -346,3 +457,88 @@ func Test_issuer_keyFunc(t *testing.T) {
 		})
 	}
 }
+
+func TestIssuerKeepRotatedKeys(t *testing.T) {
+	options := authentication.NewOptions()
+	options.OAuthOptions.SignKeyData = base64.StdEncoding.EncodeToString([]byte(privateKeyData))
+	keyCache, _ := cache.NewInMemoryCache(nil, nil)
+	i, err := NewIssuerWithKeyCache(options, keyCache)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := i.(*issuer)
+	configuredKeyID := s.Keys().SigningKey.KeyID
+	if err = s.RotateKeys(); err != nil {
+		t.Fatal(err)
+	}
+	rotatedKeyID := s.Keys().SigningKey.KeyID
+
+	// the private keys are encrypted in the cache
+	data, err := keyCache.Get(signingKeysCacheKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.NotContains(t, data, "PRIVATE KEY")
+	assert.NotContains(t, data, configuredKeyID)
+
+	// the configured key is pruned after the retention
+	s.mutex.Lock()
+	s.keys = s.keys[:1]
+	err = s.saveKeysLocked()
+	s.mutex.Unlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the configured key is not activated again after restarting
+	restarted, err := NewIssuerWithKeyCache(options, keyCache)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, rotatedKeyID, restarted.Keys().SigningKey.KeyID)
+	assert.Len(t, restarted.Keys().VerificationKeys, 1)
+
+	// the keys encrypted by another jwt secret are replaced
+	options.JwtSecret = "another-secret"
+	replaced, err := NewIssuerWithKeyCache(options, keyCache)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, configuredKeyID, replaced.Keys().SigningKey.KeyID)
+}
+
+func TestIssuerReloadKeysRateLimit(t *testing.T) {
+	options := authentication.NewOptions()
+	keyCache, _ := cache.NewInMemoryCache(nil, nil)
+	i, err := NewIssuerWithKeyCache(options, keyCache)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := i.(*issuer)
+	replica, err := NewIssuerWithKeyCache(options, keyCache)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = replica.RotateKeys(); err != nil {
+		t.Fatal(err)
+	}
+	keyID := replica.Keys().SigningKey.KeyID
+
+	// the keys are loaded recently
+	if _, err = s.verificationKey(keyID); err == nil {
+		t.Fatal("expected unknown signing key id")
+	}
+
+	s.mutex.Lock()
+	s.keysLoadedAt = time.Now().Add(-keyReloadInterval)
+	s.mutex.Unlock()
+	if _, err = s.verificationKey(keyID); err != nil {
+		t.Fatal(err)
+	}
+	// the unknown key id does not trigger reloading again
+	loadedAt := s.keysLoadedAt
+	if _, err = s.verificationKey("unknown"); err == nil {
+		t.Fatal("expected unknown signing key id")
+	}
+	assert.Equal(t, loadedAt, s.keysLoadedAt)
+}
@@ -0,0 +1,362 @@
+/*
+Copyright 2022 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package token
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"gopkg.in/square/go-jose.v2"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/klog/v2"
+
+	"kubesphere.io/kubesphere/pkg/simple/client/cache"
+)
+
+const (
+	// the signing keys are shared with the other replicas of ks-apiserver through the cache,
+	// they are encrypted with the jwt secret since the private keys are included.
+	signingKeysCacheKey = "kubesphere:oauth:signingkeys"
+	// the retired signing keys are kept at least for the default retention if the tokens never expire
+	defaultKeyRetention = 7 * 24 * time.Hour
+	// the minimum interval to reload the signing keys when verifying a token signed by an unknown key
+	keyReloadInterval = 10 * time.Second
+	// the signing keys are read, modified and saved by one replica at a time while holding the lock,
+	// the lock expires in case the holder crashes before releasing it
+	signingKeysLockKey    = "kubesphere:oauth:signingkeys:lock"
+	signingKeysLockTTL    = 30 * time.Second
+	signingKeysLockPeriod = 100 * time.Millisecond
+)
+
+// errUndecryptableKeys means the signing keys in the cache are encrypted by another jwt secret or malformed.
+var errUndecryptableKeys = errors.New("failed to decrypt signing keys")
+
+// signingKeys is the rotation state shared through the cache.
+type signingKeys struct {
+	// the first one is the active signing key
+	Keys []*signingKey `json:"keys"`
+	// the last activated configured sign key, it will not be activated again after being rotated
+	ConfiguredKeyID string `json:"configuredKeyID,omitempty"`
+}
+
+// signingKey is the RSA key used to sign the id_token, it becomes verify-only after being retired.
+type signingKey struct {
+	KeyID     string     `json:"kid"`
+	Data      []byte     `json:"data"`
+	CreatedAt time.Time  `json:"createdAt"`
+	RetiredAt *time.Time `json:"retiredAt,omitempty"`
+	key       *rsa.PrivateKey
+}
+
+func newSigningKey(data []byte, keyID string, createdAt time.Time) (*signingKey, error) {
+	key, err := loadPrivateKey(data)
+	if err != nil {
+		return nil, err
+	}
+	return &signingKey{KeyID: keyID, Data: data, CreatedAt: createdAt, key: key}, nil
+}
+
+func (k *signingKey) jwk() *jose.JSONWebKey {
+	return &jose.JSONWebKey{Key: k.key, KeyID: k.KeyID, Algorithm: jwt.SigningMethodRS256.Alg(), Use: "sig"}
+}
+
+func (k *signingKey) publicJWK() *jose.JSONWebKey {
+	return &jose.JSONWebKey{Key: k.key.Public(), KeyID: k.KeyID, Algorithm: jwt.SigningMethodRS256.Alg(), Use: "sig"}
+}
+
+// RotateKeys generates a new signing key, the previous signing key becomes verify-only
+// until the tokens signed by it expire.
+func (s *issuer) RotateKeys() error {
+	unlock, err := s.lockSharedKeys()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	// merge the rotations made by the other replicas
+	if err = s.loadKeysLocked(); err != nil {
+		return err
+	}
+	return s.rotateKeysLocked()
+}
+
+// lockSharedKeys acquires the lock of the signing keys shared through the cache,
+// it must be acquired before the mutex, and released by calling the returned function.
+func (s *issuer) lockSharedKeys() (func(), error) {
+	if s.keyCache == nil {
+		return func() {}, nil
+	}
+	holder := make([]byte, 16)
+	if _, err := rand.Read(holder); err != nil {
+		return nil, err
+	}
+	owner := base64.RawURLEncoding.EncodeToString(holder)
+	err := wait.PollImmediate(signingKeysLockPeriod, signingKeysLockTTL, func() (bool, error) {
+		return s.keyCache.SetNX(signingKeysLockKey, owner, signingKeysLockTTL)
+	})
+	if err != nil {
+		klog.Errorf("issuer: failed to acquire the lock of signing keys: %v", err)
+		return nil, err
+	}
+	return func() {
+		// the lock may have expired and been acquired by another replica
+		current, err := s.keyCache.Get(signingKeysLockKey)
+		if err != nil || current != owner {
+			return
+		}
+		if err = s.keyCache.Del(signingKeysLockKey); err != nil {
+			klog.Warningf("issuer: failed to release the lock of signing keys: %v", err)
+		}
+	}, nil
+}
+
+func (s *issuer) rotateKeysLocked() error {
+	data, err := generatePrivateKeyData()
+	if err != nil {
+		klog.Errorf("issuer: failed to generate private key: %v", err)
+		return err
+	}
+	key, err := newSigningKey(data, fmt.Sprint(fnv32a(data)), time.Now())
+	if err != nil {
+		return err
+	}
+	return s.activateKeyLocked(key)
+}
+
+// activateKeyLocked makes the key the active signing key, retires the current one and prunes the expired keys.
+func (s *issuer) activateKeyLocked(key *signingKey) error {
+	now := time.Now()
+	keys := []*signingKey{key}
+	for _, k := range s.keys {
+		if k.KeyID == key.KeyID {
+			continue
+		}
+		if k.RetiredAt == nil {
+			k.RetiredAt = &now
+		}
+		if now.Sub(*k.RetiredAt) > s.keyRetention {
+			continue
+		}
+		keys = append(keys, k)
+	}
+	s.keys = keys
+	klog.Infof("issuer: signing key %s activated", key.KeyID)
+	return s.saveKeysLocked()
+}
+
+// rotateKeysIfDue rotates the signing key when the active key is older than the rotation period,
+// it also refreshes the keys rotated by the other replicas.
+func (s *issuer) rotateKeysIfDue() {
+	if !s.reloadKeys() {
+		return
+	}
+	unlock, err := s.lockSharedKeys()
+	if err != nil {
+		return
+	}
+	defer unlock()
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	// the keys may have been rotated by another replica while waiting for the lock
+	if err = s.loadKeysLocked(); err != nil {
+		klog.Errorf("issuer: failed to load signing keys: %v", err)
+		return
+	}
+	if !s.rotationDueLocked() {
+		return
+	}
+	if err = s.rotateKeysLocked(); err != nil {
+		klog.Errorf("issuer: failed to rotate signing keys: %v", err)
+	}
+}
+
+// reloadKeys refreshes the keys rotated by the other replicas, and reports whether the rotation is due.
+func (s *issuer) reloadKeys() bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if err := s.loadKeysLocked(); err != nil {
+		klog.Errorf("issuer: failed to load signing keys: %v", err)
+		return false
+	}
+	return s.rotationDueLocked()
+}
+
+func (s *issuer) rotationDueLocked() bool {
+	return s.keyRotationPeriod > 0 && time.Since(s.keys[0].CreatedAt) >= s.keyRotationPeriod
+}
+
+// loadKeysLocked replaces the keys with the ones in the cache if exists.
+func (s *issuer) loadKeysLocked() error {
+	if s.keyCache == nil {
+		return nil
+	}
+	s.keysLoadedAt = time.Now()
+	data, err := s.keyCache.Get(signingKeysCacheKey)
+	if err != nil {
+		if err == cache.ErrNoSuchKey {
+			return nil
+		}
+		return err
+	}
+	plaintext, err := decryptKeys(s.keysEncryptionKey(), data)
+	if err != nil {
+		return fmt.Errorf("%w: %v", errUndecryptableKeys, err)
+	}
+	var state signingKeys
+	if err = json.Unmarshal(plaintext, &state); err != nil {
+		return fmt.Errorf("%w: %v", errUndecryptableKeys, err)
+	}
+	for _, k := range state.Keys {
+		if k.key, err = loadPrivateKey(k.Data); err != nil {
+			return fmt.Errorf("%w: %v", errUndecryptableKeys, err)
+		}
+	}
+	if len(state.Keys) > 0 {
+		s.keys = state.Keys
+		s.configuredKeyID = state.ConfiguredKeyID
+	}
+	return nil
+}
+
+func (s *issuer) saveKeysLocked() error {
+	if s.keyCache == nil {
+		return nil
+	}
+	data, err := json.Marshal(&signingKeys{Keys: s.keys, ConfiguredKeyID: s.configuredKeyID})
+	if err != nil {
+		return err
+	}
+	encrypted, err := encryptKeys(s.keysEncryptionKey(), data)
+	if err != nil {
+		return err
+	}
+	return s.keyCache.Set(signingKeysCacheKey, encrypted, cache.NeverExpire)
+}
+
+func (s *issuer) keysEncryptionKey() []byte {
+	key := sha256.Sum256(s.secret)
+	return key[:]
+}
+
+// verificationKey returns the public key to verify the token signed by the key with the key id.
+func (s *issuer) verificationKey(keyID string) (*rsa.PublicKey, error) {
+	s.mutex.RLock()
+	key := s.findKeyLocked(keyID)
+	loadedAt := s.keysLoadedAt
+	s.mutex.RUnlock()
+	if key != nil {
+		return key, nil
+	}
+	// the key may be rotated by another replica, the reloading is rate limited
+	// since the key id is provided by the client
+	if s.keyCache == nil || time.Since(loadedAt) < keyReloadInterval {
+		return nil, fmt.Errorf("unknown signing key id %s", keyID)
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if key = s.findKeyLocked(keyID); key != nil {
+		return key, nil
+	}
+	if time.Since(s.keysLoadedAt) >= keyReloadInterval {
+		if err := s.loadKeysLocked(); err != nil {
+			klog.Errorf("issuer: failed to load signing keys: %v", err)
+			return nil, err
+		}
+		if key = s.findKeyLocked(keyID); key != nil {
+			return key, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown signing key id %s", keyID)
+}
+
+func (s *issuer) hasKeyLocked(keyID string) bool {
+	for _, k := range s.keys {
+		if k.KeyID == keyID {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *issuer) findKeyLocked(keyID string) *rsa.PublicKey {
+	for _, k := range s.keys {
+		// tokens issued before the key id header is introduced
+		if keyID == "" || k.KeyID == keyID {
+			return &k.key.PublicKey
+		}
+	}
+	return nil
+}
+
+// RunKeyRotation rotates the signing keys of the issuer periodically until the stop channel is closed.
+func RunKeyRotation(i Issuer, stopCh <-chan struct{}) {
+	s, ok := i.(*issuer)
+	if !ok || s.keyRotationPeriod <= 0 {
+		return
+	}
+	interval := s.keyRotationPeriod / 10
+	if interval > time.Hour {
+		interval = time.Hour
+	}
+	go wait.Until(s.rotateKeysIfDue, interval, stopCh)
+}
+
+// encryptKeys encrypts the signing keys with AES-GCM, the nonce is prepended to the ciphertext.
+func encryptKeys(key []byte, data []byte) (string, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return "", err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err = rand.Read(nonce); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(gcm.Seal(nonce, nonce, data, nil)), nil
+}
+
+func decryptKeys(key []byte, encrypted string) ([]byte, error) {
+	data, err := base64.StdEncoding.DecodeString(encrypted)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < gcm.NonceSize() {
+		return nil, fmt.Errorf("malformed signing keys")
+	}
+	return gcm.Open(nil, data[:gcm.NonceSize()], data[gcm.NonceSize():], nil)
+}
@@ -0,0 +1,117 @@
+/*
+Copyright 2022 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package totp implements the Time-Based One-Time Password algorithm defined in
+// https://datatracker.ietf.org/doc/html/rfc6238, with the parameters supported by most authenticator apps:
+// HMAC-SHA1, 6 digits and a time step of 30 seconds.
+package totp
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1" // #nosec
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	// Period is the time step in seconds.
+	Period = 30
+	// Digits is the length of the passcode.
+	Digits = 6
+	// modulus is 10^Digits
+	modulus = 1000000
+	// Skew is the number of time steps before or after the current time step that are accepted.
+	Skew = 1
+	// secretSize is the length of the shared secret in bytes, as recommended by RFC 4226.
+	secretSize = 20
+)
+
+var encoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// GenerateSecret returns a random base32 encoded shared secret.
+func GenerateSecret() (string, error) {
+	secret := make([]byte, secretSize)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	return encoding.EncodeToString(secret), nil
+}
+
+// Counter returns the time step of the given time.
+func Counter(t time.Time) uint64 {
+	return uint64(t.Unix() / Period)
+}
+
+// GenerateCode returns the passcode of the time step.
+func GenerateCode(secret string, counter uint64) (string, error) {
+	key, err := encoding.DecodeString(strings.ToUpper(strings.TrimRight(secret, "=")))
+	if err != nil {
+		return "", fmt.Errorf("invalid totp secret: %v", err)
+	}
+	msg := make([]byte, 8)
+	binary.BigEndian.PutUint64(msg, counter)
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg)
+	sum := mac.Sum(nil)
+	// dynamic truncation, https://datatracker.ietf.org/doc/html/rfc4226#section-5.3
+	offset := sum[len(sum)-1] & 0xf
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	return fmt.Sprintf("%0*d", Digits, value%modulus), nil
+}
+
+// Validate verifies the passcode against the time steps around the given time,
+// and returns the matched time step which should be remembered by the caller to prevent replay.
+func Validate(passcode, secret string, t time.Time) (uint64, bool) {
+	if len(passcode) != Digits {
+		return 0, false
+	}
+	current := Counter(t)
+	for i := -Skew; i <= Skew; i++ {
+		counter := uint64(int64(current) + int64(i))
+		expected, err := GenerateCode(secret, counter)
+		if err != nil {
+			return 0, false
+		}
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(passcode)) == 1 {
+			return counter, true
+		}
+	}
+	return 0, false
+}
+
+// KeyURI returns the provisioning URI which can be encoded into a QR code for the authenticator apps,
+// for more details: https://github.com/google/google-authenticator/wiki/Key-Uri-Format
+func KeyURI(issuer, account, secret string) string {
+	query := url.Values{}
+	query.Set("secret", secret)
+	query.Set("issuer", issuer)
+	query.Set("algorithm", "SHA1")
+	query.Set("digits", fmt.Sprint(Digits))
+	query.Set("period", fmt.Sprint(Period))
+	u := url.URL{
+		Scheme:   "otpauth",
+		Host:     "totp",
+		Path:     "/" + issuer + ":" + account,
+		RawQuery: query.Encode(),
+	}
+	return u.String()
+}
@@ -0,0 +1,88 @@
+/*
+Copyright 2022 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package totp
+
+import (
+	"encoding/base32"
+	"net/url"
+	"testing"
+	"time"
+)
+
+// test vectors from https://datatracker.ietf.org/doc/html/rfc4226#appendix-D
+func TestGenerateCode(t *testing.T) {
+	secret := base32.StdEncoding.EncodeToString([]byte("12345678901234567890"))
+	expected := []string{"755224", "287082", "359152", "969429", "338314", "254676", "287922", "162583", "399871", "520489"}
+	for counter, want := range expected {
+		got, err := GenerateCode(secret, uint64(counter))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Errorf("GenerateCode(%d) = %s, want %s", counter, got, want)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	secret, err := GenerateSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Unix(1650000000, 0)
+	tests := []struct {
+		name    string
+		counter uint64
+		valid   bool
+	}{
+		{name: "current", counter: Counter(now), valid: true},
+		{name: "previous", counter: Counter(now) - 1, valid: true},
+		{name: "next", counter: Counter(now) + 1, valid: true},
+		{name: "expired", counter: Counter(now) - 2, valid: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			passcode, err := GenerateCode(secret, tt.counter)
+			if err != nil {
+				t.Fatal(err)
+			}
+			counter, valid := Validate(passcode, secret, now)
+			if valid != tt.valid {
+				t.Errorf("Validate() = %v, want %v", valid, tt.valid)
+			}
+			if valid && counter != tt.counter {
+				t.Errorf("Validate() counter = %d, want %d", counter, tt.counter)
+			}
+		})
+	}
+	if _, valid := Validate("12345", secret, now); valid {
+		t.Errorf("Validate() should reject malformed passcode")
+	}
+}
+
+func TestKeyURI(t *testing.T) {
+	u, err := url.Parse(KeyURI("KubeSphere", "admin", "JBSWY3DPEHPK3PXP"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.Scheme != "otpauth" || u.Host != "totp" || u.Path != "/KubeSphere:admin" {
+		t.Errorf("unexpected key uri %s", u)
+	}
+	if u.Query().Get("secret") != "JBSWY3DPEHPK3PXP" || u.Query().Get("issuer") != "KubeSphere" {
+		t.Errorf("unexpected key uri parameters %s", u.RawQuery)
+	}
+}
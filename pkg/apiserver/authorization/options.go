@@ -20,6 +20,7 @@ package authorization
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/spf13/pflag"
 	"k8s.io/klog/v2"
@@ -29,28 +30,70 @@ import (
 
 type Options struct {
 	Mode string `json:"mode" yaml:"mode"`
+	// Webhook is required by the Webhook mode
+	Webhook *WebhookOptions `json:"webhook,omitempty" yaml:"webhook,omitempty"`
+}
+
+// WebhookOptions defines the external policy service which is consulted before RBAC in the Webhook mode.
+// The policy service receives a SubjectAccessReview of authorization.k8s.io/v1 by POST, the cluster, workspace,
+// devops project and resource scope of the request are set as the annotations with the prefix "authorization.kubesphere.io/".
+// A denied review rejects the request, an allowed review admits it, otherwise the request is authorized by RBAC.
+type WebhookOptions struct {
+	URL string `json:"url" yaml:"url"`
+	// CAFile is used to verify the serving certificate of the policy service, the system roots are used if empty.
+	CAFile  string        `json:"caFile,omitempty" yaml:"caFile,omitempty"`
+	Timeout time.Duration `json:"timeout,omitempty" yaml:"timeout,omitempty"`
+	// The decisions are cached for the TTLs, 0 means the decision is not cached.
+	AllowCacheTTL time.Duration `json:"allowCacheTTL,omitempty" yaml:"allowCacheTTL,omitempty"`
+	DenyCacheTTL  time.Duration `json:"denyCacheTTL,omitempty" yaml:"denyCacheTTL,omitempty"`
+	// FailurePolicy defines how the request is authorized when the policy service is unavailable,
+	// NoOpinion falls back to RBAC, Deny rejects the request. Default to NoOpinion.
+	FailurePolicy string `json:"failurePolicy,omitempty" yaml:"failurePolicy,omitempty"`
 }
 
 func NewOptions() *Options {
-	return &Options{Mode: RBAC}
+	return &Options{Mode: RBAC, Webhook: NewWebhookOptions()}
+}
+
+func NewWebhookOptions() *WebhookOptions {
+	return &WebhookOptions{
+		Timeout:       5 * time.Second,
+		AllowCacheTTL: 5 * time.Minute,
+		DenyCacheTTL:  30 * time.Second,
+		FailurePolicy: FailurePolicyNoOpinion,
+	}
 }
 
 var (
 	AlwaysDeny  = "AlwaysDeny"
 	AlwaysAllow = "AlwaysAllow"
 	RBAC        = "RBAC"
+	// Webhook consults the external policy service, then RBAC
+	Webhook = "Webhook"
+)
+
+const (
+	FailurePolicyNoOpinion = "NoOpinion"
+	FailurePolicyDeny      = "Deny"
 )
 
 func (o *Options) AddFlags(fs *pflag.FlagSet, s *Options) {
-	fs.StringVar(&o.Mode, "authorization", s.Mode, "Authorization setting, allowed values: AlwaysDeny, AlwaysAllow, RBAC.")
+	fs.StringVar(&o.Mode, "authorization", s.Mode, "Authorization setting, allowed values: AlwaysDeny, AlwaysAllow, RBAC, Webhook.")
 }
 
 func (o *Options) Validate() []error {
 	errs := make([]error, 0)
-	if !sliceutil.HasString([]string{AlwaysAllow, AlwaysDeny, RBAC}, o.Mode) {
+	if !sliceutil.HasString([]string{AlwaysAllow, AlwaysDeny, RBAC, Webhook}, o.Mode) {
 		err := fmt.Errorf("authorization mode %s not support", o.Mode)
 		klog.Error(err)
 		errs = append(errs, err)
 	}
+	if o.Mode == Webhook {
+		if o.Webhook == nil || o.Webhook.URL == "" {
+			errs = append(errs, fmt.Errorf("the url of the webhook MUST not be empty in the Webhook authorization mode"))
+		} else if o.Webhook.FailurePolicy != "" && !sliceutil.HasString([]string{FailurePolicyNoOpinion, FailurePolicyDeny}, o.Webhook.FailurePolicy) {
+			errs = append(errs, fmt.Errorf("webhook failure policy %s not support", o.Webhook.FailurePolicy))
+		}
+	}
 	return errs
 }
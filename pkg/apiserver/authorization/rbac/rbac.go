@@ -36,6 +36,9 @@ import (
 	"k8s.io/klog/v2"
 
 	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 	"k8s.io/apiserver/pkg/authentication/user"
 )
@@ -91,6 +94,11 @@ func (r *ruleAccumulator) visit(_ fmt.Stringer, _ string, rule *rbacv1.PolicyRul
 }
 
 func (r *RBACAuthorizer) Authorize(requestAttributes authorizer.Attributes) (authorizer.Decision, string, error) {
+	// deny rules take precedence over the rules allowing the request in any scope
+	if denied, reason := r.deniedBy(requestAttributes); denied {
+		return authorizer.DecisionDeny, reason, nil
+	}
+
 	ruleCheckingVisitor := &authorizingVisitor{requestAttributes: requestAttributes}
 
 	r.visitRulesFor(requestAttributes, ruleCheckingVisitor.visit)
@@ -153,6 +161,64 @@ func NewRBACAuthorizer(am am.AccessManagementInterface) *RBACAuthorizer {
 	return &RBACAuthorizer{am: am}
 }
 
+// deniedBy checks the deny rules of the roles bound to the user in the scope of the request,
+// the request is denied if the deny rules can not be resolved or evaluated.
+func (r *RBACAuthorizer) deniedBy(requestAttributes authorizer.Attributes) (denied bool, reason string) {
+	applies := func(bindingSubjects []rbacv1.Subject, namespace string) (int, bool) {
+		return appliesTo(requestAttributes.GetUser(), bindingSubjects, namespace)
+	}
+	r.visitBindingsFor(requestAttributes, applies, func(source fmt.Stringer, roleRef rbacv1.RoleRef, namespace string, err error) bool {
+		// the deny rules of the bindings which can not be listed are unknown
+		if err != nil {
+			denied, reason = true, fmt.Sprintf("RBAC: failed to list bindings to resolve deny rules: %v", err)
+			return false
+		}
+		denyRules, err := r.am.GetRoleReferenceDenyRules(roleRef, namespace)
+		if err != nil {
+			denied, reason = true, fmt.Sprintf("RBAC: failed to resolve deny rules of %s: %v", source.String(), err)
+			return false
+		}
+		for i := range denyRules {
+			matches, err := r.denyRuleMatches(requestAttributes, &denyRules[i])
+			if err != nil {
+				denied, reason = true, fmt.Sprintf("RBAC: failed to evaluate deny rules of %s: %v", source.String(), err)
+				return false
+			}
+			if matches {
+				denied, reason = true, fmt.Sprintf("RBAC: denied by %s", source.String())
+				return false
+			}
+		}
+		return true
+	})
+	return denied, reason
+}
+
+func (r *RBACAuthorizer) denyRuleMatches(requestAttributes authorizer.Attributes, denyRule *iamv1alpha2.DenyRule) (bool, error) {
+	if !ruleAllows(requestAttributes, &denyRule.PolicyRule) {
+		return false, nil
+	}
+	if denyRule.ObjectSelector == nil {
+		return true, nil
+	}
+	selector, err := metav1.LabelSelectorAsSelector(denyRule.ObjectSelector)
+	if err != nil {
+		return false, err
+	}
+	// the labels of the object are unknown without a name
+	if !requestAttributes.IsResourceRequest() || requestAttributes.GetName() == "" {
+		return false, nil
+	}
+	objectLabels, err := r.am.GetObjectLabels(requestAttributes.GetResource(), requestAttributes.GetNamespace(), requestAttributes.GetName())
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return selector.Matches(labels.Set(objectLabels)), nil
+}
+
 func ruleAllows(requestAttributes authorizer.Attributes, rule *rbacv1.PolicyRule) bool {
 	if requestAttributes.IsResourceRequest() {
 		combinedResource := requestAttributes.GetResource()
@@ -202,33 +268,56 @@ func (r *RBACAuthorizer) rulesFor(requestAttributes authorizer.Attributes) ([]rb
 }
 
 func (r *RBACAuthorizer) visitRulesFor(requestAttributes authorizer.Attributes, visitor func(source fmt.Stringer, regoPolicy string, rule *rbacv1.PolicyRule, err error) bool) {
+	applies := func(bindingSubjects []rbacv1.Subject, namespace string) (int, bool) {
+		return appliesTo(requestAttributes.GetUser(), bindingSubjects, namespace)
+	}
+	r.visitBindingRulesFor(requestAttributes, applies, visitor)
+}
+
+// visitBindingRulesFor visits the rules of the bindings in the scope of the request, which have any subject that applies
+func (r *RBACAuthorizer) visitBindingRulesFor(requestAttributes authorizer.Attributes, applies func(bindingSubjects []rbacv1.Subject, namespace string) (int, bool),
+	visitor func(source fmt.Stringer, regoPolicy string, rule *rbacv1.PolicyRule, err error) bool) {
+	r.visitBindingsFor(requestAttributes, applies, func(source fmt.Stringer, roleRef rbacv1.RoleRef, namespace string, err error) bool {
+		if err != nil {
+			return visitor(nil, "", nil, err)
+		}
+		regoPolicy, rules, err := r.am.GetRoleReferenceRules(roleRef, namespace)
+		if err != nil {
+			visitor(nil, "", nil, err)
+			return true
+		}
+		if !visitor(source, regoPolicy, nil, nil) {
+			return false
+		}
+		for i := range rules {
+			if !visitor(source, "", &rules[i], nil) {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+// visitBindingsFor visits the role references of the bindings in the scope of the request, which have any subject that applies
+func (r *RBACAuthorizer) visitBindingsFor(requestAttributes authorizer.Attributes, applies func(bindingSubjects []rbacv1.Subject, namespace string) (int, bool),
+	visitor func(source fmt.Stringer, roleRef rbacv1.RoleRef, namespace string, err error) bool) {
 
 	if globalRoleBindings, err := r.am.ListGlobalRoleBindings(""); err != nil {
-		if !visitor(nil, "", nil, err) {
+		if !visitor(nil, rbacv1.RoleRef{}, "", err) {
 			return
 		}
 	} else {
 		sourceDescriber := &globalRoleBindingDescriber{}
 		for _, globalRoleBinding := range globalRoleBindings {
-			subjectIndex, applies := appliesTo(requestAttributes.GetUser(), globalRoleBinding.Subjects, "")
+			subjectIndex, applies := applies(globalRoleBinding.Subjects, "")
 			if !applies {
 				continue
 			}
-			regoPolicy, rules, err := r.am.GetRoleReferenceRules(globalRoleBinding.RoleRef, "")
-			if err != nil {
-				visitor(nil, "", nil, err)
-				continue
-			}
 			sourceDescriber.binding = globalRoleBinding
 			sourceDescriber.subject = &globalRoleBinding.Subjects[subjectIndex]
-			if !visitor(sourceDescriber, regoPolicy, nil, nil) {
+			if !visitor(sourceDescriber, globalRoleBinding.RoleRef, "", nil) {
 				return
 			}
-			for i := range rules {
-				if !visitor(sourceDescriber, "", &rules[i], nil) {
-					return
-				}
-			}
 		}
 
 		if requestAttributes.GetResourceScope() == request.GlobalScope {
@@ -245,13 +334,13 @@ func (r *RBACAuthorizer) visitRulesFor(requestAttributes authorizer.Attributes,
 		// all of resource under namespace and devops belong to workspace
 		if requestAttributes.GetResourceScope() == request.NamespaceScope {
 			if workspace, err = r.am.GetNamespaceControlledWorkspace(requestAttributes.GetNamespace()); err != nil {
-				if !visitor(nil, "", nil, err) {
+				if !visitor(nil, rbacv1.RoleRef{}, "", err) {
 					return
 				}
 			}
 		} else if requestAttributes.GetResourceScope() == request.DevOpsScope {
 			if workspace, err = r.am.GetDevOpsControlledWorkspace(requestAttributes.GetDevOps()); err != nil {
-				if !visitor(nil, "", nil, err) {
+				if !visitor(nil, rbacv1.RoleRef{}, "", err) {
 					return
 				}
 			}
@@ -262,31 +351,21 @@ func (r *RBACAuthorizer) visitRulesFor(requestAttributes authorizer.Attributes,
 		}
 
 		if workspaceRoleBindings, err := r.am.ListWorkspaceRoleBindings("", nil, workspace); err != nil {
-			if !visitor(nil, "", nil, err) {
+			if !visitor(nil, rbacv1.RoleRef{}, "", err) {
 				return
 			}
 		} else {
 			sourceDescriber := &workspaceRoleBindingDescriber{}
 			for _, workspaceRoleBinding := range workspaceRoleBindings {
-				subjectIndex, applies := appliesTo(requestAttributes.GetUser(), workspaceRoleBinding.Subjects, "")
+				subjectIndex, applies := applies(workspaceRoleBinding.Subjects, "")
 				if !applies {
 					continue
 				}
-				regoPolicy, rules, err := r.am.GetRoleReferenceRules(workspaceRoleBinding.RoleRef, "")
-				if err != nil {
-					visitor(nil, "", nil, err)
-					continue
-				}
 				sourceDescriber.binding = workspaceRoleBinding
 				sourceDescriber.subject = &workspaceRoleBinding.Subjects[subjectIndex]
-				if !visitor(sourceDescriber, regoPolicy, nil, nil) {
+				if !visitor(sourceDescriber, workspaceRoleBinding.RoleRef, "", nil) {
 					return
 				}
-				for i := range rules {
-					if !visitor(sourceDescriber, "", &rules[i], nil) {
-						return
-					}
-				}
 			}
 		}
 	}
@@ -298,7 +377,7 @@ func (r *RBACAuthorizer) visitRulesFor(requestAttributes authorizer.Attributes,
 		// list devops role binding
 		if requestAttributes.GetResourceScope() == request.DevOpsScope {
 			if relatedNamespace, err := r.am.GetDevOpsRelatedNamespace(requestAttributes.GetDevOps()); err != nil {
-				if !visitor(nil, "", nil, err) {
+				if !visitor(nil, rbacv1.RoleRef{}, "", err) {
 					return
 				}
 			} else {
@@ -307,61 +386,41 @@ func (r *RBACAuthorizer) visitRulesFor(requestAttributes authorizer.Attributes,
 		}
 
 		if roleBindings, err := r.am.ListRoleBindings("", nil, namespace); err != nil {
-			if !visitor(nil, "", nil, err) {
+			if !visitor(nil, rbacv1.RoleRef{}, "", err) {
 				return
 			}
 		} else {
 			sourceDescriber := &roleBindingDescriber{}
 			for _, roleBinding := range roleBindings {
-				subjectIndex, applies := appliesTo(requestAttributes.GetUser(), roleBinding.Subjects, namespace)
+				subjectIndex, applies := applies(roleBinding.Subjects, namespace)
 				if !applies {
 					continue
 				}
-				regoPolicy, rules, err := r.am.GetRoleReferenceRules(roleBinding.RoleRef, namespace)
-				if err != nil {
-					visitor(nil, "", nil, err)
-					continue
-				}
 				sourceDescriber.binding = roleBinding
 				sourceDescriber.subject = &roleBinding.Subjects[subjectIndex]
-				if !visitor(sourceDescriber, regoPolicy, nil, nil) {
+				if !visitor(sourceDescriber, roleBinding.RoleRef, namespace, nil) {
 					return
 				}
-				for i := range rules {
-					if !visitor(sourceDescriber, "", &rules[i], nil) {
-						return
-					}
-				}
 			}
 		}
 	}
 
 	if clusterRoleBindings, err := r.am.ListClusterRoleBindings(""); err != nil {
-		if !visitor(nil, "", nil, err) {
+		if !visitor(nil, rbacv1.RoleRef{}, "", err) {
 			return
 		}
 	} else {
 		sourceDescriber := &clusterRoleBindingDescriber{}
 		for _, clusterRoleBinding := range clusterRoleBindings {
-			subjectIndex, applies := appliesTo(requestAttributes.GetUser(), clusterRoleBinding.Subjects, "")
+			subjectIndex, applies := applies(clusterRoleBinding.Subjects, "")
 			if !applies {
 				continue
 			}
-			regoPolicy, rules, err := r.am.GetRoleReferenceRules(clusterRoleBinding.RoleRef, "")
-			if err != nil {
-				visitor(nil, "", nil, err)
-				continue
-			}
 			sourceDescriber.binding = clusterRoleBinding
 			sourceDescriber.subject = &clusterRoleBinding.Subjects[subjectIndex]
-			if !visitor(sourceDescriber, regoPolicy, nil, nil) {
+			if !visitor(sourceDescriber, clusterRoleBinding.RoleRef, "", nil) {
 				return
 			}
-			for i := range rules {
-				if !visitor(sourceDescriber, "", &rules[i], nil) {
-					return
-				}
-			}
 		}
 	}
 }
@@ -436,7 +495,7 @@ type workspaceRoleBindingDescriber struct {
 }
 
 func (d *workspaceRoleBindingDescriber) String() string {
-	return fmt.Sprintf("GlobalRoleBinding %q of %s %q to %s",
+	return fmt.Sprintf("WorkspaceRoleBinding %q of %s %q to %s",
 		d.binding.Name,
 		d.binding.RoleRef.Kind,
 		d.binding.RoleRef.Name,
@@ -856,6 +856,209 @@ func TestRBACAuthorizerMakeDecision(t *testing.T) {
 	}
 }
 
+func TestRBACAuthorizerDenyRules(t *testing.T) {
+	ruleAdmin := rbacv1.PolicyRule{
+		Verbs:     []string{"*"},
+		APIGroups: []string{"*"},
+		Resources: []string{"*"},
+	}
+
+	staticRoles := StaticRoles{
+		namespaces: []*corev1.Namespace{
+			{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:   "namespace1",
+					Labels: map[string]string{tenantv1alpha1.WorkspaceLabel: "workspace1"},
+				},
+			},
+			{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:   "protected",
+					Labels: map[string]string{tenantv1alpha1.WorkspaceLabel: "workspace1", "protected": "true"},
+				},
+			},
+		},
+		globalRoles: []*iamv1alpha2.GlobalRole{
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "platform-admin"},
+				Rules:      []rbacv1.PolicyRule{ruleAdmin},
+			},
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "no-secrets"},
+				DenyRules: []iamv1alpha2.DenyRule{
+					{
+						PolicyRule: rbacv1.PolicyRule{
+							Verbs:     []string{"*"},
+							APIGroups: []string{""},
+							Resources: []string{"secrets"},
+						},
+					},
+				},
+			},
+		},
+		workspaceRoles: []*iamv1alpha2.WorkspaceRole{
+			{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:   "workspace1-admin",
+					Labels: map[string]string{tenantv1alpha1.WorkspaceLabel: "workspace1"},
+				},
+				Rules: []rbacv1.PolicyRule{ruleAdmin},
+				DenyRules: []iamv1alpha2.DenyRule{
+					{
+						PolicyRule: rbacv1.PolicyRule{
+							Verbs:     []string{"delete"},
+							APIGroups: []string{""},
+							Resources: []string{"namespaces"},
+						},
+						ObjectSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"protected": "true"}},
+					},
+				},
+			},
+		},
+		roles: []*rbacv1.Role{
+			{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "namespace1", Name: "admin"},
+				Rules:      []rbacv1.PolicyRule{ruleAdmin},
+			},
+			{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace:   "namespace1",
+					Name:        "no-delete-pods",
+					Annotations: map[string]string{iamv1alpha2.DenyRulesAnnotation: `[{"verbs":["delete"],"apiGroups":[""],"resources":["pods"]}]`},
+				},
+			},
+			{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace:   "namespace1",
+					Name:        "broken",
+					Annotations: map[string]string{iamv1alpha2.DenyRulesAnnotation: "invalid"},
+				},
+			},
+		},
+		globalRoleBindings: []*iamv1alpha2.GlobalRoleBinding{
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "bob-no-secrets"},
+				Subjects:   []rbacv1.Subject{{Kind: rbacv1.UserKind, Name: "bob"}},
+				RoleRef:    rbacv1.RoleRef{APIGroup: iamv1alpha2.SchemeGroupVersion.Group, Kind: iamv1alpha2.ResourceKindGlobalRole, Name: "no-secrets"},
+			},
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "carol-platform-admin"},
+				Subjects:   []rbacv1.Subject{{Kind: rbacv1.UserKind, Name: "carol"}},
+				RoleRef:    rbacv1.RoleRef{APIGroup: iamv1alpha2.SchemeGroupVersion.Group, Kind: iamv1alpha2.ResourceKindGlobalRole, Name: "platform-admin"},
+			},
+		},
+		workspaceRoleBindings: []*iamv1alpha2.WorkspaceRoleBinding{
+			{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:   "alice-workspace1-admin",
+					Labels: map[string]string{tenantv1alpha1.WorkspaceLabel: "workspace1"},
+				},
+				Subjects: []rbacv1.Subject{{Kind: rbacv1.UserKind, Name: "alice"}},
+				RoleRef:  rbacv1.RoleRef{APIGroup: iamv1alpha2.SchemeGroupVersion.Group, Kind: iamv1alpha2.ResourceKindWorkspaceRole, Name: "workspace1-admin"},
+			},
+		},
+		roleBindings: []*rbacv1.RoleBinding{
+			{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "namespace1", Name: "bob-admin"},
+				Subjects:   []rbacv1.Subject{{Kind: rbacv1.UserKind, Name: "bob"}},
+				RoleRef:    rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: iamv1alpha2.ResourceKindRole, Name: "admin"},
+			},
+			{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "namespace1", Name: "carol-no-delete-pods"},
+				Subjects:   []rbacv1.Subject{{Kind: rbacv1.UserKind, Name: "carol"}},
+				RoleRef:    rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: iamv1alpha2.ResourceKindRole, Name: "no-delete-pods"},
+			},
+			{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "namespace1", Name: "dave-broken"},
+				Subjects:   []rbacv1.Subject{{Kind: rbacv1.UserKind, Name: "dave"}},
+				RoleRef:    rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: iamv1alpha2.ResourceKindRole, Name: "broken"},
+			},
+			{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "namespace1", Name: "dave-admin"},
+				Subjects:   []rbacv1.Subject{{Kind: rbacv1.UserKind, Name: "dave"}},
+				RoleRef:    rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: iamv1alpha2.ResourceKindRole, Name: "admin"},
+			},
+		},
+	}
+
+	namespaceRequest := func(username, verb, resource, namespace, name string) authorizer.AttributesRecord {
+		return authorizer.AttributesRecord{
+			User:            &user.DefaultInfo{Name: username},
+			Verb:            verb,
+			APIVersion:      "v1",
+			Resource:        resource,
+			Namespace:       namespace,
+			Name:            name,
+			ResourceRequest: true,
+			ResourceScope:   request.NamespaceScope,
+		}
+	}
+
+	tests := []struct {
+		name             string
+		request          authorizer.AttributesRecord
+		expectedDecision authorizer.Decision
+	}{
+		{
+			name:             "workspace deny rule matches the labels of the namespace",
+			request:          namespaceRequest("alice", "delete", "namespaces", "protected", "protected"),
+			expectedDecision: authorizer.DecisionDeny,
+		},
+		{
+			name:             "workspace deny rule does not match the labels of the namespace",
+			request:          namespaceRequest("alice", "delete", "namespaces", "namespace1", "namespace1"),
+			expectedDecision: authorizer.DecisionAllow,
+		},
+		{
+			name:             "workspace deny rule does not match other verbs",
+			request:          namespaceRequest("alice", "update", "namespaces", "protected", "protected"),
+			expectedDecision: authorizer.DecisionAllow,
+		},
+		{
+			name:             "global deny rule overrides namespace allow rule",
+			request:          namespaceRequest("bob", "get", "secrets", "namespace1", "secret1"),
+			expectedDecision: authorizer.DecisionDeny,
+		},
+		{
+			name:             "global deny rule does not match other resources",
+			request:          namespaceRequest("bob", "get", "pods", "namespace1", "pod1"),
+			expectedDecision: authorizer.DecisionAllow,
+		},
+		{
+			name:             "namespace deny rule overrides global allow rule",
+			request:          namespaceRequest("carol", "delete", "pods", "namespace1", "pod1"),
+			expectedDecision: authorizer.DecisionDeny,
+		},
+		{
+			name:             "namespace deny rule does not apply to other namespaces",
+			request:          namespaceRequest("carol", "delete", "pods", "protected", "pod1"),
+			expectedDecision: authorizer.DecisionAllow,
+		},
+		{
+			name:             "invalid deny rules deny the request",
+			request:          namespaceRequest("dave", "get", "pods", "namespace1", "pod1"),
+			expectedDecision: authorizer.DecisionDeny,
+		},
+	}
+
+	ruleResolver, err := newMockRBACAuthorizer(&staticRoles)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			decision, reason, err := ruleResolver.Authorize(&tc.request)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if decision != tc.expectedDecision {
+				t.Errorf("%d != %d: %s", decision, tc.expectedDecision, reason)
+			}
+		})
+	}
+}
+
 func newMockRBACAuthorizer(staticRoles *StaticRoles) (*RBACAuthorizer, error) {
 
 	ksClient := fakeks.NewSimpleClientset()
@@ -920,6 +1123,13 @@ func newMockRBACAuthorizer(staticRoles *StaticRoles) (*RBACAuthorizer, error) {
 			return nil, err
 		}
 	}
+
+	for _, namespace := range staticRoles.namespaces {
+		err := k8sInformerFactory.Core().V1().Namespaces().Informer().GetIndexer().Add(namespace)
+		if err != nil {
+			return nil, err
+		}
+	}
 	return NewRBACAuthorizer(am.NewReadOnlyOperator(fakeInformerFactory, nil)), nil
 }
 
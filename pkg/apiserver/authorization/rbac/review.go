@@ -0,0 +1,309 @@
+/*
+Copyright 2022 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rbac
+
+import (
+	"fmt"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apiserver/pkg/authentication/user"
+
+	iamv1alpha2 "kubesphere.io/api/iam/v1alpha2"
+	tenantv1alpha1 "kubesphere.io/api/tenant/v1alpha1"
+
+	"kubesphere.io/kubesphere/pkg/apiserver/authorization/authorizer"
+	"kubesphere.io/kubesphere/pkg/apiserver/request"
+)
+
+// AccessReviewer reviews the access granted by the bindings of KubeSphere RBAC.
+type AccessReviewer interface {
+	// RulesFor returns the rules and the deny rules that apply to the user of the request in the scope of the request.
+	RulesFor(requestAttributes authorizer.Attributes) ([]GrantedRule, error)
+	// SubjectsFor returns the bindings whose rules allow the request regardless of the user of the request,
+	// one for each subject of the binding. The rego policies are not evaluated since they depend on the user.
+	// The subjects denied by the deny rules bound to them in the scope are excluded, the deny rules bound to
+	// the groups are not applied to the users since the members of the groups are unknown.
+	SubjectsFor(requestAttributes authorizer.Attributes) ([]GrantedRule, error)
+	// EffectiveRulesFor returns the rules and the deny rules that apply to the user in all the workspaces and namespaces.
+	EffectiveRulesFor(user user.Info) ([]GrantedRule, error)
+}
+
+// GrantedRule is the rule along with the binding which grants it to the subject.
+type GrantedRule struct {
+	BindingKind      string             `json:"bindingKind"`
+	BindingName      string             `json:"bindingName"`
+	BindingNamespace string             `json:"bindingNamespace,omitempty"`
+	Workspace        string             `json:"workspace,omitempty"`
+	RoleRef          rbacv1.RoleRef     `json:"roleRef"`
+	Subject          rbacv1.Subject     `json:"subject"`
+	Rule             *rbacv1.PolicyRule `json:"rule,omitempty"`
+	RegoPolicy       string             `json:"regoPolicy,omitempty"`
+	// DenyRule denies the requests matching it, which takes precedence over the rules allowing the requests
+	DenyRule *iamv1alpha2.DenyRule `json:"denyRule,omitempty"`
+}
+
+// bindingSource is implemented by the describers of the bindings
+type bindingSource interface {
+	fmt.Stringer
+	// grant returns the binding and the subject that applies, without the rule
+	grant() GrantedRule
+	subjects() []rbacv1.Subject
+}
+
+func (r *RBACAuthorizer) RulesFor(requestAttributes authorizer.Attributes) ([]GrantedRule, error) {
+	visitor := &grantedRuleAccumulator{}
+	r.visitRulesFor(requestAttributes, visitor.visit)
+	r.visitDenyRulesFor(requestAttributes, visitor)
+	return visitor.rules, utilerrors.NewAggregate(visitor.errors)
+}
+
+// visitDenyRulesFor visits the deny rules of the bindings in the scope of the request, which apply to the user
+func (r *RBACAuthorizer) visitDenyRulesFor(requestAttributes authorizer.Attributes, visitor *grantedRuleAccumulator) {
+	applies := func(bindingSubjects []rbacv1.Subject, namespace string) (int, bool) {
+		return appliesTo(requestAttributes.GetUser(), bindingSubjects, namespace)
+	}
+	r.visitBindingsFor(requestAttributes, applies, func(source fmt.Stringer, roleRef rbacv1.RoleRef, namespace string, err error) bool {
+		if err != nil {
+			visitor.errors = append(visitor.errors, err)
+			return true
+		}
+		denyRules, err := r.am.GetRoleReferenceDenyRules(roleRef, namespace)
+		visitor.visitDenyRules(source.(bindingSource), denyRules, err)
+		return true
+	})
+}
+
+func (r *RBACAuthorizer) SubjectsFor(requestAttributes authorizer.Attributes) ([]GrantedRule, error) {
+	var result []GrantedRule
+	var errs []error
+	anySubject := func(bindingSubjects []rbacv1.Subject, _ string) (int, bool) {
+		return 0, len(bindingSubjects) > 0
+	}
+	r.visitBindingRulesFor(requestAttributes, anySubject, func(source fmt.Stringer, _ string, rule *rbacv1.PolicyRule, err error) bool {
+		if err != nil {
+			errs = append(errs, err)
+		}
+		if rule == nil || !ruleAllows(requestAttributes, rule) {
+			return true
+		}
+		binding := source.(bindingSource)
+		for _, subject := range binding.subjects() {
+			granted := binding.grant()
+			granted.Subject = subject
+			granted.Rule = rule.DeepCopy()
+			result = append(result, granted)
+		}
+		return true
+	})
+
+	denied, err := r.deniedSubjectsFor(requestAttributes, anySubject)
+	if err != nil {
+		errs = append(errs, err)
+	}
+	allowed := make([]GrantedRule, 0, len(result))
+	for _, granted := range result {
+		if !denied[subjectKey(granted.Subject, granted.BindingNamespace)] {
+			allowed = append(allowed, granted)
+		}
+	}
+	return allowed, utilerrors.NewAggregate(errs)
+}
+
+// deniedSubjectsFor returns the subjects of the bindings whose deny rules match the request,
+// the subjects of the bindings whose deny rules can not be resolved or evaluated are denied as well.
+func (r *RBACAuthorizer) deniedSubjectsFor(requestAttributes authorizer.Attributes,
+	applies func(bindingSubjects []rbacv1.Subject, namespace string) (int, bool)) (map[string]bool, error) {
+	denied := make(map[string]bool)
+	var errs []error
+	r.visitBindingsFor(requestAttributes, applies, func(source fmt.Stringer, roleRef rbacv1.RoleRef, namespace string, err error) bool {
+		if err != nil {
+			errs = append(errs, err)
+			return true
+		}
+		matches := false
+		denyRules, err := r.am.GetRoleReferenceDenyRules(roleRef, namespace)
+		for i := 0; err == nil && !matches && i < len(denyRules); i++ {
+			matches, err = r.denyRuleMatches(requestAttributes, &denyRules[i])
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to resolve deny rules of %s: %v", source.String(), err))
+			matches = true
+		}
+		if matches {
+			for _, subject := range source.(bindingSource).subjects() {
+				denied[subjectKey(subject, namespace)] = true
+			}
+		}
+		return true
+	})
+	return denied, utilerrors.NewAggregate(errs)
+}
+
+// subjectKey identifies the subject regardless of the API group
+func subjectKey(subject rbacv1.Subject, bindingNamespace string) string {
+	namespace := subject.Namespace
+	if subject.Kind == rbacv1.ServiceAccountKind && namespace == "" {
+		namespace = bindingNamespace
+	}
+	return subject.Kind + "/" + namespace + "/" + subject.Name
+}
+
+func (r *RBACAuthorizer) EffectiveRulesFor(u user.Info) ([]GrantedRule, error) {
+	visitor := &grantedRuleAccumulator{}
+
+	// the global role bindings and cluster role bindings are visited for any scope except the global scope
+	clusterScope := authorizer.AttributesRecord{User: u, ResourceScope: request.ClusterScope}
+	r.visitRulesFor(clusterScope, visitor.visit)
+	r.visitDenyRulesFor(clusterScope, visitor)
+
+	if workspaceRoleBindings, err := r.am.ListWorkspaceRoleBindings("", nil, ""); err != nil {
+		visitor.errors = append(visitor.errors, err)
+	} else {
+		sourceDescriber := &workspaceRoleBindingDescriber{}
+		for _, workspaceRoleBinding := range workspaceRoleBindings {
+			subjectIndex, applies := appliesTo(u, workspaceRoleBinding.Subjects, "")
+			if !applies {
+				continue
+			}
+			regoPolicy, rules, err := r.am.GetRoleReferenceRules(workspaceRoleBinding.RoleRef, "")
+			sourceDescriber.binding = workspaceRoleBinding
+			sourceDescriber.subject = &workspaceRoleBinding.Subjects[subjectIndex]
+			visitor.visitAll(sourceDescriber, regoPolicy, rules, err)
+			denyRules, err := r.am.GetRoleReferenceDenyRules(workspaceRoleBinding.RoleRef, "")
+			visitor.visitDenyRules(sourceDescriber, denyRules, err)
+		}
+	}
+
+	if roleBindings, err := r.am.ListRoleBindings("", nil, ""); err != nil {
+		visitor.errors = append(visitor.errors, err)
+	} else {
+		sourceDescriber := &roleBindingDescriber{}
+		for _, roleBinding := range roleBindings {
+			subjectIndex, applies := appliesTo(u, roleBinding.Subjects, roleBinding.Namespace)
+			if !applies {
+				continue
+			}
+			regoPolicy, rules, err := r.am.GetRoleReferenceRules(roleBinding.RoleRef, roleBinding.Namespace)
+			sourceDescriber.binding = roleBinding
+			sourceDescriber.subject = &roleBinding.Subjects[subjectIndex]
+			visitor.visitAll(sourceDescriber, regoPolicy, rules, err)
+			denyRules, err := r.am.GetRoleReferenceDenyRules(roleBinding.RoleRef, roleBinding.Namespace)
+			visitor.visitDenyRules(sourceDescriber, denyRules, err)
+		}
+	}
+
+	return visitor.rules, utilerrors.NewAggregate(visitor.errors)
+}
+
+type grantedRuleAccumulator struct {
+	rules  []GrantedRule
+	errors []error
+}
+
+func (g *grantedRuleAccumulator) visit(source fmt.Stringer, regoPolicy string, rule *rbacv1.PolicyRule, err error) bool {
+	if err != nil {
+		g.errors = append(g.errors, err)
+	}
+	if source == nil || (rule == nil && regoPolicy == "") {
+		return true
+	}
+	granted := source.(bindingSource).grant()
+	granted.RegoPolicy = regoPolicy
+	if rule != nil {
+		granted.Rule = rule.DeepCopy()
+	}
+	g.rules = append(g.rules, granted)
+	return true
+}
+
+func (g *grantedRuleAccumulator) visitAll(source bindingSource, regoPolicy string, rules []rbacv1.PolicyRule, err error) {
+	if err != nil {
+		g.visit(nil, "", nil, err)
+		return
+	}
+	g.visit(source, regoPolicy, nil, nil)
+	for i := range rules {
+		g.visit(source, "", &rules[i], nil)
+	}
+}
+
+func (g *grantedRuleAccumulator) visitDenyRules(source bindingSource, denyRules []iamv1alpha2.DenyRule, err error) {
+	if err != nil {
+		g.errors = append(g.errors, fmt.Errorf("failed to resolve deny rules of %s: %v", source.String(), err))
+		return
+	}
+	for i := range denyRules {
+		granted := source.grant()
+		granted.DenyRule = denyRules[i].DeepCopy()
+		g.rules = append(g.rules, granted)
+	}
+}
+
+func (d *globalRoleBindingDescriber) grant() GrantedRule {
+	return GrantedRule{
+		BindingKind: iamv1alpha2.ResourceKindGlobalRoleBinding,
+		BindingName: d.binding.Name,
+		RoleRef:     d.binding.RoleRef,
+		Subject:     *d.subject,
+	}
+}
+
+func (d *globalRoleBindingDescriber) subjects() []rbacv1.Subject {
+	return d.binding.Subjects
+}
+
+func (d *clusterRoleBindingDescriber) grant() GrantedRule {
+	return GrantedRule{
+		BindingKind: "ClusterRoleBinding",
+		BindingName: d.binding.Name,
+		RoleRef:     d.binding.RoleRef,
+		Subject:     *d.subject,
+	}
+}
+
+func (d *clusterRoleBindingDescriber) subjects() []rbacv1.Subject {
+	return d.binding.Subjects
+}
+
+func (d *workspaceRoleBindingDescriber) grant() GrantedRule {
+	return GrantedRule{
+		BindingKind: iamv1alpha2.ResourceKindWorkspaceRoleBinding,
+		BindingName: d.binding.Name,
+		Workspace:   d.binding.Labels[tenantv1alpha1.WorkspaceLabel],
+		RoleRef:     d.binding.RoleRef,
+		Subject:     *d.subject,
+	}
+}
+
+func (d *workspaceRoleBindingDescriber) subjects() []rbacv1.Subject {
+	return d.binding.Subjects
+}
+
+func (d *roleBindingDescriber) grant() GrantedRule {
+	return GrantedRule{
+		BindingKind:      "RoleBinding",
+		BindingName:      d.binding.Name,
+		BindingNamespace: d.binding.Namespace,
+		RoleRef:          d.binding.RoleRef,
+		Subject:          *d.subject,
+	}
+}
+
+func (d *roleBindingDescriber) subjects() []rbacv1.Subject {
+	return d.binding.Subjects
+}
@@ -0,0 +1,316 @@
+/*
+Copyright 2022 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rbac
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apiserver/pkg/authentication/user"
+
+	iamv1alpha2 "kubesphere.io/api/iam/v1alpha2"
+	tenantv1alpha1 "kubesphere.io/api/tenant/v1alpha1"
+
+	"kubesphere.io/kubesphere/pkg/apiserver/authorization/authorizer"
+	"kubesphere.io/kubesphere/pkg/apiserver/request"
+)
+
+var (
+	ruleReadPods = rbacv1.PolicyRule{
+		Verbs:     []string{"get", "watch"},
+		APIGroups: []string{""},
+		Resources: []string{"pods"},
+	}
+	ruleAdmin = rbacv1.PolicyRule{
+		Verbs:     []string{"*"},
+		APIGroups: []string{"*"},
+		Resources: []string{"*"},
+	}
+)
+
+func newReviewStaticRoles() *StaticRoles {
+	return &StaticRoles{
+		namespaces: []*corev1.Namespace{
+			{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:   "namespace1",
+					Labels: map[string]string{tenantv1alpha1.WorkspaceLabel: "workspace2"},
+				},
+			},
+		},
+		roles: []*rbacv1.Role{
+			{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "namespace1", Name: "readpods"},
+				Rules:      []rbacv1.PolicyRule{ruleReadPods},
+			},
+		},
+		workspaceRoles: []*iamv1alpha2.WorkspaceRole{
+			{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:   "workspace1-admin",
+					Labels: map[string]string{tenantv1alpha1.WorkspaceLabel: "workspace1"},
+				},
+				Rules: []rbacv1.PolicyRule{ruleAdmin},
+			},
+		},
+		globalRoles: []*iamv1alpha2.GlobalRole{
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "platform-admin"},
+				Rules:      []rbacv1.PolicyRule{ruleAdmin},
+			},
+		},
+		roleBindings: []*rbacv1.RoleBinding{
+			{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "namespace1", Name: "readpods-binding"},
+				Subjects: []rbacv1.Subject{
+					{Kind: rbacv1.UserKind, Name: "foobar"},
+					{Kind: rbacv1.GroupKind, Name: "group1"},
+				},
+				RoleRef: rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "Role", Name: "readpods"},
+			},
+		},
+		workspaceRoleBindings: []*iamv1alpha2.WorkspaceRoleBinding{
+			{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:   "workspace1-admin-tester",
+					Labels: map[string]string{tenantv1alpha1.WorkspaceLabel: "workspace1"},
+				},
+				RoleRef: rbacv1.RoleRef{
+					APIGroup: iamv1alpha2.SchemeGroupVersion.Group,
+					Kind:     iamv1alpha2.ResourceKindWorkspaceRole,
+					Name:     "workspace1-admin",
+				},
+				Subjects: []rbacv1.Subject{
+					{Kind: rbacv1.UserKind, APIGroup: iamv1alpha2.SchemeGroupVersion.Group, Name: "tester"},
+				},
+			},
+		},
+		globalRoleBindings: []*iamv1alpha2.GlobalRoleBinding{
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "admin"},
+				RoleRef: rbacv1.RoleRef{
+					APIGroup: iamv1alpha2.SchemeGroupVersion.Group,
+					Kind:     iamv1alpha2.ResourceKindGlobalRole,
+					Name:     "platform-admin",
+				},
+				Subjects: []rbacv1.Subject{
+					{Kind: rbacv1.UserKind, APIGroup: iamv1alpha2.SchemeGroupVersion.Group, Name: "admin"},
+				},
+			},
+		},
+	}
+}
+
+func TestRulesFor(t *testing.T) {
+	reviewer, err := newMockRBACAuthorizer(newReviewStaticRoles())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rules, err := reviewer.RulesFor(authorizer.AttributesRecord{
+		User:          &user.DefaultInfo{Name: "someone", Groups: []string{"group1"}},
+		Namespace:     "namespace1",
+		ResourceScope: request.NamespaceScope,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []GrantedRule{
+		{
+			BindingKind:      "RoleBinding",
+			BindingName:      "readpods-binding",
+			BindingNamespace: "namespace1",
+			RoleRef:          rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "Role", Name: "readpods"},
+			Subject:          rbacv1.Subject{Kind: rbacv1.GroupKind, Name: "group1"},
+			Rule:             &ruleReadPods,
+		},
+	}
+	if diff := cmp.Diff(expected, rules); diff != "" {
+		t.Error(diff)
+	}
+}
+
+func TestSubjectsFor(t *testing.T) {
+	reviewer, err := newMockRBACAuthorizer(newReviewStaticRoles())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		attrs    authorizer.AttributesRecord
+		expected []string
+	}{
+		{
+			name: "read pods in namespace",
+			attrs: authorizer.AttributesRecord{
+				Verb:            "get",
+				Resource:        "pods",
+				Namespace:       "namespace1",
+				ResourceRequest: true,
+				ResourceScope:   request.NamespaceScope,
+			},
+			expected: []string{"GlobalRoleBinding/admin/User/admin", "RoleBinding/readpods-binding/Group/group1", "RoleBinding/readpods-binding/User/foobar"},
+		},
+		{
+			name: "delete pods in namespace",
+			attrs: authorizer.AttributesRecord{
+				Verb:            "delete",
+				Resource:        "pods",
+				Namespace:       "namespace1",
+				ResourceRequest: true,
+				ResourceScope:   request.NamespaceScope,
+			},
+			expected: []string{"GlobalRoleBinding/admin/User/admin"},
+		},
+		{
+			name: "create devops projects in workspace",
+			attrs: authorizer.AttributesRecord{
+				Verb:            "create",
+				APIGroup:        "devops.kubesphere.io",
+				Resource:        "devops",
+				Workspace:       "workspace1",
+				ResourceRequest: true,
+				ResourceScope:   request.WorkspaceScope,
+			},
+			expected: []string{"GlobalRoleBinding/admin/User/admin", "WorkspaceRoleBinding/workspace1-admin-tester/User/tester"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			grants, err := reviewer.SubjectsFor(test.attrs)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var subjects []string
+			for _, granted := range grants {
+				subjects = append(subjects, granted.BindingKind+"/"+granted.BindingName+"/"+granted.Subject.Kind+"/"+granted.Subject.Name)
+			}
+			sort.Strings(subjects)
+			if diff := cmp.Diff(test.expected, subjects); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func TestEffectiveRulesFor(t *testing.T) {
+	reviewer, err := newMockRBACAuthorizer(newReviewStaticRoles())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rules, err := reviewer.EffectiveRulesFor(&user.DefaultInfo{Name: "tester", Groups: []string{"group1"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var bindings []string
+	for _, granted := range rules {
+		bindings = append(bindings, granted.BindingKind+"/"+granted.BindingName+"/"+granted.Workspace+"/"+granted.BindingNamespace)
+	}
+	sort.Strings(bindings)
+
+	expected := []string{"RoleBinding/readpods-binding//namespace1", "WorkspaceRoleBinding/workspace1-admin-tester/workspace1/"}
+	if diff := cmp.Diff(expected, bindings); diff != "" {
+		t.Error(diff)
+	}
+}
+
+func TestReviewDenyRules(t *testing.T) {
+	staticRoles := newReviewStaticRoles()
+	staticRoles.roles = append(staticRoles.roles, &rbacv1.Role{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:   "namespace1",
+			Name:        "deny-restricted-pod",
+			Annotations: map[string]string{iamv1alpha2.DenyRulesAnnotation: `[{"verbs":["*"],"apiGroups":[""],"resources":["pods"],"resourceNames":["restricted"]}]`},
+		},
+	})
+	staticRoles.roleBindings = append(staticRoles.roleBindings, &rbacv1.RoleBinding{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "namespace1", Name: "deny-restricted-pod-binding"},
+		Subjects:   []rbacv1.Subject{{Kind: rbacv1.UserKind, Name: "foobar"}},
+		RoleRef:    rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "Role", Name: "deny-restricted-pod"},
+	})
+	reviewer, err := newMockRBACAuthorizer(staticRoles)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the denied subject is excluded
+	grants, err := reviewer.SubjectsFor(authorizer.AttributesRecord{
+		Verb:            "get",
+		Resource:        "pods",
+		Name:            "restricted",
+		Namespace:       "namespace1",
+		ResourceRequest: true,
+		ResourceScope:   request.NamespaceScope,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var subjects []string
+	for _, granted := range grants {
+		subjects = append(subjects, granted.Subject.Kind+"/"+granted.Subject.Name)
+	}
+	sort.Strings(subjects)
+	if diff := cmp.Diff([]string{"Group/group1", "User/admin"}, subjects); diff != "" {
+		t.Error(diff)
+	}
+
+	expectedDenyRule := &iamv1alpha2.DenyRule{PolicyRule: rbacv1.PolicyRule{
+		Verbs:         []string{"*"},
+		APIGroups:     []string{""},
+		Resources:     []string{"pods"},
+		ResourceNames: []string{"restricted"},
+	}}
+	denyRules := func(rules []GrantedRule) []*iamv1alpha2.DenyRule {
+		var result []*iamv1alpha2.DenyRule
+		for _, granted := range rules {
+			if granted.DenyRule != nil {
+				result = append(result, granted.DenyRule)
+			}
+		}
+		return result
+	}
+
+	// the deny rules are reported along with the rules
+	rules, err := reviewer.RulesFor(authorizer.AttributesRecord{
+		User:          &user.DefaultInfo{Name: "foobar"},
+		Namespace:     "namespace1",
+		ResourceScope: request.NamespaceScope,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]*iamv1alpha2.DenyRule{expectedDenyRule}, denyRules(rules)); diff != "" {
+		t.Error(diff)
+	}
+
+	rules, err = reviewer.EffectiveRulesFor(&user.DefaultInfo{Name: "foobar"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]*iamv1alpha2.DenyRule{expectedDenyRule}, denyRules(rules)); diff != "" {
+		t.Error(diff)
+	}
+}
@@ -0,0 +1,97 @@
+/*
+Copyright 2022 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package scope contains an authorizer that enforces the scopes of the personal access tokens,
+// and restricts the users whose password has expired to changing the password.
+package scope
+
+import (
+	"fmt"
+
+	corev1listers "k8s.io/client-go/listers/core/v1"
+
+	iamv1alpha2 "kubesphere.io/api/iam/v1alpha2"
+	tenantv1alpha1 "kubesphere.io/api/tenant/v1alpha1"
+
+	"kubesphere.io/kubesphere/pkg/apiserver/authorization/authorizer"
+	"kubesphere.io/kubesphere/pkg/utils/sliceutil"
+)
+
+// NewAuthorizer returns an authorizer which denies the requests made with a personal access token
+// that are out of the scopes of the token, and has no opinion on the others. It should be placed
+// before any authorizer that may allow the request.
+func NewAuthorizer(namespaceLister corev1listers.NamespaceLister) authorizer.Authorizer {
+	return authorizer.AuthorizerFunc(func(a authorizer.Attributes) (authorizer.Decision, string, error) {
+		if a.GetUser() == nil {
+			return authorizer.DecisionNoOpinion, "", nil
+		}
+		extra := a.GetUser().GetExtra()
+		if len(extra[iamv1alpha2.ExtraPasswordExpired]) > 0 && !passwordChangeRequest(a) {
+			return authorizer.DecisionDeny, "the password has expired and must be changed", nil
+		}
+		if len(extra[iamv1alpha2.ExtraAccessToken]) == 0 {
+			return authorizer.DecisionNoOpinion, "", nil
+		}
+
+		if verbs := extra[iamv1alpha2.ExtraAccessTokenVerbs]; len(verbs) > 0 && !allowed(verbs, a.GetVerb()) {
+			return authorizer.DecisionDeny, fmt.Sprintf("verb %q is out of the access token scopes", a.GetVerb()), nil
+		}
+
+		if apiGroups := extra[iamv1alpha2.ExtraAccessTokenAPIGroups]; len(apiGroups) > 0 {
+			if !a.IsResourceRequest() {
+				return authorizer.DecisionDeny, "non-resource request is out of the access token scopes", nil
+			}
+			if !allowed(apiGroups, a.GetAPIGroup()) {
+				return authorizer.DecisionDeny, fmt.Sprintf("API group %q is out of the access token scopes", a.GetAPIGroup()), nil
+			}
+		}
+
+		if workspaces := extra[iamv1alpha2.ExtraAccessTokenWorkspaces]; len(workspaces) > 0 {
+			workspace := a.GetWorkspace()
+			if workspace == "" && a.GetNamespace() != "" {
+				namespace, err := namespaceLister.Get(a.GetNamespace())
+				if err == nil {
+					workspace = namespace.Labels[tenantv1alpha1.WorkspaceLabel]
+				}
+			}
+			if workspace == "" || !allowed(workspaces, workspace) {
+				return authorizer.DecisionDeny, "request is out of the workspaces of the access token scopes", nil
+			}
+		}
+
+		return authorizer.DecisionNoOpinion, "", nil
+	})
+}
+
+// passwordChangeRequest returns whether the request is required to change the password of the user
+func passwordChangeRequest(a authorizer.Attributes) bool {
+	if !a.IsResourceRequest() || a.GetAPIGroup() != iamv1alpha2.SchemeGroupVersion.Group ||
+		a.GetResource() != iamv1alpha2.ResourcesPluralUser || a.GetName() != a.GetUser().GetName() {
+		return false
+	}
+	switch a.GetSubresource() {
+	case "":
+		return a.GetVerb() == "get"
+	case "password":
+		return a.GetVerb() == "update"
+	default:
+		return false
+	}
+}
+
+func allowed(scopes []string, value string) bool {
+	return sliceutil.HasString(scopes, "*") || sliceutil.HasString(scopes, value)
+}
@@ -0,0 +1,135 @@
+/*
+Copyright 2022 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scope
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes/fake"
+
+	iamv1alpha2 "kubesphere.io/api/iam/v1alpha2"
+	tenantv1alpha1 "kubesphere.io/api/tenant/v1alpha1"
+
+	"kubesphere.io/kubesphere/pkg/apiserver/authorization/authorizer"
+)
+
+func TestAuthorizer(t *testing.T) {
+	k8sClient := fake.NewSimpleClientset()
+	informerFactory := informers.NewSharedInformerFactory(k8sClient, 0)
+	namespaces := informerFactory.Core().V1().Namespaces().Informer().GetIndexer()
+	if err := namespaces.Add(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "ns1",
+		Labels: map[string]string{tenantv1alpha1.WorkspaceLabel: "ws1"}}}); err != nil {
+		t.Fatal(err)
+	}
+	a := NewAuthorizer(informerFactory.Core().V1().Namespaces().Lister())
+
+	scoped := &user.DefaultInfo{Name: "admin", Extra: map[string][]string{
+		iamv1alpha2.ExtraAccessToken:           {"ci"},
+		iamv1alpha2.ExtraAccessTokenVerbs:      {"get", "list"},
+		iamv1alpha2.ExtraAccessTokenAPIGroups:  {"", "apps"},
+		iamv1alpha2.ExtraAccessTokenWorkspaces: {"ws1"},
+	}}
+
+	passwordExpired := &user.DefaultInfo{Name: "admin", Extra: map[string][]string{
+		iamv1alpha2.ExtraPasswordExpired: {"true"},
+	}}
+
+	tests := []struct {
+		name       string
+		attributes authorizer.AttributesRecord
+		want       authorizer.Decision
+	}{
+		{
+			name:       "regular token",
+			attributes: authorizer.AttributesRecord{User: &user.DefaultInfo{Name: "admin"}, Verb: "delete", ResourceRequest: true},
+			want:       authorizer.DecisionNoOpinion,
+		},
+		{
+			name:       "in scopes",
+			attributes: authorizer.AttributesRecord{User: scoped, Verb: "list", APIGroup: "apps", Namespace: "ns1", ResourceRequest: true},
+			want:       authorizer.DecisionNoOpinion,
+		},
+		{
+			name:       "workspace in scopes",
+			attributes: authorizer.AttributesRecord{User: scoped, Verb: "get", Workspace: "ws1", ResourceRequest: true},
+			want:       authorizer.DecisionNoOpinion,
+		},
+		{
+			name:       "verb out of scopes",
+			attributes: authorizer.AttributesRecord{User: scoped, Verb: "delete", Namespace: "ns1", ResourceRequest: true},
+			want:       authorizer.DecisionDeny,
+		},
+		{
+			name:       "api group out of scopes",
+			attributes: authorizer.AttributesRecord{User: scoped, Verb: "get", APIGroup: "rbac.authorization.k8s.io", Namespace: "ns1", ResourceRequest: true},
+			want:       authorizer.DecisionDeny,
+		},
+		{
+			name:       "non-resource request",
+			attributes: authorizer.AttributesRecord{User: scoped, Verb: "get", Path: "/version"},
+			want:       authorizer.DecisionDeny,
+		},
+		{
+			name:       "workspace out of scopes",
+			attributes: authorizer.AttributesRecord{User: scoped, Verb: "get", Workspace: "ws2", ResourceRequest: true},
+			want:       authorizer.DecisionDeny,
+		},
+		{
+			name:       "cluster scope",
+			attributes: authorizer.AttributesRecord{User: scoped, Verb: "get", ResourceRequest: true},
+			want:       authorizer.DecisionDeny,
+		},
+		{
+			name: "change the expired password",
+			attributes: authorizer.AttributesRecord{User: passwordExpired, Verb: "update", APIGroup: "iam.kubesphere.io",
+				Resource: "users", Subresource: "password", Name: "admin", ResourceRequest: true},
+			want: authorizer.DecisionNoOpinion,
+		},
+		{
+			name: "get the user whose password has expired",
+			attributes: authorizer.AttributesRecord{User: passwordExpired, Verb: "get", APIGroup: "iam.kubesphere.io",
+				Resource: "users", Name: "admin", ResourceRequest: true},
+			want: authorizer.DecisionNoOpinion,
+		},
+		{
+			name: "change the password of another user",
+			attributes: authorizer.AttributesRecord{User: passwordExpired, Verb: "update", APIGroup: "iam.kubesphere.io",
+				Resource: "users", Subresource: "password", Name: "user1", ResourceRequest: true},
+			want: authorizer.DecisionDeny,
+		},
+		{
+			name:       "password expired",
+			attributes: authorizer.AttributesRecord{User: passwordExpired, Verb: "list", Namespace: "ns1", Resource: "pods", ResourceRequest: true},
+			want:       authorizer.DecisionDeny,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decision, _, err := a.Authorize(tt.attributes)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if decision != tt.want {
+				t.Errorf("Authorize() = %v, want %v", decision, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,221 @@
+/*
+Copyright 2022 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package webhook implements an authorizer that delegates the decisions to an external policy service,
+// so that the organization specific rules such as change freeze windows can be enforced without forking.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"time"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/cache"
+	"k8s.io/klog/v2"
+
+	"kubesphere.io/kubesphere/pkg/apiserver/authorization"
+	"kubesphere.io/kubesphere/pkg/apiserver/authorization/authorizer"
+)
+
+const (
+	// the attributes of kubesphere which are not defined in the SubjectAccessReview
+	ClusterAnnotation       = "authorization.kubesphere.io/cluster"
+	WorkspaceAnnotation     = "authorization.kubesphere.io/workspace"
+	DevOpsAnnotation        = "authorization.kubesphere.io/devops"
+	ResourceScopeAnnotation = "authorization.kubesphere.io/resource-scope"
+
+	decisionCacheSize = 8192
+	maxResponseSize   = 1 << 20
+)
+
+type webhookAuthorizer struct {
+	url           string
+	client        *http.Client
+	allowCacheTTL time.Duration
+	denyCacheTTL  time.Duration
+	failurePolicy string
+	decisionCache *cache.LRUExpireCache
+}
+
+type decision struct {
+	decision authorizer.Decision
+	reason   string
+}
+
+// NewAuthorizer returns an authorizer which posts the SubjectAccessReview to the policy service,
+// it returns NoOpinion unless the review is allowed or denied explicitly.
+func NewAuthorizer(options *authorization.WebhookOptions) (authorizer.Authorizer, error) {
+	if options == nil || options.URL == "" {
+		return nil, fmt.Errorf("the url of the webhook must not be empty")
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if options.CAFile != "" {
+		data, err := os.ReadFile(options.CAFile)
+		if err != nil {
+			klog.Error(err)
+			return nil, err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(data) {
+			return nil, fmt.Errorf("no valid certificate found in %s", options.CAFile)
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}
+	}
+	return &webhookAuthorizer{
+		url:           options.URL,
+		client:        &http.Client{Transport: transport, Timeout: options.Timeout},
+		allowCacheTTL: options.AllowCacheTTL,
+		denyCacheTTL:  options.DenyCacheTTL,
+		failurePolicy: options.FailurePolicy,
+		decisionCache: cache.NewLRUExpireCache(decisionCacheSize),
+	}, nil
+}
+
+func (w *webhookAuthorizer) Authorize(a authorizer.Attributes) (authorizer.Decision, string, error) {
+	review := newSubjectAccessReview(a)
+	key, err := json.Marshal(review)
+	if err != nil {
+		klog.Error(err)
+		return authorizer.DecisionNoOpinion, "", err
+	}
+	if cached, ok := w.decisionCache.Get(string(key)); ok {
+		d := cached.(*decision)
+		return d.decision, d.reason, nil
+	}
+
+	status, err := w.review(review)
+	if err != nil {
+		// the error is not returned, otherwise the request would fail even if it is allowed by RBAC
+		klog.Errorf("failed to authorize by the webhook %s: %v", w.url, err)
+		if w.failurePolicy == authorization.FailurePolicyDeny {
+			return authorizer.DecisionDeny, "the authorization webhook is unavailable", nil
+		}
+		return authorizer.DecisionNoOpinion, "", nil
+	}
+
+	d := &decision{decision: authorizer.DecisionNoOpinion, reason: status.Reason}
+	switch {
+	case status.Denied:
+		d.decision = authorizer.DecisionDeny
+		if w.denyCacheTTL > 0 {
+			w.decisionCache.Add(string(key), d, w.denyCacheTTL)
+		}
+	case status.Allowed:
+		d.decision = authorizer.DecisionAllow
+		if w.allowCacheTTL > 0 {
+			w.decisionCache.Add(string(key), d, w.allowCacheTTL)
+		}
+	default:
+		// no opinion is cached for the DenyCacheTTL as well
+		if w.denyCacheTTL > 0 {
+			w.decisionCache.Add(string(key), d, w.denyCacheTTL)
+		}
+	}
+	if status.EvaluationError != "" {
+		klog.Warningf("authorization webhook evaluation error: %s", status.EvaluationError)
+	}
+	return d.decision, d.reason, nil
+}
+
+func (w *webhookAuthorizer) review(review *authorizationv1.SubjectAccessReview) (*authorizationv1.SubjectAccessReviewStatus, error) {
+	data, err := json.Marshal(review)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, w.url, bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return nil, fmt.Errorf("unexpected status code %d: %s", resp.StatusCode, body)
+	}
+	result := &authorizationv1.SubjectAccessReview{}
+	if err = json.Unmarshal(body, result); err != nil {
+		return nil, err
+	}
+	return &result.Status, nil
+}
+
+func newSubjectAccessReview(a authorizer.Attributes) *authorizationv1.SubjectAccessReview {
+	review := &authorizationv1.SubjectAccessReview{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: authorizationv1.SchemeGroupVersion.String(),
+			Kind:       "SubjectAccessReview",
+		},
+	}
+	if u := a.GetUser(); u != nil {
+		review.Spec.User = u.GetName()
+		review.Spec.UID = u.GetUID()
+		review.Spec.Groups = u.GetGroups()
+		if extra := u.GetExtra(); len(extra) > 0 {
+			review.Spec.Extra = make(map[string]authorizationv1.ExtraValue, len(extra))
+			for k, v := range extra {
+				review.Spec.Extra[k] = v
+			}
+		}
+	}
+	if a.IsResourceRequest() {
+		review.Spec.ResourceAttributes = &authorizationv1.ResourceAttributes{
+			Namespace:   a.GetNamespace(),
+			Verb:        a.GetVerb(),
+			Group:       a.GetAPIGroup(),
+			Version:     a.GetAPIVersion(),
+			Resource:    a.GetResource(),
+			Subresource: a.GetSubresource(),
+			Name:        a.GetName(),
+		}
+	} else {
+		review.Spec.NonResourceAttributes = &authorizationv1.NonResourceAttributes{
+			Path: a.GetPath(),
+			Verb: a.GetVerb(),
+		}
+	}
+	annotations := map[string]string{
+		ClusterAnnotation:       a.GetCluster(),
+		WorkspaceAnnotation:     a.GetWorkspace(),
+		DevOpsAnnotation:        a.GetDevOps(),
+		ResourceScopeAnnotation: a.GetResourceScope(),
+	}
+	for k, v := range annotations {
+		if v == "" {
+			delete(annotations, k)
+		}
+	}
+	if len(annotations) > 0 {
+		review.Annotations = annotations
+	}
+	return review
+}
@@ -0,0 +1,177 @@
+/*
+Copyright 2022 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"k8s.io/apiserver/pkg/authentication/user"
+
+	"kubesphere.io/kubesphere/pkg/apiserver/authorization"
+	"kubesphere.io/kubesphere/pkg/apiserver/authorization/authorizer"
+)
+
+// newPolicyServer returns a stand-in policy service, which denies the changes in the workspace
+// under change freeze, and allows everything for the group of on-call engineers.
+func newPolicyServer(t *testing.T, requests *int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(requests, 1)
+		review := &authorizationv1.SubjectAccessReview{}
+		if err := json.NewDecoder(r.Body).Decode(review); err != nil {
+			t.Error(err)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		for _, group := range review.Spec.Groups {
+			if group == "on-call" {
+				review.Status.Allowed = true
+			}
+		}
+		if attributes := review.Spec.ResourceAttributes; !review.Status.Allowed && attributes != nil &&
+			attributes.Verb != "get" && attributes.Verb != "list" && attributes.Verb != "watch" &&
+			review.Annotations[WorkspaceAnnotation] == "frozen" {
+			review.Status.Denied = true
+			review.Status.Reason = "change freeze in effect"
+		}
+		_ = json.NewEncoder(w).Encode(review)
+	}))
+}
+
+func TestAuthorizer(t *testing.T) {
+	var requests int32
+	server := newPolicyServer(t, &requests)
+	defer server.Close()
+
+	options := authorization.NewWebhookOptions()
+	options.URL = server.URL
+	a, err := NewAuthorizer(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	developer := &user.DefaultInfo{Name: "dev", Groups: []string{"developers"}}
+	onCall := &user.DefaultInfo{Name: "ops", Groups: []string{"on-call"}}
+	tests := []struct {
+		name       string
+		attributes authorizer.AttributesRecord
+		want       authorizer.Decision
+	}{
+		{
+			name:       "change in frozen workspace",
+			attributes: authorizer.AttributesRecord{User: developer, Verb: "delete", Workspace: "frozen", Resource: "deployments", ResourceRequest: true},
+			want:       authorizer.DecisionDeny,
+		},
+		{
+			name:       "read in frozen workspace",
+			attributes: authorizer.AttributesRecord{User: developer, Verb: "get", Workspace: "frozen", Resource: "deployments", ResourceRequest: true},
+			want:       authorizer.DecisionNoOpinion,
+		},
+		{
+			name:       "change in other workspace",
+			attributes: authorizer.AttributesRecord{User: developer, Verb: "delete", Workspace: "ws1", Resource: "deployments", ResourceRequest: true},
+			want:       authorizer.DecisionNoOpinion,
+		},
+		{
+			name:       "on-call engineer",
+			attributes: authorizer.AttributesRecord{User: onCall, Verb: "delete", Workspace: "frozen", Resource: "deployments", ResourceRequest: true},
+			want:       authorizer.DecisionAllow,
+		},
+		{
+			name:       "non-resource request",
+			attributes: authorizer.AttributesRecord{User: developer, Verb: "post", Path: "/kapis/version"},
+			want:       authorizer.DecisionNoOpinion,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// the second decision is served from the cache
+			for i := 0; i < 2; i++ {
+				decision, _, err := a.Authorize(tt.attributes)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if decision != tt.want {
+					t.Errorf("Authorize() = %v, want %v", decision, tt.want)
+				}
+			}
+		})
+	}
+	if requests := atomic.LoadInt32(&requests); requests != int32(len(tests)) {
+		t.Errorf("expected %d reviews posted to the webhook, got %d", len(tests), requests)
+	}
+}
+
+func TestAuthorizerCacheTTL(t *testing.T) {
+	var requests int32
+	server := newPolicyServer(t, &requests)
+	defer server.Close()
+
+	options := authorization.NewWebhookOptions()
+	options.URL = server.URL
+	options.DenyCacheTTL = 100 * time.Millisecond
+	a, err := NewAuthorizer(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	attributes := authorizer.AttributesRecord{User: &user.DefaultInfo{Name: "dev"}, Verb: "delete", Workspace: "frozen", ResourceRequest: true}
+	for i := 0; i < 2; i++ {
+		if decision, _, _ := a.Authorize(attributes); decision != authorizer.DecisionDeny {
+			t.Errorf("Authorize() = %v, want %v", decision, authorizer.DecisionDeny)
+		}
+	}
+	time.Sleep(200 * time.Millisecond)
+	if decision, _, _ := a.Authorize(attributes); decision != authorizer.DecisionDeny {
+		t.Errorf("Authorize() = %v, want %v", decision, authorizer.DecisionDeny)
+	}
+	if requests := atomic.LoadInt32(&requests); requests != 2 {
+		t.Errorf("expected the expired decision to be reviewed again, got %d reviews", requests)
+	}
+}
+
+func TestAuthorizerFailurePolicy(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	attributes := authorizer.AttributesRecord{User: &user.DefaultInfo{Name: "dev"}, Verb: "get", ResourceRequest: true}
+	for failurePolicy, want := range map[string]authorizer.Decision{
+		authorization.FailurePolicyNoOpinion: authorizer.DecisionNoOpinion,
+		authorization.FailurePolicyDeny:      authorizer.DecisionDeny,
+	} {
+		options := authorization.NewWebhookOptions()
+		options.URL = server.URL
+		options.FailurePolicy = failurePolicy
+		a, err := NewAuthorizer(options)
+		if err != nil {
+			t.Fatal(err)
+		}
+		decision, _, err := a.Authorize(attributes)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if decision != want {
+			t.Errorf("failure policy %s: Authorize() = %v, want %v", failurePolicy, decision, want)
+		}
+	}
+}
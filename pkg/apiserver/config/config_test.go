@@ -164,6 +164,9 @@ func newTestConfig() (*Config, error) {
 				AccessTokenMaxAge:            time.Hour * 24,
 				AccessTokenInactivityTimeout: 0,
 			},
+			MultiFactorAuthOptions: authentication.NewMultiFactorAuthOptions(),
+			PasswordPolicy:         authentication.NewPasswordPolicyOptions(),
+			LoginLimiter:           authentication.NewLoginLimiterOptions(),
 		},
 		MultiClusterOptions: multicluster.NewOptions(),
 		EventsOptions: &events.Options{
@@ -0,0 +1,230 @@
+/*
+Copyright 2022 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package filters
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apiserver/pkg/endpoints/handlers/responsewriters"
+	"k8s.io/klog/v2"
+
+	"kubesphere.io/kubesphere/pkg/api"
+	"kubesphere.io/kubesphere/pkg/apiserver/query"
+	"kubesphere.io/kubesphere/pkg/apiserver/request"
+	"kubesphere.io/kubesphere/pkg/constants"
+	clusterutils "kubesphere.io/kubesphere/pkg/controller/cluster/utils"
+)
+
+const (
+	// allClusters is used in the path to aggregate the list from all the clusters, e.g. /clusters/*/...
+	allClusters = "*"
+
+	aggregatedAPIGroup = "resources.kubesphere.io"
+)
+
+// ClusterListTimeout is the timeout of listing the resources from each cluster
+var ClusterListTimeout = 10 * time.Second
+
+// aggregatedClusters returns the clusters to aggregate the list from, and whether the request is an aggregated list
+func (m *multiclusterDispatcher) aggregatedClusters(info *request.RequestInfo, req *http.Request) ([]string, bool, error) {
+	var names []string
+	switch {
+	case info.Cluster == allClusters:
+		clusters, err := m.List()
+		if err != nil {
+			return nil, true, err
+		}
+		// the clusters not ready are left out rather than reported as failures
+		for _, cluster := range clusters {
+			if clusterutils.IsClusterReady(cluster) {
+				names = append(names, cluster.Name)
+			}
+		}
+	case info.Cluster == "" && req.URL.Query().Get(query.ParameterClusters) != "":
+		for _, name := range strings.Split(req.URL.Query().Get(query.ParameterClusters), ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				names = append(names, name)
+			}
+		}
+	default:
+		return nil, false, nil
+	}
+	if !info.IsResourceRequest || info.Verb != request.VerbList || info.APIGroup != aggregatedAPIGroup {
+		return nil, true, errors.NewBadRequest(fmt.Sprintf("only the lists of %s are supported across clusters", aggregatedAPIGroup))
+	}
+	if req.URL.Query().Get(query.ParameterContinue) != "" {
+		return nil, true, errors.NewBadRequest("continue is not supported across clusters, use page instead")
+	}
+	sort.Strings(names)
+	return names, true, nil
+}
+
+// clusterListResult is the list result of a cluster
+type clusterListResult struct {
+	cluster string
+	items   []map[string]interface{}
+	total   int
+	err     error
+}
+
+// serveAggregatedList lists the resources from the clusters in parallel, then merges and sorts the items
+// and annotates them with their clusters. The clusters failed to list are reported in the result.
+func (m *multiclusterDispatcher) serveAggregatedList(w http.ResponseWriter, req *http.Request, info *request.RequestInfo, clusters []string) {
+	values := req.URL.Query()
+	limit, err := strconv.Atoi(values.Get(query.ParameterLimit))
+	if err != nil || limit <= 0 {
+		limit = -1
+	}
+	page, err := strconv.Atoi(values.Get(query.ParameterPage))
+	if err != nil || page <= 0 {
+		page = 1
+	}
+
+	// every cluster lists the items up to the end of the requested page, the page is taken after merging
+	values.Del(query.ParameterClusters)
+	values.Del(query.ParameterPage)
+	if limit > 0 {
+		values.Set(query.ParameterLimit, strconv.Itoa(page*limit))
+	}
+	path := strings.Replace(req.URL.Path, fmt.Sprintf("/clusters/%s", allClusters), "", 1)
+
+	results := make([]clusterListResult, len(clusters))
+	var wg sync.WaitGroup
+	for i, cluster := range clusters {
+		wg.Add(1)
+		go func(i int, cluster string) {
+			defer wg.Done()
+			results[i] = m.listCluster(req, info, cluster, path, values.Encode())
+		}(i, cluster)
+	}
+	wg.Wait()
+
+	aggregated := &api.AggregatedListResult{Items: []interface{}{}, Failures: map[string]string{}}
+	var items []map[string]interface{}
+	for _, result := range results {
+		if result.err != nil {
+			klog.Warningf("failed to list %s from cluster %s: %v", info.Resource, result.cluster, result.err)
+			aggregated.Failures[result.cluster] = result.err.Error()
+			continue
+		}
+		for _, item := range result.items {
+			object := unstructured.Unstructured{Object: item}
+			annotations := object.GetAnnotations()
+			if annotations == nil {
+				annotations = map[string]string{}
+			}
+			annotations[constants.ClusterNameAnnotationKey] = result.cluster
+			object.SetAnnotations(annotations)
+			items = append(items, item)
+		}
+		aggregated.TotalItems += result.total
+	}
+
+	ascending, _ := strconv.ParseBool(values.Get(query.ParameterAscending))
+	sortAggregatedItems(items, query.Field(values.Get(query.ParameterOrderBy)), ascending)
+	start, end := (&query.Pagination{Limit: limit, Offset: (page - 1) * limit}).GetValidPagination(len(items))
+	if limit < 0 {
+		start, end = 0, len(items)
+	}
+	for _, item := range items[start:end] {
+		aggregated.Items = append(aggregated.Items, item)
+	}
+
+	status := http.StatusOK
+	if len(aggregated.Failures) == len(clusters) && len(clusters) > 0 {
+		status = http.StatusServiceUnavailable
+	}
+	responsewriters.WriteRawJSON(status, aggregated, w)
+}
+
+// listCluster lists the resources from the cluster through the dispatcher within ClusterListTimeout
+func (m *multiclusterDispatcher) listCluster(req *http.Request, info *request.RequestInfo, cluster, path, rawQuery string) clusterListResult {
+	result := clusterListResult{cluster: cluster}
+
+	ctx, cancel := context.WithTimeout(req.Context(), ClusterListTimeout)
+	defer cancel()
+	clusterInfo := *info
+	clusterInfo.Cluster = cluster
+	clusterReq := req.Clone(request.WithRequestInfo(ctx, &clusterInfo))
+	clusterReq.URL.Path = fmt.Sprintf("/kapis/clusters/%s%s", cluster, strings.TrimPrefix(path, "/kapis"))
+	clusterReq.URL.RawQuery = rawQuery
+
+	recorder := httptest.NewRecorder()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		m.ServeHTTP(recorder, clusterReq)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		result.err = fmt.Errorf("timed out after %s", ClusterListTimeout)
+		return result
+	}
+
+	if recorder.Code != http.StatusOK {
+		result.err = fmt.Errorf("status %d: %s", recorder.Code, strings.TrimSpace(recorder.Body.String()))
+		return result
+	}
+	list := struct {
+		Items      []map[string]interface{} `json:"items"`
+		TotalItems int                      `json:"totalItems"`
+	}{}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &list); err != nil {
+		result.err = err
+		return result
+	}
+	result.items, result.total = list.Items, list.TotalItems
+	return result
+}
+
+// sortAggregatedItems sorts the items the same way as the lists, by creation timestamp or name,
+// the items are ordered by name and then by cluster if equal
+func sortAggregatedItems(items []map[string]interface{}, sortBy query.Field, ascending bool) {
+	key := func(item map[string]interface{}) (string, string, string) {
+		object := unstructured.Unstructured{Object: item}
+		return object.GetCreationTimestamp().UTC().Format(time.RFC3339), object.GetName(), object.GetAnnotations()[constants.ClusterNameAnnotationKey]
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		leftTime, leftName, leftCluster := key(items[i])
+		rightTime, rightName, rightCluster := key(items[j])
+		left, right := []string{leftTime, leftName, leftCluster}, []string{rightTime, rightName, rightCluster}
+		if sortBy == query.FieldName {
+			left, right = []string{leftName, leftCluster}, []string{rightName, rightCluster}
+		}
+		for k := range left {
+			if left[k] != right[k] {
+				if ascending {
+					return left[k] < right[k]
+				}
+				return left[k] > right[k]
+			}
+		}
+		return false
+	})
+}
@@ -0,0 +1,200 @@
+/*
+Copyright 2022 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package filters
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	clusterv1alpha1 "kubesphere.io/api/cluster/v1alpha1"
+
+	"kubesphere.io/kubesphere/pkg/api"
+	"kubesphere.io/kubesphere/pkg/apiserver/request"
+	"kubesphere.io/kubesphere/pkg/constants"
+	"kubesphere.io/kubesphere/pkg/utils/clusterclient"
+)
+
+// fakeClusterClients serves all the clusters locally as if they were the host cluster
+type fakeClusterClients struct {
+	clusterclient.ClusterClients
+	clusters []*clusterv1alpha1.Cluster
+}
+
+func (f *fakeClusterClients) Get(name string) (*clusterv1alpha1.Cluster, error) {
+	for _, cluster := range f.clusters {
+		if cluster.Name == name {
+			return cluster, nil
+		}
+	}
+	return nil, errors.NewNotFound(clusterv1alpha1.Resource("clusters"), name)
+}
+
+func (f *fakeClusterClients) List() ([]*clusterv1alpha1.Cluster, error) {
+	return f.clusters, nil
+}
+
+func (f *fakeClusterClients) IsHostCluster(*clusterv1alpha1.Cluster) bool {
+	return true
+}
+
+func TestAggregatedList(t *testing.T) {
+	ClusterListTimeout = 100 * time.Millisecond
+	pod := func(name string, created int) map[string]interface{} {
+		return map[string]interface{}{
+			"metadata": map[string]interface{}{
+				"name":              name,
+				"creationTimestamp": time.Date(2024, 1, created, 0, 0, 0, 0, time.UTC).Format(time.RFC3339),
+			},
+		}
+	}
+	lists := map[string][]interface{}{
+		"alpha": {pod("foo", 3), pod("bar", 1)},
+		"beta":  {pod("foo", 2)},
+	}
+	var limits = sets.New[string]()
+	next := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		info, _ := request.RequestInfoFrom(req.Context())
+		limits.Insert(req.URL.Query().Get("limit"))
+		switch info.Cluster {
+		case "broken":
+			http.Error(w, "internal error", http.StatusInternalServerError)
+		case "slow":
+			<-req.Context().Done()
+		case "offline":
+			t.Errorf("the cluster not ready should not be listed from")
+		default:
+			items := lists[info.Cluster]
+			json.NewEncoder(w).Encode(api.ListResult{Items: items, TotalItems: len(items)})
+		}
+	})
+	var clusters []*clusterv1alpha1.Cluster
+	for _, name := range []string{"alpha", "beta", "broken", "slow"} {
+		clusters = append(clusters, &clusterv1alpha1.Cluster{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Status: clusterv1alpha1.ClusterStatus{Conditions: []clusterv1alpha1.ClusterCondition{
+				{Type: clusterv1alpha1.ClusterReady, Status: corev1.ConditionTrue},
+			}},
+		})
+	}
+	clusters = append(clusters, &clusterv1alpha1.Cluster{ObjectMeta: metav1.ObjectMeta{Name: "offline"}})
+	dispatcher := &multiclusterDispatcher{next: next, ClusterClients: &fakeClusterClients{clusters: clusters}}
+
+	tests := []struct {
+		description    string
+		path           string
+		cluster        string
+		expectedStatus int
+		expectedItems  []string
+		expectedTotal  int
+		expectedFailed []string
+		expectedLimit  string
+	}{
+		{
+			description:    "all clusters",
+			path:           "/kapis/clusters/*/resources.kubesphere.io/v1alpha3/pods",
+			cluster:        "*",
+			expectedStatus: http.StatusOK,
+			expectedItems:  []string{"alpha/foo", "beta/foo", "alpha/bar"},
+			expectedTotal:  3,
+			expectedFailed: []string{"broken", "slow"},
+		},
+		{
+			description:    "selected clusters sorted by name",
+			path:           "/kapis/resources.kubesphere.io/v1alpha3/pods?clusters=alpha,beta&sortBy=name&ascending=true",
+			expectedStatus: http.StatusOK,
+			expectedItems:  []string{"alpha/bar", "alpha/foo", "beta/foo"},
+			expectedTotal:  3,
+		},
+		{
+			description:    "second page",
+			path:           "/kapis/resources.kubesphere.io/v1alpha3/pods?clusters=alpha,beta&limit=2&page=2",
+			expectedStatus: http.StatusOK,
+			expectedItems:  []string{"alpha/bar"},
+			expectedTotal:  3,
+			expectedLimit:  "4",
+		},
+		{
+			description:    "all clusters failed",
+			path:           "/kapis/resources.kubesphere.io/v1alpha3/pods?clusters=broken,missing",
+			expectedStatus: http.StatusServiceUnavailable,
+			expectedItems:  []string{},
+			expectedFailed: []string{"broken", "missing"},
+		},
+		{
+			description:    "not a resource list",
+			path:           "/kapis/resources.kubesphere.io/v1alpha3/namespaces/default/pods/foo?clusters=alpha",
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	resolver := &request.RequestInfoFactory{APIPrefixes: sets.New("api", "apis", "kapis", "kapi"), GrouplessAPIPrefixes: sets.New("api", "kapi")}
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			limits = sets.New[string]()
+			req := httptest.NewRequest(http.MethodGet, test.path, nil)
+			info, err := resolver.NewRequestInfo(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if info.Cluster != test.cluster {
+				t.Fatalf("expected cluster %q, got %q", test.cluster, info.Cluster)
+			}
+			recorder := httptest.NewRecorder()
+			dispatcher.ServeHTTP(recorder, req.WithContext(request.WithRequestInfo(req.Context(), info)))
+
+			if recorder.Code != test.expectedStatus {
+				t.Fatalf("expected status %d, got %d: %s", test.expectedStatus, recorder.Code, recorder.Body.String())
+			}
+			if test.expectedStatus == http.StatusBadRequest {
+				return
+			}
+			result := &api.AggregatedListResult{}
+			if err := json.Unmarshal(recorder.Body.Bytes(), result); err != nil {
+				t.Fatal(err)
+			}
+			items := []string{}
+			for _, item := range result.Items {
+				metadata := item.(map[string]interface{})["metadata"].(map[string]interface{})
+				cluster := metadata["annotations"].(map[string]interface{})[constants.ClusterNameAnnotationKey]
+				items = append(items, fmt.Sprintf("%s/%s", cluster, metadata["name"]))
+			}
+			if diff := cmp.Diff(items, test.expectedItems); diff != "" {
+				t.Errorf("%T differ (-got, +want): %s", test.expectedItems, diff)
+			}
+			if result.TotalItems != test.expectedTotal {
+				t.Errorf("expected %d items in total, got %d", test.expectedTotal, result.TotalItems)
+			}
+			failed := sets.StringKeySet(result.Failures).List()
+			if diff := cmp.Diff(failed, test.expectedFailed); len(failed)+len(test.expectedFailed) > 0 && diff != "" {
+				t.Errorf("%T differ (-got, +want): %s", test.expectedFailed, diff)
+			}
+			if test.expectedLimit != "" && !limits.Has(test.expectedLimit) {
+				t.Errorf("expected limit %s forwarded to the clusters, got %v", test.expectedLimit, limits.UnsortedList())
+			}
+		})
+	}
+}
@@ -61,6 +61,10 @@ func (a *auditingFilter) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 
 	if event := a.LogRequestObject(req, info); event != nil {
 		resp := auditing.NewResponseCapture(w)
+		// the watch is streamed until the connection is closed, the events are not captured
+		if info.Verb == request.VerbWatch {
+			resp = auditing.NewStreamResponseCapture(w)
+		}
 		a.next.ServeHTTP(resp, req)
 		go a.LogResponseObject(event, resp)
 	} else {
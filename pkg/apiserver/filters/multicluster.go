@@ -58,6 +58,18 @@ func (m *multiclusterDispatcher) ServeHTTP(w http.ResponseWriter, req *http.Requ
 		responsewriters.InternalError(w, req, fmt.Errorf("no RequestInfo found in the context"))
 		return
 	}
+	if clusters, ok, err := m.aggregatedClusters(info, req); ok {
+		if err != nil {
+			if errors.IsBadRequest(err) {
+				responsewriters.WriteRawJSON(http.StatusBadRequest, err, w)
+			} else {
+				responsewriters.InternalError(w, req, err)
+			}
+			return
+		}
+		m.serveAggregatedList(w, req, info, clusters)
+		return
+	}
 	if info.Cluster == "" {
 		m.next.ServeHTTP(w, req)
 		return
@@ -0,0 +1,235 @@
+/*
+Copyright 2022 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package query
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/klog/v2"
+
+	"kubesphere.io/kubesphere/pkg/utils/sliceutil"
+)
+
+type Operator string
+
+const (
+	// OperatorDefault leaves the comparison to the filter funcs, e.g. equality for status and substring for name
+	OperatorDefault      Operator = ""
+	OperatorPrefix       Operator = "prefix"
+	OperatorRegex        Operator = "regex"
+	OperatorGreater      Operator = ">"
+	OperatorGreaterEqual Operator = ">="
+	OperatorLess         Operator = "<"
+	OperatorLessEqual    Operator = "<="
+)
+
+// the values of these fields have their own syntax, e.g. label=app=nginx
+var rawValueFields = []string{FieldLabel, FieldAnnotation, ParameterFieldSelector}
+
+var timeLayouts = []string{time.RFC3339, "2006-01-02T15:04:05", "2006-01-02"}
+
+// Expression is compiled from the values of a query parameter, for example:
+//
+//	status=Running&status=Pending     status is Running or Pending
+//	status=in(Running,Pending)        same as above
+//	name!=foo                         name does not contain foo
+//	name=foo*                         name starts with foo
+//	name=~^foo-[0-9]+$                name matches the regular expression
+//	creationTimestamp>2024-01-01      created after 2024-01-01
+//	creationTimestamp>=2024-01-01     created on or after 2024-01-01
+//
+// An object matches the expression if it matches any of the filters,
+// or none of them if the expression is negated.
+type Expression struct {
+	Field   Field
+	Negated bool
+	Filters []Filter
+	// Value is the query parameter value the expression is compiled from,
+	// the expression is ignored once the value in Query.Filters is changed by the callers.
+	Value Value
+}
+
+func (e *Expression) Matches(object runtime.Object, filterFunc func(runtime.Object, Filter) bool) bool {
+	for _, filter := range e.Filters {
+		if filterFunc(object, filter) {
+			return !e.Negated
+		}
+	}
+	return e.Negated
+}
+
+func compileExpression(key string, values []string) Expression {
+	expression := Expression{Value: Value(values[len(values)-1])}
+	operator := OperatorDefault
+	field := key
+	switch {
+	case strings.HasSuffix(field, "!"):
+		// name!=foo
+		expression.Negated = true
+		field = strings.TrimSuffix(field, "!")
+	case strings.HasSuffix(field, ">"):
+		// creationTimestamp>=2024-01-01
+		operator = OperatorGreaterEqual
+		field = strings.TrimSuffix(field, ">")
+	case strings.HasSuffix(field, "<"):
+		operator = OperatorLessEqual
+		field = strings.TrimSuffix(field, "<")
+	default:
+		// creationTimestamp>2024-01-01 has no '=', the whole expression is the key
+		if i := strings.IndexAny(field, "<>"); i > 0 && len(strings.Join(values, "")) == 0 {
+			operator = Operator(field[i : i+1])
+			values = []string{field[i+1:]}
+			field = field[:i]
+		}
+	}
+	expression.Field = Field(field)
+
+	for _, value := range values {
+		if operator != OperatorDefault || sliceutil.HasString(rawValueFields, field) {
+			expression.Filters = append(expression.Filters, Filter{Field: expression.Field, Value: Value(value), Operator: operator})
+			continue
+		}
+		expression.Filters = append(expression.Filters, compileFilters(expression.Field, value)...)
+	}
+	return expression
+}
+
+func compileFilters(field Field, value string) []Filter {
+	switch {
+	case strings.HasPrefix(value, "in(") && strings.HasSuffix(value, ")"):
+		var filters []Filter
+		for _, item := range strings.Split(strings.TrimSuffix(strings.TrimPrefix(value, "in("), ")"), ",") {
+			filters = append(filters, Filter{Field: field, Value: Value(strings.TrimSpace(item))})
+		}
+		return filters
+	case strings.HasPrefix(value, "~"):
+		pattern := strings.TrimPrefix(value, "~")
+		filter := Filter{Field: field, Value: Value(pattern), Operator: OperatorRegex}
+		if re, err := regexp.Compile(pattern); err != nil {
+			// leave Regexp nil, the filter matches nothing
+			klog.Warningf("invalid regular expression %s for field %s: %v", pattern, field, err)
+		} else {
+			filter.Regexp = re
+		}
+		return []Filter{filter}
+	case len(value) > 1 && strings.HasSuffix(value, "*"):
+		return []Filter{{Field: field, Value: Value(strings.TrimSuffix(value, "*")), Operator: OperatorPrefix}}
+	default:
+		return []Filter{{Field: field, Value: Value(value)}}
+	}
+}
+
+// MatchString reports whether the actual value matches the filter,
+// the values are compared for equality if the filter has no operator.
+func (f Filter) MatchString(actual string) bool {
+	return f.MatchStringFunc(actual, func(actual, expected string) bool {
+		return actual == expected
+	})
+}
+
+// MatchStringFunc is like MatchString, but uses match for the filters without operator.
+func (f Filter) MatchStringFunc(actual string, match func(actual, expected string) bool) bool {
+	switch f.Operator {
+	case OperatorPrefix:
+		return strings.HasPrefix(actual, string(f.Value))
+	case OperatorRegex:
+		return f.Regexp != nil && f.Regexp.MatchString(actual)
+	case OperatorGreater, OperatorGreaterEqual, OperatorLess, OperatorLessEqual:
+		return f.compare(compareValues(actual, string(f.Value)))
+	default:
+		return match(actual, string(f.Value))
+	}
+}
+
+// MatchTime reports whether the actual time matches the filter, the value of the filter
+// is either RFC3339 or a date like 2006-01-02.
+func (f Filter) MatchTime(actual time.Time) bool {
+	switch f.Operator {
+	case OperatorPrefix, OperatorRegex:
+		return f.MatchString(actual.UTC().Format(time.RFC3339))
+	}
+	expected, ok := parseTime(string(f.Value))
+	if !ok {
+		return false
+	}
+	switch {
+	case actual.Before(expected):
+		return f.compare(-1)
+	case actual.After(expected):
+		return f.compare(1)
+	default:
+		return f.compare(0)
+	}
+}
+
+// compare reports whether the result of comparing actual to expected satisfies the operator
+func (f Filter) compare(result int) bool {
+	switch f.Operator {
+	case OperatorGreater:
+		return result > 0
+	case OperatorGreaterEqual:
+		return result >= 0
+	case OperatorLess:
+		return result < 0
+	case OperatorLessEqual:
+		return result <= 0
+	default:
+		return result == 0
+	}
+}
+
+// compareValues compares the values as numbers or times if both of them can be parsed, otherwise as strings
+func compareValues(actual, expected string) int {
+	if a, err := strconv.ParseFloat(actual, 64); err == nil {
+		if b, err := strconv.ParseFloat(expected, 64); err == nil {
+			switch {
+			case a < b:
+				return -1
+			case a > b:
+				return 1
+			default:
+				return 0
+			}
+		}
+	}
+	if a, ok := parseTime(actual); ok {
+		if b, ok := parseTime(expected); ok {
+			switch {
+			case a.Before(b):
+				return -1
+			case a.After(b):
+				return 1
+			default:
+				return 0
+			}
+		}
+	}
+	return strings.Compare(actual, expected)
+}
+
+func parseTime(value string) (time.Time, bool) {
+	for _, layout := range timeLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,228 @@
+/*
+Copyright 2022 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package query
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/emicklei/go-restful/v3"
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func parseQuery(t *testing.T, queryString string) *Query {
+	req, err := http.NewRequest("GET", fmt.Sprintf("http://localhost?%s", queryString), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ParseQueryParameter(restful.NewRequest(req))
+}
+
+func TestCompileExpression(t *testing.T) {
+	tests := []struct {
+		queryString string
+		key         Field
+		expected    Expression
+	}{
+		{
+			"status=Running&status=Pending",
+			"status",
+			Expression{
+				Field:   "status",
+				Filters: []Filter{{Field: "status", Value: "Running"}, {Field: "status", Value: "Pending"}},
+				Value:   "Pending",
+			},
+		},
+		{
+			"status=in(Running,%20Pending)",
+			"status",
+			Expression{
+				Field:   "status",
+				Filters: []Filter{{Field: "status", Value: "Running"}, {Field: "status", Value: "Pending"}},
+				Value:   "in(Running, Pending)",
+			},
+		},
+		{
+			"name!=foo",
+			"name!",
+			Expression{
+				Field:   "name",
+				Negated: true,
+				Filters: []Filter{{Field: "name", Value: "foo"}},
+				Value:   "foo",
+			},
+		},
+		{
+			"name=foo*",
+			"name",
+			Expression{
+				Field:   "name",
+				Filters: []Filter{{Field: "name", Value: "foo", Operator: OperatorPrefix}},
+				Value:   "foo*",
+			},
+		},
+		{
+			"name=~^foo-[0-9]%2B$",
+			"name",
+			Expression{
+				Field:   "name",
+				Filters: []Filter{{Field: "name", Value: "^foo-[0-9]+$", Operator: OperatorRegex, Regexp: regexp.MustCompile("^foo-[0-9]+$")}},
+				Value:   "~^foo-[0-9]+$",
+			},
+		},
+		{
+			"creationTimestamp>2024-01-01",
+			"creationTimestamp>2024-01-01",
+			Expression{
+				Field:   "creationTimestamp",
+				Filters: []Filter{{Field: "creationTimestamp", Value: "2024-01-01", Operator: OperatorGreater}},
+			},
+		},
+		{
+			"creationTimestamp<=2024-01-01",
+			"creationTimestamp<",
+			Expression{
+				Field:   "creationTimestamp",
+				Filters: []Filter{{Field: "creationTimestamp", Value: "2024-01-01", Operator: OperatorLessEqual}},
+				Value:   "2024-01-01",
+			},
+		},
+		{
+			"label=app=*",
+			"label",
+			Expression{
+				Field:   "label",
+				Filters: []Filter{{Field: "label", Value: "app=*"}},
+				Value:   "app=*",
+			},
+		},
+	}
+
+	compareRegexp := cmp.Comparer(func(x, y *regexp.Regexp) bool {
+		if x == nil || y == nil {
+			return x == y
+		}
+		return x.String() == y.String()
+	})
+	for _, test := range tests {
+		t.Run(test.queryString, func(t *testing.T) {
+			got := parseQuery(t, test.queryString).Expressions[test.key]
+			if diff := cmp.Diff(got, test.expected, compareRegexp); diff != "" {
+				t.Errorf("%T differ (-got, +want): %s", test.expected, diff)
+			}
+		})
+	}
+}
+
+func TestQueryMatches(t *testing.T) {
+	newPod := func(name string, phase corev1.PodPhase, created string, restarts int32) *corev1.Pod {
+		creationTimestamp, _ := time.Parse("2006-01-02", created)
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, CreationTimestamp: metav1.NewTime(creationTimestamp)},
+			Status: corev1.PodStatus{
+				Phase:             phase,
+				ContainerStatuses: []corev1.ContainerStatus{{RestartCount: restarts}},
+			},
+		}
+	}
+	pods := []*corev1.Pod{
+		newPod("web-1", corev1.PodRunning, "2023-06-01", 0),
+		newPod("web-2", corev1.PodPending, "2024-01-01", 3),
+		newPod("db", corev1.PodFailed, "2024-06-01", 12),
+	}
+	filterFunc := func(object runtime.Object, filter Filter) bool {
+		pod := object.(*corev1.Pod)
+		switch filter.Field {
+		case FieldName:
+			return filter.MatchStringFunc(pod.Name, strings.Contains)
+		case FieldStatus:
+			return filter.MatchString(string(pod.Status.Phase))
+		case FieldCreationTimeStamp:
+			return filter.MatchTime(pod.CreationTimestamp.Time)
+		case "restarts":
+			return filter.MatchString(strconv.Itoa(int(pod.Status.ContainerStatuses[0].RestartCount)))
+		default:
+			return false
+		}
+	}
+
+	tests := []struct {
+		description string
+		queryString string
+		modify      func(q *Query)
+		expected    []string
+	}{
+		{"contains", "name=web", nil, []string{"web-1", "web-2"}},
+		{"or", "status=Running&status=Failed", nil, []string{"web-1", "db"}},
+		{"in", "status=in(Running,Failed)", nil, []string{"web-1", "db"}},
+		{"negation", "status!=Running", nil, []string{"web-2", "db"}},
+		{"negated set", "status!=in(Running,Failed)", nil, []string{"web-2"}},
+		{"prefix", "name=we*", nil, []string{"web-1", "web-2"}},
+		{"regex", "name=~^(db|web-2)$", nil, []string{"web-2", "db"}},
+		{"invalid regex", "name=~[", nil, nil},
+		{"after", "creationTimestamp>2024-01-01", nil, []string{"db"}},
+		{"on or after", "creationTimestamp>=2024-01-01", nil, []string{"web-2", "db"}},
+		{"before", "creationTimestamp<2024-01-01T00:00:00Z", nil, []string{"web-1"}},
+		{"numeric", "restarts>=3", nil, []string{"web-2", "db"}},
+		{"numeric not lexicographic", "restarts<3", nil, []string{"web-1"}},
+		{"and", "name=web&status!=Running", nil, []string{"web-2"}},
+		{
+			"overridden by caller",
+			"status=in(Running,Failed)",
+			func(q *Query) { q.Filters[FieldStatus] = Value(corev1.PodPending) },
+			[]string{"web-2"},
+		},
+		{
+			"removed by caller",
+			"status=Running&name=web",
+			func(q *Query) { delete(q.Filters, FieldStatus) },
+			[]string{"web-1", "web-2"},
+		},
+		{
+			"added by caller",
+			"name=web",
+			func(q *Query) { q.Filters[FieldStatus] = Value(corev1.PodRunning) },
+			[]string{"web-1"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			q := parseQuery(t, test.queryString)
+			if test.modify != nil {
+				test.modify(q)
+			}
+			var got []string
+			for _, pod := range pods {
+				if q.Matches(pod, filterFunc) {
+					got = append(got, pod.Name)
+				}
+			}
+			if diff := cmp.Diff(got, test.expected); diff != "" {
+				t.Errorf("%T differ (-got, +want): %s", test.expected, diff)
+			}
+		})
+	}
+}
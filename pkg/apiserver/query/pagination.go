@@ -0,0 +1,186 @@
+/*
+Copyright 2022 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package query
+
+import (
+	"container/heap"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// ContinueToken is the position of the last item of a page, it is encoded as an opaque
+// string in the list result and passed back in the continue parameter to fetch the next page.
+type ContinueToken struct {
+	SortBy    Field `json:"sortBy"`
+	Ascending bool  `json:"ascending"`
+	// Key is the namespace/name of the last item of the page
+	Key string `json:"key"`
+	// Offset is the number of items before the next page, used if the last item no longer exists
+	Offset int `json:"offset"`
+}
+
+func (t *ContinueToken) Encode() string {
+	data, _ := json.Marshal(t)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// ContinueToken decodes the continue parameter of the query, it returns nil if the parameter is not set.
+func (q *Query) ContinueToken() (*ContinueToken, error) {
+	if q.Continue == "" {
+		return nil, nil
+	}
+	data, err := base64.RawURLEncoding.DecodeString(q.Continue)
+	if err != nil {
+		return nil, fmt.Errorf("invalid continue token: %v", err)
+	}
+	token := &ContinueToken{}
+	if err := json.Unmarshal(data, token); err != nil {
+		return nil, fmt.Errorf("invalid continue token: %v", err)
+	}
+	if token.SortBy != q.SortBy || token.Ascending != q.Ascending || token.Offset < 0 {
+		return nil, fmt.Errorf("continue token does not match the query, the sort order has changed")
+	}
+	return token, nil
+}
+
+// Paginate returns the page of the sorted objects requested by the query, the number of remaining objects,
+// and the continue token of the next page if there are remaining ones. A malformed continue token is rejected
+// as a bad request, and the token of a page which is beyond the current objects is rejected as expired. Objects are ordered by less, and by
+// namespace/name if neither is less than the other, so that the order is stable across requests.
+//
+// With the continue parameter the page starts after the last item of the previous page, otherwise it starts
+// at the offset of the page parameter. Only the objects of the page are sorted if the page starts at the
+// beginning or after the last item, instead of sorting all of them.
+func (q *Query) Paginate(objects []runtime.Object, less func(left, right runtime.Object) bool) ([]runtime.Object, int, string, error) {
+	pagination := q.Pagination
+	if pagination == nil {
+		pagination = NoPagination
+	}
+	stableLess := func(left, right runtime.Object) bool {
+		if less(left, right) {
+			return true
+		}
+		if less(right, left) {
+			return false
+		}
+		return objectKey(left) < objectKey(right)
+	}
+
+	// skipped is the number of items before the page, start is the index of the page in the candidates
+	skipped, start := pagination.Offset, pagination.Offset
+	if q.Continue != "" {
+		token, err := q.ContinueToken()
+		if err != nil {
+			return nil, 0, "", errors.NewBadRequest(err.Error())
+		}
+		skipped, start = token.Offset, token.Offset
+		last := findObject(objects, token.Key)
+		// the last item of the previous page and the items before it have been removed,
+		// the page can not be located any more
+		if last == nil && token.Offset > len(objects) {
+			return nil, 0, "", errors.NewResourceExpired("the continue token is expired, the list should be restarted without it")
+		}
+		if last != nil {
+			var after []runtime.Object
+			for _, object := range objects {
+				if stableLess(last, object) {
+					after = append(after, object)
+				}
+			}
+			objects, start = after, 0
+		}
+	}
+
+	var page []runtime.Object
+	if pagination.Limit > 0 && start == 0 {
+		page = selectFirst(objects, pagination.Limit, stableLess)
+	} else {
+		sort.Slice(objects, func(i, j int) bool {
+			return stableLess(objects[i], objects[j])
+		})
+		from, to := (&Pagination{Limit: pagination.Limit, Offset: start}).GetValidPagination(len(objects))
+		page = objects[from:to]
+	}
+
+	remaining := len(objects) - start - len(page)
+	if remaining <= 0 || len(page) == 0 {
+		return page, 0, "", nil
+	}
+	token := &ContinueToken{
+		SortBy:    q.SortBy,
+		Ascending: q.Ascending,
+		Key:       objectKey(page[len(page)-1]),
+		Offset:    skipped + len(page),
+	}
+	return page, remaining, token.Encode(), nil
+}
+
+func objectKey(object runtime.Object) string {
+	accessor, err := meta.Accessor(object)
+	if err != nil {
+		return ""
+	}
+	return accessor.GetNamespace() + "/" + accessor.GetName()
+}
+
+func findObject(objects []runtime.Object, key string) runtime.Object {
+	for _, object := range objects {
+		if objectKey(object) == key {
+			return object
+		}
+	}
+	return nil
+}
+
+// selectFirst returns the first n objects ordered by less, sorted
+func selectFirst(objects []runtime.Object, n int, less func(left, right runtime.Object) bool) []runtime.Object {
+	h := &objectHeap{less: less}
+	for _, object := range objects {
+		if h.Len() < n {
+			heap.Push(h, object)
+		} else if less(object, h.objects[0]) {
+			h.objects[0] = object
+			heap.Fix(h, 0)
+		}
+	}
+	sort.Slice(h.objects, func(i, j int) bool {
+		return less(h.objects[i], h.objects[j])
+	})
+	return h.objects
+}
+
+// objectHeap keeps the greatest object on top
+type objectHeap struct {
+	objects []runtime.Object
+	less    func(left, right runtime.Object) bool
+}
+
+func (h *objectHeap) Len() int           { return len(h.objects) }
+func (h *objectHeap) Less(i, j int) bool { return h.less(h.objects[j], h.objects[i]) }
+func (h *objectHeap) Swap(i, j int)      { h.objects[i], h.objects[j] = h.objects[j], h.objects[i] }
+func (h *objectHeap) Push(x interface{}) { h.objects = append(h.objects, x.(runtime.Object)) }
+func (h *objectHeap) Pop() interface{} {
+	last := h.objects[len(h.objects)-1]
+	h.objects = h.objects[:len(h.objects)-1]
+	return last
+}
@@ -0,0 +1,171 @@
+/*
+Copyright 2022 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package query
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func newPods(names ...string) []runtime.Object {
+	var objects []runtime.Object
+	for _, name := range names {
+		objects = append(objects, &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name}})
+	}
+	return objects
+}
+
+func podNames(objects []runtime.Object) []string {
+	var names []string
+	for _, object := range objects {
+		names = append(names, object.(*corev1.Pod).Name)
+	}
+	return names
+}
+
+func byName(left, right runtime.Object) bool {
+	return strings.Compare(left.(*corev1.Pod).Name, right.(*corev1.Pod).Name) < 0
+}
+
+func TestPaginateContinue(t *testing.T) {
+	var names []string
+	for i := 0; i < 25; i++ {
+		names = append(names, fmt.Sprintf("pod-%02d", i))
+	}
+
+	q := &Query{Pagination: newPagination(10, 0), SortBy: FieldName, Ascending: true}
+	var got []string
+	var remainings []int
+	for {
+		page, remaining, continueToken, err := q.Paginate(newPods(names...), byName)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, podNames(page)...)
+		remainings = append(remainings, remaining)
+		if continueToken == "" {
+			break
+		}
+		q.Continue = continueToken
+	}
+
+	if diff := cmp.Diff(got, names); diff != "" {
+		t.Errorf("%T differ (-got, +want): %s", names, diff)
+	}
+	if diff := cmp.Diff(remainings, []int{15, 5, 0}); diff != "" {
+		t.Errorf("%T differ (-got, +want): %s", remainings, diff)
+	}
+}
+
+func TestPaginateConcurrentChanges(t *testing.T) {
+	q := &Query{Pagination: newPagination(2, 0), SortBy: FieldName, Ascending: true}
+	page, _, continueToken, err := q.Paginate(newPods("b", "d", "f", "h"), byName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(podNames(page), []string{"b", "d"}); diff != "" {
+		t.Fatalf("%T differ (-got, +want): %s", page, diff)
+	}
+
+	// an item inserted before the last item doesn't shift the next page
+	q.Continue = continueToken
+	page, remaining, _, err := q.Paginate(newPods("a", "b", "d", "f", "h"), byName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(podNames(page), []string{"f", "h"}); diff != "" {
+		t.Errorf("%T differ (-got, +want): %s", page, diff)
+	}
+	if remaining != 0 {
+		t.Errorf("expected no remaining items, got %d", remaining)
+	}
+
+	// the position is used if the last item is deleted
+	page, _, _, err = q.Paginate(newPods("b", "f", "h"), byName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(podNames(page), []string{"h"}); diff != "" {
+		t.Errorf("%T differ (-got, +want): %s", page, diff)
+	}
+
+	// the token is expired if the last item and the items before it are deleted
+	if _, _, _, err = q.Paginate(newPods("h"), byName); !errors.IsResourceExpired(err) {
+		t.Errorf("expected resource expired error, got %v", err)
+	}
+}
+
+func TestPaginatePage(t *testing.T) {
+	q := &Query{Pagination: newPagination(2, 2), SortBy: FieldName, Ascending: false}
+	page, remaining, continueToken, err := q.Paginate(newPods("a", "b", "c", "d", "e"), func(left, right runtime.Object) bool {
+		return byName(right, left)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(podNames(page), []string{"c", "b"}); diff != "" {
+		t.Errorf("%T differ (-got, +want): %s", page, diff)
+	}
+	if remaining != 1 {
+		t.Errorf("expected 1 remaining item, got %d", remaining)
+	}
+
+	// the continue token of a page can be used to fetch the following ones
+	q.Continue = continueToken
+	page, _, _, err = q.Paginate(newPods("a", "b", "c", "d", "e"), func(left, right runtime.Object) bool {
+		return byName(right, left)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(podNames(page), []string{"a"}); diff != "" {
+		t.Errorf("%T differ (-got, +want): %s", page, diff)
+	}
+}
+
+func TestContinueToken(t *testing.T) {
+	token := (&ContinueToken{SortBy: FieldName, Key: "default/a", Offset: 1}).Encode()
+	tests := []struct {
+		description string
+		query       *Query
+		expectError bool
+	}{
+		{"valid", &Query{SortBy: FieldName, Continue: token}, false},
+		{"sort order changed", &Query{SortBy: FieldCreationTimeStamp, Continue: token}, true},
+		{"malformed", &Query{SortBy: FieldName, Continue: "not-a-token"}, true},
+	}
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			_, err := test.query.ContinueToken()
+			if (err != nil) != test.expectError {
+				t.Errorf("expected error %v, got %v", test.expectError, err)
+			}
+			if err != nil {
+				if _, _, _, err = test.query.Paginate(newPods("a", "b"), byName); !errors.IsBadRequest(err) {
+					t.Errorf("expected bad request error for invalid token, got %v", err)
+				}
+			}
+		})
+	}
+}
@@ -17,10 +17,12 @@ limitations under the License.
 package query
 
 import (
+	"regexp"
 	"strconv"
 
 	"github.com/emicklei/go-restful/v3"
 	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
 
 	"kubesphere.io/kubesphere/pkg/utils/sliceutil"
 )
@@ -33,6 +35,13 @@ const (
 	ParameterLimit         = "limit"
 	ParameterOrderBy       = "sortBy"
 	ParameterAscending     = "ascending"
+
+	ParameterContinue        = "continue"
+	ParameterFields          = "fields"
+	ParameterAs              = "as"
+	ParameterClusters        = "clusters"
+	ParameterWatch           = "watch"
+	ParameterResourceVersion = "resourceVersion"
 )
 
 // Query represents api search terms
@@ -48,7 +57,13 @@ type Query struct {
 	//
 	Filters map[Field]Value
 
+	// Expressions are compiled from all the values of the query parameters, keyed by the parameter name
+	Expressions map[Field]Expression
+
 	LabelSelector string
+
+	// Continue is the opaque token of the next page returned by the previous list, see ContinueToken
+	Continue string
 }
 
 type Pagination struct {
@@ -109,8 +124,28 @@ func New() *Query {
 }
 
 type Filter struct {
-	Field Field
-	Value Value
+	Field    Field
+	Value    Value
+	Operator Operator
+	// Regexp is compiled from the value if the operator is OperatorRegex
+	Regexp *regexp.Regexp
+}
+
+// Matches reports whether the object matches all the filters of the query. The filters parsed from the
+// query parameters are evaluated as compiled expressions, the ones set by the callers are evaluated as is.
+func (q *Query) Matches(object runtime.Object, filterFunc func(runtime.Object, Filter) bool) bool {
+	for field, value := range q.Filters {
+		if expression, ok := q.Expressions[field]; ok && expression.Value == value {
+			if !expression.Matches(object, filterFunc) {
+				return false
+			}
+			continue
+		}
+		if !filterFunc(object, Filter{Field: field, Value: value}) {
+			return false
+		}
+	}
+	return true
 }
 
 func ParseQueryParameter(request *restful.Request) *Query {
@@ -139,13 +174,20 @@ func ParseQueryParameter(request *restful.Request) *Query {
 	}
 
 	query.LabelSelector = request.QueryParameter(ParameterLabelSelector)
+	query.Continue = request.QueryParameter(ParameterContinue)
 
 	for key, values := range request.Request.URL.Query() {
-		if !sliceutil.HasString([]string{ParameterPage, ParameterLimit, ParameterOrderBy, ParameterAscending, ParameterLabelSelector}, key) {
+		if !sliceutil.HasString([]string{ParameterPage, ParameterLimit, ParameterOrderBy, ParameterAscending, ParameterLabelSelector, ParameterContinue, ParameterFields, ParameterAs, ParameterClusters, ParameterWatch, ParameterResourceVersion}, key) {
 			// support multiple query condition
 			for _, value := range values {
 				query.Filters[Field(key)] = Value(value)
 			}
+			if len(values) > 0 {
+				if query.Expressions == nil {
+					query.Expressions = map[Field]Expression{}
+				}
+				query.Expressions[Field(key)] = compileExpression(key, values)
+			}
 		}
 	}
 
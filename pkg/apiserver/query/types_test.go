@@ -43,6 +43,23 @@ func TestParseQueryParameter(t *testing.T) {
 					FieldName:   Value("foo"),
 					FieldStatus: Value("Running"),
 				},
+				Expressions: map[Field]Expression{
+					FieldLabel: {
+						Field:   FieldLabel,
+						Filters: []Filter{{Field: FieldLabel, Value: Value("app.kubernetes.io/name=book")}},
+						Value:   Value("app.kubernetes.io/name=book"),
+					},
+					FieldName: {
+						Field:   FieldName,
+						Filters: []Filter{{Field: FieldName, Value: Value("foo")}},
+						Value:   Value("foo"),
+					},
+					FieldStatus: {
+						Field:   FieldStatus,
+						Filters: []Filter{{Field: FieldStatus, Value: Value("Running")}},
+						Value:   Value("Running"),
+					},
+				},
 			},
 		},
 		{
@@ -56,6 +73,18 @@ func TestParseQueryParameter(t *testing.T) {
 					Field("xxxx"):  Value("xxxx"),
 					Field("dsfsw"): Value("xxxx"),
 				},
+				Expressions: map[Field]Expression{
+					Field("xxxx"): {
+						Field:   Field("xxxx"),
+						Filters: []Filter{{Field: Field("xxxx"), Value: Value("xxxx")}},
+						Value:   Value("xxxx"),
+					},
+					Field("dsfsw"): {
+						Field:   Field("dsfsw"),
+						Filters: []Filter{{Field: Field("dsfsw"), Value: Value("xxxx")}},
+						Value:   Value("xxxx"),
+					},
+				},
 			},
 		},
 	}
@@ -35,6 +35,7 @@ const (
 	KubeSphereConfigMapDataKey    = "kubesphere.yaml"
 
 	ClusterNameLabelKey               = "kubesphere.io/cluster"
+	ClusterNameAnnotationKey          = "kubesphere.io/cluster"
 	NameLabelKey                      = "kubesphere.io/name"
 	WorkspaceLabelKey                 = "kubesphere.io/workspace"
 	NamespaceLabelKey                 = "kubesphere.io/namespace"
@@ -66,6 +67,9 @@ const (
 	DevOpsProjectRoleTag = "DevOps Project Role"
 	NamespaceRoleTag     = "Namespace Role"
 
+	AccessReviewTag  = "Access Review"
+	AccessRequestTag = "Access Request"
+
 	OpenpitrixTag            = "OpenPitrix Resources"
 	OpenpitrixAppInstanceTag = "App Instance"
 	OpenpitrixAppTemplateTag = "App Template"
@@ -0,0 +1,360 @@
+/*
+Copyright 2022 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package accessrequest
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/google/uuid"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apiserver/pkg/apis/audit"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	iamv1alpha2 "kubesphere.io/api/iam/v1alpha2"
+	tenantv1alpha1 "kubesphere.io/api/tenant/v1alpha1"
+
+	auditv1alpha1 "kubesphere.io/kubesphere/pkg/apiserver/auditing/v1alpha1"
+)
+
+const (
+	controllerName = "accessrequest-controller"
+	// the role bindings are named after the access requests
+	bindingNamePrefix = "accessrequest-"
+
+	accessGranted  = "AccessGranted"
+	accessRevoked  = "AccessRevoked"
+	accessConflict = "AccessConflict"
+)
+
+// Reconciler reconciles an AccessRequest object, the role binding is created once the
+// access request is approved and deleted when the access request expires.
+type Reconciler struct {
+	client.Client
+	Logger                  logr.Logger
+	Scheme                  *runtime.Scheme
+	Recorder                record.EventRecorder
+	MaxConcurrentReconciles int
+	// AuditingEvents receives the auditing events of granting and revoking the access,
+	// nil if auditing is disabled.
+	AuditingEvents chan<- *auditv1alpha1.Event
+}
+
+func (r *Reconciler) SetupWithManager(mgr ctrl.Manager) error {
+	if r.Client == nil {
+		r.Client = mgr.GetClient()
+	}
+	if r.Logger.GetSink() == nil {
+		r.Logger = ctrl.Log.WithName("controllers").WithName(controllerName)
+	}
+	if r.Scheme == nil {
+		r.Scheme = mgr.GetScheme()
+	}
+	if r.Recorder == nil {
+		r.Recorder = mgr.GetEventRecorderFor(controllerName)
+	}
+	if r.MaxConcurrentReconciles <= 0 {
+		r.MaxConcurrentReconciles = 1
+	}
+	return ctrl.NewControllerManagedBy(mgr).
+		Named(controllerName).
+		WithOptions(controller.Options{
+			MaxConcurrentReconciles: r.MaxConcurrentReconciles,
+		}).
+		For(&iamv1alpha2.AccessRequest{}).
+		Complete(r)
+}
+
+// +kubebuilder:rbac:groups=iam.kubesphere.io,resources=accessrequests,verbs=get;list;watch;update;patch
+// +kubebuilder:rbac:groups=iam.kubesphere.io,resources=accessrequests/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=iam.kubesphere.io,resources=workspacerolebindings,verbs=get;list;watch;create;delete
+// +kubebuilder:rbac:groups=rbac.authorization.k8s.io,resources=rolebindings;clusterrolebindings,verbs=get;list;watch;create;delete
+func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logger := r.Logger.WithValues("accessrequest", req.NamespacedName)
+	accessRequest := &iamv1alpha2.AccessRequest{}
+	if err := r.Get(ctx, req.NamespacedName, accessRequest); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	switch accessRequest.Status.State {
+	case "":
+		accessRequest.Status.State = iamv1alpha2.AccessRequestPending
+		if err := r.Status().Update(ctx, accessRequest); err != nil {
+			logger.Error(err, "update access request status failed")
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{}, nil
+	case iamv1alpha2.AccessRequestApproved:
+		if !expired(accessRequest) {
+			return r.grant(ctx, logger, accessRequest)
+		}
+		return ctrl.Result{}, r.revoke(ctx, logger, accessRequest)
+	case iamv1alpha2.AccessRequestActive:
+		if !expired(accessRequest) {
+			return ctrl.Result{RequeueAfter: time.Until(accessRequest.Status.ExpiresAt.Time)}, nil
+		}
+		return ctrl.Result{}, r.revoke(ctx, logger, accessRequest)
+	}
+
+	return ctrl.Result{}, nil
+}
+
+func expired(accessRequest *iamv1alpha2.AccessRequest) bool {
+	return accessRequest.Status.ExpiresAt == nil || !time.Now().Before(accessRequest.Status.ExpiresAt.Time)
+}
+
+func (r *Reconciler) grant(ctx context.Context, logger logr.Logger, accessRequest *iamv1alpha2.AccessRequest) (ctrl.Result, error) {
+	binding := newRoleBinding(accessRequest)
+	// the role binding is garbage collected along with the access request, it is not the controller
+	// reference since the workspace role bindings are controlled by the workspaces
+	if err := controllerutil.SetOwnerReference(accessRequest, binding, r.Scheme); err != nil {
+		logger.Error(err, "set owner reference failed")
+		return ctrl.Result{}, err
+	}
+	if err := r.Create(ctx, binding); err != nil {
+		if !errors.IsAlreadyExists(err) {
+			logger.Error(err, "create role binding failed")
+			return ctrl.Result{}, err
+		}
+		// the existing role binding is adopted only if it was created for the access request,
+		// otherwise the access request must not take over the binding created by others
+		existing, err := r.getRoleBinding(ctx, binding)
+		if err != nil {
+			logger.Error(err, "get role binding failed")
+			return ctrl.Result{}, err
+		}
+		if existing == nil {
+			return ctrl.Result{Requeue: true}, nil
+		}
+		if !grantedBy(existing, binding, accessRequest) {
+			message := fmt.Sprintf("role binding %s already exists and is not created for the access request", binding.GetName())
+			logger.Info(message)
+			r.Recorder.Event(accessRequest, corev1.EventTypeWarning, accessConflict, message)
+			// retried once the access request is updated
+			return ctrl.Result{}, nil
+		}
+	}
+
+	accessRequest.Status.State = iamv1alpha2.AccessRequestActive
+	if err := r.Status().Update(ctx, accessRequest); err != nil {
+		logger.Error(err, "update access request status failed")
+		return ctrl.Result{}, err
+	}
+
+	message := fmt.Sprintf("role %s is granted to user %s until %s, approved by %s", accessRequest.Spec.Role,
+		accessRequest.Spec.User, accessRequest.Status.ExpiresAt.UTC().Format(time.RFC3339), accessRequest.Status.Approver)
+	r.Recorder.Event(accessRequest, corev1.EventTypeNormal, accessGranted, message)
+	r.audit(accessRequest, binding, "create", message)
+	return ctrl.Result{RequeueAfter: time.Until(accessRequest.Status.ExpiresAt.Time)}, nil
+}
+
+func (r *Reconciler) revoke(ctx context.Context, logger logr.Logger, accessRequest *iamv1alpha2.AccessRequest) error {
+	binding := newRoleBinding(accessRequest)
+	existing, err := r.getRoleBinding(ctx, binding)
+	if err != nil {
+		logger.Error(err, "get role binding failed")
+		return err
+	}
+	// the role binding which is not created for the access request is left untouched
+	foreign := existing != nil && !grantedBy(existing, binding, accessRequest)
+	if existing != nil && !foreign {
+		uid := existing.GetUID()
+		if err = r.Delete(ctx, existing, client.Preconditions{UID: &uid}); client.IgnoreNotFound(err) != nil {
+			logger.Error(err, "delete role binding failed")
+			return err
+		}
+	} else if foreign {
+		logger.Info("role binding is not created for the access request, skip deleting", "rolebinding", binding.GetName())
+	}
+
+	wasActive := accessRequest.Status.State == iamv1alpha2.AccessRequestActive && !foreign
+	accessRequest.Status.State = iamv1alpha2.AccessRequestExpired
+	if err = r.Status().Update(ctx, accessRequest); err != nil {
+		logger.Error(err, "update access request status failed")
+		return err
+	}
+
+	if wasActive {
+		message := fmt.Sprintf("role %s of user %s is revoked, the access expired", accessRequest.Spec.Role, accessRequest.Spec.User)
+		r.Recorder.Event(accessRequest, corev1.EventTypeNormal, accessRevoked, message)
+		r.audit(accessRequest, binding, "delete", message)
+	}
+	return nil
+}
+
+// getRoleBinding returns the existing role binding with the same kind and name as the given one, nil if not found
+func (r *Reconciler) getRoleBinding(ctx context.Context, binding client.Object) (client.Object, error) {
+	existing := binding.DeepCopyObject().(client.Object)
+	if err := r.Get(ctx, client.ObjectKeyFromObject(binding), existing); err != nil {
+		if errors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return existing, nil
+}
+
+// grantedBy checks whether the existing role binding is owned by the access request,
+// and binds the requested role to the requested user only.
+func grantedBy(existing, expected client.Object, accessRequest *iamv1alpha2.AccessRequest) bool {
+	if existing.GetLabels()[iamv1alpha2.AccessRequestReferenceLabel] != accessRequest.Name {
+		return false
+	}
+	owned := false
+	for _, ownerReference := range existing.GetOwnerReferences() {
+		if ownerReference.UID == accessRequest.UID {
+			owned = true
+			break
+		}
+	}
+	if !owned {
+		return false
+	}
+	switch existing := existing.(type) {
+	case *rbacv1.RoleBinding:
+		expected := expected.(*rbacv1.RoleBinding)
+		return equality.Semantic.DeepEqual(existing.RoleRef, expected.RoleRef) && equality.Semantic.DeepEqual(existing.Subjects, expected.Subjects)
+	case *rbacv1.ClusterRoleBinding:
+		expected := expected.(*rbacv1.ClusterRoleBinding)
+		return equality.Semantic.DeepEqual(existing.RoleRef, expected.RoleRef) && equality.Semantic.DeepEqual(existing.Subjects, expected.Subjects)
+	case *iamv1alpha2.WorkspaceRoleBinding:
+		expected := expected.(*iamv1alpha2.WorkspaceRoleBinding)
+		return equality.Semantic.DeepEqual(existing.RoleRef, expected.RoleRef) && equality.Semantic.DeepEqual(existing.Subjects, expected.Subjects)
+	}
+	return false
+}
+
+// newRoleBinding returns the role binding of the access request in the requested scope
+func newRoleBinding(accessRequest *iamv1alpha2.AccessRequest) client.Object {
+	objectMeta := metav1.ObjectMeta{
+		Name: bindingNamePrefix + accessRequest.Name,
+		Labels: map[string]string{
+			iamv1alpha2.UserReferenceLabel:          accessRequest.Spec.User,
+			iamv1alpha2.AccessRequestReferenceLabel: accessRequest.Name,
+		},
+		Annotations: map[string]string{},
+	}
+	if accessRequest.Status.ExpiresAt != nil {
+		objectMeta.Annotations[iamv1alpha2.ExpiresAtAnnotation] = accessRequest.Status.ExpiresAt.UTC().Format(time.RFC3339)
+	}
+	subjects := []rbacv1.Subject{
+		{
+			Kind:     rbacv1.UserKind,
+			APIGroup: rbacv1.SchemeGroupVersion.Group,
+			Name:     accessRequest.Spec.User,
+		},
+	}
+
+	switch {
+	case accessRequest.Spec.Namespace != "":
+		objectMeta.Namespace = accessRequest.Spec.Namespace
+		return &rbacv1.RoleBinding{
+			ObjectMeta: objectMeta,
+			Subjects:   subjects,
+			RoleRef: rbacv1.RoleRef{
+				APIGroup: rbacv1.SchemeGroupVersion.Group,
+				Kind:     iamv1alpha2.ResourceKindRole,
+				Name:     accessRequest.Spec.Role,
+			},
+		}
+	case accessRequest.Spec.Workspace != "":
+		objectMeta.Labels[tenantv1alpha1.WorkspaceLabel] = accessRequest.Spec.Workspace
+		return &iamv1alpha2.WorkspaceRoleBinding{
+			ObjectMeta: objectMeta,
+			Subjects:   subjects,
+			RoleRef: rbacv1.RoleRef{
+				APIGroup: iamv1alpha2.SchemeGroupVersion.Group,
+				Kind:     iamv1alpha2.ResourceKindWorkspaceRole,
+				Name:     accessRequest.Spec.Role,
+			},
+		}
+	default:
+		return &rbacv1.ClusterRoleBinding{
+			ObjectMeta: objectMeta,
+			Subjects:   subjects,
+			RoleRef: rbacv1.RoleRef{
+				APIGroup: rbacv1.SchemeGroupVersion.Group,
+				Kind:     iamv1alpha2.ResourceKindClusterRole,
+				Name:     accessRequest.Spec.Role,
+			},
+		}
+	}
+}
+
+// audit sends the auditing event of the role binding without blocking, the event is dropped if the buffer is full
+func (r *Reconciler) audit(accessRequest *iamv1alpha2.AccessRequest, binding client.Object, verb string, message string) {
+	if r.AuditingEvents == nil {
+		return
+	}
+
+	objectRef := &audit.ObjectReference{
+		Namespace:  binding.GetNamespace(),
+		Name:       binding.GetName(),
+		APIVersion: rbacv1.SchemeGroupVersion.Version,
+		APIGroup:   rbacv1.GroupName,
+	}
+	switch binding.(type) {
+	case *rbacv1.RoleBinding:
+		objectRef.Resource = "rolebindings"
+	case *iamv1alpha2.WorkspaceRoleBinding:
+		objectRef.Resource = iamv1alpha2.ResourcesPluralWorkspaceRoleBinding
+		objectRef.APIGroup = iamv1alpha2.SchemeGroupVersion.Group
+		objectRef.APIVersion = iamv1alpha2.SchemeGroupVersion.Version
+	case *rbacv1.ClusterRoleBinding:
+		objectRef.Resource = "clusterrolebindings"
+	}
+
+	now := metav1.NowMicro()
+	event := &auditv1alpha1.Event{
+		Workspace: accessRequest.Spec.Workspace,
+		Message:   message,
+		Event: audit.Event{
+			Level:                    audit.LevelMetadata,
+			AuditID:                  types.UID(uuid.New().String()),
+			Stage:                    audit.StageResponseComplete,
+			Verb:                     verb,
+			User:                     authenticationv1.UserInfo{Username: controllerName},
+			ObjectRef:                objectRef,
+			ResponseStatus:           &metav1.Status{Status: metav1.StatusSuccess, Code: 200, Message: message},
+			RequestReceivedTimestamp: now,
+			StageTimestamp:           now,
+			Annotations: map[string]string{
+				iamv1alpha2.AccessRequestReferenceLabel: accessRequest.Name,
+				iamv1alpha2.UserReferenceLabel:          accessRequest.Spec.User,
+			},
+		},
+	}
+
+	select {
+	case r.AuditingEvents <- event:
+	default:
+		r.Logger.Info("auditing event dropped", "accessrequest", accessRequest.Name)
+	}
+}
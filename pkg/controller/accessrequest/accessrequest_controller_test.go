@@ -0,0 +1,167 @@
+/*
+Copyright 2022 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package accessrequest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	runtimefakeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	iamv1alpha2 "kubesphere.io/api/iam/v1alpha2"
+	tenantv1alpha1 "kubesphere.io/api/tenant/v1alpha1"
+
+	"kubesphere.io/kubesphere/pkg/apis"
+	auditv1alpha1 "kubesphere.io/kubesphere/pkg/apiserver/auditing/v1alpha1"
+)
+
+func newAccessRequest(name string, state iamv1alpha2.AccessRequestState, expiresAt time.Time) *iamv1alpha2.AccessRequest {
+	return &iamv1alpha2.AccessRequest{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec: iamv1alpha2.AccessRequestSpec{
+			User:     "tester",
+			Role:     "admin",
+			Duration: metav1.Duration{Duration: time.Hour},
+		},
+		Status: iamv1alpha2.AccessRequestStatus{
+			State:     state,
+			Approver:  "admin",
+			ExpiresAt: &metav1.Time{Time: expiresAt},
+		},
+	}
+}
+
+func TestReconcile(t *testing.T) {
+	pending := newAccessRequest("pending", "", time.Time{})
+	pending.Status = iamv1alpha2.AccessRequestStatus{}
+
+	approved := newAccessRequest("approved", iamv1alpha2.AccessRequestApproved, time.Now().Add(time.Hour))
+	approved.Spec.Workspace = "workspace1"
+	approved.Spec.Role = "workspace1-admin"
+
+	expired := newAccessRequest("expired", iamv1alpha2.AccessRequestActive, time.Now().Add(-time.Minute))
+	expired.UID = "expired-uid"
+	expired.Spec.Namespace = "namespace1"
+	expiredBinding := newRoleBinding(expired).(*rbacv1.RoleBinding)
+	expiredBinding.OwnerReferences = []metav1.OwnerReference{{
+		APIVersion: iamv1alpha2.SchemeGroupVersion.String(),
+		Kind:       "AccessRequest",
+		Name:       expired.Name,
+		UID:        expired.UID,
+	}}
+
+	approvedTooLate := newAccessRequest("approved-too-late", iamv1alpha2.AccessRequestApproved, time.Now().Add(-time.Minute))
+
+	// the role bindings with the same name are created by others
+	conflicted := newAccessRequest("conflicted", iamv1alpha2.AccessRequestApproved, time.Now().Add(time.Hour))
+	conflicted.UID = "conflicted-uid"
+	conflictedBinding := newRoleBinding(conflicted).(*rbacv1.ClusterRoleBinding)
+	conflictedBinding.RoleRef.Name = "platform-admin"
+	conflictedBinding.Subjects[0].Name = "admin"
+
+	expiredConflicted := newAccessRequest("expired-conflicted", iamv1alpha2.AccessRequestActive, time.Now().Add(-time.Minute))
+	expiredConflicted.UID = "expired-conflicted-uid"
+	expiredConflictedBinding := newRoleBinding(expiredConflicted).(*rbacv1.ClusterRoleBinding)
+
+	sch := scheme.Scheme
+	if err := apis.AddToScheme(sch); err != nil {
+		t.Fatalf("unable add APIs to scheme: %v", err)
+	}
+	client := runtimefakeclient.NewClientBuilder().WithScheme(sch).
+		WithRuntimeObjects(pending, approved, expired, expiredBinding, approvedTooLate,
+			conflicted, conflictedBinding, expiredConflicted, expiredConflictedBinding).Build()
+	events := make(chan *auditv1alpha1.Event, 10)
+	r := &Reconciler{
+		Client:         client,
+		Logger:         ctrl.Log.WithName("controllers").WithName(controllerName),
+		Scheme:         sch,
+		Recorder:       &record.FakeRecorder{},
+		AuditingEvents: events,
+	}
+
+	tests := []struct {
+		name           string
+		expectedState  iamv1alpha2.AccessRequestState
+		expectRequeued bool
+		expectedVerb   string
+	}{
+		{name: pending.Name, expectedState: iamv1alpha2.AccessRequestPending},
+		{name: approved.Name, expectedState: iamv1alpha2.AccessRequestActive, expectRequeued: true, expectedVerb: "create"},
+		{name: expired.Name, expectedState: iamv1alpha2.AccessRequestExpired, expectedVerb: "delete"},
+		{name: approvedTooLate.Name, expectedState: iamv1alpha2.AccessRequestExpired},
+		{name: conflicted.Name, expectedState: iamv1alpha2.AccessRequestApproved},
+		{name: expiredConflicted.Name, expectedState: iamv1alpha2.AccessRequestExpired},
+	}
+
+	ctx := context.Background()
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result, err := r.Reconcile(ctx, reconcile.Request{NamespacedName: types.NamespacedName{Name: test.name}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert.Equal(t, test.expectRequeued, result.RequeueAfter > 0)
+
+			accessRequest := &iamv1alpha2.AccessRequest{}
+			if err := client.Get(ctx, types.NamespacedName{Name: test.name}, accessRequest); err != nil {
+				t.Fatal(err)
+			}
+			assert.Equal(t, test.expectedState, accessRequest.Status.State)
+
+			select {
+			case event := <-events:
+				assert.Equal(t, test.expectedVerb, event.Verb)
+				assert.Equal(t, bindingNamePrefix+test.name, event.ObjectRef.Name)
+			default:
+				assert.Empty(t, test.expectedVerb, "auditing event expected")
+			}
+		})
+	}
+
+	workspaceRoleBinding := &iamv1alpha2.WorkspaceRoleBinding{}
+	if err := client.Get(ctx, types.NamespacedName{Name: bindingNamePrefix + approved.Name}, workspaceRoleBinding); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "workspace1", workspaceRoleBinding.Labels[tenantv1alpha1.WorkspaceLabel])
+	assert.Equal(t, approved.Name, workspaceRoleBinding.Labels[iamv1alpha2.AccessRequestReferenceLabel])
+	assert.Equal(t, approved.Status.ExpiresAt.UTC().Format(time.RFC3339), workspaceRoleBinding.Annotations[iamv1alpha2.ExpiresAtAnnotation])
+	assert.Equal(t, "workspace1-admin", workspaceRoleBinding.RoleRef.Name)
+
+	err := client.Get(ctx, types.NamespacedName{Namespace: "namespace1", Name: expiredBinding.Name}, &rbacv1.RoleBinding{})
+	assert.True(t, errors.IsNotFound(err))
+
+	// the role bindings created by others are neither adopted nor deleted
+	clusterRoleBinding := &rbacv1.ClusterRoleBinding{}
+	if err = client.Get(ctx, types.NamespacedName{Name: conflictedBinding.Name}, clusterRoleBinding); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "platform-admin", clusterRoleBinding.RoleRef.Name)
+	assert.Empty(t, clusterRoleBinding.OwnerReferences)
+	if err = client.Get(ctx, types.NamespacedName{Name: expiredConflictedBinding.Name}, clusterRoleBinding); err != nil {
+		t.Fatal(err)
+	}
+}
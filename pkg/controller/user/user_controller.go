@@ -34,6 +34,7 @@ import (
 	"kubesphere.io/kubesphere/pkg/apiserver/authentication"
 
 	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
 
 	utilwait "k8s.io/apimachinery/pkg/util/wait"
 
@@ -50,6 +51,7 @@ import (
 	iamv1alpha2 "kubesphere.io/api/iam/v1alpha2"
 
 	"kubesphere.io/kubesphere/pkg/constants"
+	"kubesphere.io/kubesphere/pkg/models/auth"
 	modelsdevops "kubesphere.io/kubesphere/pkg/models/devops"
 	"kubesphere.io/kubesphere/pkg/models/kubeconfig"
 	"kubesphere.io/kubesphere/pkg/simple/client/devops"
@@ -61,6 +63,8 @@ const (
 	// SuccessSynced is used as part of the Event 'reason' when a Foo is synced
 	successSynced = "Synced"
 	failedSynced  = "FailedSync"
+	// is used as the Event 'reason' when the password set without the API violates the password policy
+	passwordPolicyViolated = "PasswordPolicyViolated"
 	// is synced successfully
 	messageResourceSynced = "User synced successfully"
 	controllerName        = "user-controller"
@@ -79,6 +83,7 @@ type Reconciler struct {
 	DevopsClient            devops.Interface
 	LdapClient              ldapclient.Interface
 	AuthenticationOptions   *authentication.Options
+	PasswordPolicy          auth.PasswordPolicy
 	Logger                  logr.Logger
 	Scheme                  *runtime.Scheme
 	Recorder                record.EventRecorder
@@ -101,6 +106,13 @@ func (r *Reconciler) SetupWithManager(mgr ctrl.Manager) error {
 	if r.MaxConcurrentReconciles <= 0 {
 		r.MaxConcurrentReconciles = 1
 	}
+	if r.PasswordPolicy == nil {
+		passwordPolicy, err := auth.NewPasswordPolicy(r.AuthenticationOptions.PasswordPolicy)
+		if err != nil {
+			return err
+		}
+		r.PasswordPolicy = passwordPolicy
+	}
 	return ctrl.NewControllerManagedBy(mgr).
 		Named(controllerName).
 		WithOptions(controller.Options{
@@ -197,6 +209,7 @@ func (r *Reconciler) Reconcile(ctx context.Context, req reconcile.Request) (reco
 
 	// update user status if not managed by kubefed
 	managedByKubefed := user.Labels[constants.KubefedManagedLabel] == "true"
+	var passwordExpiresIn time.Duration
 	if !managedByKubefed {
 		if err = r.encryptPassword(ctx, user); err != nil {
 			klog.Error(err)
@@ -208,6 +221,11 @@ func (r *Reconciler) Reconcile(ctx context.Context, req reconcile.Request) (reco
 			r.Recorder.Event(user, corev1.EventTypeWarning, failedSynced, fmt.Sprintf(syncFailMessage, err))
 			return ctrl.Result{}, err
 		}
+		if passwordExpiresIn, err = r.syncPasswordExpiration(ctx, user); err != nil {
+			klog.Error(err)
+			r.Recorder.Event(user, corev1.EventTypeWarning, failedSynced, fmt.Sprintf(syncFailMessage, err))
+			return ctrl.Result{}, err
+		}
 	}
 
 	if r.KubeconfigClient != nil {
@@ -235,13 +253,21 @@ func (r *Reconciler) Reconcile(ctx context.Context, req reconcile.Request) (reco
 		return ctrl.Result{Requeue: true, RequeueAfter: r.AuthenticationOptions.AuthenticateRateLimiterDuration}, nil
 	}
 
+	// put it back to the queue to expire the password
+	if passwordExpiresIn > 0 {
+		return ctrl.Result{RequeueAfter: passwordExpiresIn}, nil
+	}
+
 	return ctrl.Result{}, nil
 }
 
 // encryptPassword Encrypt and update the user password
 func (r *Reconciler) encryptPassword(ctx context.Context, user *iamv1alpha2.User) error {
 	// password is not empty and not encrypted
-	if user.Spec.EncryptedPassword != "" && !isEncrypted(user.Spec.EncryptedPassword) {
+	if user.Spec.EncryptedPassword != "" && !auth.IsEncrypted(user.Spec.EncryptedPassword) {
+		// the password set by the API has been validated, the others such as those applied by kubectl
+		// can't be rejected, the user is forced to change the password on next login instead.
+		violations := r.PasswordPolicy.Validate(user, user.Spec.EncryptedPassword, field.NewPath("spec", "password"))
 		password, err := encrypt(user.Spec.EncryptedPassword)
 		if err != nil {
 			klog.Error(err)
@@ -252,6 +278,23 @@ func (r *Reconciler) encryptPassword(ctx context.Context, user *iamv1alpha2.User
 			user.Annotations = make(map[string]string)
 		}
 		user.Annotations[iamv1alpha2.LastPasswordChangeTimeAnnotation] = time.Now().UTC().Format(time.RFC3339)
+		r.PasswordPolicy.RecordPassword(user, password)
+		if len(violations) > 0 {
+			reason := fmt.Sprintf("The password violates the password policy: %s", violations.ToAggregate())
+			r.Recorder.Event(user, corev1.EventTypeWarning, passwordPolicyViolated, reason)
+			if user.Status.State != iamv1alpha2.UserDisabled {
+				user.Status = iamv1alpha2.UserStatus{
+					State:              iamv1alpha2.UserPasswordExpired,
+					Reason:             reason,
+					LastTransitionTime: &metav1.Time{Time: time.Now()},
+				}
+			}
+		} else if user.Status.State == iamv1alpha2.UserPasswordExpired {
+			user.Status = iamv1alpha2.UserStatus{
+				State:              iamv1alpha2.UserActive,
+				LastTransitionTime: &metav1.Time{Time: time.Now()},
+			}
+		}
 		// ensure plain text password won't be kept anywhere
 		delete(user.Annotations, corev1.LastAppliedConfigAnnotation)
 		err = r.Update(ctx, user, &client.UpdateOptions{})
@@ -262,6 +305,30 @@ func (r *Reconciler) encryptPassword(ctx context.Context, user *iamv1alpha2.User
 	return nil
 }
 
+// syncPasswordExpiration forces the user to change the password on next login once it exceeds the maximum age,
+// it returns how long until the password expires, or 0 if the password never expires or has expired.
+func (r *Reconciler) syncPasswordExpiration(ctx context.Context, user *iamv1alpha2.User) (time.Duration, error) {
+	if user.Status.State != iamv1alpha2.UserActive {
+		return 0, nil
+	}
+	expiration := r.PasswordPolicy.Expiration(user)
+	if expiration == nil {
+		return 0, nil
+	}
+	if expiresIn := time.Until(*expiration); expiresIn > 0 {
+		return expiresIn, nil
+	}
+	user.Status = iamv1alpha2.UserStatus{
+		State:              iamv1alpha2.UserPasswordExpired,
+		Reason:             fmt.Sprintf("The password exceeds the maximum age %s", r.AuthenticationOptions.PasswordPolicy.MaxAge),
+		LastTransitionTime: &metav1.Time{Time: time.Now()},
+	}
+	if err := r.Update(ctx, user, &client.UpdateOptions{}); err != nil {
+		return 0, err
+	}
+	return 0, nil
+}
+
 func (r *Reconciler) ensureNotControlledByKubefed(ctx context.Context, user *iamv1alpha2.User) error {
 	if user.Labels[constants.KubefedManagedLabel] != "false" {
 		if user.Labels == nil {
@@ -363,7 +430,7 @@ func (r *Reconciler) waitForUnassignDevOpsAdminRole(user *iamv1alpha2.User) erro
 }
 
 func (r *Reconciler) waitForSyncToLDAP(user *iamv1alpha2.User) error {
-	if isEncrypted(user.Spec.EncryptedPassword) {
+	if auth.IsEncrypted(user.Spec.EncryptedPassword) {
 		return nil
 	}
 	err := utilwait.PollImmediate(interval, timeout, func() (done bool, err error) {
@@ -490,7 +557,7 @@ func (r *Reconciler) syncUserStatus(ctx context.Context, user *iamv1alpha2.User)
 	}
 
 	// becomes active after password encrypted
-	if user.Status.State == "" && isEncrypted(user.Spec.EncryptedPassword) {
+	if user.Status.State == "" && auth.IsEncrypted(user.Spec.EncryptedPassword) {
 		user.Status = iamv1alpha2.UserStatus{
 			State:              iamv1alpha2.UserActive,
 			LastTransitionTime: &metav1.Time{Time: time.Now()},
@@ -559,11 +626,3 @@ func encrypt(password string) (string, error) {
 	bytes, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
 	return string(bytes), err
 }
-
-// isEncrypted returns whether the given password is encrypted
-func isEncrypted(password string) bool {
-	// bcrypt.Cost returns the hashing cost used to create the given hashed
-	cost, _ := bcrypt.Cost([]byte(password))
-	// cost > 0 means the password has been encrypted
-	return cost > 0
-}
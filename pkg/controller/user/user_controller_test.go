@@ -38,6 +38,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	"kubesphere.io/kubesphere/pkg/apis"
+	"kubesphere.io/kubesphere/pkg/models/auth"
 
 	ldapclient "kubesphere.io/kubesphere/pkg/simple/client/ldap"
 
@@ -59,6 +60,14 @@ func newUser(name string) *iamv1alpha2.User {
 	}
 }
 
+func newPasswordPolicy(t *testing.T, options *authentication.PasswordPolicyOptions) auth.PasswordPolicy {
+	passwordPolicy, err := auth.NewPasswordPolicy(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return passwordPolicy
+}
+
 func TestDoNothing(t *testing.T) {
 	authenticateOptions := authentication.NewOptions()
 	authenticateOptions.AuthenticateRateLimiterMaxTries = 1
@@ -93,6 +102,7 @@ func TestDoNothing(t *testing.T) {
 		Logger:                ctrl.Log.WithName("controllers").WithName(controllerName),
 		Client:                client,
 		AuthenticationOptions: authenticateOptions,
+		PasswordPolicy:        newPasswordPolicy(t, &authentication.PasswordPolicyOptions{}),
 	}
 
 	users := &iamv1alpha2.UserList{}
@@ -122,7 +132,7 @@ func TestDoNothing(t *testing.T) {
 	assert.NotNil(t, updateEvent.Object)
 	user = updateEvent.Object.(*iamv1alpha2.User)
 	assert.NotNil(t, user)
-	assert.True(t, isEncrypted(user.Spec.EncryptedPassword))
+	assert.True(t, auth.IsEncrypted(user.Spec.EncryptedPassword))
 
 	// becomes active after password encrypted
 	updateEvent = <-w.ResultChan()
@@ -148,3 +158,66 @@ func TestDoNothing(t *testing.T) {
 	user = updateEvent.Object.(*iamv1alpha2.User)
 	assert.Equal(t, iamv1alpha2.UserActive, user.Status.State)
 }
+
+func TestPasswordPolicy(t *testing.T) {
+	authenticateOptions := authentication.NewOptions()
+	authenticateOptions.PasswordPolicy.MaxAge = time.Hour
+	encrypted, err := encrypt("P@88w0rd")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	weakPassword := newUser("weak")
+	weakPassword.Spec.EncryptedPassword = "password"
+
+	expiredPassword := newUser("expired")
+	expiredPassword.Spec.EncryptedPassword = encrypted
+	expiredPassword.Annotations = map[string]string{
+		iamv1alpha2.LastPasswordChangeTimeAnnotation: time.Now().Add(-2 * time.Hour).UTC().Format(time.RFC3339),
+	}
+	expiredPassword.Status.State = iamv1alpha2.UserActive
+
+	validPassword := newUser("valid")
+	validPassword.Spec.EncryptedPassword = "P@88w0rd"
+
+	sch := scheme.Scheme
+	if err := apis.AddToScheme(sch); err != nil {
+		t.Fatalf("unable add APIs to scheme: %v", err)
+	}
+	client := runtimefakeclient.NewClientBuilder().WithScheme(sch).
+		WithRuntimeObjects(weakPassword, expiredPassword, validPassword).Build()
+	c := &Reconciler{
+		Recorder:              &record.FakeRecorder{},
+		Logger:                ctrl.Log.WithName("controllers").WithName(controllerName),
+		Client:                client,
+		AuthenticationOptions: authenticateOptions,
+		PasswordPolicy:        newPasswordPolicy(t, authenticateOptions.PasswordPolicy),
+	}
+
+	tests := []struct {
+		username       string
+		expectedState  iamv1alpha2.UserState
+		expectRequeued bool
+	}{
+		{username: weakPassword.Name, expectedState: iamv1alpha2.UserPasswordExpired},
+		{username: expiredPassword.Name, expectedState: iamv1alpha2.UserPasswordExpired},
+		{username: validPassword.Name, expectedState: iamv1alpha2.UserActive, expectRequeued: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.username, func(t *testing.T) {
+			result, err := c.Reconcile(context.Background(), reconcile.Request{
+				NamespacedName: types.NamespacedName{Name: tt.username},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			user := &iamv1alpha2.User{}
+			if err = client.Get(context.Background(), types.NamespacedName{Name: tt.username}, user); err != nil {
+				t.Fatal(err)
+			}
+			assert.True(t, auth.IsEncrypted(user.Spec.EncryptedPassword))
+			assert.Equal(t, tt.expectedState, user.Status.State)
+			assert.Equal(t, tt.expectRequeued, result.RequeueAfter > 0)
+		})
+	}
+}
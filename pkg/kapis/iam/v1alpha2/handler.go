@@ -18,6 +18,7 @@ package v1alpha2
 
 import (
 	"fmt"
+	"net/http"
 	"strings"
 
 	authuser "k8s.io/apiserver/pkg/authentication/user"
@@ -26,14 +27,19 @@ import (
 	"kubesphere.io/kubesphere/pkg/models/auth"
 
 	"github.com/emicklei/go-restful/v3"
+	"gopkg.in/square/go-jose.v2"
+	authorizationv1 "k8s.io/api/authorization/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/klog/v2"
 
 	iamv1alpha2 "kubesphere.io/api/iam/v1alpha2"
 
 	"kubesphere.io/kubesphere/pkg/api"
+	"kubesphere.io/kubesphere/pkg/apiserver/authentication/token"
 	"kubesphere.io/kubesphere/pkg/apiserver/authorization/authorizer"
+	"kubesphere.io/kubesphere/pkg/apiserver/authorization/rbac"
 	"kubesphere.io/kubesphere/pkg/apiserver/query"
 	apirequest "kubesphere.io/kubesphere/pkg/apiserver/request"
 	"kubesphere.io/kubesphere/pkg/models/iam/am"
@@ -57,19 +63,37 @@ type PasswordReset struct {
 	Password        string `json:"password"`
 }
 
-type iamHandler struct {
-	am         am.AccessManagementInterface
-	im         im.IdentityManagementInterface
-	group      group.GroupOperator
-	authorizer authorizer.Authorizer
+type MultiFactorVerification struct {
+	Passcode string `json:"passcode" description:"TOTP passcode or recovery code"`
 }
 
-func newIAMHandler(im im.IdentityManagementInterface, am am.AccessManagementInterface, group group.GroupOperator, authorizer authorizer.Authorizer) *iamHandler {
+type iamHandler struct {
+	am             am.AccessManagementInterface
+	im             im.IdentityManagementInterface
+	group          group.GroupOperator
+	authorizer     authorizer.Authorizer
+	multiFactor    auth.MultiFactorAuthenticator
+	accessTokens   auth.AccessTokenManagementInterface
+	sessions       auth.SessionManagementInterface
+	issuer         token.Issuer
+	reviewer       rbac.AccessReviewer
+	accessRequests am.AccessRequestInterface
+}
+
+func newIAMHandler(im im.IdentityManagementInterface, am am.AccessManagementInterface, group group.GroupOperator, authorizer authorizer.Authorizer,
+	multiFactor auth.MultiFactorAuthenticator, accessTokens auth.AccessTokenManagementInterface, sessions auth.SessionManagementInterface,
+	issuer token.Issuer, reviewer rbac.AccessReviewer, accessRequests am.AccessRequestInterface) *iamHandler {
 	return &iamHandler{
-		am:         am,
-		im:         im,
-		group:      group,
-		authorizer: authorizer,
+		am:             am,
+		im:             im,
+		group:          group,
+		authorizer:     authorizer,
+		multiFactor:    multiFactor,
+		accessTokens:   accessTokens,
+		sessions:       sessions,
+		issuer:         issuer,
+		reviewer:       reviewer,
+		accessRequests: accessRequests,
 	}
 }
 
@@ -525,7 +549,7 @@ func (h *iamHandler) CreateUser(req *restful.Request, resp *restful.Response) {
 
 	created, err := h.im.CreateUser(&user)
 	if err != nil {
-		api.HandleError(resp, req, err)
+		handlePasswordError(resp, req, err)
 		return
 	}
 
@@ -626,6 +650,127 @@ func (h *iamHandler) ModifyPassword(request *restful.Request, response *restful.
 
 	err = h.im.ModifyPassword(username, passwordReset.Password)
 	if err != nil {
+		handlePasswordError(response, request, err)
+		return
+	}
+
+	response.WriteEntity(servererr.None)
+}
+
+// handlePasswordError writes the violations of the password policy as a structured status,
+// so that the clients can tell the user which requirements are not met.
+func handlePasswordError(response *restful.Response, request *restful.Request, err error) {
+	if statusErr, ok := err.(*errors.StatusError); ok && errors.IsInvalid(err) {
+		klog.Warning(err)
+		response.WriteHeaderAndEntity(http.StatusUnprocessableEntity, statusErr.Status())
+		return
+	}
+	api.HandleError(response, request, err)
+}
+
+// EnrollMultiFactor generates the TOTP secret and recovery codes, only the user can enroll for themselves.
+func (h *iamHandler) EnrollMultiFactor(request *restful.Request, response *restful.Response) {
+	username := request.PathParameter("user")
+
+	operator, ok := apirequest.UserFrom(request.Request.Context())
+	if !ok {
+		err := errors.NewInternalError(fmt.Errorf("cannot obtain user info"))
+		api.HandleInternalError(response, request, err)
+		return
+	}
+	if operator.GetName() != username {
+		api.HandleForbidden(response, request, fmt.Errorf("multi-factor authentication can only be enrolled by the user"))
+		return
+	}
+
+	enrollment, err := h.multiFactor.Enroll(username)
+	if err != nil {
+		api.HandleError(response, request, err)
+		return
+	}
+
+	response.WriteEntity(enrollment)
+}
+
+// VerifyMultiFactor confirms the enrollment with the first passcode.
+func (h *iamHandler) VerifyMultiFactor(request *restful.Request, response *restful.Response) {
+	username := request.PathParameter("user")
+	var verification MultiFactorVerification
+	if err := request.ReadEntity(&verification); err != nil {
+		api.HandleBadRequest(response, request, err)
+		return
+	}
+
+	operator, ok := apirequest.UserFrom(request.Request.Context())
+	if !ok {
+		err := errors.NewInternalError(fmt.Errorf("cannot obtain user info"))
+		api.HandleInternalError(response, request, err)
+		return
+	}
+	if operator.GetName() != username {
+		api.HandleForbidden(response, request, fmt.Errorf("multi-factor authentication can only be enrolled by the user"))
+		return
+	}
+
+	if err := h.multiFactor.Authenticate(username, verification.Passcode); err != nil {
+		if err == auth.IncorrectPasscodeError || err == auth.MultiFactorEnrollmentRequiredError {
+			err = errors.NewBadRequest(err.Error())
+		}
+		api.HandleError(response, request, err)
+		return
+	}
+
+	response.WriteEntity(servererr.None)
+}
+
+func (h *iamHandler) DisableMultiFactor(request *restful.Request, response *restful.Response) {
+	username := request.PathParameter("user")
+	var verification MultiFactorVerification
+	// the passcode is optional for the user manager
+	if request.Request.ContentLength != 0 {
+		if err := request.ReadEntity(&verification); err != nil {
+			api.HandleBadRequest(response, request, err)
+			return
+		}
+	}
+
+	operator, ok := apirequest.UserFrom(request.Request.Context())
+	if !ok {
+		err := errors.NewInternalError(fmt.Errorf("cannot obtain user info"))
+		api.HandleInternalError(response, request, err)
+		return
+	}
+
+	userManagement := authorizer.AttributesRecord{
+		APIGroup:        iamv1alpha2.SchemeGroupVersion.Group,
+		Resource:        iamv1alpha2.ResourcesPluralUser,
+		Subresource:     "mfa",
+		Name:            username,
+		Verb:            "delete",
+		ResourceScope:   apirequest.GlobalScope,
+		ResourceRequest: true,
+		User:            operator,
+	}
+
+	decision, _, err := h.authorizer.Authorize(userManagement)
+	if err != nil {
+		api.HandleInternalError(response, request, err)
+		return
+	}
+
+	// only the user manager can disable the multi-factor authentication without verifying the passcode,
+	// e.g. the user has lost the authenticator app and all the recovery codes
+	if decision != authorizer.DecisionAllow {
+		if err = h.multiFactor.Authenticate(username, verification.Passcode); err != nil {
+			if err == auth.IncorrectPasscodeError || err == auth.MultiFactorEnrollmentRequiredError {
+				err = errors.NewBadRequest(err.Error())
+			}
+			api.HandleError(response, request, err)
+			return
+		}
+	}
+
+	if err = h.multiFactor.Disable(username); err != nil {
 		api.HandleError(response, request, err)
 		return
 	}
@@ -1240,6 +1385,175 @@ func (h *iamHandler) ListUserLoginRecords(request *restful.Request, response *re
 	response.WriteEntity(result)
 }
 
+// CreateAccessToken issues a personal access token, only the user can create access tokens for themselves,
+// and access tokens can not be used to create another one.
+func (h *iamHandler) CreateAccessToken(request *restful.Request, response *restful.Response) {
+	username := request.PathParameter("user")
+	var accessToken auth.AccessToken
+	if err := request.ReadEntity(&accessToken); err != nil {
+		api.HandleBadRequest(response, request, err)
+		return
+	}
+
+	operator, ok := apirequest.UserFrom(request.Request.Context())
+	if !ok {
+		err := errors.NewInternalError(fmt.Errorf("cannot obtain user info"))
+		api.HandleInternalError(response, request, err)
+		return
+	}
+	if operator.GetName() != username {
+		api.HandleForbidden(response, request, fmt.Errorf("access tokens can only be created by the user"))
+		return
+	}
+	if len(operator.GetExtra()[iamv1alpha2.ExtraAccessToken]) > 0 {
+		api.HandleForbidden(response, request, fmt.Errorf("access tokens can not be created with an access token"))
+		return
+	}
+
+	created, err := h.accessTokens.CreateAccessToken(username, &accessToken)
+	if err != nil {
+		api.HandleError(response, request, err)
+		return
+	}
+
+	response.WriteEntity(created)
+}
+
+// authorizeAccessTokens allows the user to manage their own access tokens,
+// the access tokens of other users can only be managed by the user manager.
+func (h *iamHandler) authorizeAccessTokens(request *restful.Request, username, verb string) error {
+	operator, ok := apirequest.UserFrom(request.Request.Context())
+	if !ok {
+		return errors.NewInternalError(fmt.Errorf("cannot obtain user info"))
+	}
+	if operator.GetName() == username {
+		return nil
+	}
+	userManagement := authorizer.AttributesRecord{
+		APIGroup:        iamv1alpha2.SchemeGroupVersion.Group,
+		Resource:        iamv1alpha2.ResourcesPluralUser,
+		Subresource:     "accesstokens",
+		Name:            username,
+		Verb:            verb,
+		ResourceScope:   apirequest.GlobalScope,
+		ResourceRequest: true,
+		User:            operator,
+	}
+	decision, _, err := h.authorizer.Authorize(userManagement)
+	if err != nil {
+		klog.Error(err)
+		return err
+	}
+	if decision != authorizer.DecisionAllow {
+		return errors.NewForbidden(iamv1alpha2.Resource("accesstokens"), "",
+			fmt.Errorf("access tokens of user %s can only be managed by the user", username))
+	}
+	return nil
+}
+
+func (h *iamHandler) ListAccessTokens(request *restful.Request, response *restful.Response) {
+	username := request.PathParameter("user")
+	if err := h.authorizeAccessTokens(request, username, "list"); err != nil {
+		api.HandleError(response, request, err)
+		return
+	}
+	accessTokens, err := h.accessTokens.ListAccessTokens(username)
+	if err != nil {
+		api.HandleError(response, request, err)
+		return
+	}
+	result := api.ListResult{Items: make([]interface{}, 0, len(accessTokens)), TotalItems: len(accessTokens)}
+	for _, accessToken := range accessTokens {
+		result.Items = append(result.Items, accessToken)
+	}
+	response.WriteEntity(result)
+}
+
+func (h *iamHandler) DescribeAccessToken(request *restful.Request, response *restful.Response) {
+	username := request.PathParameter("user")
+	name := request.PathParameter("accesstoken")
+	if err := h.authorizeAccessTokens(request, username, "get"); err != nil {
+		api.HandleError(response, request, err)
+		return
+	}
+	accessToken, err := h.accessTokens.DescribeAccessToken(username, name)
+	if err != nil {
+		api.HandleError(response, request, err)
+		return
+	}
+	response.WriteEntity(accessToken)
+}
+
+func (h *iamHandler) DeleteAccessToken(request *restful.Request, response *restful.Response) {
+	username := request.PathParameter("user")
+	name := request.PathParameter("accesstoken")
+	if err := h.authorizeAccessTokens(request, username, "delete"); err != nil {
+		api.HandleError(response, request, err)
+		return
+	}
+	if err := h.accessTokens.DeleteAccessToken(username, name); err != nil {
+		api.HandleError(response, request, err)
+		return
+	}
+	response.WriteEntity(servererr.None)
+}
+
+func (h *iamHandler) ListSessions(request *restful.Request, response *restful.Response) {
+	username := request.PathParameter("user")
+	sessions, err := h.sessions.ListSessions(username)
+	if err != nil {
+		api.HandleError(response, request, err)
+		return
+	}
+	result := api.ListResult{Items: make([]interface{}, 0, len(sessions)), TotalItems: len(sessions)}
+	for _, session := range sessions {
+		result.Items = append(result.Items, session)
+	}
+	response.WriteEntity(result)
+}
+
+// DeleteSessions terminates all the sessions of the user, the tokens issued in the sessions are invalidated.
+func (h *iamHandler) DeleteSessions(request *restful.Request, response *restful.Response) {
+	username := request.PathParameter("user")
+	if err := h.sessions.DeleteAllSessions(username); err != nil {
+		api.HandleError(response, request, err)
+		return
+	}
+	response.WriteEntity(servererr.None)
+}
+
+func (h *iamHandler) DeleteSession(request *restful.Request, response *restful.Response) {
+	username := request.PathParameter("user")
+	session := request.PathParameter("session")
+	if err := h.sessions.DeleteSession(username, session); err != nil {
+		api.HandleError(response, request, err)
+		return
+	}
+	response.WriteEntity(servererr.None)
+}
+
+// ListSigningKeys returns the public keys of the active and the verify-only signing keys.
+func (h *iamHandler) ListSigningKeys(request *restful.Request, response *restful.Response) {
+	response.WriteEntity(signingKeySet(h.issuer.Keys()))
+}
+
+// RotateSigningKeys activates a new signing key, the tokens signed by the previous keys are still valid.
+func (h *iamHandler) RotateSigningKeys(request *restful.Request, response *restful.Response) {
+	if err := h.issuer.RotateKeys(); err != nil {
+		api.HandleInternalError(response, request, err)
+		return
+	}
+	response.WriteEntity(signingKeySet(h.issuer.Keys()))
+}
+
+func signingKeySet(keys *token.Keys) jose.JSONWebKeySet {
+	keySet := jose.JSONWebKeySet{Keys: make([]jose.JSONWebKey, 0, len(keys.VerificationKeys))}
+	for _, key := range keys.VerificationKeys {
+		keySet.Keys = append(keySet.Keys, *key)
+	}
+	return keySet
+}
+
 func (h *iamHandler) ListWorkspaceGroups(request *restful.Request, response *restful.Response) {
 	workspaceName := request.PathParameter("workspace")
 	queryParam := query.ParseQueryParameter(request)
@@ -1488,3 +1802,336 @@ func (h *iamHandler) DeleteGroupBinding(request *restful.Request, response *rest
 
 	response.WriteEntity(servererr.None)
 }
+
+// SelfSubjectRulesReview enumerates the rules the current user has in the specified workspace or namespace,
+// the cluster scope is reviewed if neither of them is specified.
+type SelfSubjectRulesReview struct {
+	Spec   SubjectRulesReviewSpec                   `json:"spec"`
+	Status authorizationv1.SubjectRulesReviewStatus `json:"status,omitempty"`
+}
+
+type SubjectRulesReviewSpec struct {
+	Workspace string `json:"workspace,omitempty"`
+	Namespace string `json:"namespace,omitempty"`
+}
+
+// ResourceAccessReview reviews which users and groups are allowed to perform the action.
+type ResourceAccessReview struct {
+	Spec   ResourceAccessReviewSpec   `json:"spec"`
+	Status ResourceAccessReviewStatus `json:"status,omitempty"`
+}
+
+type ResourceAccessReviewSpec struct {
+	Verb        string `json:"verb"`
+	APIGroup    string `json:"apiGroup,omitempty"`
+	Resource    string `json:"resource"`
+	Subresource string `json:"subresource,omitempty"`
+	Name        string `json:"name,omitempty"`
+	Workspace   string `json:"workspace,omitempty"`
+	Namespace   string `json:"namespace,omitempty"`
+}
+
+type ResourceAccessReviewStatus struct {
+	Users  []string `json:"users"`
+	Groups []string `json:"groups"`
+	// Grants names the binding which allows the action for each of the users and groups
+	Grants          []rbac.GrantedRule `json:"grants"`
+	EvaluationError string             `json:"evaluationError,omitempty"`
+}
+
+// PermissionReport lists the rules granted to the user, along with the bindings granting them.
+type PermissionReport struct {
+	User            string             `json:"user"`
+	Groups          []string           `json:"groups"`
+	Rules           []rbac.GrantedRule `json:"rules"`
+	EvaluationError string             `json:"evaluationError,omitempty"`
+}
+
+func reviewScope(workspace, namespace string) string {
+	if namespace != "" {
+		return apirequest.NamespaceScope
+	}
+	if workspace != "" {
+		return apirequest.WorkspaceScope
+	}
+	return apirequest.ClusterScope
+}
+
+func (h *iamHandler) CreateSelfSubjectRulesReview(req *restful.Request, resp *restful.Response) {
+	var review SelfSubjectRulesReview
+	if err := req.ReadEntity(&review); err != nil {
+		api.HandleBadRequest(resp, req, err)
+		return
+	}
+
+	requestUser, ok := apirequest.UserFrom(req.Request.Context())
+	if !ok {
+		err := fmt.Errorf("cannot obtain user info")
+		klog.Errorln(err)
+		api.HandleForbidden(resp, req, err)
+		return
+	}
+
+	rules, err := h.reviewer.RulesFor(authorizer.AttributesRecord{
+		User:          requestUser,
+		Workspace:     review.Spec.Workspace,
+		Namespace:     review.Spec.Namespace,
+		ResourceScope: reviewScope(review.Spec.Workspace, review.Spec.Namespace),
+	})
+	if err != nil {
+		review.Status.Incomplete = true
+		review.Status.EvaluationError = err.Error()
+	}
+
+	review.Status.ResourceRules = make([]authorizationv1.ResourceRule, 0)
+	review.Status.NonResourceRules = make([]authorizationv1.NonResourceRule, 0)
+	for _, granted := range rules {
+		// rego policies and deny rules can not be expressed as rules
+		if granted.Rule == nil {
+			review.Status.Incomplete = true
+			continue
+		}
+		rule := granted.Rule
+		if len(rule.NonResourceURLs) > 0 {
+			review.Status.NonResourceRules = append(review.Status.NonResourceRules, authorizationv1.NonResourceRule{
+				Verbs:           rule.Verbs,
+				NonResourceURLs: rule.NonResourceURLs,
+			})
+			continue
+		}
+		review.Status.ResourceRules = append(review.Status.ResourceRules, authorizationv1.ResourceRule{
+			Verbs:         rule.Verbs,
+			APIGroups:     rule.APIGroups,
+			Resources:     rule.Resources,
+			ResourceNames: rule.ResourceNames,
+		})
+	}
+
+	resp.WriteEntity(review)
+}
+
+func (h *iamHandler) CreateResourceAccessReview(req *restful.Request, resp *restful.Response) {
+	var review ResourceAccessReview
+	if err := req.ReadEntity(&review); err != nil {
+		api.HandleBadRequest(resp, req, err)
+		return
+	}
+
+	if review.Spec.Verb == "" || review.Spec.Resource == "" {
+		api.HandleBadRequest(resp, req, fmt.Errorf("verb and resource must be specified"))
+		return
+	}
+
+	grants, err := h.reviewer.SubjectsFor(authorizer.AttributesRecord{
+		Verb:            review.Spec.Verb,
+		APIGroup:        review.Spec.APIGroup,
+		Resource:        review.Spec.Resource,
+		Subresource:     review.Spec.Subresource,
+		Name:            review.Spec.Name,
+		Workspace:       review.Spec.Workspace,
+		Namespace:       review.Spec.Namespace,
+		ResourceRequest: true,
+		ResourceScope:   reviewScope(review.Spec.Workspace, review.Spec.Namespace),
+	})
+	if err != nil {
+		review.Status.EvaluationError = err.Error()
+	}
+
+	users := sets.NewString()
+	groups := sets.NewString()
+	for _, granted := range grants {
+		switch granted.Subject.Kind {
+		case rbacv1.UserKind:
+			users.Insert(granted.Subject.Name)
+		case rbacv1.GroupKind:
+			groups.Insert(granted.Subject.Name)
+		case rbacv1.ServiceAccountKind:
+			namespace := granted.Subject.Namespace
+			if namespace == "" {
+				namespace = granted.BindingNamespace
+			}
+			users.Insert(fmt.Sprintf("system:serviceaccount:%s:%s", namespace, granted.Subject.Name))
+		}
+	}
+	review.Status.Users = users.List()
+	review.Status.Groups = groups.List()
+	review.Status.Grants = grants
+	if review.Status.Grants == nil {
+		review.Status.Grants = make([]rbac.GrantedRule, 0)
+	}
+
+	resp.WriteEntity(review)
+}
+
+// DescribeUserPermissions reports the effective permissions of the user, in all the scopes
+// unless the workspace or namespace is specified.
+func (h *iamHandler) DescribeUserPermissions(req *restful.Request, resp *restful.Response) {
+	username := req.PathParameter("user")
+	workspace := req.QueryParameter("workspace")
+	namespace := req.QueryParameter("namespace")
+
+	user, err := h.im.DescribeUser(username)
+	if err != nil {
+		api.HandleError(resp, req, err)
+		return
+	}
+
+	userInfo := &authuser.DefaultInfo{
+		Name:   user.Name,
+		Groups: append(append([]string{}, user.Spec.Groups...), authuser.AllAuthenticated),
+	}
+
+	var rules []rbac.GrantedRule
+	if workspace == "" && namespace == "" {
+		rules, err = h.reviewer.EffectiveRulesFor(userInfo)
+	} else {
+		rules, err = h.reviewer.RulesFor(authorizer.AttributesRecord{
+			User:          userInfo,
+			Workspace:     workspace,
+			Namespace:     namespace,
+			ResourceScope: reviewScope(workspace, namespace),
+		})
+	}
+
+	report := PermissionReport{User: userInfo.Name, Groups: userInfo.Groups, Rules: rules}
+	if err != nil {
+		report.EvaluationError = err.Error()
+	}
+	if report.Rules == nil {
+		report.Rules = make([]rbac.GrantedRule, 0)
+	}
+
+	resp.WriteEntity(report)
+}
+
+type AccessRequestReview struct {
+	Message string `json:"message,omitempty" description:"the reason of the approval or denial"`
+}
+
+func (h *iamHandler) CreateAccessRequest(request *restful.Request, response *restful.Response) {
+	username := request.PathParameter("user")
+	var accessRequest iamv1alpha2.AccessRequest
+	if err := request.ReadEntity(&accessRequest); err != nil {
+		api.HandleBadRequest(response, request, err)
+		return
+	}
+
+	operator, ok := apirequest.UserFrom(request.Request.Context())
+	if !ok {
+		err := errors.NewInternalError(fmt.Errorf("cannot obtain user info"))
+		api.HandleInternalError(response, request, err)
+		return
+	}
+	if operator.GetName() != username {
+		api.HandleForbidden(response, request, fmt.Errorf("access requests can only be created by the user"))
+		return
+	}
+
+	accessRequest.Spec.User = username
+	created, err := h.accessRequests.CreateAccessRequest(&accessRequest)
+	if err != nil {
+		api.HandleError(response, request, err)
+		return
+	}
+	response.WriteEntity(created)
+}
+
+func (h *iamHandler) ListUserAccessRequests(request *restful.Request, response *restful.Response) {
+	username := request.PathParameter("user")
+	h.listAccessRequests(request, response, func(accessRequest *iamv1alpha2.AccessRequest) bool {
+		return accessRequest.Spec.User == username
+	})
+}
+
+// ListAccessRequests lists the access requests in the scope of the request path,
+// the access requests of all the scopes are listed for the global path.
+func (h *iamHandler) ListAccessRequests(request *restful.Request, response *restful.Response) {
+	workspace := request.PathParameter("workspace")
+	namespace := request.PathParameter("namespace")
+	h.listAccessRequests(request, response, func(accessRequest *iamv1alpha2.AccessRequest) bool {
+		return (workspace == "" && namespace == "") || accessRequestInScope(accessRequest, workspace, namespace)
+	})
+}
+
+func (h *iamHandler) listAccessRequests(request *restful.Request, response *restful.Response, filter func(accessRequest *iamv1alpha2.AccessRequest) bool) {
+	accessRequests, err := h.accessRequests.ListAccessRequests("")
+	if err != nil {
+		api.HandleError(response, request, err)
+		return
+	}
+	result := api.ListResult{Items: make([]interface{}, 0)}
+	for i := range accessRequests {
+		if filter(&accessRequests[i]) {
+			result.Items = append(result.Items, accessRequests[i])
+		}
+	}
+	result.TotalItems = len(result.Items)
+	response.WriteEntity(result)
+}
+
+func (h *iamHandler) DescribeAccessRequest(request *restful.Request, response *restful.Response) {
+	accessRequest, err := h.accessRequests.DescribeAccessRequest(request.PathParameter("accessrequest"))
+	if err != nil {
+		api.HandleError(response, request, err)
+		return
+	}
+	response.WriteEntity(accessRequest)
+}
+
+func (h *iamHandler) ApproveAccessRequest(request *restful.Request, response *restful.Response) {
+	h.reviewAccessRequest(request, response, true)
+}
+
+func (h *iamHandler) DenyAccessRequest(request *restful.Request, response *restful.Response) {
+	h.reviewAccessRequest(request, response, false)
+}
+
+// reviewAccessRequest approves or denies the access request in the scope of the request path,
+// so that the access requests are reviewed by the administrators of the scope.
+func (h *iamHandler) reviewAccessRequest(request *restful.Request, response *restful.Response, approve bool) {
+	name := request.PathParameter("accessrequest")
+	var review AccessRequestReview
+	if request.Request.ContentLength > 0 {
+		if err := request.ReadEntity(&review); err != nil {
+			api.HandleBadRequest(response, request, err)
+			return
+		}
+	}
+
+	operator, ok := apirequest.UserFrom(request.Request.Context())
+	if !ok {
+		err := errors.NewInternalError(fmt.Errorf("cannot obtain user info"))
+		api.HandleInternalError(response, request, err)
+		return
+	}
+
+	accessRequest, err := h.accessRequests.DescribeAccessRequest(name)
+	if err != nil {
+		api.HandleError(response, request, err)
+		return
+	}
+	if !accessRequestInScope(accessRequest, request.PathParameter("workspace"), request.PathParameter("namespace")) {
+		api.HandleNotFound(response, request, errors.NewNotFound(iamv1alpha2.Resource(iamv1alpha2.ResourcesPluralAccessRequest), name))
+		return
+	}
+
+	if approve {
+		accessRequest, err = h.accessRequests.ApproveAccessRequest(name, operator.GetName())
+	} else {
+		accessRequest, err = h.accessRequests.DenyAccessRequest(name, operator.GetName(), review.Message)
+	}
+	if err != nil {
+		api.HandleError(response, request, err)
+		return
+	}
+	response.WriteEntity(accessRequest)
+}
+
+// accessRequestInScope returns whether the access request is for the exact workspace or namespace,
+// or for the cluster if neither is specified
+func accessRequestInScope(accessRequest *iamv1alpha2.AccessRequest, workspace, namespace string) bool {
+	if namespace != "" {
+		return accessRequest.Spec.Namespace == namespace
+	}
+	return accessRequest.Spec.Namespace == "" && accessRequest.Spec.Workspace == workspace
+}
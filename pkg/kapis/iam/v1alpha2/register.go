@@ -20,18 +20,23 @@ import (
 	"net/http"
 
 	"kubesphere.io/kubesphere/pkg/apiserver/authorization/authorizer"
+	"kubesphere.io/kubesphere/pkg/apiserver/authorization/rbac"
 
 	restfulspec "github.com/emicklei/go-restful-openapi/v2"
 	"github.com/emicklei/go-restful/v3"
+	"gopkg.in/square/go-jose.v2"
 	rbacv1 "k8s.io/api/rbac/v1"
 	v1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 
 	iamv1alpha2 "kubesphere.io/api/iam/v1alpha2"
 
 	"kubesphere.io/kubesphere/pkg/api"
+	"kubesphere.io/kubesphere/pkg/apiserver/authentication/token"
 	"kubesphere.io/kubesphere/pkg/apiserver/runtime"
 	"kubesphere.io/kubesphere/pkg/constants"
+	"kubesphere.io/kubesphere/pkg/models/auth"
 	"kubesphere.io/kubesphere/pkg/models/iam/am"
 	"kubesphere.io/kubesphere/pkg/models/iam/group"
 	"kubesphere.io/kubesphere/pkg/models/iam/im"
@@ -44,9 +49,12 @@ const (
 
 var GroupVersion = schema.GroupVersion{Group: GroupName, Version: "v1alpha2"}
 
-func AddToContainer(container *restful.Container, im im.IdentityManagementInterface, am am.AccessManagementInterface, group group.GroupOperator, authorizer authorizer.Authorizer) error {
+func AddToContainer(container *restful.Container, im im.IdentityManagementInterface, am am.AccessManagementInterface, group group.GroupOperator, authorizer authorizer.Authorizer,
+	multiFactor auth.MultiFactorAuthenticator, accessTokens auth.AccessTokenManagementInterface,
+	sessions auth.SessionManagementInterface, issuer token.Issuer, reviewer rbac.AccessReviewer,
+	accessRequests am.AccessRequestInterface) error {
 	ws := runtime.NewWebService(GroupVersion)
-	handler := newIAMHandler(im, am, group, authorizer)
+	handler := newIAMHandler(im, am, group, authorizer, multiFactor, accessTokens, sessions, issuer, reviewer, accessRequests)
 
 	// users
 	ws.Route(ws.POST("/users").
@@ -54,6 +62,7 @@ func AddToContainer(container *restful.Container, im im.IdentityManagementInterf
 		Doc("Create a global user account.").
 		Returns(http.StatusOK, api.StatusOK, iamv1alpha2.User{}).
 		Reads(iamv1alpha2.User{}).
+		Returns(http.StatusUnprocessableEntity, "the password violates the password policy", metav1.Status{}).
 		Metadata(restfulspec.KeyOpenAPITags, []string{constants.UserTag}))
 	ws.Route(ws.DELETE("/users/{user}").
 		To(handler.DeleteUser).
@@ -74,6 +83,27 @@ func AddToContainer(container *restful.Container, im im.IdentityManagementInterf
 		Reads(PasswordReset{}).
 		Param(ws.PathParameter("user", "username")).
 		Returns(http.StatusOK, api.StatusOK, errors.None).
+		Returns(http.StatusUnprocessableEntity, "the password violates the password policy", metav1.Status{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.UserTag}))
+	ws.Route(ws.POST("/users/{user}/mfa").
+		To(handler.EnrollMultiFactor).
+		Doc("Enroll the TOTP authenticator, the enrollment takes effect after the first passcode is verified.").
+		Param(ws.PathParameter("user", "username")).
+		Returns(http.StatusOK, api.StatusOK, auth.MultiFactorEnrollment{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.UserTag}))
+	ws.Route(ws.PUT("/users/{user}/mfa").
+		To(handler.VerifyMultiFactor).
+		Doc("Verify the TOTP passcode to confirm the enrollment.").
+		Reads(MultiFactorVerification{}).
+		Param(ws.PathParameter("user", "username")).
+		Returns(http.StatusOK, api.StatusOK, errors.None).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.UserTag}))
+	ws.Route(ws.DELETE("/users/{user}/mfa").
+		To(handler.DisableMultiFactor).
+		Doc("Disable the multi-factor authentication of the specified user.").
+		Reads(MultiFactorVerification{}).
+		Param(ws.PathParameter("user", "username")).
+		Returns(http.StatusOK, api.StatusOK, errors.None).
 		Metadata(restfulspec.KeyOpenAPITags, []string{constants.UserTag}))
 	ws.Route(ws.GET("/users/{user}").
 		To(handler.DescribeUser).
@@ -94,6 +124,171 @@ func AddToContainer(container *restful.Container, im im.IdentityManagementInterf
 		Returns(http.StatusOK, api.StatusOK, api.ListResult{Items: []interface{}{iamv1alpha2.LoginRecord{}}}).
 		Metadata(restfulspec.KeyOpenAPITags, []string{constants.UserResourceTag}))
 
+	ws.Route(ws.POST("/users/{user}/accesstokens").
+		To(handler.CreateAccessToken).
+		Param(ws.PathParameter("user", "username of the user")).
+		Doc("Create a personal access token, the token is only returned in the response.").
+		Reads(auth.AccessToken{}).
+		Returns(http.StatusOK, api.StatusOK, auth.AccessToken{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.UserResourceTag}))
+	ws.Route(ws.GET("/users/{user}/accesstokens").
+		To(handler.ListAccessTokens).
+		Param(ws.PathParameter("user", "username of the user")).
+		Doc("List personal access tokens of the specified user.").
+		Returns(http.StatusOK, api.StatusOK, api.ListResult{Items: []interface{}{auth.AccessToken{}}}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.UserResourceTag}))
+	ws.Route(ws.GET("/users/{user}/accesstokens/{accesstoken}").
+		To(handler.DescribeAccessToken).
+		Param(ws.PathParameter("user", "username of the user")).
+		Param(ws.PathParameter("accesstoken", "name of the access token")).
+		Doc("Retrieve the personal access token details.").
+		Returns(http.StatusOK, api.StatusOK, auth.AccessToken{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.UserResourceTag}))
+	ws.Route(ws.DELETE("/users/{user}/accesstokens/{accesstoken}").
+		To(handler.DeleteAccessToken).
+		Param(ws.PathParameter("user", "username of the user")).
+		Param(ws.PathParameter("accesstoken", "name of the access token")).
+		Doc("Revoke the personal access token.").
+		Returns(http.StatusOK, api.StatusOK, errors.None).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.UserResourceTag}))
+
+	ws.Route(ws.GET("/users/{user}/sessions").
+		To(handler.ListSessions).
+		Param(ws.PathParameter("user", "username of the user")).
+		Doc("List the active login sessions of the specified user.").
+		Returns(http.StatusOK, api.StatusOK, api.ListResult{Items: []interface{}{auth.Session{}}}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.UserResourceTag}))
+	ws.Route(ws.DELETE("/users/{user}/sessions").
+		To(handler.DeleteSessions).
+		Param(ws.PathParameter("user", "username of the user")).
+		Doc("Terminate all the sessions of the specified user, the user is logged out everywhere.").
+		Returns(http.StatusOK, api.StatusOK, errors.None).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.UserResourceTag}))
+	ws.Route(ws.DELETE("/users/{user}/sessions/{session}").
+		To(handler.DeleteSession).
+		Param(ws.PathParameter("user", "username of the user")).
+		Param(ws.PathParameter("session", "id of the session")).
+		Doc("Terminate the specified session, the tokens issued in the session are invalidated.").
+		Returns(http.StatusOK, api.StatusOK, errors.None).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.UserResourceTag}))
+
+	ws.Route(ws.GET("/signingkeys").
+		To(handler.ListSigningKeys).
+		Doc("List the public keys of the active and the verify-only signing keys.").
+		Returns(http.StatusOK, api.StatusOK, jose.JSONWebKeySet{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.UserResourceTag}))
+	ws.Route(ws.POST("/signingkeys").
+		To(handler.RotateSigningKeys).
+		Doc("Rotate the signing keys, the previous keys are kept for verification until the tokens signed by them expire.").
+		Returns(http.StatusOK, api.StatusOK, jose.JSONWebKeySet{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.UserResourceTag}))
+
+	// access requests
+	ws.Route(ws.POST("/users/{user}/accessrequests").
+		To(handler.CreateAccessRequest).
+		Param(ws.PathParameter("user", "username of the user")).
+		Doc("Request temporary access, the role is bound to the user once the request is approved and revoked when it expires.").
+		Reads(iamv1alpha2.AccessRequest{}).
+		Returns(http.StatusOK, api.StatusOK, iamv1alpha2.AccessRequest{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.AccessRequestTag}))
+	ws.Route(ws.GET("/users/{user}/accessrequests").
+		To(handler.ListUserAccessRequests).
+		Param(ws.PathParameter("user", "username of the user")).
+		Doc("List the access requests of the specified user.").
+		Returns(http.StatusOK, api.StatusOK, api.ListResult{Items: []interface{}{iamv1alpha2.AccessRequest{}}}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.AccessRequestTag}))
+	ws.Route(ws.GET("/accessrequests").
+		To(handler.ListAccessRequests).
+		Doc("List the access requests of all the scopes.").
+		Returns(http.StatusOK, api.StatusOK, api.ListResult{Items: []interface{}{iamv1alpha2.AccessRequest{}}}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.AccessRequestTag}))
+	ws.Route(ws.GET("/accessrequests/{accessrequest}").
+		To(handler.DescribeAccessRequest).
+		Param(ws.PathParameter("accessrequest", "name of the access request")).
+		Doc("Retrieve the access request details.").
+		Returns(http.StatusOK, api.StatusOK, iamv1alpha2.AccessRequest{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.AccessRequestTag}))
+	ws.Route(ws.GET("/workspaces/{workspace}/accessrequests").
+		To(handler.ListAccessRequests).
+		Param(ws.PathParameter("workspace", "workspace name")).
+		Doc("List the access requests of the workspace roles in the specified workspace.").
+		Returns(http.StatusOK, api.StatusOK, api.ListResult{Items: []interface{}{iamv1alpha2.AccessRequest{}}}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.AccessRequestTag}))
+	ws.Route(ws.GET("/namespaces/{namespace}/accessrequests").
+		To(handler.ListAccessRequests).
+		Param(ws.PathParameter("namespace", "namespace")).
+		Doc("List the access requests of the roles in the specified namespace.").
+		Returns(http.StatusOK, api.StatusOK, api.ListResult{Items: []interface{}{iamv1alpha2.AccessRequest{}}}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.AccessRequestTag}))
+	ws.Route(ws.POST("/accessrequests/{accessrequest}/approve").
+		To(handler.ApproveAccessRequest).
+		Param(ws.PathParameter("accessrequest", "name of the access request")).
+		Doc("Approve the pending access request of the cluster role, users are not allowed to approve their own requests.").
+		Reads(AccessRequestReview{}).
+		Returns(http.StatusOK, api.StatusOK, iamv1alpha2.AccessRequest{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.AccessRequestTag}))
+	ws.Route(ws.POST("/accessrequests/{accessrequest}/deny").
+		To(handler.DenyAccessRequest).
+		Param(ws.PathParameter("accessrequest", "name of the access request")).
+		Doc("Deny the pending access request of the cluster role.").
+		Reads(AccessRequestReview{}).
+		Returns(http.StatusOK, api.StatusOK, iamv1alpha2.AccessRequest{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.AccessRequestTag}))
+	ws.Route(ws.POST("/workspaces/{workspace}/accessrequests/{accessrequest}/approve").
+		To(handler.ApproveAccessRequest).
+		Param(ws.PathParameter("workspace", "workspace name")).
+		Param(ws.PathParameter("accessrequest", "name of the access request")).
+		Doc("Approve the pending access request of the workspace role, users are not allowed to approve their own requests.").
+		Reads(AccessRequestReview{}).
+		Returns(http.StatusOK, api.StatusOK, iamv1alpha2.AccessRequest{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.AccessRequestTag}))
+	ws.Route(ws.POST("/workspaces/{workspace}/accessrequests/{accessrequest}/deny").
+		To(handler.DenyAccessRequest).
+		Param(ws.PathParameter("workspace", "workspace name")).
+		Param(ws.PathParameter("accessrequest", "name of the access request")).
+		Doc("Deny the pending access request of the workspace role.").
+		Reads(AccessRequestReview{}).
+		Returns(http.StatusOK, api.StatusOK, iamv1alpha2.AccessRequest{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.AccessRequestTag}))
+	ws.Route(ws.POST("/namespaces/{namespace}/accessrequests/{accessrequest}/approve").
+		To(handler.ApproveAccessRequest).
+		Param(ws.PathParameter("namespace", "namespace")).
+		Param(ws.PathParameter("accessrequest", "name of the access request")).
+		Doc("Approve the pending access request of the namespace role, users are not allowed to approve their own requests.").
+		Reads(AccessRequestReview{}).
+		Returns(http.StatusOK, api.StatusOK, iamv1alpha2.AccessRequest{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.AccessRequestTag}))
+	ws.Route(ws.POST("/namespaces/{namespace}/accessrequests/{accessrequest}/deny").
+		To(handler.DenyAccessRequest).
+		Param(ws.PathParameter("namespace", "namespace")).
+		Param(ws.PathParameter("accessrequest", "name of the access request")).
+		Doc("Deny the pending access request of the namespace role.").
+		Reads(AccessRequestReview{}).
+		Returns(http.StatusOK, api.StatusOK, iamv1alpha2.AccessRequest{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.AccessRequestTag}))
+
+	// access reviews
+	ws.Route(ws.POST("/selfsubjectrulesreviews").
+		To(handler.CreateSelfSubjectRulesReview).
+		Doc("Enumerate the rules the current user has in the specified workspace or namespace, or in the cluster if neither is specified.").
+		Reads(SelfSubjectRulesReview{}).
+		Returns(http.StatusOK, api.StatusOK, SelfSubjectRulesReview{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.AccessReviewTag}))
+	ws.Route(ws.POST("/resourceaccessreviews").
+		To(handler.CreateResourceAccessReview).
+		Doc("Review which users and groups are allowed to perform the action on the resource, along with the bindings allowing it.").
+		Reads(ResourceAccessReview{}).
+		Returns(http.StatusOK, api.StatusOK, ResourceAccessReview{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.AccessReviewTag}))
+	ws.Route(ws.GET("/users/{user}/permissions").
+		To(handler.DescribeUserPermissions).
+		Doc("Report the effective permissions of the user and the bindings granting them.").
+		Param(ws.PathParameter("user", "username")).
+		Param(ws.QueryParameter("workspace", "only report the permissions in the workspace").Required(false)).
+		Param(ws.QueryParameter("namespace", "only report the permissions in the namespace").Required(false)).
+		Returns(http.StatusOK, api.StatusOK, PermissionReport{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.AccessReviewTag}))
+
 	// clustermembers
 	ws.Route(ws.POST("/clustermembers").
 		To(handler.CreateClusterMembers).
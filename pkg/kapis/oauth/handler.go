@@ -18,8 +18,10 @@ package oauth
 
 import (
 	"fmt"
+	"math"
 	"net/http"
 	"net/url"
+	"strconv"
 	"strings"
 	"time"
 
@@ -27,6 +29,7 @@ import (
 	"github.com/golang-jwt/jwt/v4"
 	"gopkg.in/square/go-jose.v2"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apiserver/pkg/authentication/user"
 	"k8s.io/klog/v2"
@@ -35,6 +38,7 @@ import (
 
 	"kubesphere.io/kubesphere/pkg/api"
 	"kubesphere.io/kubesphere/pkg/apiserver/authentication"
+	"kubesphere.io/kubesphere/pkg/apiserver/authentication/identityprovider"
 	"kubesphere.io/kubesphere/pkg/apiserver/authentication/oauth"
 	"kubesphere.io/kubesphere/pkg/apiserver/authentication/token"
 	"kubesphere.io/kubesphere/pkg/apiserver/query"
@@ -42,6 +46,7 @@ import (
 	"kubesphere.io/kubesphere/pkg/models/auth"
 	"kubesphere.io/kubesphere/pkg/models/iam/im"
 	"kubesphere.io/kubesphere/pkg/server/errors"
+	"kubesphere.io/kubesphere/pkg/simple/client/cache"
 	"kubesphere.io/kubesphere/pkg/utils/sliceutil"
 )
 
@@ -50,6 +55,8 @@ const (
 	grantTypePassword     = "password"
 	grantTypeRefreshToken = "refresh_token"
 	grantTypeCode         = "code"
+	grantTypeMFAOTP       = "mfa_otp"
+	grantTypeDeviceCode   = "urn:ietf:params:oauth:grant-type:device_code"
 )
 
 type Spec struct {
@@ -89,6 +96,12 @@ type discovery struct {
 	Auth string `json:"authorization_endpoint"`
 	// URL of the OP's OAuth 2.0 Token Endpoint.
 	Token string `json:"token_endpoint"`
+	// URL of the authorization server's device authorization endpoint defined in RFC 8628.
+	DeviceAuth string `json:"device_authorization_endpoint"`
+	// URL of the authorization server's OAuth 2.0 introspection endpoint defined in RFC 7662.
+	Introspection string `json:"introspection_endpoint"`
+	// URL of the authorization server's OAuth 2.0 revocation endpoint defined in RFC 7009.
+	Revocation string `json:"revocation_endpoint"`
 	// URL of the OP's UserInfo Endpoint
 	UserInfo string `json:"userinfo_endpoint"`
 	// URL of the OP's JSON Web Key Set [JWK] document.
@@ -115,26 +128,38 @@ type discovery struct {
 }
 
 type handler struct {
-	im                    im.IdentityManagementInterface
-	options               *authentication.Options
-	tokenOperator         auth.TokenManagementInterface
-	passwordAuthenticator auth.PasswordAuthenticator
-	oauthAuthenticator    auth.OAuthAuthenticator
-	loginRecorder         auth.LoginRecorder
+	im                       im.IdentityManagementInterface
+	options                  *authentication.Options
+	tokenOperator            auth.TokenManagementInterface
+	passwordAuthenticator    auth.PasswordAuthenticator
+	oauthAuthenticator       auth.OAuthAuthenticator
+	multiFactorAuthenticator auth.MultiFactorAuthenticator
+	deviceAuthorizer         auth.DeviceAuthorizer
+	sessionOperator          auth.SessionManagementInterface
+	loginRecorder            auth.LoginRecorder
+	loginLimiter             auth.LoginLimiter
 }
 
 func newHandler(im im.IdentityManagementInterface,
 	tokenOperator auth.TokenManagementInterface,
 	passwordAuthenticator auth.PasswordAuthenticator,
 	oauthAuthenticator auth.OAuthAuthenticator,
+	multiFactorAuthenticator auth.MultiFactorAuthenticator,
+	deviceAuthorizer auth.DeviceAuthorizer,
+	sessionOperator auth.SessionManagementInterface,
 	loginRecorder auth.LoginRecorder,
+	loginLimiter auth.LoginLimiter,
 	options *authentication.Options) *handler {
 	return &handler{im: im,
-		tokenOperator:         tokenOperator,
-		passwordAuthenticator: passwordAuthenticator,
-		oauthAuthenticator:    oauthAuthenticator,
-		loginRecorder:         loginRecorder,
-		options:               options}
+		tokenOperator:            tokenOperator,
+		passwordAuthenticator:    passwordAuthenticator,
+		oauthAuthenticator:       oauthAuthenticator,
+		multiFactorAuthenticator: multiFactorAuthenticator,
+		deviceAuthorizer:         deviceAuthorizer,
+		sessionOperator:          sessionOperator,
+		loginRecorder:            loginRecorder,
+		loginLimiter:             loginLimiter,
+		options:                  options}
 }
 
 // tokenReview Implement webhook authentication interface
@@ -159,6 +184,11 @@ func (h *handler) tokenReview(req *restful.Request, resp *restful.Response) {
 		return
 	}
 
+	if verified.TokenType == token.MultiFactorChallenge {
+		api.HandleBadRequest(resp, req, fmt.Errorf("multi-factor authentication is not completed"))
+		return
+	}
+
 	authenticated := verified.User
 	success := TokenReview{APIVersion: tokenReview.APIVersion,
 		Kind: KindTokenReview,
@@ -176,10 +206,13 @@ func (h *handler) discovery(req *restful.Request, response *restful.Response) {
 		Issuer:            h.options.OAuthOptions.Issuer,
 		Auth:              h.options.OAuthOptions.Issuer + "/authorize",
 		Token:             h.options.OAuthOptions.Issuer + "/token",
+		DeviceAuth:        h.options.OAuthOptions.Issuer + "/device/code",
+		Introspection:     h.options.OAuthOptions.Issuer + "/introspect",
+		Revocation:        h.options.OAuthOptions.Issuer + "/revoke",
 		Keys:              h.options.OAuthOptions.Issuer + "/keys",
 		UserInfo:          h.options.OAuthOptions.Issuer + "/userinfo",
 		Subjects:          []string{"public"},
-		GrantTypes:        []string{"authorization_code", "refresh_token"},
+		GrantTypes:        []string{"authorization_code", "refresh_token", grantTypeDeviceCode},
 		IDTokenAlgs:       []string{string(jose.RS256)},
 		CodeChallengeAlgs: []string{"S256", "plain"},
 		Scopes:            []string{"openid", "email", "profile", "offline_access"},
@@ -198,8 +231,13 @@ func (h *handler) discovery(req *restful.Request, response *restful.Response) {
 }
 
 func (h *handler) keys(req *restful.Request, response *restful.Response) {
+	keys := h.tokenOperator.Keys()
 	jwks := jose.JSONWebKeySet{
-		Keys: []jose.JSONWebKey{*h.tokenOperator.Keys().SigningKeyPub},
+		Keys: make([]jose.JSONWebKey, 0, len(keys.VerificationKeys)),
+	}
+	// publish all the valid keys, so that the tokens signed by the retired keys can still be verified
+	for _, key := range keys.VerificationKeys {
+		jwks.Keys = append(jwks.Keys, *key)
 	}
 	response.WriteEntity(jwks)
 }
@@ -318,7 +356,7 @@ func (h *handler) authorize(req *restful.Request, response *restful.Response) {
 		return
 	}
 
-	result, err := h.issueTokenTo(authenticated)
+	result, err := h.issueTokenTo(authenticated, h.newSession(req, clientID))
 	if err != nil {
 		response.WriteHeaderAndEntity(http.StatusInternalServerError, oauth.NewServerError(err))
 		return
@@ -341,17 +379,12 @@ func (h *handler) oauthCallback(req *restful.Request, response *restful.Response
 		return
 	}
 
-	result, err := h.issueTokenTo(authenticated)
+	result, err := h.login(authenticated, provider, false, h.newSession(req, ""), req)
 	if err != nil {
 		response.WriteHeaderAndEntity(http.StatusInternalServerError, oauth.NewServerError(err))
 		return
 	}
 
-	requestInfo, _ := request.RequestInfoFrom(req.Request.Context())
-	if err = h.loginRecorder.RecordLogin(authenticated.GetName(), iamv1alpha2.Token, provider, requestInfo.SourceIP, requestInfo.UserAgent, nil); err != nil {
-		klog.Errorf("Failed to record successful login for user %s, error: %v", authenticated.GetName(), err)
-	}
-
 	response.WriteEntity(result)
 }
 
@@ -360,54 +393,310 @@ func (h *handler) oauthCallback(req *restful.Request, response *restful.Response
 // as described in Section 3.2 of OAuth 2.0 [RFC6749], when using the Authorization Code Flow.
 // Communication with the Token Endpoint MUST utilize TLS.
 func (h *handler) token(req *restful.Request, response *restful.Response) {
-	// TODO(hongming) support basic auth
-	// https://datatracker.ietf.org/doc/html/rfc6749#section-2.3
-	clientID, err := req.BodyParameter("client_id")
+	client, err := h.authenticateClient(req)
 	if err != nil {
 		response.WriteHeaderAndEntity(http.StatusUnauthorized, oauth.NewInvalidClient(err))
 		return
 	}
-	clientSecret, err := req.BodyParameter("client_secret")
+
+	grantType, err := req.BodyParameter("grant_type")
 	if err != nil {
-		response.WriteHeaderAndEntity(http.StatusUnauthorized, oauth.NewInvalidClient(err))
+		response.WriteHeaderAndEntity(http.StatusBadRequest, oauth.NewInvalidRequest(err))
 		return
 	}
 
-	client, err := h.options.OAuthOptions.OAuthClient(clientID)
-	if err != nil {
-		oauthError := oauth.NewInvalidClient(err)
-		response.WriteHeaderAndEntity(http.StatusUnauthorized, oauthError)
+	switch grantType {
+	case grantTypePassword:
+		username, _ := req.BodyParameter("username")
+		password, _ := req.BodyParameter("password")
+		h.passwordGrant(client.Name, "", username, password, req, response)
+		return
+	case grantTypeRefreshToken:
+		h.refreshTokenGrant(client.Name, req, response)
+		return
+	case grantTypeCode:
+		h.codeGrant(client.Name, req, response)
+		return
+	case grantTypeMFAOTP:
+		h.multiFactorGrant(client.Name, req, response)
+		return
+	case grantTypeDeviceCode:
+		h.deviceCodeGrant(client, req, response)
+		return
+	default:
+		response.WriteHeaderAndEntity(http.StatusBadRequest, oauth.ErrorUnsupportedGrantType)
 		return
 	}
+}
 
+// authenticateClient verifies the client credentials in the authorization header or the request body,
+// for more details: https://datatracker.ietf.org/doc/html/rfc6749#section-2.3
+func (h *handler) authenticateClient(req *restful.Request) (*oauth.Client, error) {
+	clientID, clientSecret, ok := req.Request.BasicAuth()
+	if !ok {
+		var err error
+		if clientID, err = req.BodyParameter("client_id"); err != nil {
+			return nil, err
+		}
+		if clientSecret, err = req.BodyParameter("client_secret"); err != nil {
+			return nil, err
+		}
+	}
+	client, err := h.options.OAuthOptions.OAuthClient(clientID)
+	if err != nil {
+		return nil, err
+	}
 	if client.Secret != clientSecret {
-		oauthError := oauth.NewInvalidClient(fmt.Errorf("invalid client credential"))
-		response.WriteHeaderAndEntity(http.StatusUnauthorized, oauthError)
+		return nil, fmt.Errorf("invalid client credential")
+	}
+	return &client, nil
+}
+
+// introspect returns the meta information of the token to the protected resources,
+// for more details: https://datatracker.ietf.org/doc/html/rfc7662
+func (h *handler) introspect(req *restful.Request, response *restful.Response) {
+	if _, err := h.authenticateClient(req); err != nil {
+		response.WriteHeaderAndEntity(http.StatusUnauthorized, oauth.NewInvalidClient(err))
+		return
+	}
+	tokenStr, err := req.BodyParameter("token")
+	if err != nil || tokenStr == "" {
+		response.WriteHeaderAndEntity(http.StatusBadRequest, oauth.NewInvalidRequest(fmt.Errorf("token must not be empty")))
 		return
 	}
 
-	grantType, err := req.BodyParameter("grant_type")
+	// the token_type_hint is ignored, all types of the tokens are verified in the same way
+	verified, err := h.tokenOperator.Verify(tokenStr)
 	if err != nil {
-		response.WriteHeaderAndEntity(http.StatusBadRequest, oauth.NewInvalidRequest(err))
+		klog.V(4).Infof("introspect: inactive token: %v", err)
+		response.WriteEntity(oauth.TokenIntrospection{Active: false})
+		return
+	}
+	// authorization codes and challenges can not be used to access the protected resources
+	switch verified.TokenType {
+	case token.AccessToken, token.RefreshToken, token.StaticToken:
+	default:
+		response.WriteEntity(oauth.TokenIntrospection{Active: false})
 		return
 	}
 
-	switch grantType {
-	case grantTypePassword:
-		username, _ := req.BodyParameter("username")
-		password, _ := req.BodyParameter("password")
-		h.passwordGrant("", username, password, req, response)
+	result := oauth.TokenIntrospection{
+		Active:    true,
+		Scope:     strings.Join(verified.Scopes, " "),
+		Username:  verified.User.GetName(),
+		TokenType: string(verified.TokenType),
+		Subject:   verified.Subject,
+		Audience:  verified.Audience,
+		Issuer:    verified.Issuer,
+		ID:        verified.ID,
+	}
+	if verified.ExpiresAt != nil {
+		result.ExpiresAt = verified.ExpiresAt.Unix()
+	}
+	if verified.IssuedAt != nil {
+		result.IssuedAt = verified.IssuedAt.Unix()
+	}
+	response.WriteEntity(result)
+}
+
+// revoke invalidates the access token or refresh token issued to the client,
+// for more details: https://datatracker.ietf.org/doc/html/rfc7009
+func (h *handler) revoke(req *restful.Request, response *restful.Response) {
+	client, err := h.authenticateClient(req)
+	if err != nil {
+		response.WriteHeaderAndEntity(http.StatusUnauthorized, oauth.NewInvalidClient(err))
 		return
-	case grantTypeRefreshToken:
-		h.refreshTokenGrant(req, response)
+	}
+	tokenStr, err := req.BodyParameter("token")
+	if err != nil || tokenStr == "" {
+		response.WriteHeaderAndEntity(http.StatusBadRequest, oauth.NewInvalidRequest(fmt.Errorf("token must not be empty")))
 		return
-	case grantTypeCode:
-		h.codeGrant(req, response)
+	}
+	// invalid tokens do not cause an error response since the client cannot handle such an error in a reasonable way
+	verified, err := h.tokenOperator.Verify(tokenStr)
+	if err != nil {
+		klog.V(4).Infof("revoke: invalid token: %v", err)
+		response.WriteHeader(http.StatusOK)
 		return
+	}
+	issued, err := h.tokenIssuedTo(verified, client.Name)
+	if err != nil {
+		response.WriteHeaderAndEntity(http.StatusServiceUnavailable, oauth.NewServerError(err))
+		return
+	}
+	if !issued {
+		oauthError := oauth.ErrorUnauthorizedClient
+		oauthError.Description = "the token was not issued to the client"
+		response.WriteHeaderAndEntity(http.StatusBadRequest, oauthError)
+		return
+	}
+	if err = h.tokenOperator.Revoke(tokenStr); err != nil {
+		response.WriteHeaderAndEntity(http.StatusServiceUnavailable, oauth.NewServerError(err))
+		return
+	}
+	response.WriteHeader(http.StatusOK)
+}
+
+// tokenIssuedTo checks whether the access token or refresh token was issued to the client,
+// the tokens are issued to the client of the session they belong to.
+func (h *handler) tokenIssuedTo(verified *token.VerifiedResponse, clientID string) (bool, error) {
+	switch verified.TokenType {
+	case token.AccessToken, token.RefreshToken:
 	default:
-		response.WriteHeaderAndEntity(http.StatusBadRequest, oauth.ErrorUnsupportedGrantType)
+		return false, nil
+	}
+	if verified.SessionID == "" {
+		return sliceutil.HasString(verified.Audience, clientID), nil
+	}
+	session, err := h.sessionOperator.DescribeSession(verified.User.GetName(), verified.SessionID)
+	if err != nil {
+		// the session is terminated, the token has been invalidated
+		if apierrors.IsNotFound(err) {
+			return true, nil
+		}
+		klog.Error(err)
+		return false, err
+	}
+	return session.ClientID == clientID, nil
+}
+
+// deviceAuthorization is the device authorization endpoint, the device obtains the device code
+// and the user code here, then polls the token endpoint while the user approves the request on another device,
+// for more details: https://datatracker.ietf.org/doc/html/rfc8628#section-3.1
+func (h *handler) deviceAuthorization(req *restful.Request, response *restful.Response) {
+	client, err := h.authenticateClient(req)
+	if err != nil {
+		response.WriteHeaderAndEntity(http.StatusUnauthorized, oauth.NewInvalidClient(err))
+		return
+	}
+	if client.GrantMethod == oauth.GrantHandlerDeny {
+		response.WriteHeaderAndEntity(http.StatusBadRequest, oauth.ErrorUnauthorizedClient)
+		return
+	}
+
+	scope, _ := req.BodyParameter("scope")
+	var scopes []string
+	if scope != "" {
+		scopes = strings.Split(scope, " ")
+	}
+	if !oauth.IsValidScopes(scopes) {
+		klog.Warningf("Some requested scopes were invalid: %v", scopes)
+	}
+
+	deviceCode, authorization, err := h.deviceAuthorizer.Authorize(client.Name, scopes)
+	if err != nil {
+		response.WriteHeaderAndEntity(http.StatusInternalServerError, oauth.NewServerError(err))
+		return
+	}
+
+	verificationURI := h.options.OAuthOptions.Issuer + "/device"
+	result := oauth.DeviceAuthorizationResponse{
+		DeviceCode:              deviceCode,
+		UserCode:                authorization.UserCode,
+		VerificationURI:         verificationURI,
+		VerificationURIComplete: verificationURI + "?" + url.Values{"user_code": {authorization.UserCode}}.Encode(),
+		ExpiresIn:               int(auth.DeviceCodeMaxAge.Seconds()),
+		Interval:                int(auth.DevicePollingInterval.Seconds()),
+	}
+	response.WriteEntity(result)
+}
+
+// deviceVerification returns the pending device authorization to the authenticated user for confirmation.
+func (h *handler) deviceVerification(req *restful.Request, response *restful.Response) {
+	authenticated, _ := request.UserFrom(req.Request.Context())
+	if authenticated == nil || authenticated.GetName() == user.Anonymous {
+		response.Header().Add("WWW-Authenticate", "Basic")
+		response.WriteHeaderAndEntity(http.StatusUnauthorized, oauth.ErrorLoginRequired)
+		return
+	}
+	authorization, err := h.deviceAuthorizer.DescribeByUserCode(req.QueryParameter("user_code"))
+	if err != nil {
+		api.HandleError(response, req, err)
+		return
+	}
+	response.WriteEntity(authorization)
+}
+
+// deviceApproval approves or denies the device authorization on behalf of the authenticated user,
+// the user can be authenticated by any of the configured identity providers.
+func (h *handler) deviceApproval(req *restful.Request, response *restful.Response) {
+	authenticated, _ := request.UserFrom(req.Request.Context())
+	if authenticated == nil || authenticated.GetName() == user.Anonymous {
+		response.Header().Add("WWW-Authenticate", "Basic")
+		response.WriteHeaderAndEntity(http.StatusUnauthorized, oauth.ErrorLoginRequired)
+		return
+	}
+	// the scoped access token can not be exchanged for unrestricted tokens
+	if len(authenticated.GetExtra()[iamv1alpha2.ExtraAccessToken]) > 0 {
+		api.HandleForbidden(response, req, fmt.Errorf("device authorization can not be approved with an access token"))
+		return
+	}
+
+	userCode, err := req.BodyParameter("user_code")
+	if err != nil {
+		api.HandleBadRequest(response, req, err)
+		return
+	}
+	approve, _ := req.BodyParameter("approve")
+	if approve == "true" {
+		err = h.deviceAuthorizer.Approve(userCode, authenticated.GetName())
+	} else {
+		err = h.deviceAuthorizer.Deny(userCode)
+	}
+	if err != nil {
+		api.HandleError(response, req, err)
 		return
 	}
+	response.WriteEntity(errors.None)
+}
+
+// deviceCodeGrant exchanges the device code for tokens once the user has approved the authorization,
+// for more details: https://datatracker.ietf.org/doc/html/rfc8628#section-3.4
+func (h *handler) deviceCodeGrant(client *oauth.Client, req *restful.Request, response *restful.Response) {
+	deviceCode, err := req.BodyParameter("device_code")
+	if err != nil {
+		response.WriteHeaderAndEntity(http.StatusBadRequest, oauth.NewInvalidRequest(err))
+		return
+	}
+
+	authorization, err := h.deviceAuthorizer.Poll(client.Name, deviceCode)
+	if err != nil {
+		switch err {
+		case auth.DeviceAuthorizationPendingError:
+			response.WriteHeaderAndEntity(http.StatusBadRequest, oauth.ErrorAuthorizationPending)
+		case auth.DeviceAuthorizationSlowDownError:
+			response.WriteHeaderAndEntity(http.StatusBadRequest, oauth.ErrorSlowDown)
+		case auth.DeviceAuthorizationDeniedError:
+			response.WriteHeaderAndEntity(http.StatusBadRequest, oauth.ErrorAccessDenied)
+		case auth.DeviceCodeExpiredError:
+			response.WriteHeaderAndEntity(http.StatusBadRequest, oauth.ErrorExpiredToken)
+		case auth.DeviceCodeClientMismatchError:
+			response.WriteHeaderAndEntity(http.StatusBadRequest, oauth.NewInvalidGrant(err))
+		default:
+			response.WriteHeaderAndEntity(http.StatusInternalServerError, oauth.NewServerError(err))
+		}
+		return
+	}
+
+	authenticated := &user.DefaultInfo{Name: authorization.Username}
+	// the tokens are granted the scopes requested at the device authorization
+	session := h.newSession(req, client.Name)
+	session.Scopes = authorization.Scopes
+	result, err := h.login(authenticated, "", false, session, req)
+	if err != nil {
+		response.WriteHeaderAndEntity(http.StatusInternalServerError, oauth.NewServerError(err))
+		return
+	}
+
+	if sliceutil.HasString(authorization.Scopes, oauth.ScopeOpenID) {
+		result.IDToken, err = h.issueIDToken(authenticated, []string{client.Name}, "", authorization.Scopes)
+		if err != nil {
+			response.WriteHeaderAndEntity(http.StatusInternalServerError, oauth.NewServerError(err))
+			return
+		}
+	}
+
+	response.WriteEntity(result)
 }
 
 // passwordGrant handle Resource Owner Password Credentials Grant
@@ -417,7 +706,18 @@ func (h *handler) token(req *restful.Request, response *restful.Response) {
 // such as the device operating system or a highly privileged application.
 // The authorization server should take special care when enabling this
 // grant type and only allow it when other flows are not viable.
-func (h *handler) passwordGrant(provider, username string, password string, req *restful.Request, response *restful.Response) {
+func (h *handler) passwordGrant(clientID, provider, username string, password string, req *restful.Request, response *restful.Response) {
+	requestInfo, _ := request.RequestInfoFrom(req.Request.Context())
+	limit, err := h.loginLimiter.Attempt(username, requestInfo.SourceIP)
+	if err != nil {
+		response.WriteHeaderAndEntity(http.StatusInternalServerError, oauth.NewServerError(err))
+		return
+	}
+	if limit.RetryAfter > 0 {
+		writeLoginThrottled(limit, response)
+		return
+	}
+
 	authenticated, provider, err := h.passwordAuthenticator.Authenticate(req.Request.Context(), provider, username, password)
 	if err != nil {
 		switch err {
@@ -425,44 +725,235 @@ func (h *handler) passwordGrant(provider, username string, password string, req
 			response.WriteHeaderAndEntity(http.StatusBadRequest, oauth.NewInvalidGrant(err))
 			return
 		case auth.IncorrectPasswordError:
-			requestInfo, _ := request.RequestInfoFrom(req.Request.Context())
-			if err := h.loginRecorder.RecordLogin(username, iamv1alpha2.Token, provider, requestInfo.SourceIP, requestInfo.UserAgent, err); err != nil {
+			if _, err := h.loginRecorder.RecordLogin(username, iamv1alpha2.Token, provider, requestInfo.SourceIP, requestInfo.UserAgent, false, err); err != nil {
 				klog.Errorf("Failed to record unsuccessful login attempt for user %s, error: %v", username, err)
 			}
-			response.WriteHeaderAndEntity(http.StatusBadRequest, oauth.NewInvalidGrant(err))
+			oauthError := oauth.NewInvalidGrant(err)
+			if limit, err = h.loginLimiter.RecordFailure(username, requestInfo.SourceIP); err != nil {
+				klog.Errorf("Failed to record unsuccessful login attempt for user %s, error: %v", username, err)
+			} else {
+				oauthError.CaptchaRequired = limit.CaptchaRequired
+			}
+			response.WriteHeaderAndEntity(http.StatusBadRequest, oauthError)
 			return
 		case auth.RateLimitExceededError:
 			response.WriteHeaderAndEntity(http.StatusTooManyRequests, oauth.NewInvalidGrant(err))
 			return
+		case auth.MultiFactorRequiredError, auth.MultiFactorEnrollmentRequiredError:
+			// the earlier failures are reset once the second factor is verified by the mfa_otp grant
+			if err := h.loginLimiter.Withdraw(username, requestInfo.SourceIP); err != nil {
+				klog.Errorf("Failed to withdraw the login attempt of user %s, error: %v", username, err)
+			}
+			h.multiFactorChallenge(authenticated, err, response)
+			return
 		default:
 			response.WriteHeaderAndEntity(http.StatusInternalServerError, oauth.NewServerError(err))
 			return
 		}
 	}
 
-	result, err := h.issueTokenTo(authenticated)
+	h.resetLoginLimit(username, requestInfo.SourceIP)
+	result, err := h.login(authenticated, provider, false, h.newSession(req, clientID), req)
 	if err != nil {
 		response.WriteHeaderAndEntity(http.StatusInternalServerError, oauth.NewServerError(err))
 		return
 	}
 
+	response.WriteEntity(result)
+}
+
+// writeLoginThrottled rejects the login attempt, the client should retry after the delay in the Retry-After header.
+func writeLoginThrottled(limit *auth.LoginLimit, response *restful.Response) {
+	retryAfter := int(math.Ceil(limit.RetryAfter.Seconds()))
+	oauthError := oauth.NewInvalidGrant(fmt.Errorf("%s, retry after %ds", auth.LoginThrottledError, retryAfter))
+	oauthError.CaptchaRequired = limit.CaptchaRequired
+	response.AddHeader("Retry-After", strconv.Itoa(retryAfter))
+	response.WriteHeaderAndEntity(http.StatusTooManyRequests, oauthError)
+}
+
+func (h *handler) resetLoginLimit(username, sourceIP string) {
+	if err := h.loginLimiter.RecordSuccess(username, sourceIP); err != nil {
+		klog.Errorf("Failed to reset the login limit of user %s, error: %v", username, err)
+	}
+}
+
+// multiFactorChallenge issues a short-lived mfa_token to the user whose password has been verified,
+// the client should exchange it along with the TOTP passcode for tokens by the mfa_otp grant.
+func (h *handler) multiFactorChallenge(authenticated user.Info, reason error, response *restful.Response) {
+	expiresIn := authentication.NewMultiFactorAuthOptions().ChallengeMaxAge
+	if h.options.MultiFactorAuthOptions != nil && h.options.MultiFactorAuthOptions.ChallengeMaxAge > 0 {
+		expiresIn = h.options.MultiFactorAuthOptions.ChallengeMaxAge
+	}
+	mfaToken, err := h.tokenOperator.IssueTo(&token.IssueRequest{
+		User:      authenticated,
+		Claims:    token.Claims{TokenType: token.MultiFactorChallenge},
+		ExpiresIn: expiresIn,
+	})
+	if err != nil {
+		response.WriteHeaderAndEntity(http.StatusInternalServerError, oauth.NewServerError(err))
+		return
+	}
+	oauthError := oauth.ErrorMultiFactorRequired
+	if reason == auth.MultiFactorEnrollmentRequiredError {
+		oauthError = oauth.ErrorMultiFactorEnrollmentRequired
+	}
+	oauthError.Description = reason.Error()
+	oauthError.MultiFactorToken = mfaToken
+	response.WriteHeaderAndEntity(http.StatusForbidden, oauthError)
+}
+
+// verifyMultiFactorChallenge returns the user who the mfa_token was issued to.
+func (h *handler) verifyMultiFactorChallenge(mfaToken string) (user.Info, error) {
+	verified, err := h.tokenOperator.Verify(mfaToken)
+	if err != nil {
+		return nil, err
+	}
+	if verified.TokenType != token.MultiFactorChallenge {
+		return nil, fmt.Errorf("invalid token type %v want %v", verified.TokenType, token.MultiFactorChallenge)
+	}
+	return verified.User, nil
+}
+
+// multiFactorGrant exchanges the mfa_token along with the TOTP passcode or a recovery code for tokens.
+// The mfa_token can only be used once, a new challenge must be requested by the password grant
+// after a failed attempt, so that the passcode can not be guessed without the password. The incorrect
+// passcodes are counted as failed logins, the login limit is only reset after the passcode is verified.
+func (h *handler) multiFactorGrant(clientID string, req *restful.Request, response *restful.Response) {
+	mfaToken, err := req.BodyParameter("mfa_token")
+	if err != nil {
+		response.WriteHeaderAndEntity(http.StatusBadRequest, oauth.NewInvalidRequest(err))
+		return
+	}
+	otp, err := req.BodyParameter("otp")
+	if err != nil {
+		response.WriteHeaderAndEntity(http.StatusBadRequest, oauth.NewInvalidRequest(err))
+		return
+	}
+
+	authenticated, err := h.verifyMultiFactorChallenge(mfaToken)
+	if err != nil {
+		response.WriteHeaderAndEntity(http.StatusBadRequest, oauth.NewInvalidGrant(err))
+		return
+	}
+
 	requestInfo, _ := request.RequestInfoFrom(req.Request.Context())
-	if err = h.loginRecorder.RecordLogin(authenticated.GetName(), iamv1alpha2.Token, provider, requestInfo.SourceIP, requestInfo.UserAgent, nil); err != nil {
-		klog.Errorf("Failed to record successful login for user %s, error: %v", authenticated.GetName(), err)
+	limit, err := h.loginLimiter.Attempt(authenticated.GetName(), requestInfo.SourceIP)
+	if err != nil {
+		response.WriteHeaderAndEntity(http.StatusInternalServerError, oauth.NewServerError(err))
+		return
+	}
+	if limit.RetryAfter > 0 {
+		writeLoginThrottled(limit, response)
+		return
+	}
+
+	// the mfa_token is claimed before the passcode is evaluated, so that it can't be replayed by concurrent requests
+	if err = h.tokenOperator.Claim(mfaToken); err != nil {
+		if err == auth.TokenClaimedError {
+			response.WriteHeaderAndEntity(http.StatusBadRequest, oauth.NewInvalidGrant(err))
+			return
+		}
+		response.WriteHeaderAndEntity(http.StatusInternalServerError, oauth.NewServerError(err))
+		return
+	}
+
+	if err = h.multiFactorAuthenticator.Authenticate(authenticated.GetName(), otp); err != nil {
+		switch err {
+		case auth.IncorrectPasscodeError:
+			if _, err := h.loginRecorder.RecordLogin(authenticated.GetName(), iamv1alpha2.Token, "", requestInfo.SourceIP, requestInfo.UserAgent, true, err); err != nil {
+				klog.Errorf("Failed to record unsuccessful login attempt for user %s, error: %v", authenticated.GetName(), err)
+			}
+			oauthError := oauth.NewInvalidGrant(err)
+			if limit, err = h.loginLimiter.RecordFailure(authenticated.GetName(), requestInfo.SourceIP); err != nil {
+				klog.Errorf("Failed to record unsuccessful login attempt for user %s, error: %v", authenticated.GetName(), err)
+			} else {
+				oauthError.CaptchaRequired = limit.CaptchaRequired
+			}
+			response.WriteHeaderAndEntity(http.StatusBadRequest, oauthError)
+			return
+		case auth.MultiFactorEnrollmentRequiredError:
+			response.WriteHeaderAndEntity(http.StatusBadRequest, oauth.NewInvalidGrant(err))
+			return
+		default:
+			response.WriteHeaderAndEntity(http.StatusInternalServerError, oauth.NewServerError(err))
+			return
+		}
+	}
+
+	h.resetLoginLimit(authenticated.GetName(), requestInfo.SourceIP)
+	result, err := h.login(authenticated, "", true, h.newSession(req, clientID), req)
+	if err != nil {
+		response.WriteHeaderAndEntity(http.StatusInternalServerError, oauth.NewServerError(err))
+		return
 	}
 
 	response.WriteEntity(result)
 }
 
-func (h *handler) issueTokenTo(user user.Info) (*oauth.Token, error) {
+// multiFactorEnroll allows the user who is required to enroll to generate the TOTP secret with the mfa_token,
+// the enrollment is confirmed by the first passcode verified in the mfa_otp grant.
+func (h *handler) multiFactorEnroll(req *restful.Request, response *restful.Response) {
+	mfaToken, err := req.BodyParameter("mfa_token")
+	if err != nil {
+		response.WriteHeaderAndEntity(http.StatusBadRequest, oauth.NewInvalidRequest(err))
+		return
+	}
+	authenticated, err := h.verifyMultiFactorChallenge(mfaToken)
+	if err != nil {
+		response.WriteHeaderAndEntity(http.StatusBadRequest, oauth.NewInvalidGrant(err))
+		return
+	}
+	enrollment, err := h.multiFactorAuthenticator.Enroll(authenticated.GetName())
+	if err != nil {
+		if apierrors.IsConflict(err) {
+			response.WriteHeaderAndEntity(http.StatusBadRequest, oauth.NewInvalidGrant(err))
+			return
+		}
+		response.WriteHeaderAndEntity(http.StatusInternalServerError, oauth.NewServerError(err))
+		return
+	}
+	response.WriteEntity(enrollment)
+}
+
+// login records the successful login, and issues the tokens in the new session linked to the login record.
+func (h *handler) login(authenticated user.Info, provider string, multiFactor bool, session *auth.Session, req *restful.Request) (*oauth.Token, error) {
+	requestInfo, _ := request.RequestInfoFrom(req.Request.Context())
+	loginRecord, err := h.loginRecorder.RecordLogin(authenticated.GetName(), iamv1alpha2.Token, provider, requestInfo.SourceIP, requestInfo.UserAgent, multiFactor, nil)
+	if err != nil {
+		klog.Errorf("Failed to record successful login for user %s, error: %v", authenticated.GetName(), err)
+	}
+	session.LoginRecord = loginRecord
+	return h.issueTokenTo(authenticated, session)
+}
+
+func (h *handler) newSession(req *restful.Request, clientID string) *auth.Session {
+	session := &auth.Session{ClientID: clientID}
+	if requestInfo, ok := request.RequestInfoFrom(req.Request.Context()); ok {
+		session.SourceIP = requestInfo.SourceIP
+		session.UserAgent = requestInfo.UserAgent
+	}
+	return session
+}
+
+// issueTokenTo issues the access token and refresh token in the session,
+// the session is renewed if it already exists.
+func (h *handler) issueTokenTo(user user.Info, session *auth.Session) (*oauth.Token, error) {
 	if !h.options.MultipleLogin {
 		if err := h.tokenOperator.RevokeAllUserTokens(user.GetName()); err != nil {
 			return nil, err
 		}
 	}
+	// the session lasts as long as the refresh token
+	sessionMaxAge := h.options.OAuthOptions.AccessTokenMaxAge + h.options.OAuthOptions.AccessTokenInactivityTimeout
+	if h.options.OAuthOptions.AccessTokenMaxAge == 0 {
+		sessionMaxAge = cache.NeverExpire
+	}
+	if err := h.sessionOperator.CreateSession(user.GetName(), session, sessionMaxAge); err != nil {
+		return nil, err
+	}
 	accessToken, err := h.tokenOperator.IssueTo(&token.IssueRequest{
 		User:      user,
-		Claims:    token.Claims{TokenType: token.AccessToken},
+		Claims:    token.Claims{TokenType: token.AccessToken, SessionID: session.ID, Scopes: session.Scopes},
 		ExpiresIn: h.options.OAuthOptions.AccessTokenMaxAge,
 	})
 	if err != nil {
@@ -470,7 +961,7 @@ func (h *handler) issueTokenTo(user user.Info) (*oauth.Token, error) {
 	}
 	refreshToken, err := h.tokenOperator.IssueTo(&token.IssueRequest{
 		User:      user,
-		Claims:    token.Claims{TokenType: token.RefreshToken},
+		Claims:    token.Claims{TokenType: token.RefreshToken, SessionID: session.ID, Scopes: session.Scopes},
 		ExpiresIn: h.options.OAuthOptions.AccessTokenMaxAge + h.options.OAuthOptions.AccessTokenInactivityTimeout,
 	})
 	if err != nil {
@@ -487,7 +978,7 @@ func (h *handler) issueTokenTo(user user.Info) (*oauth.Token, error) {
 	return &result, nil
 }
 
-func (h *handler) refreshTokenGrant(req *restful.Request, response *restful.Response) {
+func (h *handler) refreshTokenGrant(clientID string, req *restful.Request, response *restful.Response) {
 	refreshToken, err := req.BodyParameter("refresh_token")
 	if err != nil {
 		response.WriteHeaderAndEntity(http.StatusBadRequest, oauth.NewInvalidRequest(err))
@@ -532,7 +1023,18 @@ func (h *handler) refreshTokenGrant(req *restful.Request, response *restful.Resp
 		authenticated = &user.DefaultInfo{Name: result.Items[0].(*iamv1alpha2.User).Name}
 	}
 
-	result, err := h.issueTokenTo(authenticated)
+	// the refreshed tokens are issued in the same session
+	session := h.newSession(req, clientID)
+	session.Scopes = verified.Scopes
+	if verified.SessionID != "" && authenticated.GetName() == verified.User.GetName() {
+		if session, err = h.sessionOperator.DescribeSession(authenticated.GetName(), verified.SessionID); err != nil {
+			response.WriteHeaderAndEntity(http.StatusBadRequest, oauth.NewInvalidGrant(err))
+			return
+		}
+		session.LastSeenTimestamp = &metav1.Time{Time: time.Now()}
+	}
+
+	result, err := h.issueTokenTo(authenticated, session)
 	if err != nil {
 		response.WriteHeaderAndEntity(http.StatusInternalServerError, oauth.NewServerError(err))
 		return
@@ -541,7 +1043,7 @@ func (h *handler) refreshTokenGrant(req *restful.Request, response *restful.Resp
 	response.WriteEntity(result)
 }
 
-func (h *handler) codeGrant(req *restful.Request, response *restful.Response) {
+func (h *handler) codeGrant(clientID string, req *restful.Request, response *restful.Response) {
 	code, err := req.BodyParameter("code")
 	if err != nil {
 		response.WriteHeaderAndEntity(http.StatusBadRequest, oauth.NewInvalidRequest(err))
@@ -568,7 +1070,7 @@ func (h *handler) codeGrant(req *restful.Request, response *restful.Response) {
 		}
 	}()
 
-	result, err := h.issueTokenTo(authorizeContext.User)
+	result, err := h.issueTokenTo(authorizeContext.User, h.newSession(req, clientID))
 	if err != nil {
 		response.WriteHeaderAndEntity(http.StatusInternalServerError, oauth.NewServerError(err))
 		return
@@ -581,43 +1083,45 @@ func (h *handler) codeGrant(req *restful.Request, response *restful.Response) {
 		return
 	}
 
-	authenticated, err := h.im.DescribeUser(authorizeContext.User.GetName())
+	result.IDToken, err = h.issueIDToken(authorizeContext.User, authorizeContext.Audience, authorizeContext.Nonce, authorizeContext.Scopes)
 	if err != nil {
 		response.WriteHeaderAndEntity(http.StatusInternalServerError, oauth.NewServerError(err))
 		return
 	}
 
+	response.WriteEntity(result)
+}
+
+// issueIDToken issues the ID token to the user, the claims of the user are included as the scopes request.
+func (h *handler) issueIDToken(authenticated user.Info, audience []string, nonce string, scopes []string) (string, error) {
+	described, err := h.im.DescribeUser(authenticated.GetName())
+	if err != nil {
+		return "", err
+	}
+
 	idTokenRequest := &token.IssueRequest{
-		User: authorizeContext.User,
+		User: authenticated,
 		Claims: token.Claims{
 			RegisteredClaims: jwt.RegisteredClaims{
-				Audience: authorizeContext.Audience,
+				Audience: audience,
 			},
-			Nonce:     authorizeContext.Nonce,
+			Nonce:     nonce,
 			TokenType: token.IDToken,
-			Name:      authorizeContext.User.GetName(),
+			Name:      authenticated.GetName(),
 		},
 		ExpiresIn: h.options.OAuthOptions.AccessTokenMaxAge + h.options.OAuthOptions.AccessTokenInactivityTimeout,
 	}
 
-	if sliceutil.HasString(authorizeContext.Scopes, oauth.ScopeProfile) {
-		idTokenRequest.PreferredUsername = authenticated.Name
-		idTokenRequest.Locale = authenticated.Spec.Lang
+	if sliceutil.HasString(scopes, oauth.ScopeProfile) {
+		idTokenRequest.PreferredUsername = described.Name
+		idTokenRequest.Locale = described.Spec.Lang
 	}
 
-	if sliceutil.HasString(authorizeContext.Scopes, oauth.ScopeEmail) {
-		idTokenRequest.Email = authenticated.Spec.Email
+	if sliceutil.HasString(scopes, oauth.ScopeEmail) {
+		idTokenRequest.Email = described.Spec.Email
 	}
 
-	idToken, err := h.tokenOperator.IssueTo(idTokenRequest)
-	if err != nil {
-		response.WriteHeaderAndEntity(http.StatusInternalServerError, oauth.NewServerError(err))
-		return
-	}
-
-	result.IDToken = idToken
-
-	response.WriteEntity(result)
+	return h.tokenOperator.IssueTo(idTokenRequest)
 }
 
 func (h *handler) logout(req *restful.Request, resp *restful.Response) {
@@ -677,10 +1181,57 @@ func (h *handler) userinfo(req *restful.Request, response *restful.Response) {
 	response.WriteEntity(result)
 }
 
+// samlMetadata serves the SAML metadata of KubeSphere as a service provider
+func (h *handler) samlMetadata(req *restful.Request, response *restful.Response) {
+	provider, err := h.samlProvider(req.PathParameter("identityprovider"))
+	if err != nil {
+		api.HandleNotFound(response, req, err)
+		return
+	}
+	metadata, err := provider.ServiceProviderMetadata()
+	if err != nil {
+		api.HandleInternalError(response, req, err)
+		return
+	}
+	response.Header().Set("Content-Type", mimeSAMLMetadata)
+	response.WriteHeader(http.StatusOK)
+	_, _ = response.Write(metadata)
+}
+
+// samlLogin initiates the SAML single sign-on from the service provider
+func (h *handler) samlLogin(req *restful.Request, response *restful.Response) {
+	if _, err := h.samlProvider(req.PathParameter("identityprovider")); err != nil {
+		api.HandleNotFound(response, req, err)
+		return
+	}
+	redirectURL, err := h.oauthAuthenticator.AuthnRequestURL(req.PathParameter("identityprovider"), req.QueryParameter("state"))
+	if err != nil {
+		api.HandleInternalError(response, req, err)
+		return
+	}
+	http.Redirect(response, req.Request, redirectURL, http.StatusFound)
+}
+
+func (h *handler) samlProvider(name string) (identityprovider.SAMLProvider, error) {
+	providerOptions, err := h.options.OAuthOptions.IdentityProviderOptions(name)
+	if err != nil {
+		return nil, err
+	}
+	provider, err := identityprovider.GetOAuthProvider(providerOptions.Name)
+	if err != nil {
+		return nil, err
+	}
+	samlProvider, ok := provider.(identityprovider.SAMLProvider)
+	if !ok {
+		return nil, fmt.Errorf("identity provider %s is not a SAML identity provider", name)
+	}
+	return samlProvider, nil
+}
+
 func (h *handler) loginByIdentityProvider(req *restful.Request, response *restful.Response) {
 	username, _ := req.BodyParameter("username")
 	password, _ := req.BodyParameter("password")
 	idp := req.PathParameter("identityprovider")
 
-	h.passwordGrant(idp, username, password, req, response)
+	h.passwordGrant("", idp, username, password, req, response)
 }
@@ -29,9 +29,13 @@ import (
 	"kubesphere.io/kubesphere/pkg/constants"
 	"kubesphere.io/kubesphere/pkg/models/auth"
 	"kubesphere.io/kubesphere/pkg/models/iam/im"
+	"kubesphere.io/kubesphere/pkg/server/errors"
 )
 
-const contentTypeFormData = "application/x-www-form-urlencoded"
+const (
+	contentTypeFormData = "application/x-www-form-urlencoded"
+	mimeSAMLMetadata    = "application/samlmetadata+xml"
+)
 
 // AddToContainer ks-apiserver includes a built-in OAuth server. Users obtain OAuth access tokens to authenticate themselves to the API.
 // The OAuth server supports standard authorization code grant and the implicit grant OAuth authorization flows.
@@ -43,7 +47,11 @@ func AddToContainer(c *restful.Container, im im.IdentityManagementInterface,
 	tokenOperator auth.TokenManagementInterface,
 	passwordAuthenticator auth.PasswordAuthenticator,
 	oauth2Authenticator auth.OAuthAuthenticator,
+	multiFactorAuthenticator auth.MultiFactorAuthenticator,
+	deviceAuthorizer auth.DeviceAuthorizer,
+	sessionOperator auth.SessionManagementInterface,
 	loginRecorder auth.LoginRecorder,
+	loginLimiter auth.LoginLimiter,
 	options *authentication.Options) error {
 
 	ws := &restful.WebService{}
@@ -51,7 +59,7 @@ func AddToContainer(c *restful.Container, im im.IdentityManagementInterface,
 		Consumes(restful.MIME_JSON).
 		Produces(restful.MIME_JSON)
 
-	handler := newHandler(im, tokenOperator, passwordAuthenticator, oauth2Authenticator, loginRecorder, options)
+	handler := newHandler(im, tokenOperator, passwordAuthenticator, oauth2Authenticator, multiFactorAuthenticator, deviceAuthorizer, sessionOperator, loginRecorder, loginLimiter, options)
 
 	ws.Route(ws.GET("/.well-known/openid-configuration").To(handler.discovery).
 		Doc("The OpenID Provider's configuration information can be retrieved."))
@@ -116,8 +124,77 @@ func AddToContainer(c *restful.Container, im im.IdentityManagementInterface,
 		Param(ws.FormParameter("username", "The resource owner username.").Required(false)).
 		Param(ws.FormParameter("password", "The resource owner password.").Required(false)).
 		Param(ws.FormParameter("code", "Valid authorization code.").Required(false)).
+		Param(ws.FormParameter("mfa_token", "The challenge returned by the password grant "+
+			"when multi-factor authentication is required.").Required(false)).
+		Param(ws.FormParameter("otp", "The TOTP passcode or a recovery code.").Required(false)).
+		Param(ws.FormParameter("device_code", "The device verification code returned by the device authorization endpoint.").Required(false)).
 		To(handler.token).
 		Returns(http.StatusOK, http.StatusText(http.StatusOK), &oauth.Token{}).
+		Returns(http.StatusForbidden, "Multi-factor authentication required", oauth.Error{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.AuthenticationTag}))
+
+	// https://datatracker.ietf.org/doc/html/rfc7662#section-2
+	ws.Route(ws.POST("/introspect").
+		Consumes(contentTypeFormData).
+		Doc("The introspection endpoint is used by the protected resources to query the active state and "+
+			"the meta information of a token, the client credentials are required.").
+		Param(ws.FormParameter("token", "The string value of the token.").Required(true)).
+		Param(ws.FormParameter("token_type_hint", "A hint about the type of the token, it is ignored.").Required(false)).
+		Param(ws.FormParameter("client_id", "Valid client credential, or use the basic authentication.").Required(false)).
+		Param(ws.FormParameter("client_secret", "Valid client credential, or use the basic authentication.").Required(false)).
+		To(handler.introspect).
+		Returns(http.StatusOK, http.StatusText(http.StatusOK), oauth.TokenIntrospection{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.AuthenticationTag}))
+
+	// https://datatracker.ietf.org/doc/html/rfc7009#section-2
+	ws.Route(ws.POST("/revoke").
+		Consumes(contentTypeFormData).
+		Doc("The revocation endpoint invalidates the access token or refresh token issued to the client, "+
+			"the client credentials are required.").
+		Param(ws.FormParameter("token", "The token that the client wants to get revoked.").Required(true)).
+		Param(ws.FormParameter("token_type_hint", "A hint about the type of the token, it is ignored.").Required(false)).
+		Param(ws.FormParameter("client_id", "Valid client credential, or use the basic authentication.").Required(false)).
+		Param(ws.FormParameter("client_secret", "Valid client credential, or use the basic authentication.").Required(false)).
+		To(handler.revoke).
+		Returns(http.StatusOK, http.StatusText(http.StatusOK), nil).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.AuthenticationTag}))
+
+	// https://datatracker.ietf.org/doc/html/rfc8628#section-3.1
+	ws.Route(ws.POST("/device/code").
+		Consumes(contentTypeFormData).
+		Doc("The device authorization endpoint is used by the devices that lack a browser to obtain the device code "+
+			"and the user code, the device then polls the token endpoint with the device_code grant type.").
+		Param(ws.FormParameter("client_id", "Valid client credential.").Required(true)).
+		Param(ws.FormParameter("client_secret", "Valid client credential.").Required(true)).
+		Param(ws.FormParameter("scope", "The scope of the access request.").Required(false)).
+		To(handler.deviceAuthorization).
+		Returns(http.StatusOK, http.StatusText(http.StatusOK), oauth.DeviceAuthorizationResponse{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.AuthenticationTag}))
+
+	// https://datatracker.ietf.org/doc/html/rfc8628#section-3.3
+	ws.Route(ws.GET("/device").
+		Doc("The end-user verification endpoint returns the pending device authorization for the user to confirm.").
+		Param(ws.QueryParameter("user_code", "The user code displayed on the device.").Required(true)).
+		To(handler.deviceVerification).
+		Returns(http.StatusOK, http.StatusText(http.StatusOK), auth.DeviceAuthorization{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.AuthenticationTag}))
+
+	ws.Route(ws.POST("/device").
+		Consumes(contentTypeFormData).
+		Doc("Approve or deny the device authorization on behalf of the authenticated user.").
+		Param(ws.FormParameter("user_code", "The user code displayed on the device.").Required(true)).
+		Param(ws.FormParameter("approve", "Approve the authorization if true, otherwise deny it.").Required(false)).
+		To(handler.deviceApproval).
+		Returns(http.StatusOK, http.StatusText(http.StatusOK), errors.None).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.AuthenticationTag}))
+
+	ws.Route(ws.POST("/mfa/enroll").
+		Consumes(contentTypeFormData).
+		Doc("Enroll the TOTP authenticator with the challenge returned by the password grant, "+
+			"the enrollment is confirmed by the first passcode verified in the mfa_otp grant.").
+		Param(ws.FormParameter("mfa_token", "The challenge returned by the password grant.").Required(true)).
+		To(handler.multiFactorEnroll).
+		Returns(http.StatusOK, http.StatusText(http.StatusOK), auth.MultiFactorEnrollment{}).
 		Metadata(restfulspec.KeyOpenAPITags, []string{constants.AuthenticationTag}))
 
 	// Authorization callback URL, where the end of the URL contains the identity provider name.
@@ -141,6 +218,33 @@ func AddToContainer(c *restful.Container, im im.IdentityManagementInterface,
 		Returns(http.StatusOK, api.StatusOK, oauth.Token{}).
 		Metadata(restfulspec.KeyOpenAPITags, []string{constants.AuthenticationTag}))
 
+	// The SAML identity provider posts the SAMLResponse to the assertion consumer service with HTTP POST binding.
+	ws.Route(ws.POST("/callback/{callback}").
+		Consumes(contentTypeFormData).
+		Doc("SAML assertion consumer service, the path param callback is config by identity provider").
+		Param(ws.FormParameter("SAMLResponse", "The base64 encoded SAML response.").Required(true)).
+		Param(ws.FormParameter("RelayState", "The exact value received from the service provider.").Required(false)).
+		To(handler.oauthCallback).
+		Returns(http.StatusOK, api.StatusOK, oauth.Token{}).
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.AuthenticationTag}))
+
+	ws.Route(ws.GET("/saml/{identityprovider}/metadata").
+		Produces(mimeSAMLMetadata).
+		Doc("The SAML metadata of KubeSphere as a service provider.").
+		Param(ws.PathParameter("identityprovider", "The identity provider name")).
+		To(handler.samlMetadata).
+		Returns(http.StatusOK, http.StatusText(http.StatusOK), "").
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.AuthenticationTag}))
+
+	ws.Route(ws.GET("/saml/{identityprovider}/login").
+		Doc("Redirect the user agent to the SAML identity provider with an AuthnRequest.").
+		Param(ws.PathParameter("identityprovider", "The identity provider name")).
+		Param(ws.QueryParameter("state", "Opaque value used to maintain state between the request and the callback, "+
+			"sent as the RelayState.").Required(false)).
+		To(handler.samlLogin).
+		Returns(http.StatusFound, http.StatusText(http.StatusFound), "").
+		Metadata(restfulspec.KeyOpenAPITags, []string{constants.AuthenticationTag}))
+
 	// https://openid.net/specs/openid-connect-rpinitiated-1_0.html
 	ws.Route(ws.GET("/logout").
 		Doc("This endpoint takes an ID token and logs the user out of KubeSphere if the "+
@@ -17,22 +17,33 @@ limitations under the License.
 package v1alpha3
 
 import (
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"sort"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/emicklei/go-restful/v3"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
 	"k8s.io/klog/v2"
 
 	"kubesphere.io/kubesphere/pkg/api"
+	"kubesphere.io/kubesphere/pkg/apiserver/authorization/authorizer"
 	"kubesphere.io/kubesphere/pkg/apiserver/query"
+	requestctx "kubesphere.io/kubesphere/pkg/apiserver/request"
 	"kubesphere.io/kubesphere/pkg/models/components"
 	v2 "kubesphere.io/kubesphere/pkg/models/registries/v2"
 	"kubesphere.io/kubesphere/pkg/models/resources/v1alpha2"
 	resourcev1alpha2 "kubesphere.io/kubesphere/pkg/models/resources/v1alpha2/resource"
+	resourcesv1alpha3 "kubesphere.io/kubesphere/pkg/models/resources/v1alpha3"
 	resourcev1alpha3 "kubesphere.io/kubesphere/pkg/models/resources/v1alpha3/resource"
 	"kubesphere.io/kubesphere/pkg/server/params"
 )
@@ -42,14 +53,16 @@ type Handler struct {
 	resourcesGetterV1alpha2 *resourcev1alpha2.ResourceGetter
 	componentsGetter        components.ComponentsGetter
 	registryHelper          v2.RegistryHelper
+	authorizer              authorizer.Authorizer
 }
 
-func New(resourceGetterV1alpha3 *resourcev1alpha3.ResourceGetter, resourcesGetterV1alpha2 *resourcev1alpha2.ResourceGetter, componentsGetter components.ComponentsGetter) *Handler {
+func New(resourceGetterV1alpha3 *resourcev1alpha3.ResourceGetter, resourcesGetterV1alpha2 *resourcev1alpha2.ResourceGetter, componentsGetter components.ComponentsGetter, authorizer authorizer.Authorizer) *Handler {
 	return &Handler{
 		resourceGetterV1alpha3:  resourceGetterV1alpha3,
 		resourcesGetterV1alpha2: resourcesGetterV1alpha2,
 		componentsGetter:        componentsGetter,
 		registryHelper:          v2.NewRegistryHelper(),
+		authorizer:              authorizer,
 	}
 }
 
@@ -89,19 +102,24 @@ func (h *Handler) handleGetResources(request *restful.Request, response *restful
 
 // handleListResources retrieves resources
 func (h *Handler) handleListResources(request *restful.Request, response *restful.Response) {
+	if watch, _ := strconv.ParseBool(request.QueryParameter(query.ParameterWatch)); watch {
+		h.handleWatchResources(request, response)
+		return
+	}
+
 	query := query.ParseQueryParameter(request)
 	resourceType := request.PathParameter("resources")
 	namespace := request.PathParameter("namespace")
 
 	result, err := h.resourceGetterV1alpha3.List(resourceType, namespace, query)
 	if err == nil {
-		response.WriteEntity(result)
+		h.writeListResult(request, response, resourceType, namespace, result)
 		return
 	}
 
 	if err != resourcev1alpha3.ErrResourceNotSupported {
 		klog.Errorf("%s, resource type: %s", err, resourceType)
-		api.HandleInternalError(response, request, err)
+		api.HandleError(response, request, err)
 		return
 	}
 
@@ -116,9 +134,222 @@ func (h *Handler) handleListResources(request *restful.Request, response *restfu
 		api.HandleError(response, request, err)
 		return
 	}
+	h.writeListResult(request, response, resourceType, namespace, result)
+}
+
+// writeListResult writes the list result as a Kubernetes Table if as=Table is requested,
+// otherwise writes only the requested fields of the items if any.
+func (h *Handler) writeListResult(request *restful.Request, response *restful.Response, resourceType, namespace string, result *api.ListResult) {
+	if request.QueryParameter(query.ParameterAs) == tableKind {
+		table, err := resourcesv1alpha3.DefaultTable(result, h.resourceGetterV1alpha3.TableColumns(resourceType, namespace))
+		if err != nil {
+			api.HandleInternalError(response, request, err)
+			return
+		}
+		response.WriteEntity(table)
+		return
+	}
+	if fields := request.QueryParameter(query.ParameterFields); fields != "" {
+		projected, err := resourcesv1alpha3.ProjectFields(result, strings.Split(fields, ","))
+		if err != nil {
+			api.HandleError(response, request, err)
+			return
+		}
+		result = projected
+	}
+	response.WriteEntity(result)
+}
+
+// handleSearch searches the resources by keyword, the hits are filtered by the permissions of the user,
+// the resources failed to be searched are reported in the result
+func (h *Handler) handleSearch(request *restful.Request, response *restful.Response) {
+	keyword := strings.TrimSpace(request.QueryParameter(parameterSearchKeyword))
+	if keyword == "" {
+		api.HandleBadRequest(response, request, fmt.Errorf("the search keyword is required"))
+		return
+	}
+	var resources []string
+	if value := request.QueryParameter(parameterSearchResources); value != "" {
+		resources = strings.Split(value, ",")
+	}
+	limit, err := strconv.Atoi(request.QueryParameter(query.ParameterLimit))
+	if err != nil || limit <= 0 {
+		limit = defaultSearchLimit
+	}
+
+	user, ok := requestctx.UserFrom(request.Request.Context())
+	if !ok {
+		api.HandleUnauthorized(response, request, fmt.Errorf("unauthenticated request"))
+		return
+	}
+	requestInfo, _ := requestctx.RequestInfoFrom(request.Request.Context())
+	allowed := func(resource schema.GroupVersionResource, namespace string) bool {
+		if h.authorizer == nil {
+			return true
+		}
+		listResources := authorizer.AttributesRecord{
+			User:            user,
+			Verb:            requestctx.VerbList,
+			APIGroup:        resource.Group,
+			APIVersion:      resource.Version,
+			Resource:        resource.Resource,
+			Namespace:       namespace,
+			ResourceRequest: true,
+			ResourceScope:   requestctx.NamespaceScope,
+		}
+		if requestInfo != nil {
+			listResources.Cluster = requestInfo.Cluster
+		}
+		decision, _, err := h.authorizer.Authorize(listResources)
+		if err != nil {
+			klog.Error(err)
+			return false
+		}
+		return decision == authorizer.DecisionAllow
+	}
+
+	result, err := h.resourceGetterV1alpha3.Search(keyword, resources, limit, allowed)
+	if err != nil {
+		if err == resourcev1alpha3.ErrResourceNotSupported {
+			api.HandleBadRequest(response, request, err)
+			return
+		}
+		api.HandleInternalError(response, request, err)
+		return
+	}
 	response.WriteEntity(result)
 }
 
+// handleWatchResources streams the changes of the resources matching the query as newline delimited
+// JSON watch events, or as server-sent events if the client accepts text/event-stream
+func (h *Handler) handleWatchResources(request *restful.Request, response *restful.Response) {
+	q := query.ParseQueryParameter(request)
+	resourceType := request.PathParameter("resources")
+	namespace := request.PathParameter("namespace")
+	resourceVersion := request.QueryParameter(query.ParameterResourceVersion)
+
+	watcher, err := h.resourceGetterV1alpha3.Watch(resourceType, namespace, q, resourceVersion)
+	if err != nil {
+		if err == resourcev1alpha3.ErrResourceNotSupported || err == resourcev1alpha3.ErrWatchNotSupported {
+			api.HandleNotFound(response, request, err)
+			return
+		}
+		api.HandleError(response, request, err)
+		return
+	}
+	defer watcher.Stop()
+
+	eventStream := strings.Contains(request.HeaderParameter(restful.HEADER_Accept), mimeEventStream)
+	if eventStream {
+		response.Header().Set(restful.HEADER_ContentType, mimeEventStream)
+		response.Header().Set("Cache-Control", "no-cache")
+	} else {
+		response.Header().Set(restful.HEADER_ContentType, restful.MIME_JSON)
+	}
+	response.WriteHeader(http.StatusOK)
+	response.Flush()
+
+	// authorization decisions of the namespaces the events belong to
+	decisions := make(map[string]watchDecision)
+	ctx := request.Request.Context()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-watcher.ResultChan():
+			if !ok {
+				return
+			}
+			if !h.authorizeWatchEvent(request, event, decisions) {
+				continue
+			}
+			data, err := encodeWatchEvent(event)
+			if err != nil {
+				klog.Error(err)
+				return
+			}
+			if eventStream {
+				_, err = fmt.Fprintf(response, "event: %s\ndata: %s\n\n", event.Type, data)
+			} else {
+				_, err = fmt.Fprintf(response, "%s\n", data)
+			}
+			if err != nil {
+				klog.V(4).Infof("failed to write watch event: %s", err)
+				return
+			}
+			response.Flush()
+		}
+	}
+}
+
+// watchAuthorizationTTL is how long the authorization decisions of a watch are cached
+const watchAuthorizationTTL = time.Minute
+
+// watchDecision is a cached authorization decision of a namespace, it is checked again once expired
+// so that revoked permissions take effect on long-running watches.
+type watchDecision struct {
+	allowed bool
+	expires time.Time
+}
+
+// authorizeWatchEvent checks whether the user is still allowed to watch the resources in the namespace of the event,
+// the request is only authorized in the scope of the request path when the watch starts.
+func (h *Handler) authorizeWatchEvent(request *restful.Request, event watch.Event, decisions map[string]watchDecision) bool {
+	if h.authorizer == nil || event.Type == watch.Error {
+		return true
+	}
+	object, err := meta.Accessor(event.Object)
+	if err != nil {
+		return true
+	}
+	namespace := object.GetNamespace()
+	now := time.Now()
+	if decision, ok := decisions[namespace]; ok && now.Before(decision.expires) {
+		return decision.allowed
+	}
+
+	user, ok := requestctx.UserFrom(request.Request.Context())
+	if !ok {
+		return false
+	}
+	requestInfo, ok := requestctx.RequestInfoFrom(request.Request.Context())
+	if !ok {
+		return false
+	}
+	watchResources := authorizer.AttributesRecord{
+		User:            user,
+		Verb:            requestInfo.Verb,
+		Cluster:         requestInfo.Cluster,
+		Workspace:       requestInfo.Workspace,
+		Namespace:       requestInfo.Namespace,
+		APIGroup:        requestInfo.APIGroup,
+		APIVersion:      requestInfo.APIVersion,
+		Resource:        requestInfo.Resource,
+		ResourceRequest: true,
+		ResourceScope:   requestInfo.ResourceScope,
+	}
+	// cluster-scoped objects are authorized in the scope of the request
+	if namespace != "" {
+		watchResources.Namespace = namespace
+		watchResources.ResourceScope = requestctx.NamespaceScope
+	}
+	decision, _, err := h.authorizer.Authorize(watchResources)
+	if err != nil {
+		klog.Error(err)
+		return false
+	}
+	decisions[namespace] = watchDecision{allowed: decision == authorizer.DecisionAllow, expires: now.Add(watchAuthorizationTTL)}
+	return decisions[namespace].allowed
+}
+
+func encodeWatchEvent(event watch.Event) ([]byte, error) {
+	object, err := json.Marshal(event.Object)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(metav1.WatchEvent{Type: string(event.Type), Object: runtime.RawExtension{Raw: object}})
+}
+
 func (h *Handler) fallback(resourceType string, namespace string, q *query.Query) (*api.ListResult, error) {
 	orderBy := string(q.SortBy)
 	limit, offset := q.Pagination.Limit, q.Pagination.Offset
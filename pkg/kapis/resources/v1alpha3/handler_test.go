@@ -17,12 +17,14 @@ limitations under the License.
 package v1alpha3
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
 	"reflect"
 	"testing"
+	"time"
 	"unsafe"
 
 	"github.com/emicklei/go-restful/v3"
@@ -35,10 +37,16 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	fakeapiextensions "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset/fake"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/apiserver/pkg/authentication/user"
+	k8srequest "k8s.io/apiserver/pkg/endpoints/request"
 	fakek8s "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/cache"
 
 	"kubesphere.io/kubesphere/pkg/api"
+	"kubesphere.io/kubesphere/pkg/apiserver/authorization/authorizer"
 	"kubesphere.io/kubesphere/pkg/apiserver/query"
+	requestctx "kubesphere.io/kubesphere/pkg/apiserver/request"
 	fakeks "kubesphere.io/kubesphere/pkg/client/clientset/versioned/fake"
 	"kubesphere.io/kubesphere/pkg/informers"
 	"kubesphere.io/kubesphere/pkg/models/components"
@@ -243,7 +251,7 @@ func prepare() (*Handler, error) {
 		}
 	}
 
-	handler := New(resourcev1alpha3.NewResourceGetter(fakeInformerFactory, nil), resourcev1alpha2.NewResourceGetter(fakeInformerFactory), components.NewComponentsGetter(fakeInformerFactory.KubernetesSharedInformerFactory()))
+	handler := New(resourcev1alpha3.NewResourceGetter(fakeInformerFactory, nil), resourcev1alpha2.NewResourceGetter(fakeInformerFactory), components.NewComponentsGetter(fakeInformerFactory.KubernetesSharedInformerFactory()), nil)
 
 	return handler, nil
 }
@@ -287,6 +295,124 @@ func TestHandleGetComponents(t *testing.T) {
 	}
 }
 
+func TestHandleWatchResources(t *testing.T) {
+	k8sClient := fakek8s.NewSimpleClientset(
+		&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "allowed", Name: "foo", ResourceVersion: "1"}},
+		&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "denied", Name: "bar", ResourceVersion: "2"}},
+	)
+	fakeInformerFactory := informers.NewInformerFactories(k8sClient, fakeks.NewSimpleClientset(), fakeistio.NewSimpleClientset(),
+		fakesnapshot.NewSimpleClientset(), fakeapiextensions.NewSimpleClientset(), nil)
+	informer := fakeInformerFactory.KubernetesSharedInformerFactory().Core().V1().ConfigMaps().Informer()
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	fakeInformerFactory.KubernetesSharedInformerFactory().Start(stopCh)
+	if !cache.WaitForCacheSync(stopCh, informer.HasSynced) {
+		t.Fatal("failed to sync informer")
+	}
+
+	namespaceAuthorizer := authorizer.AuthorizerFunc(func(a authorizer.Attributes) (authorizer.Decision, string, error) {
+		if a.GetNamespace() == "allowed" && a.GetVerb() == "watch" && a.GetResource() == "configmaps" {
+			return authorizer.DecisionAllow, "", nil
+		}
+		return authorizer.DecisionNoOpinion, "", nil
+	})
+	handler := New(resourcev1alpha3.NewResourceGetter(fakeInformerFactory, nil), resourcev1alpha2.NewResourceGetter(fakeInformerFactory),
+		components.NewComponentsGetter(fakeInformerFactory.KubernetesSharedInformerFactory()), namespaceAuthorizer)
+
+	tests := []struct {
+		name     string
+		accept   string
+		expected string
+	}{
+		{
+			name:     "json stream",
+			expected: `{"type":"ADDED","object":{"metadata":{"name":"foo","namespace":"allowed","resourceVersion":"1","creationTimestamp":null}}}` + "\n",
+		},
+		{
+			name:     "server-sent events",
+			accept:   mimeEventStream,
+			expected: `event: ADDED` + "\n" + `data: {"type":"ADDED","object":{"metadata":{"name":"foo","namespace":"allowed","resourceVersion":"1","creationTimestamp":null}}}` + "\n\n",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			request, response, err := buildReqAndRes("GET", "/kapis/resources.kubesphere.io/v1alpha3/configmaps?watch=true&resourceVersion=0", map[string]string{"resources": "configmaps"}, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if test.accept != "" {
+				request.Request.Header.Set(restful.HEADER_Accept, test.accept)
+			}
+			ctx, cancel := context.WithCancel(request.Request.Context())
+			ctx = requestctx.WithUser(ctx, &user.DefaultInfo{Name: "foo"})
+			ctx = requestctx.WithRequestInfo(ctx, &requestctx.RequestInfo{
+				RequestInfo: &k8srequest.RequestInfo{IsResourceRequest: true, Verb: "watch", Resource: "configmaps"},
+			})
+			request.Request = request.Request.WithContext(ctx)
+
+			done := make(chan struct{})
+			go func() {
+				handler.handleListResources(request, response)
+				close(done)
+			}()
+			time.Sleep(200 * time.Millisecond)
+			cancel()
+			<-done
+
+			recorder := response.ResponseWriter.(*httptest.ResponseRecorder)
+			if diff := cmp.Diff(test.expected, recorder.Body.String()); diff != "" {
+				t.Errorf("%T differ (-want, +got): %s", test.expected, diff)
+			}
+		})
+	}
+}
+
+func TestAuthorizeWatchEvent(t *testing.T) {
+	allowed := true
+	authorizations := 0
+	watchAuthorizer := authorizer.AuthorizerFunc(func(a authorizer.Attributes) (authorizer.Decision, string, error) {
+		authorizations++
+		if allowed && a.GetNamespace() == "foo" {
+			return authorizer.DecisionAllow, "", nil
+		}
+		return authorizer.DecisionNoOpinion, "", nil
+	})
+	handler := &Handler{authorizer: watchAuthorizer}
+
+	request, _, err := buildReqAndRes("GET", "/kapis/resources.kubesphere.io/v1alpha3/configmaps?watch=true", map[string]string{"resources": "configmaps"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := requestctx.WithUser(request.Request.Context(), &user.DefaultInfo{Name: "foo"})
+	ctx = requestctx.WithRequestInfo(ctx, &requestctx.RequestInfo{
+		RequestInfo: &k8srequest.RequestInfo{IsResourceRequest: true, Verb: "watch", Resource: "configmaps"},
+	})
+	request.Request = request.Request.WithContext(ctx)
+	event := watch.Event{Type: watch.Modified, Object: &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "foo", Name: "bar"}}}
+
+	decisions := make(map[string]watchDecision)
+	if !handler.authorizeWatchEvent(request, event, decisions) || !handler.authorizeWatchEvent(request, event, decisions) {
+		t.Fatal("expected the event to be allowed")
+	}
+	if authorizations != 1 {
+		t.Errorf("expected the decision to be cached, got %d authorizations", authorizations)
+	}
+
+	// the permission is revoked, it takes effect once the cached decision expires
+	allowed = false
+	if !handler.authorizeWatchEvent(request, event, decisions) {
+		t.Error("expected the cached decision to be used")
+	}
+	decisions["foo"] = watchDecision{allowed: true, expires: time.Now().Add(-time.Second)}
+	if handler.authorizeWatchEvent(request, event, decisions) {
+		t.Error("expected the event to be denied after the decision expired")
+	}
+	if authorizations != 2 {
+		t.Errorf("expected the expired decision to be checked again, got %d authorizations", authorizations)
+	}
+}
+
 // build req and res in *restful
 func buildReqAndRes(method, target string, param map[string]string, body io.Reader) (*restful.Request, *restful.Response, error) {
 	//build req
@@ -25,6 +25,7 @@ import (
 
 	"kubesphere.io/kubesphere/pkg/api"
 	"kubesphere.io/kubesphere/pkg/api/resource/v1alpha2"
+	"kubesphere.io/kubesphere/pkg/apiserver/authorization/authorizer"
 	"kubesphere.io/kubesphere/pkg/apiserver/query"
 	"kubesphere.io/kubesphere/pkg/apiserver/runtime"
 	"kubesphere.io/kubesphere/pkg/informers"
@@ -44,6 +45,14 @@ const (
 	tagNamespacedResource = "Namespaced Resource"
 
 	ok = "OK"
+
+	mimeEventStream = "text/event-stream"
+
+	tableKind = "Table"
+
+	parameterSearchKeyword   = "q"
+	parameterSearchResources = "resources"
+	defaultSearchLimit       = 50
 )
 
 var GroupVersion = schema.GroupVersion{Group: GroupName, Version: "v1alpha3"}
@@ -52,21 +61,27 @@ func Resource(resource string) schema.GroupResource {
 	return GroupVersion.WithResource(resource).GroupResource()
 }
 
-func AddToContainer(c *restful.Container, informerFactory informers.InformerFactory, cache cache.Cache) error {
+func AddToContainer(c *restful.Container, informerFactory informers.InformerFactory, cache cache.Cache, authorizer authorizer.Authorizer) error {
 
 	webservice := runtime.NewWebService(GroupVersion)
-	handler := New(resourcev1alpha3.NewResourceGetter(informerFactory, cache), resourcev1alpha2.NewResourceGetter(informerFactory), components.NewComponentsGetter(informerFactory.KubernetesSharedInformerFactory()))
+	handler := New(resourcev1alpha3.NewResourceGetter(informerFactory, cache), resourcev1alpha2.NewResourceGetter(informerFactory), components.NewComponentsGetter(informerFactory.KubernetesSharedInformerFactory()), authorizer)
 
 	webservice.Route(webservice.GET("/{resources}").
 		To(handler.handleListResources).
 		Metadata(restfulspec.KeyOpenAPITags, []string{tagClusteredResource}).
 		Doc("Cluster level resources").
 		Param(webservice.PathParameter("resources", "cluster level resource type, e.g. pods,jobs,configmaps,services.")).
-		Param(webservice.QueryParameter(query.ParameterName, "name used to do filtering").Required(false)).
+		Param(webservice.QueryParameter(query.ParameterName, "name used to do filtering, the filters support expressions, e.g. name=foo*, name=~^foo-[0-9]+$, name!=foo, status=in(Running,Pending), creationTimestamp>2024-01-01").Required(false)).
 		Param(webservice.QueryParameter(query.ParameterPage, "page").Required(false).DataFormat("page=%d").DefaultValue("page=1")).
 		Param(webservice.QueryParameter(query.ParameterLimit, "limit").Required(false)).
 		Param(webservice.QueryParameter(query.ParameterAscending, "sort parameters, e.g. reverse=true").Required(false).DefaultValue("ascending=false")).
 		Param(webservice.QueryParameter(query.ParameterOrderBy, "sort parameters, e.g. orderBy=createTime")).
+		Param(webservice.QueryParameter(query.ParameterClusters, "list the resources from the clusters and merge the results, separated by comma, e.g. clusters=host,member, also available as /clusters/*/").Required(false)).
+		Param(webservice.QueryParameter(query.ParameterFields, "only return the fields of the items, separated by comma, e.g. fields=metadata.name,status.phase").Required(false)).
+		Param(webservice.QueryParameter(query.ParameterAs, "return the items as a Kubernetes Table with the columns of the resource, e.g. as=Table").Required(false)).
+		Param(webservice.QueryParameter(query.ParameterContinue, "the continue token returned by the previous list, used with limit to fetch the next page instead of page").Required(false)).
+		Param(webservice.QueryParameter(query.ParameterWatch, "watch the changes of the resources matching the filters, streamed as newline delimited JSON or as server-sent events if text/event-stream is accepted, e.g. watch=true").Required(false)).
+		Param(webservice.QueryParameter(query.ParameterResourceVersion, "the resource version to start watching after").Required(false)).
 		Returns(http.StatusOK, ok, api.ListResult{}))
 
 	webservice.Route(webservice.GET("/{resources}/{name}").
@@ -83,12 +98,18 @@ func AddToContainer(c *restful.Container, informerFactory informers.InformerFact
 		Doc("Namespace level resource query").
 		Param(webservice.PathParameter("namespace", "the name of the project")).
 		Param(webservice.PathParameter("resources", "namespace level resource type, e.g. pods,jobs,configmaps,services.")).
-		Param(webservice.QueryParameter(query.ParameterName, "name used to do filtering").Required(false)).
+		Param(webservice.QueryParameter(query.ParameterName, "name used to do filtering, the filters support expressions, e.g. name=foo*, name=~^foo-[0-9]+$, name!=foo, status=in(Running,Pending), creationTimestamp>2024-01-01").Required(false)).
 		Param(webservice.QueryParameter(query.ParameterPage, "page").Required(false).DataFormat("page=%d").DefaultValue("page=1")).
 		Param(webservice.QueryParameter(query.ParameterLimit, "limit").Required(false)).
 		Param(webservice.QueryParameter(query.ParameterAscending, "sort parameters, e.g. reverse=true").Required(false).DefaultValue("ascending=false")).
 		Param(webservice.QueryParameter(query.ParameterOrderBy, "sort parameters, e.g. orderBy=createTime")).
 		Param(webservice.QueryParameter(query.ParameterFieldSelector, "field selector used for filtering, you can use the = , == and != operators with field selectors( = and == mean the same thing), e.g. fieldSelector=type=kubernetes.io/dockerconfigjson, multiple separated by comma").Required(false)).
+		Param(webservice.QueryParameter(query.ParameterClusters, "list the resources from the clusters and merge the results, separated by comma, e.g. clusters=host,member, also available as /clusters/*/").Required(false)).
+		Param(webservice.QueryParameter(query.ParameterFields, "only return the fields of the items, separated by comma, e.g. fields=metadata.name,status.phase").Required(false)).
+		Param(webservice.QueryParameter(query.ParameterAs, "return the items as a Kubernetes Table with the columns of the resource, e.g. as=Table").Required(false)).
+		Param(webservice.QueryParameter(query.ParameterContinue, "the continue token returned by the previous list, used with limit to fetch the next page instead of page").Required(false)).
+		Param(webservice.QueryParameter(query.ParameterWatch, "watch the changes of the resources matching the filters, streamed as newline delimited JSON or as server-sent events if text/event-stream is accepted, e.g. watch=true").Required(false)).
+		Param(webservice.QueryParameter(query.ParameterResourceVersion, "the resource version to start watching after").Required(false)).
 		Returns(http.StatusOK, ok, api.ListResult{}))
 
 	webservice.Route(webservice.GET("/namespaces/{namespace}/{resources}/{name}").
@@ -117,6 +138,15 @@ func AddToContainer(c *restful.Container, informerFactory informers.InformerFact
 		Doc("Get the health status of system components.").
 		Returns(http.StatusOK, ok, v1alpha2.HealthStatus{}))
 
+	webservice.Route(webservice.GET("/search").
+		To(handler.handleSearch).
+		Metadata(restfulspec.KeyOpenAPITags, []string{tagNamespacedResource}).
+		Doc("Search the workloads, services, configmaps, secrets, ingresses and helm releases visible to the user by name, alias or label value.").
+		Param(webservice.QueryParameter(parameterSearchKeyword, "the keyword to search for, e.g. q=payments").Required(true)).
+		Param(webservice.QueryParameter(parameterSearchResources, "only search the resources, separated by comma, e.g. resources=deployments,services").Required(false)).
+		Param(webservice.QueryParameter(query.ParameterLimit, "the maximum number of hits, default to 50").Required(false)).
+		Returns(http.StatusOK, ok, resourcev1alpha3.SearchResult{}))
+
 	webservice.Route(webservice.POST("/namespaces/{namespace}/registrysecrets/{secret}/verify").
 		To(handler.handleVerifyImageRepositorySecret).
 		Param(webservice.PathParameter("namespace", "Namespace of the image repository secret to create.").Required(true)).
@@ -88,7 +88,7 @@ func (h *tenantHandler) ListWorkspaceTemplates(req *restful.Request, resp *restf
 	result, err := h.tenant.ListWorkspaceTemplates(user, queryParam)
 
 	if err != nil {
-		api.HandleInternalError(resp, nil, err)
+		api.HandleError(resp, nil, err)
 		return
 	}
 
@@ -109,7 +109,7 @@ func (h *tenantHandler) ListFederatedNamespaces(req *restful.Request, resp *rest
 
 	result, err := h.tenant.ListFederatedNamespaces(workspaceMember, workspace, queryParam)
 	if err != nil {
-		api.HandleInternalError(resp, nil, err)
+		api.HandleError(resp, nil, err)
 		return
 	}
 
@@ -138,7 +138,7 @@ func (h *tenantHandler) ListNamespaces(req *restful.Request, resp *restful.Respo
 
 	result, err := h.tenant.ListNamespaces(workspaceMember, workspace, queryParam)
 	if err != nil {
-		api.HandleInternalError(resp, nil, err)
+		api.HandleError(resp, nil, err)
 		return
 	}
 
@@ -168,7 +168,7 @@ func (h *tenantHandler) ListDevOpsProjects(req *restful.Request, resp *restful.R
 	result, err := h.tenant.ListDevOpsProjects(workspaceMember, workspace, queryParam)
 
 	if err != nil {
-		api.HandleInternalError(resp, nil, err)
+		api.HandleError(resp, nil, err)
 		return
 	}
 
@@ -75,7 +75,7 @@ func (h *tenantHandler) ListWorkspaces(req *restful.Request, resp *restful.Respo
 
 	result, err := h.tenant.ListWorkspaces(user, queryParam)
 	if err != nil {
-		api.HandleInternalError(resp, nil, err)
+		api.HandleError(resp, nil, err)
 		return
 	}
 
@@ -151,7 +151,7 @@ func (o *ruleGroupOperator) ListRuleGroups(ctx context.Context, namespace string
 		return nil, err
 	}
 
-	listResult := resources.DefaultList(groups, queryParam, func(left, right runtime.Object, field query.Field) bool {
+	listResult, err := resources.DefaultList(groups, queryParam, func(left, right runtime.Object, field query.Field) bool {
 		hit, great := o.compareRuleGroupStatus(
 			&(left.(*kapialertingv2beta1.RuleGroup).Status), &(right.(*kapialertingv2beta1.RuleGroup).Status), field)
 		if hit {
@@ -166,6 +166,9 @@ func (o *ruleGroupOperator) ListRuleGroups(ctx context.Context, namespace string
 		}
 		return resources.DefaultObjectMetaFilter(obj.(*kapialertingv2beta1.RuleGroup).ObjectMeta, filter)
 	})
+	if err != nil {
+		return nil, err
+	}
 
 	return listResult, nil
 }
@@ -248,11 +251,14 @@ func (o *ruleGroupOperator) ListAlerts(ctx context.Context, namespace string,
 	if err != nil {
 		return nil, err
 	}
-	listResult := resources.DefaultList(alerts, queryParam, func(left, right runtime.Object, field query.Field) bool {
+	listResult, err := resources.DefaultList(alerts, queryParam, func(left, right runtime.Object, field query.Field) bool {
 		return o.compareAlert(&left.(*wrapAlert).Alert, &right.(*wrapAlert).Alert, field)
 	}, func(obj runtime.Object, filter query.Filter) bool {
 		return filterAlert(&obj.(*wrapAlert).Alert, filter)
 	})
+	if err != nil {
+		return nil, err
+	}
 	for i := range listResult.Items {
 		listResult.Items[i] = &listResult.Items[i].(*wrapAlert).Alert
 	}
@@ -458,7 +464,7 @@ func (o *ruleGroupOperator) ListClusterRuleGroups(ctx context.Context,
 		return nil, err
 	}
 
-	listResult := resources.DefaultList(groups, queryParam, func(left, right runtime.Object, field query.Field) bool {
+	listResult, err := resources.DefaultList(groups, queryParam, func(left, right runtime.Object, field query.Field) bool {
 		hit, great := o.compareRuleGroupStatus(
 			&(left.(*kapialertingv2beta1.ClusterRuleGroup).Status), &(right.(*kapialertingv2beta1.ClusterRuleGroup).Status), field)
 		if hit {
@@ -473,6 +479,9 @@ func (o *ruleGroupOperator) ListClusterRuleGroups(ctx context.Context,
 		}
 		return resources.DefaultObjectMetaFilter(obj.(*kapialertingv2beta1.ClusterRuleGroup).ObjectMeta, filter)
 	})
+	if err != nil {
+		return nil, err
+	}
 
 	return listResult, nil
 }
@@ -501,11 +510,14 @@ func (o *ruleGroupOperator) ListClusterAlerts(ctx context.Context,
 	if err != nil {
 		return nil, err
 	}
-	listResult := resources.DefaultList(alerts, queryParam, func(left, right runtime.Object, field query.Field) bool {
+	listResult, err := resources.DefaultList(alerts, queryParam, func(left, right runtime.Object, field query.Field) bool {
 		return o.compareAlert(&left.(*wrapAlert).Alert, &right.(*wrapAlert).Alert, field)
 	}, func(obj runtime.Object, filter query.Filter) bool {
 		return filterAlert(&obj.(*wrapAlert).Alert, filter)
 	})
+	if err != nil {
+		return nil, err
+	}
 	for i := range listResult.Items {
 		listResult.Items[i] = &listResult.Items[i].(*wrapAlert).Alert
 	}
@@ -686,7 +698,7 @@ func (o *ruleGroupOperator) ListGlobalRuleGroups(ctx context.Context,
 		return nil, err
 	}
 
-	listResult := resources.DefaultList(groups, queryParam, func(left, right runtime.Object, field query.Field) bool {
+	listResult, err := resources.DefaultList(groups, queryParam, func(left, right runtime.Object, field query.Field) bool {
 		hit, great := o.compareRuleGroupStatus(
 			&(left.(*kapialertingv2beta1.GlobalRuleGroup).Status), &(right.(*kapialertingv2beta1.GlobalRuleGroup).Status), field)
 		if hit {
@@ -704,6 +716,9 @@ func (o *ruleGroupOperator) ListGlobalRuleGroups(ctx context.Context,
 		}
 		return resources.DefaultObjectMetaFilter(obj.(*kapialertingv2beta1.GlobalRuleGroup).ObjectMeta, filter)
 	})
+	if err != nil {
+		return nil, err
+	}
 
 	return listResult, nil
 }
@@ -747,7 +762,7 @@ func (o *ruleGroupOperator) ListGlobalAlerts(ctx context.Context,
 	if err != nil {
 		return nil, err
 	}
-	listResult := resources.DefaultList(alerts, queryParam, func(left, right runtime.Object, field query.Field) bool {
+	listResult, err := resources.DefaultList(alerts, queryParam, func(left, right runtime.Object, field query.Field) bool {
 		return o.compareAlert(&left.(*wrapAlert).Alert, &right.(*wrapAlert).Alert, field)
 	}, func(obj runtime.Object, filter query.Filter) bool {
 		if filter.Field == kapialertingv2beta1.FieldBuiltin { // ignoring this filter because it is filtered at the front
@@ -755,6 +770,9 @@ func (o *ruleGroupOperator) ListGlobalAlerts(ctx context.Context,
 		}
 		return filterAlert(&obj.(*wrapAlert).Alert, filter)
 	})
+	if err != nil {
+		return nil, err
+	}
 	for i := range listResult.Items {
 		listResult.Items[i] = &listResult.Items[i].(*wrapAlert).Alert
 	}
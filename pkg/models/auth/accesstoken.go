@@ -0,0 +1,285 @@
+/*
+Copyright 2022 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	authuser "k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/klog/v2"
+
+	iamv1alpha2 "kubesphere.io/api/iam/v1alpha2"
+
+	"kubesphere.io/kubesphere/pkg/apiserver/authentication/token"
+	"kubesphere.io/kubesphere/pkg/simple/client/cache"
+)
+
+// the last-used timestamp is updated at most once per minute to reduce the writes to the cache
+const accessTokenLastUsedResolution = time.Minute
+
+// AccessToken is a long-lived static token minted by the user for scripts, the token
+// is only returned when created, only the hash of it is stored.
+type AccessToken struct {
+	Name                string             `json:"name" description:"unique name of the access token"`
+	Token               string             `json:"token,omitempty" description:"the access token, only returned when created"`
+	Scopes              *AccessTokenScopes `json:"scopes,omitempty" description:"optional restrictions of the access token"`
+	CreationTimestamp   metav1.Time        `json:"creationTimestamp,omitempty"`
+	ExpirationTimestamp *metav1.Time       `json:"expirationTimestamp,omitempty" description:"the access token never expires if not specified"`
+	LastUsedTimestamp   *metav1.Time       `json:"lastUsedTimestamp,omitempty"`
+}
+
+// AccessTokenScopes restricts the requests that can be made with the access token,
+// in addition to the permissions of the user. Empty means no restriction.
+type AccessTokenScopes struct {
+	Verbs      []string `json:"verbs,omitempty" description:"allowed verbs, e.g. get, list, watch"`
+	APIGroups  []string `json:"apiGroups,omitempty" description:"allowed API groups of the resource requests, \"\" represents the core API group"`
+	Workspaces []string `json:"workspaces,omitempty" description:"allowed workspaces, requests outside these workspaces are denied"`
+}
+
+type accessTokenRecord struct {
+	AccessToken
+	TokenHash string `json:"tokenHash"`
+}
+
+// AccessTokenManagementInterface manages the personal access tokens of the users.
+type AccessTokenManagementInterface interface {
+	// CreateAccessToken issues a static token for the user with the given name, scopes and expiration
+	CreateAccessToken(username string, accessToken *AccessToken) (*AccessToken, error)
+	// ListAccessTokens returns the access tokens of the user without the tokens themselves
+	ListAccessTokens(username string) ([]*AccessToken, error)
+	// DescribeAccessToken returns the specified access token without the token itself
+	DescribeAccessToken(username, name string) (*AccessToken, error)
+	// DeleteAccessToken revokes the specified access token
+	DeleteAccessToken(username, name string) error
+}
+
+type accessTokenOperator struct {
+	issuer token.Issuer
+	cache  cache.Interface
+}
+
+func NewAccessTokenOperator(cache cache.Interface, issuer token.Issuer) AccessTokenManagementInterface {
+	return &accessTokenOperator{
+		issuer: issuer,
+		cache:  cache,
+	}
+}
+
+func (a *accessTokenOperator) CreateAccessToken(username string, accessToken *AccessToken) (*AccessToken, error) {
+	if errs := validation.IsDNS1123Label(accessToken.Name); len(errs) > 0 {
+		return nil, errors.NewBadRequest(fmt.Sprintf("invalid access token name %q: %s", accessToken.Name, strings.Join(errs, ",")))
+	}
+
+	now := time.Now()
+	expiresIn := cache.NeverExpire
+	if accessToken.ExpirationTimestamp != nil {
+		expiresIn = accessToken.ExpirationTimestamp.Sub(now)
+		if expiresIn <= 0 {
+			return nil, errors.NewBadRequest("expiration timestamp must be in the future")
+		}
+	}
+
+	key := accessTokenKey(username, accessToken.Name)
+	if exists, err := a.cache.Exists(key); err != nil {
+		klog.Error(err)
+		return nil, err
+	} else if exists {
+		return nil, errors.NewAlreadyExists(iamv1alpha2.Resource("accesstokens"), accessToken.Name)
+	}
+
+	tokenStr, err := a.issuer.IssueTo(&token.IssueRequest{
+		User: &authuser.DefaultInfo{
+			Name:  username,
+			Extra: accessToken.Scopes.extra(accessToken.Name),
+		},
+		Claims: token.Claims{
+			TokenType:        token.StaticToken,
+			RegisteredClaims: jwt.RegisteredClaims{ID: accessToken.Name},
+		},
+		ExpiresIn: expiresIn,
+	})
+	if err != nil {
+		klog.Error(err)
+		return nil, err
+	}
+
+	record := &accessTokenRecord{
+		AccessToken: AccessToken{
+			Name:                accessToken.Name,
+			Scopes:              accessToken.Scopes,
+			CreationTimestamp:   metav1.NewTime(now),
+			ExpirationTimestamp: accessToken.ExpirationTimestamp,
+		},
+		TokenHash: hashToken(tokenStr),
+	}
+	if err = setAccessTokenRecord(a.cache, username, record); err != nil {
+		return nil, err
+	}
+
+	created := record.AccessToken
+	created.Token = tokenStr
+	return &created, nil
+}
+
+func (a *accessTokenOperator) ListAccessTokens(username string) ([]*AccessToken, error) {
+	keys, err := a.cache.Keys(accessTokenKey(username, "*"))
+	if err != nil {
+		klog.Error(err)
+		return nil, err
+	}
+	accessTokens := make([]*AccessToken, 0, len(keys))
+	for _, key := range keys {
+		record, err := getAccessTokenRecord(a.cache, key)
+		if err != nil {
+			// expired between listing and getting
+			if errors.IsNotFound(err) {
+				continue
+			}
+			return nil, err
+		}
+		accessTokens = append(accessTokens, &record.AccessToken)
+	}
+	return accessTokens, nil
+}
+
+func (a *accessTokenOperator) DescribeAccessToken(username, name string) (*AccessToken, error) {
+	record, err := getAccessTokenRecord(a.cache, accessTokenKey(username, name))
+	if err != nil {
+		return nil, err
+	}
+	return &record.AccessToken, nil
+}
+
+func (a *accessTokenOperator) DeleteAccessToken(username, name string) error {
+	key := accessTokenKey(username, name)
+	if exists, err := a.cache.Exists(key); err != nil {
+		klog.Error(err)
+		return err
+	} else if !exists {
+		return errors.NewNotFound(iamv1alpha2.Resource("accesstokens"), name)
+	}
+	if err := a.cache.Del(key); err != nil {
+		klog.Error(err)
+		return err
+	}
+	return nil
+}
+
+// verifyAccessToken checks that the static token issued as the access token has not been revoked,
+// and records the last-used timestamp.
+func verifyAccessToken(c cache.Interface, username, name, tokenStr string) error {
+	record, err := getAccessTokenRecord(c, accessTokenKey(username, name))
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return fmt.Errorf("access token %s has been revoked", name)
+		}
+		return err
+	}
+	if subtle.ConstantTimeCompare([]byte(record.TokenHash), []byte(hashToken(tokenStr))) != 1 {
+		return fmt.Errorf("access token %s has been revoked", name)
+	}
+	now := time.Now()
+	if record.LastUsedTimestamp == nil || now.Sub(record.LastUsedTimestamp.Time) > accessTokenLastUsedResolution {
+		record.LastUsedTimestamp = &metav1.Time{Time: now}
+		if err = setAccessTokenRecord(c, username, record); err != nil {
+			klog.Warningf("failed to update the last-used timestamp of access token %s: %v", name, err)
+		}
+	}
+	return nil
+}
+
+func (s *AccessTokenScopes) extra(name string) map[string][]string {
+	extra := map[string][]string{iamv1alpha2.ExtraAccessToken: {name}}
+	if s == nil {
+		return extra
+	}
+	if len(s.Verbs) > 0 {
+		extra[iamv1alpha2.ExtraAccessTokenVerbs] = s.Verbs
+	}
+	if len(s.APIGroups) > 0 {
+		extra[iamv1alpha2.ExtraAccessTokenAPIGroups] = s.APIGroups
+	}
+	if len(s.Workspaces) > 0 {
+		extra[iamv1alpha2.ExtraAccessTokenWorkspaces] = s.Workspaces
+	}
+	return extra
+}
+
+func getAccessTokenRecord(c cache.Interface, key string) (*accessTokenRecord, error) {
+	notFound := errors.NewNotFound(iamv1alpha2.Resource("accesstokens"), key[strings.LastIndex(key, ":")+1:])
+	if exists, err := c.Exists(key); err != nil {
+		klog.Error(err)
+		return nil, err
+	} else if !exists {
+		return nil, notFound
+	}
+	data, err := c.Get(key)
+	if err != nil {
+		// expired
+		if err == cache.ErrNoSuchKey {
+			return nil, notFound
+		}
+		klog.Error(err)
+		return nil, err
+	}
+	record := &accessTokenRecord{}
+	if err = json.Unmarshal([]byte(data), record); err != nil {
+		klog.Error(err)
+		return nil, err
+	}
+	return record, nil
+}
+
+func setAccessTokenRecord(c cache.Interface, username string, record *accessTokenRecord) error {
+	expiresIn := cache.NeverExpire
+	if record.ExpirationTimestamp != nil {
+		expiresIn = time.Until(record.ExpirationTimestamp.Time)
+		// expired, the record will be removed
+		if expiresIn <= 0 {
+			return nil
+		}
+	}
+	data, err := json.Marshal(record)
+	if err != nil {
+		klog.Error(err)
+		return err
+	}
+	if err = c.Set(accessTokenKey(username, record.Name), string(data), expiresIn); err != nil {
+		klog.Error(err)
+		return err
+	}
+	return nil
+}
+
+func accessTokenKey(username, name string) string {
+	return fmt.Sprintf("kubesphere:user:%s:accesstoken:%s", username, name)
+}
+
+func hashToken(tokenStr string) string {
+	sum := sha256.Sum256([]byte(tokenStr))
+	return hex.EncodeToString(sum[:])
+}
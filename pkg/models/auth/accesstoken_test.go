@@ -0,0 +1,103 @@
+/*
+Copyright 2022 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	iamv1alpha2 "kubesphere.io/api/iam/v1alpha2"
+
+	"kubesphere.io/kubesphere/pkg/apiserver/authentication"
+	"kubesphere.io/kubesphere/pkg/apiserver/authentication/token"
+	"kubesphere.io/kubesphere/pkg/simple/client/cache"
+)
+
+func Test_accessTokenOperator(t *testing.T) {
+	cacheClient, _ := cache.NewInMemoryCache(nil, nil)
+	options := authentication.NewOptions()
+	options.JwtSecret = "secret"
+	issuer, err := token.NewIssuer(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	accessTokens := NewAccessTokenOperator(cacheClient, issuer)
+	tokenOperator := NewTokenOperator(cacheClient, issuer, options)
+
+	if _, err = accessTokens.CreateAccessToken("admin", &AccessToken{Name: "Invalid_Name"}); !errors.IsBadRequest(err) {
+		t.Errorf("CreateAccessToken() error = %v, want bad request", err)
+	}
+	expired := metav1.NewTime(time.Now().Add(-time.Hour))
+	if _, err = accessTokens.CreateAccessToken("admin", &AccessToken{Name: "expired", ExpirationTimestamp: &expired}); !errors.IsBadRequest(err) {
+		t.Errorf("CreateAccessToken() error = %v, want bad request", err)
+	}
+
+	expiration := metav1.NewTime(time.Now().Add(time.Hour))
+	created, err := accessTokens.CreateAccessToken("admin", &AccessToken{
+		Name:                "ci",
+		Scopes:              &AccessTokenScopes{Verbs: []string{"get", "list"}},
+		ExpirationTimestamp: &expiration,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if created.Token == "" {
+		t.Fatal("the token should be returned when created")
+	}
+	if _, err = accessTokens.CreateAccessToken("admin", &AccessToken{Name: "ci"}); !errors.IsAlreadyExists(err) {
+		t.Errorf("CreateAccessToken() error = %v, want already exists", err)
+	}
+
+	verified, err := tokenOperator.Verify(created.Token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	extra := verified.User.GetExtra()
+	if got := extra[iamv1alpha2.ExtraAccessToken]; len(got) != 1 || got[0] != "ci" {
+		t.Errorf("unexpected access token extra %v", got)
+	}
+	if got := extra[iamv1alpha2.ExtraAccessTokenVerbs]; len(got) != 2 {
+		t.Errorf("unexpected access token verbs %v", got)
+	}
+
+	list, err := accessTokens.ListAccessTokens("admin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 1 || list[0].Name != "ci" || list[0].Token != "" {
+		t.Fatalf("unexpected access tokens %v", list)
+	}
+	if list[0].LastUsedTimestamp == nil {
+		t.Errorf("the last-used timestamp should be recorded")
+	}
+
+	if err = accessTokens.DeleteAccessToken("admin", "ci"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = tokenOperator.Verify(created.Token); err == nil {
+		t.Errorf("revoked access token should not be verified")
+	}
+	if _, err = accessTokens.DescribeAccessToken("admin", "ci"); !errors.IsNotFound(err) {
+		t.Errorf("DescribeAccessToken() error = %v, want not found", err)
+	}
+	if err = accessTokens.DeleteAccessToken("admin", "ci"); !errors.IsNotFound(err) {
+		t.Errorf("DeleteAccessToken() error = %v, want not found", err)
+	}
+}
@@ -43,6 +43,23 @@ var (
 	RateLimitExceededError  = fmt.Errorf("auth rate limit exceeded")
 	IncorrectPasswordError  = fmt.Errorf("incorrect password")
 	AccountIsNotActiveError = fmt.Errorf("account is not active")
+	IncorrectPasscodeError  = fmt.Errorf("incorrect passcode")
+	// TokenClaimedError is returned when the token which can only be used once has been used
+	TokenClaimedError = fmt.Errorf("token has already been used")
+	// LoginThrottledError is returned when the login attempt is rejected by the LoginLimiter
+	LoginThrottledError = fmt.Errorf("too many failed login attempts")
+	// MultiFactorRequiredError is returned along with the authenticated user by PasswordAuthenticator
+	// when the password is correct, but the user must pass the TOTP challenge before tokens are issued.
+	MultiFactorRequiredError = fmt.Errorf("multi-factor authentication required")
+	// MultiFactorEnrollmentRequiredError is the same as MultiFactorRequiredError,
+	// except that the user must enroll before passing the challenge.
+	MultiFactorEnrollmentRequiredError = fmt.Errorf("multi-factor authentication enrollment required")
+	// The following errors are returned by DeviceAuthorizer when the device polls for the authorization result.
+	DeviceAuthorizationPendingError  = fmt.Errorf("device authorization pending")
+	DeviceAuthorizationSlowDownError = fmt.Errorf("device authorization polling too frequently")
+	DeviceAuthorizationDeniedError   = fmt.Errorf("device authorization denied")
+	DeviceCodeExpiredError           = fmt.Errorf("device code expired")
+	DeviceCodeClientMismatchError    = fmt.Errorf("the device code was issued to another client")
 )
 
 // PasswordAuthenticator is an interface implemented by authenticator which take a
@@ -61,6 +78,9 @@ type PasswordAuthenticator interface {
 // "github.com/emicklei/go-restful/v3", or the server cannot handle error correctly.
 type OAuthAuthenticator interface {
 	Authenticate(ctx context.Context, provider string, req *http.Request) (authuser.Info, string, error)
+	// AuthnRequestURL returns the URL that initiates the single sign-on with the SAML identity provider,
+	// the AuthnRequest is tracked so that only the response to it is accepted.
+	AuthnRequestURL(provider, relayState string) (string, error)
 }
 
 type userGetter struct {
@@ -0,0 +1,321 @@
+/*
+Copyright 2022 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/klog/v2"
+
+	"kubesphere.io/kubesphere/pkg/simple/client/cache"
+)
+
+const (
+	// DeviceCodeMaxAge is the lifetime of the device code and the user code.
+	DeviceCodeMaxAge = 10 * time.Minute
+	// DevicePollingInterval is the minimum interval between the polling requests of the device.
+	DevicePollingInterval = 5 * time.Second
+	// the characters of the user code exclude vowels to avoid generating words,
+	// for more details: https://datatracker.ietf.org/doc/html/rfc8628#section-6.1
+	userCodeCharset = "BCDFGHJKLMNPQRSTVWXZ"
+	userCodeLength  = 8
+	deviceCodeSize  = 32
+)
+
+type DeviceAuthorizationStatus string
+
+const (
+	DeviceAuthorizationPending  DeviceAuthorizationStatus = "Pending"
+	DeviceAuthorizationApproved DeviceAuthorizationStatus = "Approved"
+	DeviceAuthorizationDenied   DeviceAuthorizationStatus = "Denied"
+)
+
+// DeviceAuthorization is the authorization request of a device which is waiting for the user's approval.
+type DeviceAuthorization struct {
+	ClientID            string                    `json:"clientID" description:"the OAuth client which requests the authorization"`
+	Scopes              []string                  `json:"scopes,omitempty" description:"the requested scopes"`
+	UserCode            string                    `json:"userCode" description:"the user code displayed on the device"`
+	Status              DeviceAuthorizationStatus `json:"status" description:"Pending, Approved or Denied"`
+	Username            string                    `json:"username,omitempty" description:"the user who approved the authorization"`
+	ExpirationTimestamp metav1.Time               `json:"expirationTimestamp"`
+}
+
+// DeviceAuthorizer implements the state of OAuth 2.0 Device Authorization Grant,
+// for more details: https://datatracker.ietf.org/doc/html/rfc8628
+type DeviceAuthorizer interface {
+	// Authorize starts the device authorization, returns the device code which is only known by the device
+	Authorize(clientID string, scopes []string) (string, *DeviceAuthorization, error)
+	// DescribeByUserCode returns the pending authorization for the user to verify
+	DescribeByUserCode(userCode string) (*DeviceAuthorization, error)
+	// Approve grants the authorization to the device on behalf of the user
+	Approve(userCode, username string) error
+	// Deny rejects the authorization
+	Deny(userCode string) error
+	// Poll returns the approved authorization, the device code can only be exchanged once
+	Poll(clientID, deviceCode string) (*DeviceAuthorization, error)
+}
+
+type deviceAuthorizer struct {
+	cache cache.Interface
+}
+
+func NewDeviceAuthorizer(cache cache.Interface) DeviceAuthorizer {
+	return &deviceAuthorizer{cache: cache}
+}
+
+func (d *deviceAuthorizer) Authorize(clientID string, scopes []string) (string, *DeviceAuthorization, error) {
+	deviceCode, err := generateDeviceCode()
+	if err != nil {
+		klog.Error(err)
+		return "", nil, err
+	}
+	userCode, err := generateUserCode()
+	if err != nil {
+		klog.Error(err)
+		return "", nil, err
+	}
+	authorization := &DeviceAuthorization{
+		ClientID:            clientID,
+		Scopes:              scopes,
+		UserCode:            userCode,
+		Status:              DeviceAuthorizationPending,
+		ExpirationTimestamp: metav1.NewTime(time.Now().Add(DeviceCodeMaxAge)),
+	}
+	if err = d.save(deviceCode, authorization); err != nil {
+		return "", nil, err
+	}
+	if err = d.cache.Set(userCodeKey(userCode), hashToken(deviceCode), DeviceCodeMaxAge); err != nil {
+		klog.Error(err)
+		return "", nil, err
+	}
+	return deviceCode, authorization, nil
+}
+
+func (d *deviceAuthorizer) DescribeByUserCode(userCode string) (*DeviceAuthorization, error) {
+	_, authorization, err := d.getByUserCode(userCode)
+	if err != nil {
+		return nil, err
+	}
+	return authorization, nil
+}
+
+func (d *deviceAuthorizer) Approve(userCode, username string) error {
+	return d.complete(userCode, DeviceAuthorizationApproved, username)
+}
+
+func (d *deviceAuthorizer) Deny(userCode string) error {
+	return d.complete(userCode, DeviceAuthorizationDenied, "")
+}
+
+func (d *deviceAuthorizer) complete(userCode string, status DeviceAuthorizationStatus, username string) error {
+	deviceCodeHash, authorization, err := d.getByUserCode(userCode)
+	if err != nil {
+		return err
+	}
+	if authorization.Status != DeviceAuthorizationPending {
+		return errors.NewConflict(deviceAuthorizationResource(), userCode, fmt.Errorf("the authorization has been %s", strings.ToLower(string(authorization.Status))))
+	}
+	expiresIn := time.Until(authorization.ExpirationTimestamp.Time)
+	if expiresIn <= 0 {
+		return DeviceCodeExpiredError
+	}
+	// the authorization is completed atomically, so that only one of the concurrent approvals and denials succeeds
+	completed, err := d.cache.SetNX(deviceCodeCompleteKey(deviceCodeHash), string(status), expiresIn)
+	if err != nil {
+		klog.Error(err)
+		return err
+	}
+	if !completed {
+		return errors.NewConflict(deviceAuthorizationResource(), userCode, fmt.Errorf("the authorization has been completed"))
+	}
+	authorization.Status = status
+	authorization.Username = username
+	// the user code can only be verified once
+	if err = d.cache.Del(userCodeKey(authorization.UserCode)); err != nil {
+		klog.Error(err)
+		return err
+	}
+	return d.saveByHash(deviceCodeHash, authorization)
+}
+
+func (d *deviceAuthorizer) Poll(clientID, deviceCode string) (*DeviceAuthorization, error) {
+	deviceCodeHash := hashToken(deviceCode)
+	authorization, err := d.get(deviceCodeHash)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil, DeviceCodeExpiredError
+		}
+		return nil, err
+	}
+	if authorization.ClientID != clientID {
+		return nil, DeviceCodeClientMismatchError
+	}
+
+	switch authorization.Status {
+	case DeviceAuthorizationApproved, DeviceAuthorizationDenied:
+		// the device code is claimed atomically before it's exchanged, so that only one of the concurrent polls succeeds
+		claimed, err := d.cache.SetNX(deviceCodeClaimKey(deviceCodeHash), "", DeviceCodeMaxAge)
+		if err != nil {
+			klog.Error(err)
+			return nil, err
+		}
+		if !claimed {
+			return nil, DeviceCodeExpiredError
+		}
+		if err = d.cache.Del(deviceCodeKey(deviceCodeHash)); err != nil {
+			klog.Error(err)
+			return nil, err
+		}
+		if authorization.Status == DeviceAuthorizationDenied {
+			return nil, DeviceAuthorizationDeniedError
+		}
+		return authorization, nil
+	default:
+		// the polls are tracked apart from the authorization, which must not be written back here,
+		// otherwise a concurrent approval could be overwritten
+		allowed, err := d.cache.SetNX(devicePollKey(deviceCodeHash), "", DevicePollingInterval)
+		if err != nil {
+			klog.Error(err)
+			return nil, err
+		}
+		if !allowed {
+			return nil, DeviceAuthorizationSlowDownError
+		}
+		return nil, DeviceAuthorizationPendingError
+	}
+}
+
+func (d *deviceAuthorizer) getByUserCode(userCode string) (string, *DeviceAuthorization, error) {
+	deviceCodeHash, err := d.cache.Get(userCodeKey(normalizeUserCode(userCode)))
+	if err != nil {
+		if err == cache.ErrNoSuchKey {
+			return "", nil, errors.NewNotFound(deviceAuthorizationResource(), userCode)
+		}
+		klog.Error(err)
+		return "", nil, err
+	}
+	authorization, err := d.get(deviceCodeHash)
+	if err != nil {
+		return "", nil, err
+	}
+	return deviceCodeHash, authorization, nil
+}
+
+func (d *deviceAuthorizer) get(deviceCodeHash string) (*DeviceAuthorization, error) {
+	data, err := d.cache.Get(deviceCodeKey(deviceCodeHash))
+	if err != nil {
+		if err == cache.ErrNoSuchKey {
+			return nil, errors.NewNotFound(deviceAuthorizationResource(), "")
+		}
+		klog.Error(err)
+		return nil, err
+	}
+	authorization := &DeviceAuthorization{}
+	if err = json.Unmarshal([]byte(data), authorization); err != nil {
+		klog.Error(err)
+		return nil, err
+	}
+	return authorization, nil
+}
+
+func (d *deviceAuthorizer) save(deviceCode string, authorization *DeviceAuthorization) error {
+	return d.saveByHash(hashToken(deviceCode), authorization)
+}
+
+func (d *deviceAuthorizer) saveByHash(deviceCodeHash string, authorization *DeviceAuthorization) error {
+	expiresIn := time.Until(authorization.ExpirationTimestamp.Time)
+	if expiresIn <= 0 {
+		return DeviceCodeExpiredError
+	}
+	data, err := json.Marshal(authorization)
+	if err != nil {
+		klog.Error(err)
+		return err
+	}
+	if err = d.cache.Set(deviceCodeKey(deviceCodeHash), string(data), expiresIn); err != nil {
+		klog.Error(err)
+		return err
+	}
+	return nil
+}
+
+func generateDeviceCode() (string, error) {
+	b := make([]byte, deviceCodeSize)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// generateUserCode returns a user code in the format of XXXX-XXXX
+func generateUserCode() (string, error) {
+	var b strings.Builder
+	max := big.NewInt(int64(len(userCodeCharset)))
+	for i := 0; i < userCodeLength; i++ {
+		if i == userCodeLength/2 {
+			b.WriteByte('-')
+		}
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		b.WriteByte(userCodeCharset[n.Int64()])
+	}
+	return b.String(), nil
+}
+
+// normalizeUserCode makes the user code case-insensitive and tolerant of the missing dash
+func normalizeUserCode(userCode string) string {
+	code := strings.ToUpper(strings.NewReplacer("-", "", " ", "").Replace(userCode))
+	if len(code) != userCodeLength {
+		return code
+	}
+	return code[:userCodeLength/2] + "-" + code[userCodeLength/2:]
+}
+
+func deviceAuthorizationResource() schema.GroupResource {
+	return schema.GroupResource{Group: "oauth", Resource: "deviceauthorizations"}
+}
+
+func deviceCodeKey(deviceCodeHash string) string {
+	return fmt.Sprintf("kubesphere:oauth:devicecode:%s", deviceCodeHash)
+}
+
+func deviceCodeClaimKey(deviceCodeHash string) string {
+	return fmt.Sprintf("kubesphere:oauth:devicecode:%s:claimed", deviceCodeHash)
+}
+
+func deviceCodeCompleteKey(deviceCodeHash string) string {
+	return fmt.Sprintf("kubesphere:oauth:devicecode:%s:completed", deviceCodeHash)
+}
+
+func devicePollKey(deviceCodeHash string) string {
+	return fmt.Sprintf("kubesphere:oauth:devicecode:%s:polled", deviceCodeHash)
+}
+
+func userCodeKey(userCode string) string {
+	return fmt.Sprintf("kubesphere:oauth:usercode:%s", userCode)
+}
@@ -0,0 +1,145 @@
+/*
+Copyright 2022 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"strings"
+	"sync"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+
+	"kubesphere.io/kubesphere/pkg/simple/client/cache"
+)
+
+func Test_deviceAuthorizer(t *testing.T) {
+	cacheClient, _ := cache.NewInMemoryCache(nil, nil)
+	authorizer := NewDeviceAuthorizer(cacheClient)
+
+	deviceCode, authorization, err := authorizer.Authorize("kubesphere", []string{"openid"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(authorization.UserCode) != userCodeLength+1 || authorization.Status != DeviceAuthorizationPending {
+		t.Fatalf("unexpected authorization %v", authorization)
+	}
+
+	if _, err = authorizer.Poll("kubesphere", deviceCode); err != DeviceAuthorizationPendingError {
+		t.Errorf("Poll() error = %v, want %v", err, DeviceAuthorizationPendingError)
+	}
+	if _, err = authorizer.Poll("kubesphere", deviceCode); err != DeviceAuthorizationSlowDownError {
+		t.Errorf("Poll() error = %v, want %v", err, DeviceAuthorizationSlowDownError)
+	}
+	if _, err = authorizer.Poll("another", deviceCode); err != DeviceCodeClientMismatchError {
+		t.Errorf("Poll() error = %v, want %v", err, DeviceCodeClientMismatchError)
+	}
+
+	// the user code is case-insensitive and the dash is optional
+	userCode := strings.ToLower(strings.Replace(authorization.UserCode, "-", "", 1))
+	if _, err = authorizer.DescribeByUserCode(userCode); err != nil {
+		t.Fatal(err)
+	}
+	if err = authorizer.Approve(userCode, "admin"); err != nil {
+		t.Fatal(err)
+	}
+	if err = authorizer.Deny(userCode); !errors.IsNotFound(err) {
+		t.Errorf("the user code should only be verified once, error = %v", err)
+	}
+
+	// the device code is only exchanged once by the concurrent polls
+	var wg sync.WaitGroup
+	results := make(chan *DeviceAuthorization, 10)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			approved, err := authorizer.Poll("kubesphere", deviceCode)
+			if err != nil && err != DeviceCodeExpiredError {
+				t.Errorf("Poll() error = %v", err)
+			}
+			if approved != nil {
+				results <- approved
+			}
+		}()
+	}
+	wg.Wait()
+	close(results)
+	if len(results) != 1 {
+		t.Fatalf("the device code should only be exchanged once, exchanged %d times", len(results))
+	}
+	if approved := <-results; approved.Username != "admin" || approved.Status != DeviceAuthorizationApproved ||
+		len(approved.Scopes) != 1 || approved.Scopes[0] != "openid" {
+		t.Errorf("unexpected authorization %v", approved)
+	}
+	if _, err = authorizer.Poll("kubesphere", deviceCode); err != DeviceCodeExpiredError {
+		t.Errorf("the device code should only be exchanged once, error = %v", err)
+	}
+
+	deviceCode, authorization, err = authorizer.Authorize("kubesphere", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = authorizer.Deny(authorization.UserCode); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = authorizer.Poll("kubesphere", deviceCode); err != DeviceAuthorizationDeniedError {
+		t.Errorf("Poll() error = %v, want %v", err, DeviceAuthorizationDeniedError)
+	}
+
+	// only one of the concurrent approvals and denials succeeds
+	deviceCode, authorization, err = authorizer.Authorize("kubesphere", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	completed := make(chan DeviceAuthorizationStatus, 10)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(approve bool) {
+			defer wg.Done()
+			var err error
+			status := DeviceAuthorizationDenied
+			if approve {
+				status = DeviceAuthorizationApproved
+				err = authorizer.Approve(authorization.UserCode, "admin")
+			} else {
+				err = authorizer.Deny(authorization.UserCode)
+			}
+			if err == nil {
+				completed <- status
+			} else if !errors.IsConflict(err) && !errors.IsNotFound(err) {
+				t.Errorf("complete() error = %v", err)
+			}
+		}(i%2 == 0)
+	}
+	wg.Wait()
+	close(completed)
+	if len(completed) != 1 {
+		t.Fatalf("the authorization should only be completed once, completed %d times", len(completed))
+	}
+	status := <-completed
+	approved, err := authorizer.Poll("kubesphere", deviceCode)
+	switch status {
+	case DeviceAuthorizationApproved:
+		if err != nil || approved.Status != DeviceAuthorizationApproved {
+			t.Errorf("Poll() = %v, %v, want the approved authorization", approved, err)
+		}
+	case DeviceAuthorizationDenied:
+		if err != DeviceAuthorizationDeniedError {
+			t.Errorf("Poll() error = %v, want %v", err, DeviceAuthorizationDeniedError)
+		}
+	}
+}
@@ -0,0 +1,145 @@
+/*
+Copyright 2022 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"context"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/klog/v2"
+
+	iamv1alpha2 "kubesphere.io/api/iam/v1alpha2"
+	tenantv1alpha1 "kubesphere.io/api/tenant/v1alpha1"
+
+	"kubesphere.io/kubesphere/pkg/apiserver/authentication/identityprovider"
+	"kubesphere.io/kubesphere/pkg/apiserver/authentication/oauth"
+	kubesphere "kubesphere.io/kubesphere/pkg/client/clientset/versioned"
+	"kubesphere.io/kubesphere/pkg/utils/sliceutil"
+)
+
+// groupMapper reconciles the GroupBindings of the user with the groups returned by the identity provider.
+// Only the GroupBindings created by the groupMapper are managed, memberships granted manually are never touched.
+type groupMapper struct {
+	ksClient kubesphere.Interface
+}
+
+// syncGroups creates the GroupBindings for the mapped groups that the identity belongs to,
+// and deletes the GroupBindings that are no longer present in the identity.
+func (g *groupMapper) syncGroups(providerOptions *oauth.IdentityProviderOptions, username string, identity identityprovider.Identity) error {
+	if len(providerOptions.GroupMappings) == 0 {
+		return nil
+	}
+
+	expected := make(map[string]oauth.GroupMapping)
+	for _, mapping := range providerOptions.GroupMappings {
+		if sliceutil.HasString(identity.GetGroups(), mapping.Claim) {
+			expected[mapping.Group] = mapping
+		}
+	}
+
+	selector := labels.SelectorFromSet(labels.Set{
+		iamv1alpha2.UserReferenceLabel:    username,
+		iamv1alpha2.IdentifyProviderLabel: providerOptions.Name,
+	})
+	groupBindings, err := g.ksClient.IamV1alpha2().GroupBindings().List(context.Background(),
+		metav1.ListOptions{LabelSelector: selector.String()})
+	if err != nil {
+		klog.Error(err)
+		return err
+	}
+
+	for _, groupBinding := range groupBindings.Items {
+		if _, ok := expected[groupBinding.GroupRef.Name]; ok {
+			delete(expected, groupBinding.GroupRef.Name)
+			continue
+		}
+		err = g.ksClient.IamV1alpha2().GroupBindings().Delete(context.Background(), groupBinding.Name, metav1.DeleteOptions{})
+		if err != nil && !errors.IsNotFound(err) {
+			klog.Error(err)
+			return err
+		}
+	}
+
+	for _, mapping := range expected {
+		group, err := g.ensureGroup(mapping)
+		if err != nil {
+			klog.Error(err)
+			return err
+		}
+		// the group does not exist and can not be created
+		if group == nil {
+			continue
+		}
+		if _, err = g.ksClient.IamV1alpha2().GroupBindings().Create(context.Background(),
+			newMappedGroupBinding(providerOptions.Name, group, username), metav1.CreateOptions{}); err != nil {
+			klog.Error(err)
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ensureGroup returns the group of the mapping, the group will be created if the workspace is specified.
+func (g *groupMapper) ensureGroup(mapping oauth.GroupMapping) (*iamv1alpha2.Group, error) {
+	group, err := g.ksClient.IamV1alpha2().Groups().Get(context.Background(), mapping.Group, metav1.GetOptions{})
+	if err == nil {
+		return group, nil
+	}
+	if !errors.IsNotFound(err) {
+		return nil, err
+	}
+	if mapping.Workspace == "" {
+		klog.Warningf("group %s mapped from %s not found", mapping.Group, mapping.Claim)
+		return nil, nil
+	}
+	group = &iamv1alpha2.Group{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: mapping.Group,
+			Labels: map[string]string{
+				tenantv1alpha1.WorkspaceLabel: mapping.Workspace,
+			},
+		},
+	}
+	return g.ksClient.IamV1alpha2().Groups().Create(context.Background(), group, metav1.CreateOptions{})
+}
+
+func newMappedGroupBinding(idp string, group *iamv1alpha2.Group, username string) *iamv1alpha2.GroupBinding {
+	groupBinding := &iamv1alpha2.GroupBinding{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: fmt.Sprintf("%s-%s-", group.Name, username),
+			Labels: map[string]string{
+				iamv1alpha2.UserReferenceLabel:    username,
+				iamv1alpha2.GroupReferenceLabel:   group.Name,
+				iamv1alpha2.IdentifyProviderLabel: idp,
+			},
+		},
+		Users: []string{username},
+		GroupRef: iamv1alpha2.GroupRef{
+			APIGroup: iamv1alpha2.SchemeGroupVersion.Group,
+			Kind:     iamv1alpha2.ResourcePluralGroup,
+			Name:     group.Name,
+		},
+	}
+	if workspace := group.Labels[tenantv1alpha1.WorkspaceLabel]; workspace != "" {
+		groupBinding.Labels[tenantv1alpha1.WorkspaceLabel] = workspace
+	}
+	return groupBinding
+}
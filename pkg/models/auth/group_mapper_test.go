@@ -0,0 +1,80 @@
+/*
+Copyright 2022 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"context"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	iamv1alpha2 "kubesphere.io/api/iam/v1alpha2"
+	tenantv1alpha1 "kubesphere.io/api/tenant/v1alpha1"
+
+	"kubesphere.io/kubesphere/pkg/apiserver/authentication/oauth"
+	fakeks "kubesphere.io/kubesphere/pkg/client/clientset/versioned/fake"
+)
+
+func Test_groupMapper_syncGroups(t *testing.T) {
+	staleBinding := newMappedGroupBinding("fake", &iamv1alpha2.Group{ObjectMeta: metav1.ObjectMeta{Name: "operators"}}, "user1")
+	staleBinding.Name = "operators-user1-abcde"
+	manualBinding := &iamv1alpha2.GroupBinding{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   "testers-user1-abcde",
+			Labels: map[string]string{iamv1alpha2.UserReferenceLabel: "user1"},
+		},
+		Users:    []string{"user1"},
+		GroupRef: iamv1alpha2.GroupRef{Name: "testers"},
+	}
+	ksClient := fakeks.NewSimpleClientset(staleBinding, manualBinding)
+
+	providerOptions := &oauth.IdentityProviderOptions{
+		Name: "fake",
+		GroupMappings: []oauth.GroupMapping{
+			{Claim: "dev", Group: "developers", Workspace: "system-workspace"},
+			{Claim: "ops", Group: "operators"},
+			{Claim: "qa", Group: "testers"},
+		},
+	}
+	identity := fakeIdentity{UID: "100001", Username: "user1", Groups: []string{"dev", "unmapped"}}
+
+	mapper := &groupMapper{ksClient: ksClient}
+	if err := mapper.syncGroups(providerOptions, "user1", identity); err != nil {
+		t.Fatal(err)
+	}
+
+	group, err := ksClient.IamV1alpha2().Groups().Get(context.Background(), "developers", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if group.Labels[tenantv1alpha1.WorkspaceLabel] != "system-workspace" {
+		t.Errorf("group should be created in workspace system-workspace, got %v", group.Labels)
+	}
+
+	groupBindings, err := ksClient.IamV1alpha2().GroupBindings().List(context.Background(), metav1.ListOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := make(map[string]bool)
+	for _, groupBinding := range groupBindings.Items {
+		got[groupBinding.GroupRef.Name] = true
+	}
+	want := map[string]bool{"developers": true, "testers": true}
+	if len(got) != len(want) || !got["developers"] || !got["testers"] {
+		t.Errorf("syncGroups() got group bindings %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,303 @@
+/*
+Copyright 2022 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"fmt"
+	"time"
+
+	compbasemetrics "k8s.io/component-base/metrics"
+	"k8s.io/klog/v2"
+
+	"kubesphere.io/kubesphere/pkg/apiserver/authentication"
+	"kubesphere.io/kubesphere/pkg/simple/client/cache"
+	"kubesphere.io/kubesphere/pkg/utils/metrics"
+)
+
+const (
+	limiterUsername = "username"
+	limiterSourceIP = "source_ip"
+)
+
+var (
+	loginFailureCounter = compbasemetrics.NewCounterVec(
+		&compbasemetrics.CounterOpts{
+			Name:           "ks_server_login_failure_total",
+			Help:           "Counter of failed login attempts recorded by the login limiter broken out for each limiter.",
+			StabilityLevel: compbasemetrics.ALPHA,
+		},
+		[]string{"limiter"},
+	)
+	loginThrottledCounter = compbasemetrics.NewCounterVec(
+		&compbasemetrics.CounterOpts{
+			Name:           "ks_server_login_throttled_total",
+			Help:           "Counter of login attempts rejected by the login limiter broken out for each limiter.",
+			StabilityLevel: compbasemetrics.ALPHA,
+		},
+		[]string{"limiter"},
+	)
+	loginCaptchaRequiredCounter = compbasemetrics.NewCounter(
+		&compbasemetrics.CounterOpts{
+			Name:           "ks_server_login_captcha_required_total",
+			Help:           "Counter of login responses signaling the client to present a CAPTCHA.",
+			StabilityLevel: compbasemetrics.ALPHA,
+		},
+	)
+)
+
+func init() {
+	metrics.MustRegister(loginFailureCounter, loginThrottledCounter, loginCaptchaRequiredCounter)
+}
+
+// LoginLimit is the restriction on the next login attempt.
+type LoginLimit struct {
+	// RetryAfter is how long the client must wait before the next attempt, 0 means the attempt is allowed.
+	RetryAfter time.Duration
+	// CaptchaRequired signals the client to present a CAPTCHA.
+	CaptchaRequired bool
+}
+
+// LoginLimiter throttles the failed login attempts with the counters keyed on the username and the source IP.
+type LoginLimiter interface {
+	// Attempt checks and records the login attempt of the username from the source IP in one step,
+	// so that the concurrent attempts can't pass the check before their failures are recorded.
+	// The attempt is counted as a failure unless RecordSuccess is called, and it should be rejected
+	// with LoginThrottledError if the RetryAfter of the returned limit is not zero.
+	Attempt(username, sourceIP string) (*LoginLimit, error)
+	// RecordFailure returns the limit on the next attempt after the failed attempt.
+	RecordFailure(username, sourceIP string) (*LoginLimit, error)
+	// RecordSuccess resets the failed attempts of the username and withdraws the attempt from the source IP,
+	// the earlier failures from the source IP are kept so that a known account can't be used to reset them.
+	RecordSuccess(username, sourceIP string) error
+	// Withdraw removes the attempt which has neither failed nor succeeded yet, e.g. the password is correct
+	// but the second factor is not verified. The earlier failures are kept until RecordSuccess is called.
+	Withdraw(username, sourceIP string) error
+}
+
+type loginLimiter struct {
+	cache   cache.Interface
+	options *authentication.LoginLimiterOptions
+}
+
+// NewLoginLimiter returns the LoginLimiter, nil options means the login attempts are never throttled.
+func NewLoginLimiter(cache cache.Interface, options *authentication.LoginLimiterOptions) LoginLimiter {
+	return &loginLimiter{cache: cache, options: options}
+}
+
+func (l *loginLimiter) Attempt(username, sourceIP string) (*LoginLimit, error) {
+	limit := &LoginLimit{}
+	if l.options == nil {
+		return limit, nil
+	}
+	now := time.Now()
+	limiters := l.limiters(username, sourceIP)
+	failures := make([]int, 0, len(limiters))
+	for i, limiter := range limiters {
+		// the failures are counted in the sliding window ending with this attempt
+		attempts, err := l.cache.AddToWindow(limiter.key, now, l.options.Window)
+		if err != nil {
+			klog.Error(err)
+			l.withdraw(limiters[:i])
+			return nil, err
+		}
+		failures = append(failures, int(attempts)-1)
+	}
+	for i, limiter := range limiters {
+		retryAfter, err := l.retryAfter(limiter, now)
+		if err != nil {
+			l.withdraw(limiters)
+			return nil, err
+		}
+		l.throttle(limit, limiter, retryAfter, failures[i])
+	}
+	// the backoff after this attempt is claimed atomically, so that the concurrent attempts are rejected
+	for i, limiter := range limiters {
+		if limit.RetryAfter > 0 {
+			break
+		}
+		if failures[i]+1 < limiter.maxFailures {
+			continue
+		}
+		backoff := l.backoff(failures[i]+1, limiter.maxFailures)
+		claimed, err := l.cache.SetNX(loginBackoffKey(limiter.key), now.Add(backoff).Format(time.RFC3339Nano), backoff)
+		if err != nil {
+			klog.Error(err)
+			l.withdraw(limiters)
+			return nil, err
+		}
+		if !claimed {
+			l.throttle(limit, limiter, backoff, failures[i])
+		}
+	}
+	// the allowed attempt is not responded with the limit
+	if limit.RetryAfter == 0 {
+		return &LoginLimit{}, nil
+	}
+	// the rejected attempt is not counted as a failure
+	l.withdraw(limiters)
+	if limit.CaptchaRequired {
+		loginCaptchaRequiredCounter.Inc()
+	}
+	return limit, nil
+}
+
+// throttle merges the restriction of the limiter into the limit
+func (l *loginLimiter) throttle(limit *LoginLimit, limiter limiter, retryAfter time.Duration, failures int) {
+	if l.captchaRequired(failures) {
+		limit.CaptchaRequired = true
+	}
+	if retryAfter == 0 {
+		return
+	}
+	loginThrottledCounter.WithLabelValues(limiter.name).Inc()
+	if retryAfter > limit.RetryAfter {
+		limit.RetryAfter = retryAfter
+	}
+}
+
+func (l *loginLimiter) RecordFailure(username, sourceIP string) (*LoginLimit, error) {
+	limit := &LoginLimit{}
+	if l.options == nil {
+		return limit, nil
+	}
+	now := time.Now()
+	for _, limiter := range l.limiters(username, sourceIP) {
+		loginFailureCounter.WithLabelValues(limiter.name).Inc()
+		failures, err := l.failures(limiter.key, now)
+		if err != nil {
+			return nil, err
+		}
+		retryAfter, err := l.retryAfter(limiter, now)
+		if err != nil {
+			return nil, err
+		}
+		if retryAfter > limit.RetryAfter {
+			limit.RetryAfter = retryAfter
+		}
+		if l.captchaRequired(failures) {
+			limit.CaptchaRequired = true
+		}
+	}
+	if limit.CaptchaRequired {
+		loginCaptchaRequiredCounter.Inc()
+	}
+	return limit, nil
+}
+
+func (l *loginLimiter) RecordSuccess(username, sourceIP string) error {
+	if l.options == nil {
+		return nil
+	}
+	usernameKey := loginFailuresKey(limiterUsername, username)
+	if err := l.cache.Del(usernameKey, loginBackoffKey(usernameKey)); err != nil {
+		klog.Error(err)
+		return err
+	}
+	if sourceIP != "" && l.options.SourceIPMaxFailures > 0 {
+		l.withdraw([]limiter{{name: limiterSourceIP, key: loginFailuresKey(limiterSourceIP, sourceIP)}})
+	}
+	return nil
+}
+
+func (l *loginLimiter) Withdraw(username, sourceIP string) error {
+	if l.options == nil {
+		return nil
+	}
+	l.withdraw(l.limiters(username, sourceIP))
+	return nil
+}
+
+type limiter struct {
+	name        string
+	key         string
+	maxFailures int
+}
+
+func (l *loginLimiter) limiters(username, sourceIP string) []limiter {
+	limiters := make([]limiter, 0, 2)
+	if username != "" && l.options.UsernameMaxFailures > 0 {
+		limiters = append(limiters, limiter{name: limiterUsername, key: loginFailuresKey(limiterUsername, username), maxFailures: l.options.UsernameMaxFailures})
+	}
+	if sourceIP != "" && l.options.SourceIPMaxFailures > 0 {
+		limiters = append(limiters, limiter{name: limiterSourceIP, key: loginFailuresKey(limiterSourceIP, sourceIP), maxFailures: l.options.SourceIPMaxFailures})
+	}
+	return limiters
+}
+
+// withdraw removes the recorded attempt from the windows
+func (l *loginLimiter) withdraw(limiters []limiter) {
+	for _, limiter := range limiters {
+		if err := l.cache.RemoveFromWindow(limiter.key); err != nil {
+			klog.Error(err)
+		}
+	}
+}
+
+// failures returns the number of the failed attempts in the window
+func (l *loginLimiter) failures(key string, now time.Time) (int, error) {
+	failures, err := l.cache.CountWindow(key, now, l.options.Window)
+	if err != nil {
+		klog.Error(err)
+		return 0, err
+	}
+	return int(failures), nil
+}
+
+// retryAfter returns the remaining backoff of the limiter
+func (l *loginLimiter) retryAfter(limiter limiter, now time.Time) (time.Duration, error) {
+	data, err := l.cache.Get(loginBackoffKey(limiter.key))
+	if err != nil {
+		if err == cache.ErrNoSuchKey {
+			return 0, nil
+		}
+		klog.Error(err)
+		return 0, err
+	}
+	until, err := time.Parse(time.RFC3339Nano, data)
+	if err != nil {
+		klog.Warningf("invalid login backoff %s: %v", limiter.key, err)
+		return 0, nil
+	}
+	if retryAfter := until.Sub(now); retryAfter > 0 {
+		return retryAfter, nil
+	}
+	return 0, nil
+}
+
+// backoff returns the backoff after the failures, the backoff starts once the failures
+// reach the limit and doubles with every further failure, up to the MaxBackoff
+func (l *loginLimiter) backoff(failures, maxFailures int) time.Duration {
+	backoff := l.options.MaxBackoff
+	if exponent := failures - maxFailures; exponent < 32 {
+		if delay := l.options.BaseBackoff * time.Duration(1<<exponent); delay > 0 && delay < backoff {
+			backoff = delay
+		}
+	}
+	return backoff
+}
+
+func (l *loginLimiter) captchaRequired(failures int) bool {
+	return l.options.CaptchaThreshold > 0 && failures >= l.options.CaptchaThreshold
+}
+
+func loginFailuresKey(limiter, value string) string {
+	return fmt.Sprintf("kubesphere:login:failures:%s:%s", limiter, value)
+}
+
+func loginBackoffKey(failuresKey string) string {
+	return failuresKey + ":backoff"
+}
@@ -0,0 +1,199 @@
+/*
+Copyright 2022 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"kubesphere.io/kubesphere/pkg/apiserver/authentication"
+	"kubesphere.io/kubesphere/pkg/simple/client/cache"
+)
+
+func newTestLoginLimiter(t *testing.T) (LoginLimiter, cache.Interface) {
+	cacheClient, err := cache.NewInMemoryCache(nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	options := authentication.NewLoginLimiterOptions()
+	options.UsernameMaxFailures = 3
+	options.SourceIPMaxFailures = 5
+	options.CaptchaThreshold = 2
+	options.BaseBackoff = time.Minute
+	options.MaxBackoff = 3 * time.Minute
+	return NewLoginLimiter(cacheClient, options), cacheClient
+}
+
+func Test_loginLimiter_Username(t *testing.T) {
+	limiter, cacheClient := newTestLoginLimiter(t)
+
+	tests := []struct {
+		expectedRetryAfter time.Duration
+		captchaRequired    bool
+	}{
+		{expectedRetryAfter: 0, captchaRequired: false},
+		{expectedRetryAfter: 0, captchaRequired: true},
+		{expectedRetryAfter: time.Minute, captchaRequired: true},
+		{expectedRetryAfter: 2 * time.Minute, captchaRequired: true},
+		// capped by the MaxBackoff
+		{expectedRetryAfter: 3 * time.Minute, captchaRequired: true},
+	}
+	for i, tt := range tests {
+		// a different source IP every time, so that only the username limiter takes effect
+		sourceIP := fmt.Sprintf("10.0.0.%d", i+1)
+		// the backoff elapses
+		if err := cacheClient.Del(loginBackoffKey(loginFailuresKey(limiterUsername, "admin"))); err != nil {
+			t.Fatal(err)
+		}
+		if limit, err := limiter.Attempt("admin", sourceIP); err != nil {
+			t.Fatal(err)
+		} else if limit.RetryAfter != 0 {
+			t.Fatalf("attempt %d: expected the attempt to be allowed, got %+v", i+1, limit)
+		}
+		limit, err := limiter.RecordFailure("admin", sourceIP)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !roughlyEqual(limit.RetryAfter, tt.expectedRetryAfter) || limit.CaptchaRequired != tt.captchaRequired {
+			t.Errorf("failure %d: expected retry after %s and captcha required %v, got %+v", i+1, tt.expectedRetryAfter, tt.captchaRequired, limit)
+		}
+	}
+
+	limit, err := limiter.Attempt("admin", "10.0.1.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !roughlyEqual(limit.RetryAfter, 3*time.Minute) {
+		t.Errorf("expected retry after 3m, got %s", limit.RetryAfter)
+	}
+	limit, err = limiter.Attempt("user1", "10.0.1.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if limit.RetryAfter != 0 || limit.CaptchaRequired {
+		t.Errorf("other users should not be limited, got %+v", limit)
+	}
+	if err = limiter.RecordSuccess("user1", "10.0.1.1"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = limiter.RecordSuccess("admin", "10.0.1.1"); err != nil {
+		t.Fatal(err)
+	}
+	if limit, err = limiter.Attempt("admin", "10.0.1.1"); err != nil {
+		t.Fatal(err)
+	} else if limit.RetryAfter != 0 {
+		t.Errorf("the limit should be reset after a successful login, got %+v", limit)
+	}
+}
+
+func Test_loginLimiter_SourceIP(t *testing.T) {
+	limiter, _ := newTestLoginLimiter(t)
+	// password spraying, one attempt for each username
+	for _, username := range []string{"user1", "user2", "user3", "user4", "user5"} {
+		if limit, err := limiter.Attempt(username, "10.0.0.1"); err != nil {
+			t.Fatal(err)
+		} else if limit.RetryAfter != 0 {
+			t.Fatalf("expected the attempt of %s to be allowed, got %+v", username, limit)
+		}
+		if _, err := limiter.RecordFailure(username, "10.0.0.1"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	limit, err := limiter.Attempt("user6", "10.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !roughlyEqual(limit.RetryAfter, time.Minute) || !limit.CaptchaRequired {
+		t.Errorf("expected the source IP to be limited, got %+v", limit)
+	}
+	// the successful login of a known account doesn't reset the limit of the source IP
+	if err = limiter.RecordSuccess("user1", "10.0.0.1"); err != nil {
+		t.Fatal(err)
+	}
+	if limit, err = limiter.Attempt("user6", "10.0.0.1"); err != nil {
+		t.Fatal(err)
+	} else if limit.RetryAfter == 0 {
+		t.Errorf("expected the source IP to be limited")
+	}
+	if limit, err = limiter.Attempt("user6", "10.0.0.2"); err != nil {
+		t.Fatal(err)
+	} else if limit.RetryAfter != 0 || limit.CaptchaRequired {
+		t.Errorf("other source IPs should not be limited, got %+v", limit)
+	}
+}
+
+func Test_loginLimiter_ConcurrentAttempts(t *testing.T) {
+	limiter, _ := newTestLoginLimiter(t)
+	// the attempts are counted before the passwords are verified
+	var wg sync.WaitGroup
+	var allowed int32
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			limit, err := limiter.Attempt("admin", "")
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if limit.RetryAfter == 0 {
+				atomic.AddInt32(&allowed, 1)
+			}
+		}()
+	}
+	wg.Wait()
+	// the attempts before the limit, and the one which claims the backoff
+	if allowed != 3 {
+		t.Errorf("expected 3 attempts to be allowed, got %d", allowed)
+	}
+}
+
+func Test_loginLimiter_Window(t *testing.T) {
+	limiter, cacheClient := newTestLoginLimiter(t)
+	window := authentication.NewLoginLimiterOptions().Window
+	key := loginFailuresKey(limiterUsername, "admin")
+	now := time.Now()
+	// the failures slide out of the window one by one, instead of being reset at once
+	for _, at := range []time.Time{now.Add(-window), now.Add(-window + time.Minute), now.Add(-time.Minute)} {
+		if _, err := cacheClient.AddToWindow(key, at, window); err != nil {
+			t.Fatal(err)
+		}
+	}
+	limit, err := limiter.Attempt("admin", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if limit.RetryAfter != 0 {
+		t.Errorf("expected the attempt to be allowed, got %+v", limit)
+	}
+	limit, err = limiter.RecordFailure("admin", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the first failure is out of the window, the other two and this one reach the limit
+	if !roughlyEqual(limit.RetryAfter, time.Minute) || !limit.CaptchaRequired {
+		t.Errorf("expected the failures in the window to be limited, got %+v", limit)
+	}
+}
+
+func roughlyEqual(actual, expected time.Duration) bool {
+	return actual <= expected && actual > expected-time.Second
+}
@@ -31,7 +31,8 @@ import (
 )
 
 type LoginRecorder interface {
-	RecordLogin(username string, loginType iamv1alpha2.LoginType, provider string, sourceIP string, userAgent string, authErr error) error
+	// RecordLogin returns the name of the login record, or empty if the user does not exist
+	RecordLogin(username string, loginType iamv1alpha2.LoginType, provider string, sourceIP string, userAgent string, multiFactor bool, authErr error) (string, error)
 }
 
 type loginRecorder struct {
@@ -47,16 +48,16 @@ func NewLoginRecorder(ksClient kubesphere.Interface, userLister iamv1alpha2liste
 }
 
 // RecordLogin Create v1alpha2.LoginRecord for existing accounts
-func (l *loginRecorder) RecordLogin(username string, loginType iamv1alpha2.LoginType, provider, sourceIP, userAgent string, authErr error) error {
+func (l *loginRecorder) RecordLogin(username string, loginType iamv1alpha2.LoginType, provider, sourceIP, userAgent string, multiFactor bool, authErr error) (string, error) {
 	// only for existing accounts, solve the problem of huge entries
 	user, err := l.userGetter.findUser(username)
 	if err != nil {
 		// ignore not found error
 		if errors.IsNotFound(err) {
-			return nil
+			return "", nil
 		}
 		klog.Error(err)
-		return err
+		return "", err
 	}
 	loginEntry := &iamv1alpha2.LoginRecord{
 		ObjectMeta: metav1.ObjectMeta{
@@ -66,12 +67,13 @@ func (l *loginRecorder) RecordLogin(username string, loginType iamv1alpha2.Login
 			},
 		},
 		Spec: iamv1alpha2.LoginRecordSpec{
-			Type:      loginType,
-			Provider:  provider,
-			Success:   true,
-			Reason:    iamv1alpha2.AuthenticatedSuccessfully,
-			SourceIP:  sourceIP,
-			UserAgent: userAgent,
+			Type:        loginType,
+			Provider:    provider,
+			Success:     true,
+			Reason:      iamv1alpha2.AuthenticatedSuccessfully,
+			SourceIP:    sourceIP,
+			UserAgent:   userAgent,
+			MultiFactor: multiFactor,
 		},
 	}
 
@@ -80,10 +82,10 @@ func (l *loginRecorder) RecordLogin(username string, loginType iamv1alpha2.Login
 		loginEntry.Spec.Reason = authErr.Error()
 	}
 
-	_, err = l.ksClient.IamV1alpha2().LoginRecords().Create(context.Background(), loginEntry, metav1.CreateOptions{})
+	created, err := l.ksClient.IamV1alpha2().LoginRecords().Create(context.Background(), loginEntry, metav1.CreateOptions{})
 	if err != nil {
 		klog.Error(err)
-		return err
+		return "", err
 	}
-	return nil
+	return created.Name, nil
 }
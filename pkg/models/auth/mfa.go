@@ -0,0 +1,341 @@
+/*
+Copyright 2022 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/klog/v2"
+
+	iamv1alpha2 "kubesphere.io/api/iam/v1alpha2"
+
+	"kubesphere.io/kubesphere/pkg/apiserver/authentication"
+	"kubesphere.io/kubesphere/pkg/apiserver/authentication/totp"
+	kubesphere "kubesphere.io/kubesphere/pkg/client/clientset/versioned"
+	iamv1alpha2listers "kubesphere.io/kubesphere/pkg/client/listers/iam/v1alpha2"
+	"kubesphere.io/kubesphere/pkg/simple/client/cache"
+	"kubesphere.io/kubesphere/pkg/utils/sliceutil"
+)
+
+const (
+	recoveryCodeCount  = 10
+	recoveryCodeLength = 10
+	defaultMFAIssuer   = "KubeSphere"
+)
+
+// MultiFactorEnrollment contains the credentials that must be shown to the user only once when enrolling.
+type MultiFactorEnrollment struct {
+	Secret        string   `json:"secret" description:"base32 encoded TOTP secret"`
+	KeyURI        string   `json:"keyURI" description:"otpauth URI of the TOTP secret, can be rendered as a QR code"`
+	RecoveryCodes []string `json:"recoveryCodes" description:"single-use codes which can be used when the authenticator app is unavailable"`
+}
+
+// MultiFactorAuthenticator manages the TOTP enrollment of kubesphere accounts and verifies the passcodes.
+type MultiFactorAuthenticator interface {
+	// Enroll generates a new TOTP secret and recovery codes for the user, the enrollment takes effect
+	// after the first passcode is verified. An enabled enrollment must be disabled before enrolling again.
+	Enroll(username string) (*MultiFactorEnrollment, error)
+	// Authenticate verifies the TOTP passcode or one of the recovery codes, each of them can only be used once.
+	Authenticate(username, passcode string) error
+	// Disable removes the TOTP secret and recovery codes of the user.
+	Disable(username string) error
+}
+
+type multiFactorAuthenticator struct {
+	ksClient kubesphere.Interface
+	cache    cache.Interface
+	options  *authentication.Options
+}
+
+func NewMultiFactorAuthenticator(ksClient kubesphere.Interface, cache cache.Interface, options *authentication.Options) MultiFactorAuthenticator {
+	return &multiFactorAuthenticator{
+		ksClient: ksClient,
+		cache:    cache,
+		options:  options,
+	}
+}
+
+func (m *multiFactorAuthenticator) Enroll(username string) (*MultiFactorEnrollment, error) {
+	user, err := m.ksClient.IamV1alpha2().Users().Get(context.Background(), username, metav1.GetOptions{})
+	if err != nil {
+		klog.Error(err)
+		return nil, err
+	}
+	if user.Annotations[iamv1alpha2.TOTPEnabledAnnotation] == "true" {
+		return nil, errors.NewConflict(iamv1alpha2.Resource(iamv1alpha2.ResourcesSingularUser), username,
+			fmt.Errorf("multi-factor authentication is already enabled"))
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		klog.Error(err)
+		return nil, err
+	}
+	encrypted, err := encryptSecret(m.encryptionKey(), secret)
+	if err != nil {
+		klog.Error(err)
+		return nil, err
+	}
+	recoveryCodes, hashedRecoveryCodes, err := generateRecoveryCodes()
+	if err != nil {
+		klog.Error(err)
+		return nil, err
+	}
+
+	user = user.DeepCopy()
+	if user.Annotations == nil {
+		user.Annotations = make(map[string]string)
+	}
+	user.Annotations[iamv1alpha2.TOTPSecretAnnotation] = encrypted
+	user.Annotations[iamv1alpha2.TOTPRecoveryCodesAnnotation] = hashedRecoveryCodes
+	delete(user.Annotations, iamv1alpha2.TOTPEnabledAnnotation)
+	if _, err = m.ksClient.IamV1alpha2().Users().Update(context.Background(), user, metav1.UpdateOptions{}); err != nil {
+		klog.Error(err)
+		return nil, err
+	}
+
+	return &MultiFactorEnrollment{
+		Secret:        secret,
+		KeyURI:        totp.KeyURI(m.issuer(), user.Name, secret),
+		RecoveryCodes: recoveryCodes,
+	}, nil
+}
+
+func (m *multiFactorAuthenticator) Authenticate(username, passcode string) error {
+	user, err := m.ksClient.IamV1alpha2().Users().Get(context.Background(), username, metav1.GetOptions{})
+	if err != nil {
+		klog.Error(err)
+		return err
+	}
+	encrypted := user.Annotations[iamv1alpha2.TOTPSecretAnnotation]
+	if encrypted == "" {
+		return MultiFactorEnrollmentRequiredError
+	}
+	secret, err := decryptSecret(m.encryptionKey(), encrypted)
+	if err != nil {
+		klog.Error(err)
+		return err
+	}
+
+	enabled := user.Annotations[iamv1alpha2.TOTPEnabledAnnotation] == "true"
+	counter, ok := totp.Validate(passcode, secret, time.Now())
+	if !ok {
+		// recovery codes are available after the enrollment is confirmed
+		if enabled {
+			return m.useRecoveryCode(user, passcode)
+		}
+		return IncorrectPasscodeError
+	}
+
+	// the passcode must not be accepted again until it is expired, it is claimed atomically
+	// so that only one of the concurrent requests using the same passcode succeeds
+	key := fmt.Sprintf("kubesphere:user:%s:totp:%d", username, counter)
+	claimed, err := m.cache.SetNX(key, passcode, time.Duration(2*totp.Skew+1)*totp.Period*time.Second)
+	if err != nil {
+		klog.Error(err)
+		return err
+	}
+	if !claimed {
+		return IncorrectPasscodeError
+	}
+
+	// confirm the enrollment
+	if !enabled {
+		user = user.DeepCopy()
+		user.Annotations[iamv1alpha2.TOTPEnabledAnnotation] = "true"
+		if _, err = m.ksClient.IamV1alpha2().Users().Update(context.Background(), user, metav1.UpdateOptions{}); err != nil {
+			klog.Error(err)
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *multiFactorAuthenticator) Disable(username string) error {
+	user, err := m.ksClient.IamV1alpha2().Users().Get(context.Background(), username, metav1.GetOptions{})
+	if err != nil {
+		klog.Error(err)
+		return err
+	}
+	user = user.DeepCopy()
+	delete(user.Annotations, iamv1alpha2.TOTPSecretAnnotation)
+	delete(user.Annotations, iamv1alpha2.TOTPEnabledAnnotation)
+	delete(user.Annotations, iamv1alpha2.TOTPRecoveryCodesAnnotation)
+	if _, err = m.ksClient.IamV1alpha2().Users().Update(context.Background(), user, metav1.UpdateOptions{}); err != nil {
+		klog.Error(err)
+		return err
+	}
+	return nil
+}
+
+// useRecoveryCode verifies the recovery code and removes it from the user,
+// concurrent requests using the same code will be rejected by the resource version.
+func (m *multiFactorAuthenticator) useRecoveryCode(user *iamv1alpha2.User, recoveryCode string) error {
+	var hashedRecoveryCodes []string
+	if err := json.Unmarshal([]byte(user.Annotations[iamv1alpha2.TOTPRecoveryCodesAnnotation]), &hashedRecoveryCodes); err != nil {
+		klog.Error(err)
+		return IncorrectPasscodeError
+	}
+	recoveryCode = strings.ToLower(strings.TrimSpace(recoveryCode))
+	for i, hashed := range hashedRecoveryCodes {
+		if bcrypt.CompareHashAndPassword([]byte(hashed), []byte(recoveryCode)) != nil {
+			continue
+		}
+		remaining, err := json.Marshal(append(hashedRecoveryCodes[:i:i], hashedRecoveryCodes[i+1:]...))
+		if err != nil {
+			klog.Error(err)
+			return err
+		}
+		user = user.DeepCopy()
+		user.Annotations[iamv1alpha2.TOTPRecoveryCodesAnnotation] = string(remaining)
+		if _, err = m.ksClient.IamV1alpha2().Users().Update(context.Background(), user, metav1.UpdateOptions{}); err != nil {
+			klog.Error(err)
+			return err
+		}
+		klog.Infof("recovery code used by user %s, %d remaining", user.Name, len(hashedRecoveryCodes)-1)
+		return nil
+	}
+	return IncorrectPasscodeError
+}
+
+func (m *multiFactorAuthenticator) issuer() string {
+	if m.options.MultiFactorAuthOptions != nil && m.options.MultiFactorAuthOptions.Issuer != "" {
+		return m.options.MultiFactorAuthOptions.Issuer
+	}
+	return defaultMFAIssuer
+}
+
+// encryptionKey returns the key used to encrypt the TOTP secrets, the secrets must be
+// enrolled again after the JWT secret is changed.
+func (m *multiFactorAuthenticator) encryptionKey() []byte {
+	key := sha256.Sum256([]byte(m.options.JwtSecret))
+	return key[:]
+}
+
+// multiFactorPolicy decides whether the second factor is required for the kubesphere account.
+type multiFactorPolicy struct {
+	globalRoleBindingLister iamv1alpha2listers.GlobalRoleBindingLister
+	options                 *authentication.Options
+}
+
+// verify returns MultiFactorRequiredError if the user has enabled the multi-factor authentication,
+// or MultiFactorEnrollmentRequiredError if the user is bound to the GlobalRoles that require it.
+func (p *multiFactorPolicy) verify(user *iamv1alpha2.User) error {
+	if user.Annotations[iamv1alpha2.TOTPEnabledAnnotation] == "true" {
+		return MultiFactorRequiredError
+	}
+	if p.options.MultiFactorAuthOptions == nil || len(p.options.MultiFactorAuthOptions.RequiredGlobalRoles) == 0 {
+		return nil
+	}
+	globalRoleBindings, err := p.globalRoleBindingLister.List(labels.Everything())
+	if err != nil {
+		klog.Error(err)
+		return err
+	}
+	for _, globalRoleBinding := range globalRoleBindings {
+		if !sliceutil.HasString(p.options.MultiFactorAuthOptions.RequiredGlobalRoles, globalRoleBinding.RoleRef.Name) {
+			continue
+		}
+		for _, subject := range globalRoleBinding.Subjects {
+			if (subject.Kind == rbacv1.UserKind && subject.Name == user.Name) ||
+				(subject.Kind == rbacv1.GroupKind && sliceutil.HasString(user.Spec.Groups, subject.Name)) {
+				return MultiFactorEnrollmentRequiredError
+			}
+		}
+	}
+	return nil
+}
+
+// generateRecoveryCodes returns the recovery codes and the JSON encoded bcrypt hashes of them.
+func generateRecoveryCodes() ([]string, string, error) {
+	encoding := base32.StdEncoding.WithPadding(base32.NoPadding)
+	recoveryCodes := make([]string, 0, recoveryCodeCount)
+	hashedRecoveryCodes := make([]string, 0, recoveryCodeCount)
+	for i := 0; i < recoveryCodeCount; i++ {
+		random := make([]byte, recoveryCodeLength)
+		if _, err := rand.Read(random); err != nil {
+			return nil, "", err
+		}
+		code := strings.ToLower(encoding.EncodeToString(random)[:recoveryCodeLength])
+		hashed, err := bcrypt.GenerateFromPassword([]byte(code), bcrypt.DefaultCost)
+		if err != nil {
+			return nil, "", err
+		}
+		recoveryCodes = append(recoveryCodes, code)
+		hashedRecoveryCodes = append(hashedRecoveryCodes, string(hashed))
+	}
+	data, err := json.Marshal(hashedRecoveryCodes)
+	if err != nil {
+		return nil, "", err
+	}
+	return recoveryCodes, string(data), nil
+}
+
+// encryptSecret encrypts the secret with AES-GCM, the nonce is prepended to the ciphertext.
+func encryptSecret(key []byte, secret string) (string, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return "", err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err = rand.Read(nonce); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(gcm.Seal(nonce, nonce, []byte(secret), nil)), nil
+}
+
+func decryptSecret(key []byte, encrypted string) (string, error) {
+	data, err := base64.StdEncoding.DecodeString(encrypted)
+	if err != nil {
+		return "", err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return "", err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return "", err
+	}
+	if len(data) < gcm.NonceSize() {
+		return "", fmt.Errorf("malformed totp secret")
+	}
+	secret, err := gcm.Open(nil, data[:gcm.NonceSize()], data[gcm.NonceSize():], nil)
+	if err != nil {
+		return "", err
+	}
+	return string(secret), nil
+}
@@ -0,0 +1,191 @@
+/*
+Copyright 2022 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	iamv1alpha2 "kubesphere.io/api/iam/v1alpha2"
+
+	"kubesphere.io/kubesphere/pkg/apiserver/authentication"
+	"kubesphere.io/kubesphere/pkg/apiserver/authentication/totp"
+	fakeks "kubesphere.io/kubesphere/pkg/client/clientset/versioned/fake"
+	ksinformers "kubesphere.io/kubesphere/pkg/client/informers/externalversions"
+	"kubesphere.io/kubesphere/pkg/simple/client/cache"
+)
+
+func Test_multiFactorAuthenticator(t *testing.T) {
+	ksClient := fakeks.NewSimpleClientset(&iamv1alpha2.User{ObjectMeta: metav1.ObjectMeta{Name: "admin"}})
+	cacheClient, _ := cache.NewInMemoryCache(nil, nil)
+	options := authentication.NewOptions()
+	options.JwtSecret = "secret"
+	authenticator := NewMultiFactorAuthenticator(ksClient, cacheClient, options)
+
+	if err := authenticator.Authenticate("admin", "123456"); err != MultiFactorEnrollmentRequiredError {
+		t.Fatalf("Authenticate() error = %v, want %v", err, MultiFactorEnrollmentRequiredError)
+	}
+
+	enrollment, err := authenticator.Enroll("admin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	user := getUser(t, ksClient, "admin")
+	if user.Annotations[iamv1alpha2.TOTPSecretAnnotation] == enrollment.Secret {
+		t.Errorf("totp secret must be encrypted")
+	}
+	if user.Annotations[iamv1alpha2.TOTPEnabledAnnotation] != "" {
+		t.Errorf("enrollment should not take effect before the first passcode is verified")
+	}
+	// recovery codes are not available before the enrollment is confirmed
+	if err = authenticator.Authenticate("admin", enrollment.RecoveryCodes[0]); err != IncorrectPasscodeError {
+		t.Errorf("Authenticate() error = %v, want %v", err, IncorrectPasscodeError)
+	}
+
+	passcode, err := totp.GenerateCode(enrollment.Secret, totp.Counter(time.Now()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = authenticator.Authenticate("admin", passcode); err != nil {
+		t.Fatal(err)
+	}
+	if user = getUser(t, ksClient, "admin"); user.Annotations[iamv1alpha2.TOTPEnabledAnnotation] != "true" {
+		t.Errorf("enrollment should be confirmed")
+	}
+	if err = authenticator.Authenticate("admin", passcode); err != IncorrectPasscodeError {
+		t.Errorf("passcode should not be accepted twice, error = %v", err)
+	}
+
+	// only one of the concurrent requests using the same passcode succeeds
+	passcode, err = totp.GenerateCode(enrollment.Secret, totp.Counter(time.Now())+totp.Skew)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var wg sync.WaitGroup
+	var accepted int32
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if authenticator.Authenticate("admin", passcode) == nil {
+				atomic.AddInt32(&accepted, 1)
+			}
+		}()
+	}
+	wg.Wait()
+	if accepted != 1 {
+		t.Errorf("expected the passcode to be accepted once, got %d", accepted)
+	}
+
+	if err = authenticator.Authenticate("admin", enrollment.RecoveryCodes[1]); err != nil {
+		t.Fatal(err)
+	}
+	if err = authenticator.Authenticate("admin", enrollment.RecoveryCodes[1]); err != IncorrectPasscodeError {
+		t.Errorf("recovery code should not be accepted twice, error = %v", err)
+	}
+
+	if _, err = authenticator.Enroll("admin"); !errors.IsConflict(err) {
+		t.Errorf("Enroll() error = %v, want conflict", err)
+	}
+
+	if err = authenticator.Disable("admin"); err != nil {
+		t.Fatal(err)
+	}
+	if user = getUser(t, ksClient, "admin"); len(user.Annotations) != 0 {
+		t.Errorf("unexpected annotations %v", user.Annotations)
+	}
+}
+
+func Test_multiFactorPolicy_verify(t *testing.T) {
+	globalRoleBindings := []*iamv1alpha2.GlobalRoleBinding{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "admin-platform-admin"},
+			RoleRef:    rbacv1.RoleRef{Name: iamv1alpha2.PlatformAdmin},
+			Subjects:   []rbacv1.Subject{{Kind: rbacv1.UserKind, Name: "admin"}},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "operators-platform-admin"},
+			RoleRef:    rbacv1.RoleRef{Name: iamv1alpha2.PlatformAdmin},
+			Subjects:   []rbacv1.Subject{{Kind: rbacv1.GroupKind, Name: "operators"}},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "user1-platform-regular"},
+			RoleRef:    rbacv1.RoleRef{Name: "platform-regular"},
+			Subjects:   []rbacv1.Subject{{Kind: rbacv1.UserKind, Name: "user1"}},
+		},
+	}
+	ksInformerFactory := ksinformers.NewSharedInformerFactory(fakeks.NewSimpleClientset(), 0)
+	for _, globalRoleBinding := range globalRoleBindings {
+		if err := ksInformerFactory.Iam().V1alpha2().GlobalRoleBindings().Informer().GetIndexer().Add(globalRoleBinding); err != nil {
+			t.Fatal(err)
+		}
+	}
+	options := authentication.NewOptions()
+	options.MultiFactorAuthOptions.RequiredGlobalRoles = []string{iamv1alpha2.PlatformAdmin}
+	policy := &multiFactorPolicy{globalRoleBindingLister: ksInformerFactory.Iam().V1alpha2().GlobalRoleBindings().Lister(), options: options}
+
+	tests := []struct {
+		name string
+		user *iamv1alpha2.User
+		want error
+	}{
+		{
+			name: "bound to required role",
+			user: &iamv1alpha2.User{ObjectMeta: metav1.ObjectMeta{Name: "admin"}},
+			want: MultiFactorEnrollmentRequiredError,
+		},
+		{
+			name: "enrolled",
+			user: &iamv1alpha2.User{ObjectMeta: metav1.ObjectMeta{Name: "admin",
+				Annotations: map[string]string{iamv1alpha2.TOTPEnabledAnnotation: "true"}}},
+			want: MultiFactorRequiredError,
+		},
+		{
+			name: "group bound to required role",
+			user: &iamv1alpha2.User{ObjectMeta: metav1.ObjectMeta{Name: "user2"},
+				Spec: iamv1alpha2.UserSpec{Groups: []string{"operators"}}},
+			want: MultiFactorEnrollmentRequiredError,
+		},
+		{
+			name: "not required",
+			user: &iamv1alpha2.User{ObjectMeta: metav1.ObjectMeta{Name: "user1"}},
+			want: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := policy.verify(tt.user); err != tt.want {
+				t.Errorf("verify() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func getUser(t *testing.T, ksClient *fakeks.Clientset, name string) *iamv1alpha2.User {
+	user, err := ksClient.IamV1alpha2().Users().Get(context.Background(), name, metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return user
+}
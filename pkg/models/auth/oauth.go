@@ -20,7 +20,9 @@ package auth
 
 import (
 	"context"
+	"fmt"
 	"net/http"
+	"time"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
@@ -36,25 +38,94 @@ import (
 	"kubesphere.io/kubesphere/pkg/apiserver/authentication/identityprovider"
 	"kubesphere.io/kubesphere/pkg/apiserver/authentication/oauth"
 	iamv1alpha2listers "kubesphere.io/kubesphere/pkg/client/listers/iam/v1alpha2"
+	"kubesphere.io/kubesphere/pkg/simple/client/cache"
 )
 
+// samlRequestMaxAge is how long an AuthnRequest is outstanding, the user must sign in with the identity provider in time
+const samlRequestMaxAge = 10 * time.Minute
+
 type oauthAuthenticator struct {
-	ksClient   kubesphere.Interface
-	userGetter *userGetter
-	options    *authentication.Options
+	ksClient    kubesphere.Interface
+	userGetter  *userGetter
+	groupMapper *groupMapper
+	cache       cache.Interface
+	options     *authentication.Options
 }
 
 func NewOAuthAuthenticator(ksClient kubesphere.Interface,
 	userLister iamv1alpha2listers.UserLister,
+	cache cache.Interface,
 	options *authentication.Options) OAuthAuthenticator {
 	authenticator := &oauthAuthenticator{
-		ksClient:   ksClient,
-		userGetter: &userGetter{userLister: userLister},
-		options:    options,
+		ksClient:    ksClient,
+		userGetter:  &userGetter{userLister: userLister},
+		groupMapper: &groupMapper{ksClient: ksClient},
+		cache:       cache,
+		options:     options,
 	}
 	return authenticator
 }
 
+func (o *oauthAuthenticator) AuthnRequestURL(provider, relayState string) (string, error) {
+	providerOptions, err := o.options.OAuthOptions.IdentityProviderOptions(provider)
+	if err != nil {
+		klog.Error(err)
+		return "", err
+	}
+	oauthIdentityProvider, err := identityprovider.GetOAuthProvider(providerOptions.Name)
+	if err != nil {
+		klog.Error(err)
+		return "", err
+	}
+	samlProvider, ok := oauthIdentityProvider.(identityprovider.SAMLProvider)
+	if !ok {
+		return "", fmt.Errorf("identity provider %s is not a SAML identity provider", provider)
+	}
+	requestID, redirectURL, err := samlProvider.AuthnRequestURL(relayState)
+	if err != nil {
+		klog.Error(err)
+		return "", err
+	}
+	if err = o.cache.Set(samlRequestKey(providerOptions.Name, requestID), "", samlRequestMaxAge); err != nil {
+		klog.Error(err)
+		return "", err
+	}
+	return redirectURL, nil
+}
+
+// consumeSAMLAssertion accepts the assertion only once and in response to an outstanding AuthnRequest,
+// the AuthnRequest is consumed along with the assertion.
+func (o *oauthAuthenticator) consumeSAMLAssertion(provider string, assertion identityprovider.SAMLAssertion) error {
+	// the assertion is remembered until it's rejected as expired by the identity provider
+	ttl := time.Until(assertion.GetNotOnOrAfter())
+	if ttl < time.Second {
+		ttl = time.Second
+	}
+	consumed, err := o.cache.SetNX(samlAssertionKey(provider, assertion.GetAssertionID()), "", ttl)
+	if err != nil {
+		klog.Error(err)
+		return err
+	}
+	if !consumed {
+		return fmt.Errorf("saml: the assertion %s has already been consumed", assertion.GetAssertionID())
+	}
+
+	requestKey := samlRequestKey(provider, assertion.GetInResponseTo())
+	outstanding, err := o.cache.Exists(requestKey)
+	if err != nil {
+		klog.Error(err)
+		return err
+	}
+	if !outstanding {
+		return fmt.Errorf("saml: the assertion is not in response to an outstanding AuthnRequest")
+	}
+	if err = o.cache.Del(requestKey); err != nil {
+		klog.Error(err)
+		return err
+	}
+	return nil
+}
+
 func (o *oauthAuthenticator) Authenticate(_ context.Context, provider string, req *http.Request) (authuser.Info, string, error) {
 	providerOptions, err := o.options.OAuthOptions.IdentityProviderOptions(provider)
 	// identity provider not registered
@@ -72,6 +143,12 @@ func (o *oauthAuthenticator) Authenticate(_ context.Context, provider string, re
 		klog.Error(err)
 		return nil, "", err
 	}
+	if assertion, ok := authenticated.(identityprovider.SAMLAssertion); ok {
+		if err = o.consumeSAMLAssertion(providerOptions.Name, assertion); err != nil {
+			klog.Error(err)
+			return nil, "", err
+		}
+	}
 
 	user, err := o.userGetter.findMappedUser(providerOptions.Name, authenticated.GetUserID())
 	if user == nil && providerOptions.MappingMethod == oauth.MappingMethodLookup {
@@ -95,8 +172,19 @@ func (o *oauthAuthenticator) Authenticate(_ context.Context, provider string, re
 			// state not active
 			return nil, "", AccountIsNotActiveError
 		}
+		if err = o.groupMapper.syncGroups(providerOptions, user.GetName(), authenticated); err != nil {
+			return nil, "", err
+		}
 		return &authuser.DefaultInfo{Name: user.GetName()}, providerOptions.Name, nil
 	}
 
 	return nil, "", errors.NewNotFound(iamv1alpha2.Resource("user"), authenticated.GetUsername())
 }
+
+func samlRequestKey(provider, requestID string) string {
+	return fmt.Sprintf("kubesphere:saml:%s:request:%s", provider, requestID)
+}
+
+func samlAssertionKey(provider, assertionID string) string {
+	return fmt.Sprintf("kubesphere:saml:%s:assertion:%s", provider, assertionID)
+}
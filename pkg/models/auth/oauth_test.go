@@ -21,6 +21,7 @@ import (
 	"net/http"
 	"reflect"
 	"testing"
+	"time"
 
 	"kubesphere.io/kubesphere/pkg/server/options"
 
@@ -35,6 +36,7 @@ import (
 	"kubesphere.io/kubesphere/pkg/apiserver/authentication/oauth"
 	fakeks "kubesphere.io/kubesphere/pkg/client/clientset/versioned/fake"
 	ksinformers "kubesphere.io/kubesphere/pkg/client/informers/externalversions"
+	"kubesphere.io/kubesphere/pkg/simple/client/cache"
 )
 
 func Test_oauthAuthenticator_Authenticate(t *testing.T) {
@@ -101,6 +103,7 @@ func Test_oauthAuthenticator_Authenticate(t *testing.T) {
 			oauthAuthenticator: NewOAuthAuthenticator(
 				nil,
 				ksInformerFactory.Iam().V1alpha2().Users().Lister(),
+				nil,
 				oauthOptions,
 			),
 			args: args{
@@ -119,6 +122,7 @@ func Test_oauthAuthenticator_Authenticate(t *testing.T) {
 			oauthAuthenticator: NewOAuthAuthenticator(
 				nil,
 				ksInformerFactory.Iam().V1alpha2().Users().Lister(),
+				nil,
 				oauthOptions,
 			),
 			args: args{
@@ -135,6 +139,7 @@ func Test_oauthAuthenticator_Authenticate(t *testing.T) {
 			oauthAuthenticator: NewOAuthAuthenticator(
 				nil,
 				ksInformerFactory.Iam().V1alpha2().Users().Lister(),
+				nil,
 				oauthOptions,
 			),
 			args: args{
@@ -163,6 +168,54 @@ func Test_oauthAuthenticator_Authenticate(t *testing.T) {
 	}
 }
 
+func Test_oauthAuthenticator_SAMLReplay(t *testing.T) {
+	oauthOptions := &authentication.Options{
+		OAuthOptions: &oauth.Options{
+			IdentityProviders: []oauth.IdentityProviderOptions{
+				{
+					Name:          "fake-saml",
+					MappingMethod: "lookup",
+					Type:          "FakeSAMLIdentityProvider",
+				},
+			},
+		},
+	}
+	identityprovider.RegisterOAuthProvider(&fakeSAMLProviderFactory{})
+	if err := identityprovider.SetupWithOptions(oauthOptions.OAuthOptions.IdentityProviders); err != nil {
+		t.Fatal(err)
+	}
+
+	ksInformerFactory := ksinformers.NewSharedInformerFactory(fakeks.NewSimpleClientset(), 0)
+	if err := ksInformerFactory.Iam().V1alpha2().Users().Informer().GetIndexer().Add(newUser("user1", "100001", "fake-saml")); err != nil {
+		t.Fatal(err)
+	}
+	cacheClient, _ := cache.NewInMemoryCache(nil, nil)
+	authenticator := NewOAuthAuthenticator(nil, ksInformerFactory.Iam().V1alpha2().Users().Lister(), cacheClient, oauthOptions)
+
+	redirectURL, err := authenticator.AuthnRequestURL("fake-saml", "state")
+	if err != nil {
+		t.Fatal(err)
+	}
+	callback := func(assertionID, inResponseTo string) error {
+		req := must(http.NewRequest(http.MethodPost, fmt.Sprintf("https://ks-console.kubesphere.io/oauth/callback/fake-saml?assertion=%s&request=%s", assertionID, inResponseTo), nil))
+		_, _, err := authenticator.Authenticate(context.Background(), "fake-saml", req)
+		return err
+	}
+
+	if err = callback("assertion-1", "request-unknown"); err == nil {
+		t.Error("the response to an unknown AuthnRequest should be rejected")
+	}
+	if err = callback("assertion-2", redirectURL); err != nil {
+		t.Errorf("the response to the outstanding AuthnRequest should be accepted, got %v", err)
+	}
+	if err = callback("assertion-2", redirectURL); err == nil {
+		t.Error("the replayed assertion should be rejected")
+	}
+	if err = callback("assertion-3", redirectURL); err == nil {
+		t.Error("the second response to the consumed AuthnRequest should be rejected")
+	}
+}
+
 func must(r *http.Request, err error) *http.Request {
 	if err != nil {
 		panic(err)
@@ -194,9 +247,10 @@ type fakeProvider struct {
 }
 
 type fakeIdentity struct {
-	UID      string `json:"uid"`
-	Username string `json:"username"`
-	Email    string `json:"email"`
+	UID      string   `json:"uid"`
+	Username string   `json:"username"`
+	Email    string   `json:"email"`
+	Groups   []string `json:"groups"`
 }
 
 func (f fakeIdentity) GetUserID() string {
@@ -211,6 +265,10 @@ func (f fakeIdentity) GetEmail() string {
 	return f.Email
 }
 
+func (f fakeIdentity) GetGroups() []string {
+	return f.Groups
+}
+
 func (fakeProviderFactory) Type() string {
 	return "FakeIdentityProvider"
 }
@@ -230,3 +288,55 @@ func (f fakeProvider) IdentityExchangeCallback(req *http.Request) (identityprovi
 	}
 	return nil, fmt.Errorf("authorization failed")
 }
+
+type fakeSAMLProviderFactory struct {
+}
+
+// fakeSAMLProvider issues the AuthnRequests with sequential IDs, and the redirect URL is the request ID
+type fakeSAMLProvider struct {
+	requests int
+}
+
+type fakeSAMLAssertion struct {
+	fakeIdentity
+	assertionID  string
+	inResponseTo string
+}
+
+func (f fakeSAMLAssertion) GetAssertionID() string {
+	return f.assertionID
+}
+
+func (f fakeSAMLAssertion) GetInResponseTo() string {
+	return f.inResponseTo
+}
+
+func (f fakeSAMLAssertion) GetNotOnOrAfter() time.Time {
+	return time.Now().Add(time.Minute)
+}
+
+func (fakeSAMLProviderFactory) Type() string {
+	return "FakeSAMLIdentityProvider"
+}
+
+func (fakeSAMLProviderFactory) Create(options.DynamicOptions) (identityprovider.OAuthProvider, error) {
+	return &fakeSAMLProvider{}, nil
+}
+
+func (f *fakeSAMLProvider) IdentityExchangeCallback(req *http.Request) (identityprovider.Identity, error) {
+	return fakeSAMLAssertion{
+		fakeIdentity: fakeIdentity{UID: "100001", Username: "user1"},
+		assertionID:  req.URL.Query().Get("assertion"),
+		inResponseTo: req.URL.Query().Get("request"),
+	}, nil
+}
+
+func (f *fakeSAMLProvider) AuthnRequestURL(string) (string, string, error) {
+	f.requests++
+	id := fmt.Sprintf("request-%d", f.requests)
+	return id, id, nil
+}
+
+func (f *fakeSAMLProvider) ServiceProviderMetadata() ([]byte, error) {
+	return nil, nil
+}
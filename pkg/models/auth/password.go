@@ -37,18 +37,23 @@ import (
 )
 
 type passwordAuthenticator struct {
-	ksClient    kubesphere.Interface
-	userGetter  *userGetter
-	authOptions *authentication.Options
+	ksClient          kubesphere.Interface
+	userGetter        *userGetter
+	groupMapper       *groupMapper
+	multiFactorPolicy *multiFactorPolicy
+	authOptions       *authentication.Options
 }
 
 func NewPasswordAuthenticator(ksClient kubesphere.Interface,
 	userLister iamv1alpha2listers.UserLister,
+	globalRoleBindingLister iamv1alpha2listers.GlobalRoleBindingLister,
 	options *authentication.Options) PasswordAuthenticator {
 	passwordAuthenticator := &passwordAuthenticator{
-		ksClient:    ksClient,
-		userGetter:  &userGetter{userLister: userLister},
-		authOptions: options,
+		ksClient:          ksClient,
+		userGetter:        &userGetter{userLister: userLister},
+		groupMapper:       &groupMapper{ksClient: ksClient},
+		multiFactorPolicy: &multiFactorPolicy{globalRoleBindingLister: globalRoleBindingLister, options: options},
+		authOptions:       options,
 	}
 	return passwordAuthenticator
 }
@@ -76,7 +81,8 @@ func (p *passwordAuthenticator) authByKubeSphere(username, password string) (aut
 	}
 
 	// check user status
-	if user != nil && user.Status.State != iamv1alpha2.UserActive {
+	// the user whose password has expired is able to log in to change the password
+	if user != nil && user.Status.State != iamv1alpha2.UserActive && user.Status.State != iamv1alpha2.UserPasswordExpired {
 		if user.Status.State == iamv1alpha2.UserAuthLimitExceeded {
 			klog.Errorf("%s, username: %s", RateLimitExceededError, username)
 			return nil, "", RateLimitExceededError
@@ -103,6 +109,15 @@ func (p *passwordAuthenticator) authByKubeSphere(username, password string) (aut
 				iamv1alpha2.ExtraUninitialized: {uninitialized},
 			}
 		}
+		u.Extra = WithPasswordExpiredExtra(user, u.Extra)
+		// the second factor is required, the authenticated user will be returned along with the error
+		if err = p.multiFactorPolicy.verify(user); err != nil {
+			if err == MultiFactorRequiredError || err == MultiFactorEnrollmentRequiredError {
+				return u, "", err
+			}
+			klog.Error(err)
+			return nil, "", err
+		}
 		return u, "", nil
 	}
 
@@ -137,6 +152,9 @@ func (p *passwordAuthenticator) authByProvider(provider, username, password stri
 	}
 
 	if linkedAccount != nil {
+		if err = p.groupMapper.syncGroups(providerOptions, linkedAccount.Name, authenticated); err != nil {
+			return nil, "", err
+		}
 		return &authuser.DefaultInfo{Name: linkedAccount.Name}, provider, nil
 	}
 
@@ -150,6 +168,9 @@ func (p *passwordAuthenticator) authByProvider(provider, username, password stri
 			klog.Error(err)
 			return nil, "", err
 		}
+		if err = p.groupMapper.syncGroups(providerOptions, linkedAccount.Name, authenticated); err != nil {
+			return nil, "", err
+		}
 		return &authuser.DefaultInfo{Name: linkedAccount.Name}, provider, nil
 	}
 
@@ -0,0 +1,242 @@
+/*
+Copyright 2022 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+	"unicode"
+
+	"golang.org/x/crypto/bcrypt"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/klog/v2"
+
+	iamv1alpha2 "kubesphere.io/api/iam/v1alpha2"
+
+	"kubesphere.io/kubesphere/pkg/apiserver/authentication"
+)
+
+// PasswordPolicy enforces the requirements of the passwords of kubesphere accounts.
+type PasswordPolicy interface {
+	// Validate checks the plain text password of the user against the policy,
+	// the violations are returned as the errors of the given field.
+	Validate(user *iamv1alpha2.User, password string, fldPath *field.Path) field.ErrorList
+	// RecordPassword records the encrypted password in the password history of the user.
+	RecordPassword(user *iamv1alpha2.User, encryptedPassword string)
+	// Expiration returns when the password of the user expires, nil means the password never expires.
+	Expiration(user *iamv1alpha2.User) *time.Time
+}
+
+type passwordPolicy struct {
+	options *authentication.PasswordPolicyOptions
+	// lowercase common passwords and uppercase SHA-1 hashes of breached passwords
+	dictionary map[string]struct{}
+}
+
+// NewPasswordPolicy returns the PasswordPolicy, nil options means any password is accepted.
+func NewPasswordPolicy(options *authentication.PasswordPolicyOptions) (PasswordPolicy, error) {
+	if options == nil {
+		options = &authentication.PasswordPolicyOptions{}
+	}
+	policy := &passwordPolicy{options: options}
+	if options.DictionaryFile != "" {
+		dictionary, err := loadPasswordDictionary(options.DictionaryFile)
+		if err != nil {
+			klog.Error(err)
+			return nil, err
+		}
+		policy.dictionary = dictionary
+	}
+	return policy, nil
+}
+
+func (p *passwordPolicy) Validate(user *iamv1alpha2.User, password string, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	// the password itself must never be output
+	invalid := func(detail string) {
+		allErrs = append(allErrs, field.Invalid(fldPath, field.OmitValueType{}, detail))
+	}
+
+	if length := len([]rune(password)); length < p.options.MinLength {
+		invalid(fmt.Sprintf("must be at least %d characters", p.options.MinLength))
+	}
+
+	var lower, upper, digit, symbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsDigit(r):
+			digit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r) || unicode.IsSpace(r):
+			symbol = true
+		}
+	}
+	if p.options.RequireLowercase && !lower {
+		invalid("must contain at least one lowercase letter")
+	}
+	if p.options.RequireUppercase && !upper {
+		invalid("must contain at least one uppercase letter")
+	}
+	if p.options.RequireDigit && !digit {
+		invalid("must contain at least one digit")
+	}
+	if p.options.RequireSymbol && !symbol {
+		invalid("must contain at least one symbol")
+	}
+
+	if p.inDictionary(password) {
+		invalid("is too common or has appeared in a data breach")
+	}
+
+	if p.options.HistorySize > 0 && user != nil && p.reused(user, password) {
+		allErrs = append(allErrs, field.Forbidden(fldPath, fmt.Sprintf("must not be the same as any of the last %d passwords", p.options.HistorySize)))
+	}
+
+	return allErrs
+}
+
+func (p *passwordPolicy) inDictionary(password string) bool {
+	if len(p.dictionary) == 0 {
+		return false
+	}
+	if _, ok := p.dictionary[strings.ToLower(password)]; ok {
+		return true
+	}
+	sum := sha1.Sum([]byte(password))
+	_, ok := p.dictionary[strings.ToUpper(hex.EncodeToString(sum[:]))]
+	return ok
+}
+
+// reused returns whether the password matches the current password or any password in the history
+func (p *passwordPolicy) reused(user *iamv1alpha2.User, password string) bool {
+	history := passwordHistory(user)
+	if user.Spec.EncryptedPassword != "" {
+		history = append(history, user.Spec.EncryptedPassword)
+	}
+	for _, encrypted := range history {
+		if bcrypt.CompareHashAndPassword([]byte(encrypted), []byte(password)) == nil {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *passwordPolicy) RecordPassword(user *iamv1alpha2.User, encryptedPassword string) {
+	if p.options.HistorySize <= 0 {
+		delete(user.Annotations, iamv1alpha2.PasswordHistoryAnnotation)
+		return
+	}
+	history := append(passwordHistory(user), encryptedPassword)
+	if len(history) > p.options.HistorySize {
+		history = history[len(history)-p.options.HistorySize:]
+	}
+	data, _ := json.Marshal(history)
+	if user.Annotations == nil {
+		user.Annotations = make(map[string]string)
+	}
+	user.Annotations[iamv1alpha2.PasswordHistoryAnnotation] = string(data)
+}
+
+func (p *passwordPolicy) Expiration(user *iamv1alpha2.User) *time.Time {
+	if p.options.MaxAge <= 0 || user.Spec.EncryptedPassword == "" {
+		return nil
+	}
+	lastPasswordChangeTime := user.CreationTimestamp.Time
+	if value := user.Annotations[iamv1alpha2.LastPasswordChangeTimeAnnotation]; value != "" {
+		if t, err := time.Parse(time.RFC3339, value); err == nil {
+			lastPasswordChangeTime = t
+		}
+	}
+	expiration := lastPasswordChangeTime.Add(p.options.MaxAge)
+	return &expiration
+}
+
+// IsEncrypted returns whether the given password is encrypted by bcrypt
+func IsEncrypted(password string) bool {
+	// bcrypt.Cost returns the hashing cost used to create the given hashed,
+	// cost > 0 means the password has been encrypted
+	cost, _ := bcrypt.Cost([]byte(password))
+	return cost > 0
+}
+
+// WithPasswordExpiredExtra adds the password expired flag to the extra of the user if the password has expired,
+// the requests are restricted to changing the password until it is changed.
+func WithPasswordExpiredExtra(user *iamv1alpha2.User, extra map[string][]string) map[string][]string {
+	if user.Status.State != iamv1alpha2.UserPasswordExpired {
+		return extra
+	}
+	if extra == nil {
+		extra = make(map[string][]string)
+	}
+	extra[iamv1alpha2.ExtraPasswordExpired] = []string{"true"}
+	return extra
+}
+
+// passwordHistory returns the encrypted passwords in the history, from the oldest to the newest
+func passwordHistory(user *iamv1alpha2.User) []string {
+	history := make([]string, 0)
+	if value := user.Annotations[iamv1alpha2.PasswordHistoryAnnotation]; value != "" {
+		if err := json.Unmarshal([]byte(value), &history); err != nil {
+			klog.Warningf("invalid password history of user %s: %v", user.Name, err)
+		}
+	}
+	return history
+}
+
+func loadPasswordDictionary(path string) (map[string]struct{}, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	dictionary := make(map[string]struct{})
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		// SHA-1 hash of the breached password, optionally followed by the count
+		if hash, _, _ := strings.Cut(line, ":"); isSHA1Hex(hash) {
+			dictionary[strings.ToUpper(hash)] = struct{}{}
+			continue
+		}
+		dictionary[strings.ToLower(line)] = struct{}{}
+	}
+	if err = scanner.Err(); err != nil {
+		return nil, err
+	}
+	return dictionary, nil
+}
+
+func isSHA1Hex(s string) bool {
+	if len(s) != sha1.Size*2 {
+		return false
+	}
+	_, err := hex.DecodeString(s)
+	return err == nil
+}
@@ -0,0 +1,117 @@
+/*
+Copyright 2022 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+
+	iamv1alpha2 "kubesphere.io/api/iam/v1alpha2"
+
+	"kubesphere.io/kubesphere/pkg/apiserver/authentication"
+)
+
+func Test_passwordPolicy_Validate(t *testing.T) {
+	breached := sha1.Sum([]byte("Breached123"))
+	dictionaryFile := filepath.Join(t.TempDir(), "dictionary.txt")
+	dictionary := "p@ssw0rd1234\n" + strings.ToUpper(hex.EncodeToString(breached[:])) + ":42\n"
+	if err := os.WriteFile(dictionaryFile, []byte(dictionary), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	options := authentication.NewPasswordPolicyOptions()
+	options.RequireSymbol = true
+	options.DictionaryFile = dictionaryFile
+	options.HistorySize = 2
+	policy, err := NewPasswordPolicy(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	user := &iamv1alpha2.User{ObjectMeta: metav1.ObjectMeta{Name: "admin"}}
+	for _, password := range []string{"Old-P@ssw0rd1", "Old-P@ssw0rd2", "Old-P@ssw0rd3"} {
+		encrypted, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+		if err != nil {
+			t.Fatal(err)
+		}
+		user.Spec.EncryptedPassword = string(encrypted)
+		policy.RecordPassword(user, string(encrypted))
+	}
+
+	tests := []struct {
+		name           string
+		password       string
+		expectedErrors int
+	}{
+		{name: "valid", password: "New-P@ssw0rd"},
+		{name: "too short", password: "P@ss1", expectedErrors: 1},
+		{name: "missing character classes", password: "password", expectedErrors: 3},
+		{name: "common password", password: "P@ssw0rd1234", expectedErrors: 1},
+		{name: "breached password", password: "Breached123", expectedErrors: 2},
+		{name: "current password", password: "Old-P@ssw0rd3", expectedErrors: 1},
+		{name: "password in history", password: "Old-P@ssw0rd2", expectedErrors: 1},
+		{name: "password out of history", password: "Old-P@ssw0rd1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := policy.Validate(user, tt.password, field.NewPath("password"))
+			if len(errs) != tt.expectedErrors {
+				t.Errorf("expected %d errors, got %v", tt.expectedErrors, errs)
+			}
+			for _, err := range errs {
+				if err.BadValue == tt.password {
+					t.Errorf("the password should not be output")
+				}
+			}
+		})
+	}
+}
+
+func Test_passwordPolicy_Expiration(t *testing.T) {
+	options := authentication.NewPasswordPolicyOptions()
+	policy, _ := NewPasswordPolicy(options)
+	now := time.Now().Truncate(time.Second)
+	user := &iamv1alpha2.User{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              "admin",
+			CreationTimestamp: metav1.NewTime(now.Add(-time.Hour)),
+		},
+		Spec: iamv1alpha2.UserSpec{EncryptedPassword: "$2a$10$"},
+	}
+	if expiration := policy.Expiration(user); expiration != nil {
+		t.Errorf("password should never expire, got %v", expiration)
+	}
+
+	options.MaxAge = 24 * time.Hour
+	if expiration := policy.Expiration(user); expiration == nil || !expiration.Equal(now.Add(23*time.Hour)) {
+		t.Errorf("password should expire 24h after the creation, got %v", expiration)
+	}
+
+	user.Annotations = map[string]string{iamv1alpha2.LastPasswordChangeTimeAnnotation: now.UTC().Format(time.RFC3339)}
+	if expiration := policy.Expiration(user); expiration == nil || !expiration.Equal(now.Add(24*time.Hour)) {
+		t.Errorf("password should expire 24h after the last change, got %v", expiration)
+	}
+}
@@ -135,6 +135,7 @@ func Test_passwordAuthenticator_Authenticate(t *testing.T) {
 	authenticator := NewPasswordAuthenticator(
 		ksClient,
 		ksInformerFactory.Iam().V1alpha2().Users().Lister(),
+		ksInformerFactory.Iam().V1alpha2().GlobalRoleBindings().Lister(),
 		oauthOptions,
 	)
 
@@ -274,6 +275,10 @@ func (f fakePasswordIdentity) GetEmail() string {
 	return f.Email
 }
 
+func (f fakePasswordIdentity) GetGroups() []string {
+	return nil
+}
+
 func (fakePasswordProviderFactory) Type() string {
 	return "fakePasswordProvider"
 }
@@ -0,0 +1,247 @@
+/*
+Copyright 2022 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/rand"
+	"k8s.io/klog/v2"
+
+	iamv1alpha2 "kubesphere.io/api/iam/v1alpha2"
+
+	"kubesphere.io/kubesphere/pkg/simple/client/cache"
+)
+
+// the last-seen timestamp is updated at most once per minute to reduce the writes to the cache
+const sessionLastSeenResolution = time.Minute
+
+// Session is started when the user logs in, the access tokens and refresh tokens issued
+// in the session are invalidated when the session is terminated.
+type Session struct {
+	ID                  string       `json:"id" description:"session id, the sid claim of the tokens"`
+	ClientID            string       `json:"clientID,omitempty" description:"the OAuth client which the tokens are issued to"`
+	SourceIP            string       `json:"sourceIP,omitempty"`
+	UserAgent           string       `json:"userAgent,omitempty"`
+	LoginRecord         string       `json:"loginRecord,omitempty" description:"name of the login record of the session"`
+	Scopes              []string     `json:"scopes,omitempty" description:"the scopes granted to the tokens of the session"`
+	CreationTimestamp   metav1.Time  `json:"creationTimestamp,omitempty"`
+	LastSeenTimestamp   *metav1.Time `json:"lastSeenTimestamp,omitempty"`
+	ExpirationTimestamp *metav1.Time `json:"expirationTimestamp,omitempty"`
+}
+
+// SessionManagementInterface manages the active sessions of the users.
+type SessionManagementInterface interface {
+	// CreateSession starts a new session or renews the session with the same id, it expires after the given duration
+	CreateSession(username string, session *Session, expiresIn time.Duration) error
+	// DescribeSession returns the specified session
+	DescribeSession(username, id string) (*Session, error)
+	// ListSessions returns the active sessions of the user
+	ListSessions(username string) ([]*Session, error)
+	// DeleteSession terminates the specified session
+	DeleteSession(username, id string) error
+	// DeleteAllSessions terminates all the sessions of the user
+	DeleteAllSessions(username string) error
+}
+
+type sessionOperator struct {
+	cache cache.Interface
+}
+
+func NewSessionOperator(cache cache.Interface) SessionManagementInterface {
+	return &sessionOperator{cache: cache}
+}
+
+func (s *sessionOperator) CreateSession(username string, session *Session, expiresIn time.Duration) error {
+	if session.ID == "" {
+		session.ID = rand.String(16)
+	}
+	now := time.Now()
+	if session.CreationTimestamp.IsZero() {
+		session.CreationTimestamp = metav1.NewTime(now)
+	}
+	session.ExpirationTimestamp = nil
+	if expiresIn > 0 {
+		session.ExpirationTimestamp = &metav1.Time{Time: now.Add(expiresIn)}
+	}
+	return setSession(s.cache, username, session)
+}
+
+func (s *sessionOperator) DescribeSession(username, id string) (*Session, error) {
+	return getSession(s.cache, username, id)
+}
+
+func (s *sessionOperator) ListSessions(username string) ([]*Session, error) {
+	keys, err := s.cache.Keys(sessionKey(username, "*"))
+	if err != nil {
+		klog.Error(err)
+		return nil, err
+	}
+	sessions := make([]*Session, 0, len(keys))
+	for _, key := range keys {
+		session, err := getSession(s.cache, username, key[strings.LastIndex(key, ":")+1:])
+		if err != nil {
+			// expired between listing and getting
+			if errors.IsNotFound(err) {
+				continue
+			}
+			return nil, err
+		}
+		sessions = append(sessions, session)
+	}
+	return sessions, nil
+}
+
+func (s *sessionOperator) DeleteSession(username, id string) error {
+	key := sessionKey(username, id)
+	if exists, err := s.cache.Exists(key); err != nil {
+		klog.Error(err)
+		return err
+	} else if !exists {
+		return errors.NewNotFound(iamv1alpha2.Resource("sessions"), id)
+	}
+	if err := s.cache.Del(key, lastSeenKey(username, id)); err != nil {
+		klog.Error(err)
+		return err
+	}
+	return nil
+}
+
+func (s *sessionOperator) DeleteAllSessions(username string) error {
+	return deleteAllSessions(s.cache, username)
+}
+
+// verifySession checks that the session of the token has not been terminated, and records the last-seen timestamp.
+func verifySession(c cache.Interface, username, id string) error {
+	session, err := getSession(c, username, id)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return fmt.Errorf("session %s has been terminated", id)
+		}
+		return err
+	}
+	now := time.Now()
+	if session.LastSeenTimestamp == nil || now.Sub(session.LastSeenTimestamp.Time) > sessionLastSeenResolution {
+		// the last-seen timestamp is kept under its own key, so that the session terminated
+		// after being read here is never written back
+		if err = setLastSeen(c, username, session, now); err != nil {
+			klog.Warningf("failed to update the last-seen timestamp of session %s: %v", id, err)
+		}
+	}
+	return nil
+}
+
+func deleteAllSessions(c cache.Interface, username string) error {
+	sessionKeys, err := c.Keys(sessionKey(username, "*"))
+	if err != nil {
+		klog.Error(err)
+		return err
+	}
+	lastSeenKeys, err := c.Keys(lastSeenKey(username, "*"))
+	if err != nil {
+		klog.Error(err)
+		return err
+	}
+	if keys := append(sessionKeys, lastSeenKeys...); len(keys) > 0 {
+		if err = c.Del(keys...); err != nil {
+			klog.Error(err)
+			return err
+		}
+	}
+	return nil
+}
+
+func getSession(c cache.Interface, username, id string) (*Session, error) {
+	data, err := c.Get(sessionKey(username, id))
+	if err != nil {
+		if err == cache.ErrNoSuchKey {
+			return nil, errors.NewNotFound(iamv1alpha2.Resource("sessions"), id)
+		}
+		klog.Error(err)
+		return nil, err
+	}
+	session := &Session{}
+	if err = json.Unmarshal([]byte(data), session); err != nil {
+		klog.Error(err)
+		return nil, err
+	}
+	lastSeen, err := c.Get(lastSeenKey(username, id))
+	if err != nil {
+		if err == cache.ErrNoSuchKey {
+			return session, nil
+		}
+		klog.Error(err)
+		return nil, err
+	}
+	if t, err := time.Parse(time.RFC3339Nano, lastSeen); err == nil &&
+		(session.LastSeenTimestamp == nil || t.After(session.LastSeenTimestamp.Time)) {
+		session.LastSeenTimestamp = &metav1.Time{Time: t}
+	}
+	return session, nil
+}
+
+func setSession(c cache.Interface, username string, session *Session) error {
+	expiresIn, expired := sessionExpiresIn(session)
+	// expired, the session will be removed
+	if expired {
+		return nil
+	}
+	data, err := json.Marshal(session)
+	if err != nil {
+		klog.Error(err)
+		return err
+	}
+	if err = c.Set(sessionKey(username, session.ID), string(data), expiresIn); err != nil {
+		klog.Error(err)
+		return err
+	}
+	return nil
+}
+
+// setLastSeen records the last-seen timestamp of the session, it expires along with the session.
+func setLastSeen(c cache.Interface, username string, session *Session, lastSeen time.Time) error {
+	expiresIn, expired := sessionExpiresIn(session)
+	if expired {
+		return nil
+	}
+	if err := c.Set(lastSeenKey(username, session.ID), lastSeen.Format(time.RFC3339Nano), expiresIn); err != nil {
+		klog.Error(err)
+		return err
+	}
+	return nil
+}
+
+func sessionExpiresIn(session *Session) (time.Duration, bool) {
+	if session.ExpirationTimestamp == nil {
+		return cache.NeverExpire, false
+	}
+	expiresIn := time.Until(session.ExpirationTimestamp.Time)
+	return expiresIn, expiresIn <= 0
+}
+
+func sessionKey(username, id string) string {
+	return fmt.Sprintf("kubesphere:user:%s:session:%s", username, id)
+}
+
+func lastSeenKey(username, id string) string {
+	return fmt.Sprintf("kubesphere:user:%s:lastseen:%s", username, id)
+}
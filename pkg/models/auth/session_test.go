@@ -0,0 +1,109 @@
+/*
+Copyright 2022 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apiserver/pkg/authentication/user"
+
+	"kubesphere.io/kubesphere/pkg/apiserver/authentication"
+	"kubesphere.io/kubesphere/pkg/apiserver/authentication/token"
+	"kubesphere.io/kubesphere/pkg/simple/client/cache"
+)
+
+func Test_sessionOperator(t *testing.T) {
+	cacheClient, _ := cache.NewInMemoryCache(nil, nil)
+	options := authentication.NewOptions()
+	options.JwtSecret = "secret"
+	issuer, err := token.NewIssuer(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tokenOperator := NewTokenOperator(cacheClient, issuer, options)
+	sessionOperator := NewSessionOperator(cacheClient)
+
+	issue := func(session *Session) string {
+		if err := sessionOperator.CreateSession("admin", session, time.Hour); err != nil {
+			t.Fatal(err)
+		}
+		tokenStr, err := tokenOperator.IssueTo(&token.IssueRequest{
+			User:      &user.DefaultInfo{Name: "admin"},
+			Claims:    token.Claims{TokenType: token.AccessToken, SessionID: session.ID},
+			ExpiresIn: time.Hour,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tokenStr
+	}
+
+	first := &Session{ClientID: "kubesphere", SourceIP: "10.0.0.1"}
+	second := &Session{ClientID: "kubesphere", SourceIP: "10.0.0.2"}
+	firstToken, secondToken := issue(first), issue(second)
+
+	sessions, err := sessionOperator.ListSessions("admin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sessions) != 2 {
+		t.Fatalf("expected 2 sessions, got %d", len(sessions))
+	}
+
+	if _, err = tokenOperator.Verify(firstToken); err != nil {
+		t.Fatal(err)
+	}
+	session, err := sessionOperator.DescribeSession("admin", first.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if session.LastSeenTimestamp == nil {
+		t.Errorf("last-seen timestamp should be recorded")
+	}
+
+	if err = sessionOperator.DeleteSession("admin", first.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = tokenOperator.Verify(firstToken); err == nil {
+		t.Errorf("token of the terminated session should not be verified")
+	}
+	// the last-seen timestamp recorded concurrently with the termination does not recreate the session
+	if err = setLastSeen(cacheClient, "admin", session, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = sessionOperator.DescribeSession("admin", first.ID); !errors.IsNotFound(err) {
+		t.Errorf("expected not found error, got %v", err)
+	}
+	if sessions, err = sessionOperator.ListSessions("admin"); err != nil || len(sessions) != 1 {
+		t.Errorf("expected 1 session, got %d, error %v", len(sessions), err)
+	}
+	if _, err = tokenOperator.Verify(secondToken); err != nil {
+		t.Errorf("token of the other session should be verified: %v", err)
+	}
+	if err = sessionOperator.DeleteSession("admin", first.ID); !errors.IsNotFound(err) {
+		t.Errorf("expected not found error, got %v", err)
+	}
+
+	if err = sessionOperator.DeleteAllSessions("admin"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = tokenOperator.Verify(secondToken); err == nil {
+		t.Errorf("token of the terminated session should not be verified")
+	}
+}
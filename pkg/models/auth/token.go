@@ -39,6 +39,9 @@ type TokenManagementInterface interface {
 	IssueTo(request *token.IssueRequest) (string, error)
 	// Revoke revoke the specified token
 	Revoke(token string) error
+	// Claim revokes the token which can only be used once, TokenClaimedError is returned
+	// if the token has been claimed, so that only one of the concurrent requests succeeds
+	Claim(token string) error
 	// RevokeAllUserTokens revoke all user tokens
 	RevokeAllUserTokens(username string) error
 	// Keys hold encryption and signing keys.
@@ -51,13 +54,31 @@ type tokenOperator struct {
 	cache   cache.Interface
 }
 
-func (t tokenOperator) Revoke(token string) error {
-	pattern := fmt.Sprintf("kubesphere:user:*:token:%s", token)
-	if keys, err := t.cache.Keys(pattern); err != nil {
+func (t *tokenOperator) Revoke(tokenStr string) error {
+	// the token is verified before revoking, so that the cache keys are not derived from the unverified input
+	verified, err := t.issuer.Verify(tokenStr)
+	if err != nil {
+		// invalid or expired tokens need not be revoked
+		return nil
+	}
+	if verified.TokenType == token.StaticToken && verified.ID != "" {
+		if err = t.cache.Del(accessTokenKey(verified.User.GetName(), verified.ID)); err != nil {
+			klog.Error(err)
+			return err
+		}
+		return nil
+	}
+	if err = t.cache.Del(tokenCacheKey(verified.User.GetName(), tokenStr)); err != nil {
 		klog.Error(err)
 		return err
-	} else if len(keys) > 0 {
-		if err := t.cache.Del(keys...); err != nil {
+	}
+	// the tokens are not tracked in the cache, record the revocation until they expire
+	if verified.TokenType == token.StaticToken || t.options.OAuthOptions.AccessTokenMaxAge == 0 {
+		expiresIn := cache.NeverExpire
+		if verified.ExpiresAt != nil {
+			expiresIn = time.Until(verified.ExpiresAt.Time)
+		}
+		if err = t.cache.Set(revokedTokenKey(tokenStr), "", expiresIn); err != nil {
 			klog.Error(err)
 			return err
 		}
@@ -65,6 +86,28 @@ func (t tokenOperator) Revoke(token string) error {
 	return nil
 }
 
+func (t *tokenOperator) Claim(tokenStr string) error {
+	verified, err := t.issuer.Verify(tokenStr)
+	if err != nil {
+		return err
+	}
+	expiresIn := cache.NeverExpire
+	if verified.ExpiresAt != nil {
+		if expiresIn = time.Until(verified.ExpiresAt.Time); expiresIn <= 0 {
+			return TokenClaimedError
+		}
+	}
+	claimed, err := t.cache.SetNX(claimedTokenKey(tokenStr), "", expiresIn)
+	if err != nil {
+		klog.Error(err)
+		return err
+	}
+	if !claimed {
+		return TokenClaimedError
+	}
+	return t.Revoke(tokenStr)
+}
+
 func NewTokenOperator(cache cache.Interface, issuer token.Issuer, options *authentication.Options) TokenManagementInterface {
 	operator := &tokenOperator{
 		issuer:  issuer,
@@ -79,8 +122,29 @@ func (t *tokenOperator) Verify(tokenStr string) (*token.VerifiedResponse, error)
 	if err != nil {
 		return nil, err
 	}
-	if t.options.OAuthOptions.AccessTokenMaxAge == 0 ||
-		response.TokenType == token.StaticToken {
+	if response.TokenType == token.StaticToken {
+		// static tokens issued as personal access tokens can be revoked
+		if response.ID != "" {
+			if err = verifyAccessToken(t.cache, response.User.GetName(), response.ID, tokenStr); err != nil {
+				return nil, err
+			}
+			return response, nil
+		}
+		if err = t.tokenRevocationValidate(tokenStr); err != nil {
+			return nil, err
+		}
+		return response, nil
+	}
+	// the tokens are invalidated once the session is terminated
+	if response.SessionID != "" {
+		if err = verifySession(t.cache, response.User.GetName(), response.SessionID); err != nil {
+			return nil, err
+		}
+	}
+	if t.options.OAuthOptions.AccessTokenMaxAge == 0 {
+		if err = t.tokenRevocationValidate(tokenStr); err != nil {
+			return nil, err
+		}
 		return response, nil
 	}
 	if err := t.tokenCacheValidate(response.User.GetName(), tokenStr); err != nil {
@@ -116,7 +180,7 @@ func (t *tokenOperator) RevokeAllUserTokens(username string) error {
 			return err
 		}
 	}
-	return nil
+	return deleteAllSessions(t.cache, username)
 }
 
 func (t *tokenOperator) Keys() *token.Keys {
@@ -125,7 +189,7 @@ func (t *tokenOperator) Keys() *token.Keys {
 
 // tokenCacheValidate verify that the token is in the cache
 func (t *tokenOperator) tokenCacheValidate(username, token string) error {
-	key := fmt.Sprintf("kubesphere:user:%s:token:%s", username, token)
+	key := tokenCacheKey(username, token)
 	if exist, err := t.cache.Exists(key); err != nil {
 		return err
 	} else if !exist {
@@ -134,9 +198,31 @@ func (t *tokenOperator) tokenCacheValidate(username, token string) error {
 	return nil
 }
 
+// tokenRevocationValidate verify that the token which is not tracked in the cache has not been revoked
+func (t *tokenOperator) tokenRevocationValidate(tokenStr string) error {
+	if revoked, err := t.cache.Exists(revokedTokenKey(tokenStr)); err != nil {
+		return err
+	} else if revoked {
+		return errors.New("token has been revoked")
+	}
+	return nil
+}
+
+func tokenCacheKey(username, tokenStr string) string {
+	return fmt.Sprintf("kubesphere:user:%s:token:%s", username, tokenStr)
+}
+
+func claimedTokenKey(tokenStr string) string {
+	return fmt.Sprintf("kubesphere:token:claimed:%s", hashToken(tokenStr))
+}
+
+func revokedTokenKey(tokenStr string) string {
+	return fmt.Sprintf("kubesphere:token:revoked:%s", hashToken(tokenStr))
+}
+
 // cacheToken cache the token for a period of time
 func (t *tokenOperator) cacheToken(username, token string, duration time.Duration) error {
-	key := fmt.Sprintf("kubesphere:user:%s:token:%s", username, token)
+	key := tokenCacheKey(username, token)
 	if err := t.cache.Set(key, token, duration); err != nil {
 		klog.Error(err)
 		return err
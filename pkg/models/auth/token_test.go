@@ -0,0 +1,161 @@
+/*
+Copyright 2022 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"k8s.io/apiserver/pkg/authentication/user"
+
+	"kubesphere.io/kubesphere/pkg/apiserver/authentication"
+	"kubesphere.io/kubesphere/pkg/apiserver/authentication/token"
+	"kubesphere.io/kubesphere/pkg/simple/client/cache"
+)
+
+func Test_tokenOperator_Revoke(t *testing.T) {
+	tests := []struct {
+		name              string
+		accessTokenMaxAge time.Duration
+		tokenType         token.Type
+	}{
+		{name: "cached access token", accessTokenMaxAge: time.Hour, tokenType: token.AccessToken},
+		{name: "cached refresh token", accessTokenMaxAge: time.Hour, tokenType: token.RefreshToken},
+		{name: "access token never expires", accessTokenMaxAge: 0, tokenType: token.AccessToken},
+		{name: "static token", accessTokenMaxAge: time.Hour, tokenType: token.StaticToken},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cacheClient, _ := cache.NewInMemoryCache(nil, nil)
+			options := authentication.NewOptions()
+			options.JwtSecret = "secret"
+			options.OAuthOptions.AccessTokenMaxAge = tt.accessTokenMaxAge
+			issuer, err := token.NewIssuer(options)
+			if err != nil {
+				t.Fatal(err)
+			}
+			operator := NewTokenOperator(cacheClient, issuer, options)
+			tokenStr, err := operator.IssueTo(&token.IssueRequest{
+				User:      &user.DefaultInfo{Name: "admin"},
+				Claims:    token.Claims{TokenType: tt.tokenType},
+				ExpiresIn: tt.accessTokenMaxAge,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err = operator.Verify(tokenStr); err != nil {
+				t.Fatal(err)
+			}
+			if err = operator.Revoke(tokenStr); err != nil {
+				t.Fatal(err)
+			}
+			if _, err = operator.Verify(tokenStr); err == nil {
+				t.Errorf("revoked token should not be verified")
+			}
+		})
+	}
+}
+
+func Test_tokenOperator_RevokeAccessToken(t *testing.T) {
+	cacheClient, _ := cache.NewInMemoryCache(nil, nil)
+	options := authentication.NewOptions()
+	options.JwtSecret = "secret"
+	issuer, err := token.NewIssuer(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	operator := NewTokenOperator(cacheClient, issuer, options)
+	accessTokens := NewAccessTokenOperator(cacheClient, issuer)
+	created, err := accessTokens.CreateAccessToken("admin", &AccessToken{Name: "ci"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = operator.Revoke(created.Token); err != nil {
+		t.Fatal(err)
+	}
+	if list, _ := accessTokens.ListAccessTokens("admin"); len(list) != 0 {
+		t.Errorf("revoked access token should be removed, got %v", list)
+	}
+}
+
+func Test_tokenOperator_RevokePattern(t *testing.T) {
+	cacheClient, _ := cache.NewInMemoryCache(nil, nil)
+	options := authentication.NewOptions()
+	options.JwtSecret = "secret"
+	issuer, err := token.NewIssuer(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	operator := NewTokenOperator(cacheClient, issuer, options)
+	tokenStr, err := operator.IssueTo(&token.IssueRequest{
+		User:      &user.DefaultInfo{Name: "admin"},
+		Claims:    token.Claims{TokenType: token.AccessToken},
+		ExpiresIn: time.Hour,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the unverified input must not be used as a pattern of the cache keys
+	if err = operator.Revoke("*"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = operator.Verify(tokenStr); err != nil {
+		t.Errorf("token should not be revoked by a pattern, got %v", err)
+	}
+}
+
+func Test_tokenOperator_Claim(t *testing.T) {
+	cacheClient, _ := cache.NewInMemoryCache(nil, nil)
+	options := authentication.NewOptions()
+	options.JwtSecret = "secret"
+	issuer, err := token.NewIssuer(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	operator := NewTokenOperator(cacheClient, issuer, options)
+	tokenStr, err := operator.IssueTo(&token.IssueRequest{
+		User:      &user.DefaultInfo{Name: "admin"},
+		Claims:    token.Claims{TokenType: token.MultiFactorChallenge},
+		ExpiresIn: time.Minute,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	var claimed int32
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := operator.Claim(tokenStr); err == nil {
+				atomic.AddInt32(&claimed, 1)
+			} else if err != TokenClaimedError {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	if claimed != 1 {
+		t.Errorf("expected the token to be claimed once, got %d", claimed)
+	}
+	if _, err = operator.Verify(tokenStr); err == nil {
+		t.Errorf("claimed token should not be verified")
+	}
+}
@@ -369,7 +369,11 @@ func (d devopsOperator) ListCredentialObj(projectName string, query *query.Query
 		}
 	}
 
-	return *resourcesV1alpha3.DefaultList(result, query, d.compareCredentialObj, d.filterCredentialObj), nil
+	listResult, err := resourcesV1alpha3.DefaultList(result, query, d.compareCredentialObj, d.filterCredentialObj)
+	if err != nil {
+		return api.ListResult{}, err
+	}
+	return *listResult, nil
 }
 
 func (d devopsOperator) compareCredentialObj(left runtime.Object, right runtime.Object, field query.Field) bool {
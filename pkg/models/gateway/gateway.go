@@ -411,7 +411,7 @@ func (c *gatewayOperator) ListGateways(query *query.Query) (*api.ListResult, err
 		result = append(result, &services.Items[i])
 	}
 
-	return v1alpha3.DefaultList(result, query, c.compare, c.filter, c.transform), nil
+	return v1alpha3.DefaultList(result, query, c.compare, c.filter, c.transform)
 }
 
 func (c *gatewayOperator) transform(obj runtime.Object) runtime.Object {
@@ -0,0 +1,144 @@
+/*
+Copyright 2022 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package am
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+	runtimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	iamv1alpha2 "kubesphere.io/api/iam/v1alpha2"
+)
+
+// AccessRequestInterface manages the requests for temporary access, the role binding is
+// created by the accessrequest controller once the request is approved, and revoked when it expires.
+type AccessRequestInterface interface {
+	CreateAccessRequest(request *iamv1alpha2.AccessRequest) (*iamv1alpha2.AccessRequest, error)
+	DescribeAccessRequest(name string) (*iamv1alpha2.AccessRequest, error)
+	// ListAccessRequests lists the access requests of the user, or all of them if the user is not specified
+	ListAccessRequests(username string) ([]iamv1alpha2.AccessRequest, error)
+	ApproveAccessRequest(name string, approver string) (*iamv1alpha2.AccessRequest, error)
+	DenyAccessRequest(name string, approver string, message string) (*iamv1alpha2.AccessRequest, error)
+}
+
+type accessRequestOperator struct {
+	client runtimeclient.Client
+	am     AccessManagementInterface
+}
+
+func NewAccessRequestOperator(client runtimeclient.Client, am AccessManagementInterface) AccessRequestInterface {
+	return &accessRequestOperator{client: client, am: am}
+}
+
+func (o *accessRequestOperator) CreateAccessRequest(request *iamv1alpha2.AccessRequest) (*iamv1alpha2.AccessRequest, error) {
+	if request.Spec.Duration.Duration <= 0 {
+		return nil, errors.NewBadRequest("the duration of the access request must be positive")
+	}
+	if err := o.roleExists(request); err != nil {
+		return nil, err
+	}
+	if request.Name == "" && request.GenerateName == "" {
+		request.GenerateName = fmt.Sprintf("%s-", request.Spec.User)
+	}
+	request.Status = iamv1alpha2.AccessRequestStatus{}
+	if err := o.client.Create(context.Background(), request); err != nil {
+		klog.Error(err)
+		return nil, err
+	}
+	return request, nil
+}
+
+func (o *accessRequestOperator) roleExists(request *iamv1alpha2.AccessRequest) error {
+	var err error
+	switch {
+	case request.Spec.Namespace != "":
+		_, err = o.am.GetNamespaceRole(request.Spec.Namespace, request.Spec.Role)
+	case request.Spec.Workspace != "":
+		_, err = o.am.GetWorkspaceRole(request.Spec.Workspace, request.Spec.Role)
+	default:
+		_, err = o.am.GetClusterRole(request.Spec.Role)
+	}
+	return err
+}
+
+func (o *accessRequestOperator) DescribeAccessRequest(name string) (*iamv1alpha2.AccessRequest, error) {
+	request := &iamv1alpha2.AccessRequest{}
+	if err := o.client.Get(context.Background(), runtimeclient.ObjectKey{Name: name}, request); err != nil {
+		klog.Error(err)
+		return nil, err
+	}
+	return request, nil
+}
+
+func (o *accessRequestOperator) ListAccessRequests(username string) ([]iamv1alpha2.AccessRequest, error) {
+	requests := &iamv1alpha2.AccessRequestList{}
+	if err := o.client.List(context.Background(), requests); err != nil {
+		klog.Error(err)
+		return nil, err
+	}
+	result := make([]iamv1alpha2.AccessRequest, 0, len(requests.Items))
+	for _, request := range requests.Items {
+		if username == "" || request.Spec.User == username {
+			result = append(result, request)
+		}
+	}
+	return result, nil
+}
+
+func (o *accessRequestOperator) ApproveAccessRequest(name string, approver string) (*iamv1alpha2.AccessRequest, error) {
+	return o.review(name, approver, func(request *iamv1alpha2.AccessRequest) {
+		now := time.Now()
+		request.Status.State = iamv1alpha2.AccessRequestApproved
+		request.Status.ApprovedAt = &metav1.Time{Time: now}
+		request.Status.ExpiresAt = &metav1.Time{Time: now.Add(request.Spec.Duration.Duration)}
+	})
+}
+
+func (o *accessRequestOperator) DenyAccessRequest(name string, approver string, message string) (*iamv1alpha2.AccessRequest, error) {
+	return o.review(name, approver, func(request *iamv1alpha2.AccessRequest) {
+		request.Status.State = iamv1alpha2.AccessRequestDenied
+		request.Status.Message = message
+	})
+}
+
+// review updates the status of the pending access request, the users are not allowed to review their own requests
+func (o *accessRequestOperator) review(name string, approver string, update func(request *iamv1alpha2.AccessRequest)) (*iamv1alpha2.AccessRequest, error) {
+	request, err := o.DescribeAccessRequest(name)
+	if err != nil {
+		return nil, err
+	}
+	if request.Spec.User == approver {
+		return nil, errors.NewForbidden(iamv1alpha2.Resource(iamv1alpha2.ResourcesPluralAccessRequest), name,
+			fmt.Errorf("users are not allowed to review their own access requests"))
+	}
+	if request.Status.State != "" && request.Status.State != iamv1alpha2.AccessRequestPending {
+		return nil, errors.NewConflict(iamv1alpha2.Resource(iamv1alpha2.ResourcesPluralAccessRequest), name,
+			fmt.Errorf("the access request is already %s", request.Status.State))
+	}
+	request.Status.Approver = approver
+	update(request)
+	if err := o.client.Status().Update(context.Background(), request); err != nil {
+		klog.Error(err)
+		return nil, err
+	}
+	return request, nil
+}
@@ -23,6 +23,7 @@ import (
 	"kubesphere.io/kubesphere/pkg/simple/client/devops"
 
 	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
@@ -65,6 +66,8 @@ type AccessManagementInterface interface {
 	ListWorkspaceRoleBindings(username string, groups []string, workspace string) ([]*iamv1alpha2.WorkspaceRoleBinding, error)
 	ListRoleBindings(username string, groups []string, namespace string) ([]*rbacv1.RoleBinding, error)
 	GetRoleReferenceRules(roleRef rbacv1.RoleRef, namespace string) (string, []rbacv1.PolicyRule, error)
+	GetRoleReferenceDenyRules(roleRef rbacv1.RoleRef, namespace string) ([]iamv1alpha2.DenyRule, error)
+	GetObjectLabels(resource, namespace, name string) (map[string]string, error)
 	GetGlobalRole(globalRole string) (*iamv1alpha2.GlobalRole, error)
 	GetWorkspaceRole(workspace string, name string) (*iamv1alpha2.WorkspaceRole, error)
 	CreateGlobalRoleBinding(username string, globalRole string) error
@@ -451,6 +454,7 @@ func (am *amOperator) CreateOrUpdateWorkspaceRole(workspace string, workspaceRol
 				return nil, err
 			}
 			workspaceRole.Rules = append(workspaceRole.Rules, aggregationRole.Rules...)
+			workspaceRole.DenyRules = inheritDenyRules(workspaceRole.DenyRules, aggregationRole.DenyRules)
 		}
 	}
 	var created *iamv1alpha2.WorkspaceRole
@@ -476,6 +480,9 @@ func (am *amOperator) PatchGlobalRole(globalRole *iamv1alpha2.GlobalRole) (*iamv
 	// aggregate roles if annotation has change
 	if aggregateRoles := am.getAggregateRoles(globalRole.ObjectMeta); aggregateRoles != nil {
 		globalRole.Rules = make([]rbacv1.PolicyRule, 0)
+		if globalRole.DenyRules == nil {
+			globalRole.DenyRules = old.DenyRules
+		}
 		for _, roleName := range aggregateRoles {
 			aggregationRole, err := am.GetGlobalRole(roleName)
 			if err != nil {
@@ -483,6 +490,7 @@ func (am *amOperator) PatchGlobalRole(globalRole *iamv1alpha2.GlobalRole) (*iamv
 				return nil, err
 			}
 			globalRole.Rules = append(globalRole.Rules, aggregationRole.Rules...)
+			globalRole.DenyRules = inheritDenyRules(globalRole.DenyRules, aggregationRole.DenyRules)
 		}
 	}
 
@@ -505,6 +513,24 @@ func (am *amOperator) getAggregateRoles(obj metav1.ObjectMeta) []string {
 	return nil
 }
 
+// inheritDenyRules appends the deny rules of the aggregated role, a role aggregating
+// the role templates never allows the requests denied by any of them.
+func inheritDenyRules(denyRules []iamv1alpha2.DenyRule, inherited []iamv1alpha2.DenyRule) []iamv1alpha2.DenyRule {
+	for _, denyRule := range inherited {
+		exists := false
+		for _, existing := range denyRules {
+			if equality.Semantic.DeepEqual(existing, denyRule) {
+				exists = true
+				break
+			}
+		}
+		if !exists {
+			denyRules = append(denyRules, denyRule)
+		}
+	}
+	return denyRules
+}
+
 func (am *amOperator) PatchWorkspaceRole(workspace string, workspaceRole *iamv1alpha2.WorkspaceRole) (*iamv1alpha2.WorkspaceRole, error) {
 	old, err := am.GetWorkspaceRole(workspace, workspaceRole.Name)
 	if err != nil {
@@ -522,6 +548,9 @@ func (am *amOperator) PatchWorkspaceRole(workspace string, workspaceRole *iamv1a
 	// aggregate roles if annotation has change
 	if aggregateRoles := am.getAggregateRoles(workspaceRole.ObjectMeta); aggregateRoles != nil {
 		workspaceRole.Rules = make([]rbacv1.PolicyRule, 0)
+		if workspaceRole.DenyRules == nil {
+			workspaceRole.DenyRules = old.DenyRules
+		}
 		for _, roleName := range aggregateRoles {
 			aggregationRole, err := am.GetWorkspaceRole("", roleName)
 			if err != nil {
@@ -529,6 +558,7 @@ func (am *amOperator) PatchWorkspaceRole(workspace string, workspaceRole *iamv1a
 				return nil, err
 			}
 			workspaceRole.Rules = append(workspaceRole.Rules, aggregationRole.Rules...)
+			workspaceRole.DenyRules = inheritDenyRules(workspaceRole.DenyRules, aggregationRole.DenyRules)
 		}
 	}
 
@@ -838,6 +868,7 @@ func (am *amOperator) CreateOrUpdateGlobalRole(globalRole *iamv1alpha2.GlobalRol
 				return nil, err
 			}
 			globalRole.Rules = append(globalRole.Rules, aggregationRole.Rules...)
+			globalRole.DenyRules = inheritDenyRules(globalRole.DenyRules, aggregationRole.DenyRules)
 		}
 	}
 	var created *iamv1alpha2.GlobalRole
@@ -962,6 +993,81 @@ func (am *amOperator) GetRoleReferenceRules(roleRef rbacv1.RoleRef, namespace st
 	}
 }
 
+// GetRoleReferenceDenyRules attempts to resolve the deny rules of the role referenced by the RoleBinding or ClusterRoleBinding.
+// The deny rules of Roles and ClusterRoles are decoded from the DenyRulesAnnotation.
+func (am *amOperator) GetRoleReferenceDenyRules(roleRef rbacv1.RoleRef, namespace string) ([]iamv1alpha2.DenyRule, error) {
+
+	empty := make([]iamv1alpha2.DenyRule, 0)
+
+	var annotations map[string]string
+	switch roleRef.Kind {
+	case iamv1alpha2.ResourceKindRole:
+		role, err := am.GetNamespaceRole(namespace, roleRef.Name)
+		if err != nil {
+			if errors.IsNotFound(err) {
+				return empty, nil
+			}
+			return nil, err
+		}
+		annotations = role.Annotations
+	case iamv1alpha2.ResourceKindClusterRole:
+		clusterRole, err := am.GetClusterRole(roleRef.Name)
+		if err != nil {
+			if errors.IsNotFound(err) {
+				return empty, nil
+			}
+			return nil, err
+		}
+		annotations = clusterRole.Annotations
+	case iamv1alpha2.ResourceKindGlobalRole:
+		globalRole, err := am.GetGlobalRole(roleRef.Name)
+		if err != nil {
+			if errors.IsNotFound(err) {
+				return empty, nil
+			}
+			return nil, err
+		}
+		return globalRole.DenyRules, nil
+	case iamv1alpha2.ResourceKindWorkspaceRole:
+		workspaceRole, err := am.GetWorkspaceRole("", roleRef.Name)
+		if err != nil {
+			if errors.IsNotFound(err) {
+				return empty, nil
+			}
+			return nil, err
+		}
+		return workspaceRole.DenyRules, nil
+	default:
+		return nil, fmt.Errorf("unsupported role reference kind: %q", roleRef.Kind)
+	}
+
+	value := annotations[iamv1alpha2.DenyRulesAnnotation]
+	if value == "" {
+		return empty, nil
+	}
+	var denyRules []iamv1alpha2.DenyRule
+	if err := json.Unmarshal([]byte(value), &denyRules); err != nil {
+		err = fmt.Errorf("invalid %s annotation of %s %s: %v", iamv1alpha2.DenyRulesAnnotation, roleRef.Kind, roleRef.Name, err)
+		klog.Error(err)
+		return nil, err
+	}
+	return denyRules, nil
+}
+
+// GetObjectLabels returns the labels of the object, only namespaces are supported for now.
+func (am *amOperator) GetObjectLabels(resource, namespace, name string) (map[string]string, error) {
+	switch resource {
+	case "namespaces":
+		ns, err := am.namespaceLister.Get(name)
+		if err != nil {
+			return nil, err
+		}
+		return ns.Labels, nil
+	default:
+		return nil, fmt.Errorf("unsupported resource: %q", resource)
+	}
+}
+
 func (am *amOperator) GetWorkspaceRole(workspace string, name string) (*iamv1alpha2.WorkspaceRole, error) {
 	obj, err := am.workspaceRoleGetter.Get("", name)
 	if err != nil {
@@ -22,7 +22,9 @@ import (
 
 	"kubesphere.io/kubesphere/pkg/apiserver/authentication"
 
+	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
 	"k8s.io/klog/v2"
 
 	iamv1alpha2 "kubesphere.io/api/iam/v1alpha2"
@@ -34,6 +36,14 @@ import (
 	resources "kubesphere.io/kubesphere/pkg/models/resources/v1alpha3"
 )
 
+// the annotations can only be managed by the dedicated API or the controller
+var managedAnnotations = []string{
+	iamv1alpha2.TOTPSecretAnnotation,
+	iamv1alpha2.TOTPEnabledAnnotation,
+	iamv1alpha2.TOTPRecoveryCodesAnnotation,
+	iamv1alpha2.PasswordHistoryAnnotation,
+}
+
 type IdentityManagementInterface interface {
 	CreateUser(user *iamv1alpha2.User) (*iamv1alpha2.User, error)
 	ListUsers(query *query.Query) (*api.ListResult, error)
@@ -45,12 +55,14 @@ type IdentityManagementInterface interface {
 	PasswordVerify(username string, password string) error
 }
 
-func NewOperator(ksClient kubesphere.Interface, userGetter resources.Interface, loginRecordGetter resources.Interface, options *authentication.Options) IdentityManagementInterface {
+func NewOperator(ksClient kubesphere.Interface, userGetter resources.Interface, loginRecordGetter resources.Interface,
+	options *authentication.Options, passwordPolicy auth.PasswordPolicy) IdentityManagementInterface {
 	im := &imOperator{
 		ksClient:          ksClient,
 		userGetter:        userGetter,
 		loginRecordGetter: loginRecordGetter,
 		options:           options,
+		passwordPolicy:    passwordPolicy,
 	}
 	return im
 }
@@ -60,6 +72,7 @@ type imOperator struct {
 	userGetter        resources.Interface
 	loginRecordGetter resources.Interface
 	options           *authentication.Options
+	passwordPolicy    auth.PasswordPolicy
 }
 
 // UpdateUser returns user information after update.
@@ -71,6 +84,15 @@ func (im *imOperator) UpdateUser(new *iamv1alpha2.User) (*iamv1alpha2.User, erro
 	}
 	// keep encrypted password and user status
 	new.Spec.EncryptedPassword = old.Spec.EncryptedPassword
+	for _, annotation := range managedAnnotations {
+		delete(new.Annotations, annotation)
+		if value, ok := old.Annotations[annotation]; ok {
+			if new.Annotations == nil {
+				new.Annotations = make(map[string]string)
+			}
+			new.Annotations[annotation] = value
+		}
+	}
 	status := old.Status
 	// only support enable or disable
 	if new.Status.State == iamv1alpha2.UserDisabled || new.Status.State == iamv1alpha2.UserActive {
@@ -102,6 +124,14 @@ func (im *imOperator) ModifyPassword(username string, password string) error {
 		klog.Error(err)
 		return err
 	}
+	// the encrypted password would be stored as is, bypassing the password policy
+	if auth.IsEncrypted(password) {
+		errs := field.ErrorList{field.Forbidden(field.NewPath("password"), "the password must not be encrypted")}
+		return errors.NewInvalid(iamv1alpha2.SchemeGroupVersion.WithKind(iamv1alpha2.ResourceKindUser).GroupKind(), username, errs)
+	}
+	if errs := im.passwordPolicy.Validate(user, password, field.NewPath("password")); len(errs) > 0 {
+		return errors.NewInvalid(iamv1alpha2.SchemeGroupVersion.WithKind(iamv1alpha2.ResourceKindUser).GroupKind(), username, errs)
+	}
 	user.Spec.EncryptedPassword = password
 	_, err = im.ksClient.IamV1alpha2().Users().Update(context.Background(), user, metav1.UpdateOptions{})
 	if err != nil {
@@ -155,6 +185,13 @@ func (im *imOperator) DeleteUser(username string) error {
 }
 
 func (im *imOperator) CreateUser(user *iamv1alpha2.User) (*iamv1alpha2.User, error) {
+	// the password of the user mapped from identity provider is empty, and the encrypted password can't be checked
+	if password := user.Spec.EncryptedPassword; password != "" && !auth.IsEncrypted(password) {
+		if errs := im.passwordPolicy.Validate(nil, password, field.NewPath("spec", "password")); len(errs) > 0 {
+			return nil, errors.NewInvalid(iamv1alpha2.SchemeGroupVersion.WithKind(iamv1alpha2.ResourceKindUser).GroupKind(), user.Name, errs)
+		}
+	}
+	delete(user.Annotations, iamv1alpha2.PasswordHistoryAnnotation)
 	user, err := im.ksClient.IamV1alpha2().Users().Create(context.Background(), user, metav1.CreateOptions{})
 	if err != nil {
 		klog.Error(err)
@@ -177,5 +214,9 @@ func ensurePasswordNotOutput(user *iamv1alpha2.User) *iamv1alpha2.User {
 	out := user.DeepCopy()
 	// ensure encrypted password will not be output
 	out.Spec.EncryptedPassword = ""
+	// ensure the totp secret and recovery codes will not be output
+	delete(out.Annotations, iamv1alpha2.TOTPSecretAnnotation)
+	delete(out.Annotations, iamv1alpha2.TOTPRecoveryCodesAnnotation)
+	delete(out.Annotations, iamv1alpha2.PasswordHistoryAnnotation)
 	return out
 }
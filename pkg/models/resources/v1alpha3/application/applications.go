@@ -62,7 +62,7 @@ func (d *applicationsGetter) List(namespace string, query *query.Query) (*api.Li
 		result = append(result, &applications.Items[i])
 	}
 
-	return v1alpha3.DefaultList(result, query, d.compare, d.filter), nil
+	return v1alpha3.DefaultList(result, query, d.compare, d.filter)
 }
 
 func (d *applicationsGetter) compare(left runtime.Object, right runtime.Object, field query.Field) bool {
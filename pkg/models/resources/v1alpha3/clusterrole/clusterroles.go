@@ -65,7 +65,7 @@ func (d *clusterrolesGetter) List(namespace string, query *query.Query) (*api.Li
 		result = append(result, clusterrole)
 	}
 
-	return v1alpha3.DefaultList(result, query, d.compare, d.filter), nil
+	return v1alpha3.DefaultList(result, query, d.compare, d.filter)
 }
 
 func (d *clusterrolesGetter) compare(left runtime.Object, right runtime.Object, field query.Field) bool {
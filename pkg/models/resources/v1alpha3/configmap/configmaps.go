@@ -18,7 +18,9 @@ package configmap
 
 import (
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
 	"k8s.io/client-go/informers"
 
 	"kubesphere.io/kubesphere/pkg/api"
@@ -49,7 +51,11 @@ func (d *configmapsGetter) List(namespace string, query *query.Query) (*api.List
 		result = append(result, configmap)
 	}
 
-	return v1alpha3.DefaultList(result, query, d.compare, d.filter), nil
+	return v1alpha3.DefaultList(result, query, d.compare, d.filter)
+}
+
+func (d *configmapsGetter) Watch(namespace string, query *query.Query, resourceVersion string) (watch.Interface, error) {
+	return v1alpha3.DefaultWatch(d.informer.Core().V1().ConfigMaps().Informer(), namespace, query, resourceVersion, d.filter)
 }
 
 func (d *configmapsGetter) compare(left runtime.Object, right runtime.Object, field query.Field) bool {
@@ -75,3 +81,17 @@ func (d *configmapsGetter) filter(object runtime.Object, filter query.Filter) bo
 
 	return v1alpha3.DefaultObjectMetaFilter(configMap.ObjectMeta, filter)
 }
+
+// TableColumns returns the columns of the Table output of configmaps
+func (d *configmapsGetter) TableColumns() []v1alpha3.TableColumn {
+	return []v1alpha3.TableColumn{
+		v1alpha3.NameColumn,
+		{
+			TableColumnDefinition: metav1.TableColumnDefinition{Name: "Data", Type: "integer", Description: "The number of data entries of the configmap."},
+			Cell: v1alpha3.Cell(func(configMap *corev1.ConfigMap) interface{} {
+				return int64(len(configMap.Data) + len(configMap.BinaryData))
+			}),
+		},
+		v1alpha3.AgeColumn,
+	}
+}
@@ -53,7 +53,7 @@ func (c crdGetter) List(_ string, query *query.Query) (*api.ListResult, error) {
 		result = append(result, crd)
 	}
 
-	return v1alpha3.DefaultList(result, query, c.compare, c.filter), nil
+	return v1alpha3.DefaultList(result, query, c.compare, c.filter)
 }
 
 func (c crdGetter) compare(left runtime.Object, right runtime.Object, field query.Field) bool {
@@ -78,7 +78,7 @@ func (c crdGetter) filter(object runtime.Object, filter query.Filter) bool {
 
 	switch filter.Field {
 	case query.FieldName:
-		return strings.Contains(crd.Name, string(filter.Value)) || strings.Contains(crd.Spec.Names.Kind, string(filter.Value))
+		return filter.MatchStringFunc(crd.Name, strings.Contains) || filter.MatchStringFunc(crd.Spec.Names.Kind, strings.Contains)
 	default:
 		return v1alpha3.DefaultObjectMetaFilter(crd.ObjectMeta, filter)
 	}
@@ -17,10 +17,10 @@ limitations under the License.
 package daemonset
 
 import (
-	"strings"
-
 	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
 	"k8s.io/client-go/informers"
 
 	"kubesphere.io/kubesphere/pkg/api"
@@ -58,7 +58,11 @@ func (d *daemonSetGetter) List(namespace string, query *query.Query) (*api.ListR
 		result = append(result, daemonSet)
 	}
 
-	return v1alpha3.DefaultList(result, query, d.compare, d.filter), nil
+	return v1alpha3.DefaultList(result, query, d.compare, d.filter)
+}
+
+func (d *daemonSetGetter) Watch(namespace string, query *query.Query, resourceVersion string) (watch.Interface, error) {
+	return v1alpha3.DefaultWatch(d.sharedInformers.Apps().V1().DaemonSets().Informer(), namespace, query, resourceVersion, d.filter)
 }
 
 func (d *daemonSetGetter) compare(left runtime.Object, right runtime.Object, field query.Field) bool {
@@ -83,12 +87,38 @@ func (d *daemonSetGetter) filter(object runtime.Object, filter query.Filter) boo
 	}
 	switch filter.Field {
 	case query.FieldStatus:
-		return strings.Compare(daemonsetStatus(&daemonSet.Status), string(filter.Value)) == 0
+		return filter.MatchString(daemonsetStatus(&daemonSet.Status))
 	default:
 		return v1alpha3.DefaultObjectMetaFilter(daemonSet.ObjectMeta, filter)
 	}
 }
 
+// TableColumns returns the columns of the Table output of daemonsets
+func (d *daemonSetGetter) TableColumns() []v1alpha3.TableColumn {
+	return []v1alpha3.TableColumn{
+		v1alpha3.NameColumn,
+		{
+			TableColumnDefinition: metav1.TableColumnDefinition{Name: "Desired", Type: "integer", Description: "The number of nodes that should be running the daemon pod."},
+			Cell: v1alpha3.Cell(func(daemonSet *appsv1.DaemonSet) interface{} {
+				return int64(daemonSet.Status.DesiredNumberScheduled)
+			}),
+		},
+		{
+			TableColumnDefinition: metav1.TableColumnDefinition{Name: "Ready", Type: "integer", Description: "The number of nodes that have the daemon pod running and ready."},
+			Cell: v1alpha3.Cell(func(daemonSet *appsv1.DaemonSet) interface{} {
+				return int64(daemonSet.Status.NumberReady)
+			}),
+		},
+		{
+			TableColumnDefinition: metav1.TableColumnDefinition{Name: "Status", Type: "string", Description: "The status of the daemonset."},
+			Cell: v1alpha3.Cell(func(daemonSet *appsv1.DaemonSet) interface{} {
+				return daemonsetStatus(&daemonSet.Status)
+			}),
+		},
+		v1alpha3.AgeColumn,
+	}
+}
+
 func daemonsetStatus(status *appsv1.DaemonSetStatus) string {
 	if status.DesiredNumberScheduled == 0 && status.NumberReady == 0 {
 		return statusStopped
@@ -17,10 +17,12 @@ limitations under the License.
 package deployment
 
 import (
-	"strings"
+	"fmt"
 	"time"
 
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
 	"k8s.io/client-go/informers"
 
 	"kubesphere.io/kubesphere/pkg/api"
@@ -60,7 +62,11 @@ func (d *deploymentsGetter) List(namespace string, query *query.Query) (*api.Lis
 		result = append(result, deployment)
 	}
 
-	return v1alpha3.DefaultList(result, query, d.compare, d.filter), nil
+	return v1alpha3.DefaultList(result, query, d.compare, d.filter)
+}
+
+func (d *deploymentsGetter) Watch(namespace string, query *query.Query, resourceVersion string) (watch.Interface, error) {
+	return v1alpha3.DefaultWatch(d.sharedInformers.Apps().V1().Deployments().Informer(), namespace, query, resourceVersion, d.filter)
 }
 
 func (d *deploymentsGetter) compare(left runtime.Object, right runtime.Object, field query.Field) bool {
@@ -93,12 +99,48 @@ func (d *deploymentsGetter) filter(object runtime.Object, filter query.Filter) b
 
 	switch filter.Field {
 	case query.FieldStatus:
-		return strings.Compare(deploymentStatus(deployment.Status), string(filter.Value)) == 0
+		return filter.MatchString(deploymentStatus(deployment.Status))
 	default:
 		return v1alpha3.DefaultObjectMetaFilter(deployment.ObjectMeta, filter)
 	}
 }
 
+// TableColumns returns the columns of the Table output of deployments
+func (d *deploymentsGetter) TableColumns() []v1alpha3.TableColumn {
+	return []v1alpha3.TableColumn{
+		v1alpha3.NameColumn,
+		{
+			TableColumnDefinition: metav1.TableColumnDefinition{Name: "Ready", Type: "string", Description: "The number of ready replicas out of the desired replicas."},
+			Cell: v1alpha3.Cell(func(deployment *v1.Deployment) interface{} {
+				replicas := int32(1)
+				if deployment.Spec.Replicas != nil {
+					replicas = *deployment.Spec.Replicas
+				}
+				return fmt.Sprintf("%d/%d", deployment.Status.ReadyReplicas, replicas)
+			}),
+		},
+		{
+			TableColumnDefinition: metav1.TableColumnDefinition{Name: "Up-to-date", Type: "integer", Description: "The number of replicas updated to the desired template."},
+			Cell: v1alpha3.Cell(func(deployment *v1.Deployment) interface{} {
+				return int64(deployment.Status.UpdatedReplicas)
+			}),
+		},
+		{
+			TableColumnDefinition: metav1.TableColumnDefinition{Name: "Available", Type: "integer", Description: "The number of available replicas."},
+			Cell: v1alpha3.Cell(func(deployment *v1.Deployment) interface{} {
+				return int64(deployment.Status.AvailableReplicas)
+			}),
+		},
+		{
+			TableColumnDefinition: metav1.TableColumnDefinition{Name: "Status", Type: "string", Description: "The status of the deployment."},
+			Cell: v1alpha3.Cell(func(deployment *v1.Deployment) interface{} {
+				return deploymentStatus(deployment.Status)
+			}),
+		},
+		v1alpha3.AgeColumn,
+	}
+}
+
 func deploymentStatus(status v1.DeploymentStatus) string {
 	if status.ReadyReplicas == 0 && status.Replicas == 0 {
 		return statusStopped
@@ -50,7 +50,7 @@ func (d *fedApplicationsGetter) List(namespace string, query *query.Query) (*api
 		result = append(result, app)
 	}
 
-	return v1alpha3.DefaultList(result, query, d.compare, d.filter), nil
+	return v1alpha3.DefaultList(result, query, d.compare, d.filter)
 }
 
 func (d *fedApplicationsGetter) compare(left runtime.Object, right runtime.Object, field query.Field) bool {
@@ -50,7 +50,7 @@ func (g *fedIngressGetter) List(namespace string, query *query.Query) (*api.List
 		result = append(result, ingress)
 	}
 
-	return v1alpha3.DefaultList(result, query, g.compare, g.filter), nil
+	return v1alpha3.DefaultList(result, query, g.compare, g.filter)
 }
 
 func (g *fedIngressGetter) compare(left runtime.Object, right runtime.Object, field query.Field) bool {
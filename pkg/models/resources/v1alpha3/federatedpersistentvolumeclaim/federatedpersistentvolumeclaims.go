@@ -54,7 +54,7 @@ func (p *fedPersistentVolumeClaimGetter) List(namespace string, query *query.Que
 	for _, pvc := range all {
 		result = append(result, pvc)
 	}
-	return v1alpha3.DefaultList(result, query, p.compare, p.filter), nil
+	return v1alpha3.DefaultList(result, query, p.compare, p.filter)
 }
 
 func (p *fedPersistentVolumeClaimGetter) compare(left, right runtime.Object, field query.Field) bool {
@@ -77,7 +77,7 @@ func (p *fedPersistentVolumeClaimGetter) filter(object runtime.Object, filter qu
 
 	switch filter.Field {
 	case storageClassName:
-		return pvc.Spec.Template.Spec.StorageClassName != nil && *pvc.Spec.Template.Spec.StorageClassName == string(filter.Value)
+		return pvc.Spec.Template.Spec.StorageClassName != nil && filter.MatchString(*pvc.Spec.Template.Spec.StorageClassName)
 	default:
 		return v1alpha3.DefaultObjectMetaFilter(pvc.ObjectMeta, filter)
 	}
@@ -17,8 +17,6 @@ limitations under the License.
 package federatedsecret
 
 import (
-	"strings"
-
 	"k8s.io/apimachinery/pkg/runtime"
 
 	"kubesphere.io/api/types/v1beta1"
@@ -52,7 +50,7 @@ func (d *fedSecretGetter) List(namespace string, query *query.Query) (*api.ListR
 		result = append(result, secret)
 	}
 
-	return v1alpha3.DefaultList(result, query, d.compare, d.filter), nil
+	return v1alpha3.DefaultList(result, query, d.compare, d.filter)
 }
 
 func (d *fedSecretGetter) compare(left runtime.Object, right runtime.Object, field query.Field) bool {
@@ -78,7 +76,7 @@ func (d *fedSecretGetter) filter(object runtime.Object, filter query.Filter) boo
 
 	switch filter.Field {
 	case query.FieldType:
-		return strings.Compare(string(fedSecret.Spec.Template.Type), string(filter.Value)) == 0
+		return filter.MatchString(string(fedSecret.Spec.Template.Type))
 	default:
 		return v1alpha3.DefaultObjectMetaFilter(fedSecret.ObjectMeta, filter)
 	}
@@ -52,7 +52,7 @@ func (f *federatedServiceGetter) List(namespace string, query *query.Query) (*ap
 		result = append(result, fedService)
 	}
 
-	return v1alpha3.DefaultList(result, query, f.compare, f.filter), nil
+	return v1alpha3.DefaultList(result, query, f.compare, f.filter)
 }
 
 func (f *federatedServiceGetter) compare(left runtime.Object, right runtime.Object, field query.Field) bool {
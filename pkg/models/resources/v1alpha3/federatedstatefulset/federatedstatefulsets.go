@@ -51,7 +51,7 @@ func (d *fedStatefulSetGetter) List(namespace string, query *query.Query) (*api.
 		result = append(result, statefulSet)
 	}
 
-	return v1alpha3.DefaultList(result, query, d.compare, d.filter), nil
+	return v1alpha3.DefaultList(result, query, d.compare, d.filter)
 }
 
 func (d *fedStatefulSetGetter) compare(left runtime.Object, right runtime.Object, field query.Field) bool {
@@ -52,7 +52,7 @@ func (d *globalrolebindingsGetter) List(_ string, query *query.Query) (*api.List
 		result = append(result, globalRoleBinding)
 	}
 
-	return v1alpha3.DefaultList(result, query, d.compare, d.filter), nil
+	return v1alpha3.DefaultList(result, query, d.compare, d.filter)
 }
 
 func (d *globalrolebindingsGetter) compare(left runtime.Object, right runtime.Object, field query.Field) bool {
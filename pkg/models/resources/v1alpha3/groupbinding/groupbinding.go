@@ -54,7 +54,7 @@ func (d *groupBindingGetter) List(_ string, query *query.Query) (*api.ListResult
 		result = append(result, groupBinding)
 	}
 
-	return v1alpha3.DefaultList(result, query, d.compare, d.filter), nil
+	return v1alpha3.DefaultList(result, query, d.compare, d.filter)
 }
 
 func (d *groupBindingGetter) compare(left runtime.Object, right runtime.Object, field query.Field) bool {
@@ -50,7 +50,7 @@ func (g *ingressGetter) List(namespace string, query *query.Query) (*api.ListRes
 		result = append(result, ingress)
 	}
 
-	return v1alpha3.DefaultList(result, query, g.compare, g.filter), nil
+	return v1alpha3.DefaultList(result, query, g.compare, g.filter)
 }
 
 func (g *ingressGetter) compare(left runtime.Object, right runtime.Object, field query.Field) bool {
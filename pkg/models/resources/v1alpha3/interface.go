@@ -17,12 +17,12 @@ limitations under the License.
 package v1alpha3
 
 import (
-	"sort"
 	"strings"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
 	"k8s.io/klog/v2"
 
 	"kubesphere.io/kubesphere/pkg/api"
@@ -38,6 +38,14 @@ type Interface interface {
 	List(namespace string, query *query.Query) (*api.ListResult, error)
 }
 
+// WatchInterface is implemented by the resource getters backed by shared informers,
+// which are able to stream the changes of the objects.
+type WatchInterface interface {
+	// Watch streams the changes of the objects within the namespace matching given query,
+	// starting after the resourceVersion if it is specified
+	Watch(namespace string, query *query.Query, resourceVersion string) (watch.Interface, error)
+}
+
 // CompareFunc return true is left great than right
 type CompareFunc func(runtime.Object, runtime.Object, query.Field) bool
 
@@ -45,19 +53,12 @@ type FilterFunc func(runtime.Object, query.Filter) bool
 
 type TransformFunc func(runtime.Object) runtime.Object
 
-func DefaultList(objects []runtime.Object, q *query.Query, compareFunc CompareFunc, filterFunc FilterFunc, transformFuncs ...TransformFunc) *api.ListResult {
+// DefaultList filters, sorts and paginates the objects by the query, it returns an error if the continue token is invalid.
+func DefaultList(objects []runtime.Object, q *query.Query, compareFunc CompareFunc, filterFunc FilterFunc, transformFuncs ...TransformFunc) (*api.ListResult, error) {
 	// selected matched ones
 	var filtered []runtime.Object
 	for _, object := range objects {
-		selected := true
-		for field, value := range q.Filters {
-			if !filterFunc(object, query.Filter{Field: field, Value: value}) {
-				selected = false
-				break
-			}
-		}
-
-		if selected {
+		if q.Matches(object, filterFunc) {
 			for _, transform := range transformFuncs {
 				object = transform(object)
 			}
@@ -65,26 +66,22 @@ func DefaultList(objects []runtime.Object, q *query.Query, compareFunc CompareFu
 		}
 	}
 
-	// sort by sortBy field
-	sort.Slice(filtered, func(i, j int) bool {
+	// sort by sortBy field, and paginate by page or continue token
+	page, _, continueToken, err := q.Paginate(filtered, func(left, right runtime.Object) bool {
 		if !q.Ascending {
-			return compareFunc(filtered[i], filtered[j], q.SortBy)
+			return compareFunc(left, right, q.SortBy)
 		}
-		return !compareFunc(filtered[i], filtered[j], q.SortBy)
+		return compareFunc(right, left, q.SortBy)
 	})
-
-	total := len(filtered)
-
-	if q.Pagination == nil {
-		q.Pagination = query.NoPagination
+	if err != nil {
+		return nil, err
 	}
 
-	start, end := q.Pagination.GetValidPagination(total)
-
 	return &api.ListResult{
 		TotalItems: len(filtered),
-		Items:      objectsToInterfaces(filtered[start:end]),
-	}
+		Items:      objectsToInterfaces(page),
+		Continue:   continueToken,
+	}, nil
 }
 
 // DefaultObjectMetaCompare return true is left great than right
@@ -119,23 +116,23 @@ func DefaultObjectMetaFilter(item metav1.ObjectMeta, filter query.Filter) bool {
 		return false
 	// /namespaces?page=1&limit=10&name=default
 	case query.FieldName:
-		return strings.Contains(item.Name, string(filter.Value))
+		return filter.MatchStringFunc(item.Name, strings.Contains)
 	// /clusters?page=1&limit=10&alias=xxx
 	case query.FieldAlias:
 		if item.Annotations == nil {
 			return false
 		}
-		return strings.Contains(item.Annotations[constants.DisplayNameAnnotationKey], string(filter.Value))
+		return filter.MatchStringFunc(item.Annotations[constants.DisplayNameAnnotationKey], strings.Contains)
 	// /namespaces?page=1&limit=10&uid=a8a8d6cf-f6a5-4fea-9c1b-e57610115706
 	case query.FieldUID:
-		return strings.Compare(string(item.UID), string(filter.Value)) == 0
+		return filter.MatchString(string(item.UID))
 	// /deployments?page=1&limit=10&namespace=kubesphere-system
 	case query.FieldNamespace:
-		return strings.Compare(item.Namespace, string(filter.Value)) == 0
+		return filter.MatchString(item.Namespace)
 	// /namespaces?page=1&limit=10&ownerReference=a8a8d6cf-f6a5-4fea-9c1b-e57610115706
 	case query.FieldOwnerReference:
 		for _, ownerReference := range item.OwnerReferences {
-			if strings.Compare(string(ownerReference.UID), string(filter.Value)) == 0 {
+			if filter.MatchString(string(ownerReference.UID)) {
 				return true
 			}
 		}
@@ -143,11 +140,14 @@ func DefaultObjectMetaFilter(item metav1.ObjectMeta, filter query.Filter) bool {
 	// /namespaces?page=1&limit=10&ownerKind=Workspace
 	case query.FieldOwnerKind:
 		for _, ownerReference := range item.OwnerReferences {
-			if strings.Compare(ownerReference.Kind, string(filter.Value)) == 0 {
+			if filter.MatchString(ownerReference.Kind) {
 				return true
 			}
 		}
 		return false
+	// /namespaces?page=1&limit=10&creationTimestamp>2024-01-01
+	case query.FieldCreationTimeStamp:
+		return filter.MatchTime(item.CreationTimestamp.Time)
 	// /namespaces?page=1&limit=10&annotation=openpitrix_runtime
 	case query.FieldAnnotation:
 		return labelMatch(item.Annotations, string(filter.Value))
@@ -18,7 +18,14 @@
 
 package v1alpha3
 
-import "testing"
+import (
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"kubesphere.io/kubesphere/pkg/apiserver/query"
+)
 
 func TestLabelMatch(t *testing.T) {
 	tests := []struct {
@@ -83,3 +90,30 @@ func TestLabelMatch(t *testing.T) {
 		}
 	}
 }
+
+func TestDefaultObjectMetaFilter(t *testing.T) {
+	item := metav1.ObjectMeta{
+		Name:              "web-1",
+		Namespace:         "default",
+		CreationTimestamp: metav1.NewTime(time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)),
+	}
+	tests := []struct {
+		filter       query.Filter
+		expectResult bool
+	}{
+		{query.Filter{Field: query.FieldName, Value: "eb"}, true},
+		{query.Filter{Field: query.FieldName, Value: "eb", Operator: query.OperatorPrefix}, false},
+		{query.Filter{Field: query.FieldName, Value: "web", Operator: query.OperatorPrefix}, true},
+		{query.Filter{Field: query.FieldNamespace, Value: "def"}, false},
+		{query.Filter{Field: query.FieldNamespace, Value: "def", Operator: query.OperatorPrefix}, true},
+		{query.Filter{Field: query.FieldCreationTimeStamp, Value: "2024-01-01", Operator: query.OperatorGreater}, true},
+		{query.Filter{Field: query.FieldCreationTimeStamp, Value: "2024-03-01"}, true},
+		{query.Filter{Field: query.FieldCreationTimeStamp, Value: "2024-03-01T00:00:00Z", Operator: query.OperatorLess}, false},
+	}
+	for i, test := range tests {
+		result := DefaultObjectMetaFilter(item, test.filter)
+		if result != test.expectResult {
+			t.Errorf("case %d, got %#v, expected %#v", i, result, test.expectResult)
+		}
+	}
+}
@@ -91,7 +91,7 @@ func (n ippoolGetter) List(namespace string, query *query.Query) (*api.ListResul
 		}
 	}
 
-	return v1alpha3.DefaultList(result, query, n.compare, n.filter), nil
+	return v1alpha3.DefaultList(result, query, n.compare, n.filter)
 }
 
 func (n ippoolGetter) filter(item runtime.Object, filter query.Filter) bool {
@@ -17,12 +17,15 @@ limitations under the License.
 package job
 
 import (
+	"fmt"
 	"strings"
 	"time"
 
 	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
 	"k8s.io/client-go/informers"
 
 	"kubesphere.io/kubesphere/pkg/api"
@@ -59,7 +62,11 @@ func (d *jobsGetter) List(namespace string, query *query.Query) (*api.ListResult
 		result = append(result, job)
 	}
 
-	return v1alpha3.DefaultList(result, query, d.compare, d.filter), nil
+	return v1alpha3.DefaultList(result, query, d.compare, d.filter)
+}
+
+func (d *jobsGetter) Watch(namespace string, query *query.Query, resourceVersion string) (watch.Interface, error) {
+	return v1alpha3.DefaultWatch(d.sharedInformers.Batch().V1().Jobs().Informer(), namespace, query, resourceVersion, d.filter)
 }
 
 func (d *jobsGetter) compare(left runtime.Object, right runtime.Object, field query.Field) bool {
@@ -94,12 +101,36 @@ func (d *jobsGetter) filter(object runtime.Object, filter query.Filter) bool {
 
 	switch filter.Field {
 	case query.FieldStatus:
-		return strings.Compare(jobStatus(job.Status), string(filter.Value)) == 0
+		return filter.MatchString(jobStatus(job.Status))
 	default:
 		return v1alpha3.DefaultObjectMetaFilter(job.ObjectMeta, filter)
 	}
 }
 
+// TableColumns returns the columns of the Table output of jobs
+func (d *jobsGetter) TableColumns() []v1alpha3.TableColumn {
+	return []v1alpha3.TableColumn{
+		v1alpha3.NameColumn,
+		{
+			TableColumnDefinition: metav1.TableColumnDefinition{Name: "Completions", Type: "string", Description: "The number of succeeded pods out of the desired completions."},
+			Cell: v1alpha3.Cell(func(job *batchv1.Job) interface{} {
+				completions := int32(1)
+				if job.Spec.Completions != nil {
+					completions = *job.Spec.Completions
+				}
+				return fmt.Sprintf("%d/%d", job.Status.Succeeded, completions)
+			}),
+		},
+		{
+			TableColumnDefinition: metav1.TableColumnDefinition{Name: "Status", Type: "string", Description: "The status of the job."},
+			Cell: v1alpha3.Cell(func(job *batchv1.Job) interface{} {
+				return jobStatus(job.Status)
+			}),
+		},
+		v1alpha3.AgeColumn,
+	}
+}
+
 func jobStatus(status batchv1.JobStatus) string {
 	for _, condition := range status.Conditions {
 		if condition.Type == batchv1.JobComplete && condition.Status == corev1.ConditionTrue {
@@ -54,7 +54,7 @@ func (d *loginrecordsGetter) List(_ string, query *query.Query) (*api.ListResult
 		result = append(result, user)
 	}
 
-	return v1alpha3.DefaultList(result, query, d.compare, d.filter), nil
+	return v1alpha3.DefaultList(result, query, d.compare, d.filter)
 }
 
 func (d *loginrecordsGetter) compare(left runtime.Object, right runtime.Object, field query.Field) bool {
@@ -81,7 +81,7 @@ func (d *loginrecordsGetter) filter(object runtime.Object, filter query.Filter)
 
 	switch filter.Field {
 	case recordType:
-		return string(record.Spec.Type) == string(filter.Value)
+		return filter.MatchString(string(record.Spec.Type))
 	default:
 		return v1alpha3.DefaultObjectMetaFilter(record.ObjectMeta, filter)
 	}
@@ -17,10 +17,10 @@ limitations under the License.
 package namespace
 
 import (
-	"strings"
-
 	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
 	"k8s.io/client-go/informers"
 
 	"kubesphere.io/kubesphere/pkg/api"
@@ -51,7 +51,11 @@ func (n namespacesGetter) List(_ string, query *query.Query) (*api.ListResult, e
 		result = append(result, item)
 	}
 
-	return v1alpha3.DefaultList(result, query, n.compare, n.filter), nil
+	return v1alpha3.DefaultList(result, query, n.compare, n.filter)
+}
+
+func (n namespacesGetter) Watch(_ string, query *query.Query, resourceVersion string) (watch.Interface, error) {
+	return v1alpha3.DefaultWatch(n.informers.Core().V1().Namespaces().Informer(), "", query, resourceVersion, n.filter)
 }
 
 func (n namespacesGetter) filter(item runtime.Object, filter query.Filter) bool {
@@ -61,12 +65,26 @@ func (n namespacesGetter) filter(item runtime.Object, filter query.Filter) bool
 	}
 	switch filter.Field {
 	case query.FieldStatus:
-		return strings.Compare(string(namespace.Status.Phase), string(filter.Value)) == 0
+		return filter.MatchString(string(namespace.Status.Phase))
 	default:
 		return v1alpha3.DefaultObjectMetaFilter(namespace.ObjectMeta, filter)
 	}
 }
 
+// TableColumns returns the columns of the Table output of namespaces
+func (n namespacesGetter) TableColumns() []v1alpha3.TableColumn {
+	return []v1alpha3.TableColumn{
+		v1alpha3.NameColumn,
+		{
+			TableColumnDefinition: metav1.TableColumnDefinition{Name: "Status", Type: "string", Description: "The phase of the namespace."},
+			Cell: v1alpha3.Cell(func(namespace *v1.Namespace) interface{} {
+				return string(namespace.Status.Phase)
+			}),
+		},
+		v1alpha3.AgeColumn,
+	}
+}
+
 func (n namespacesGetter) compare(left runtime.Object, right runtime.Object, field query.Field) bool {
 	leftNs, ok := left.(*v1.Namespace)
 	if !ok {
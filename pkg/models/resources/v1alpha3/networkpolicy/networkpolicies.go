@@ -49,7 +49,7 @@ func (n networkpolicyGetter) List(namespace string, query *query.Query) (*api.Li
 		result = append(result, item)
 	}
 
-	return v1alpha3.DefaultList(result, query, n.compare, n.filter), nil
+	return v1alpha3.DefaultList(result, query, n.compare, n.filter)
 }
 
 func (n networkpolicyGetter) filter(item runtime.Object, filter query.Filter) bool {
@@ -22,6 +22,7 @@ import (
 
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/informers"
@@ -94,15 +95,7 @@ func (c *nodesGetter) List(_ string, q *query.Query) (*api.ListResult, error) {
 
 	var filtered []*v1.Node
 	for _, object := range nodes {
-		selected := true
-		for field, value := range q.Filters {
-			if !c.filter(object, query.Filter{Field: field, Value: value}) {
-				selected = false
-				break
-			}
-		}
-
-		if selected {
+		if q.Matches(object, c.filter) {
 			filtered = append(filtered, object)
 		}
 	}
@@ -165,12 +158,43 @@ func (c *nodesGetter) filter(object runtime.Object, filter query.Filter) bool {
 	}
 	switch filter.Field {
 	case query.FieldStatus:
-		return getNodeStatus(node) == string(filter.Value)
+		return filter.MatchString(getNodeStatus(node))
 	}
 
 	return v1alpha3.DefaultObjectMetaFilter(node.ObjectMeta, filter)
 }
 
+// TableColumns returns the columns of the Table output of nodes
+func (c *nodesGetter) TableColumns() []v1alpha3.TableColumn {
+	return []v1alpha3.TableColumn{
+		v1alpha3.NameColumn,
+		{
+			TableColumnDefinition: metav1.TableColumnDefinition{Name: "Status", Type: "string", Description: "The status of the node."},
+			Cell: v1alpha3.Cell(func(node *v1.Node) interface{} {
+				return getNodeStatus(node)
+			}),
+		},
+		{
+			TableColumnDefinition: metav1.TableColumnDefinition{Name: "Version", Type: "string", Description: "The kubelet version of the node."},
+			Cell: v1alpha3.Cell(func(node *v1.Node) interface{} {
+				return node.Status.NodeInfo.KubeletVersion
+			}),
+		},
+		{
+			TableColumnDefinition: metav1.TableColumnDefinition{Name: "Internal-IP", Type: "string", Description: "The internal IP of the node."},
+			Cell: v1alpha3.Cell(func(node *v1.Node) interface{} {
+				for _, address := range node.Status.Addresses {
+					if address.Type == v1.NodeInternalIP {
+						return address.Address
+					}
+				}
+				return ""
+			}),
+		},
+		v1alpha3.AgeColumn,
+	}
+}
+
 // annotateNode adds cpu/memory requests usage data to node's annotations
 // this operation mutates the *v1.Node passed in
 // so DO A DEEPCOPY before calling
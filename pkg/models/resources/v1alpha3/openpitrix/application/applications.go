@@ -63,7 +63,7 @@ func (r *helmApplicationsGetter) List(_ string, query *query.Query) (*api.ListRe
 		result = append(result, apps[i])
 	}
 
-	return v1alpha3.DefaultList(result, query, r.compare, r.filter), nil
+	return v1alpha3.DefaultList(result, query, r.compare, r.filter)
 }
 
 func (r *helmApplicationsGetter) compare(left runtime.Object, right runtime.Object, field query.Field) bool {
@@ -93,9 +93,9 @@ func (r *helmApplicationsGetter) filter(object runtime.Object, filter query.Filt
 
 	switch filter.Field {
 	case query.FieldName:
-		return strings.Contains(application.Spec.Name, string(filter.Value))
+		return filter.MatchStringFunc(application.Spec.Name, strings.Contains)
 	case query.FieldStatus:
-		return strings.Contains(application.Status.State, string(filter.Value))
+		return filter.MatchStringFunc(application.Status.State, strings.Contains)
 	default:
 		return v1alpha3.DefaultObjectMetaFilter(application.ObjectMeta, filter)
 	}
@@ -63,7 +63,7 @@ func (r *applicationVersionsGetter) List(_ string, query *query.Query) (*api.Lis
 		result = append(result, apps[i])
 	}
 
-	return v1alpha3.DefaultList(result, query, r.compare, r.filter), nil
+	return v1alpha3.DefaultList(result, query, r.compare, r.filter)
 }
 
 func (r *applicationVersionsGetter) compare(left runtime.Object, right runtime.Object, field query.Field) bool {
@@ -93,9 +93,9 @@ func (r *applicationVersionsGetter) filter(object runtime.Object, filter query.F
 
 	switch filter.Field {
 	case query.FieldName:
-		return strings.Contains(appVer.Spec.Name, string(filter.Value))
+		return filter.MatchStringFunc(appVer.Spec.Name, strings.Contains)
 	case query.FieldStatus:
-		return strings.Contains(appVer.Status.State, string(filter.Value))
+		return filter.MatchStringFunc(appVer.Status.State, strings.Contains)
 	default:
 		return v1alpha3.DefaultObjectMetaFilter(appVer.ObjectMeta, filter)
 	}
@@ -63,7 +63,7 @@ func (r *helmCategoriesGetter) List(_ string, query *query.Query) (*api.ListResu
 		result = append(result, ctg[i])
 	}
 
-	return v1alpha3.DefaultList(result, query, r.compare, r.filter), nil
+	return v1alpha3.DefaultList(result, query, r.compare, r.filter)
 }
 
 func (r *helmCategoriesGetter) compare(left runtime.Object, right runtime.Object, field query.Field) bool {
@@ -93,7 +93,7 @@ func (r *helmCategoriesGetter) filter(object runtime.Object, filter query.Filter
 
 	switch filter.Field {
 	case query.FieldName:
-		return strings.Contains(application.Spec.Name, string(filter.Value))
+		return filter.MatchStringFunc(application.Spec.Name, strings.Contains)
 	default:
 		return v1alpha3.DefaultObjectMetaFilter(application.ObjectMeta, filter)
 	}
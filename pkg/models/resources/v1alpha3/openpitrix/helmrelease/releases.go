@@ -63,7 +63,7 @@ func (r *helmReleasesGetter) List(_ string, query *query.Query) (*api.ListResult
 		result = append(result, rls[i])
 	}
 
-	return v1alpha3.DefaultList(result, query, r.compare, r.filter), nil
+	return v1alpha3.DefaultList(result, query, r.compare, r.filter)
 }
 
 func (r *helmReleasesGetter) compare(left runtime.Object, right runtime.Object, field query.Field) bool {
@@ -93,9 +93,9 @@ func (r *helmReleasesGetter) filter(object runtime.Object, filter query.Filter)
 
 	switch filter.Field {
 	case query.FieldName:
-		return strings.Contains(rls.Spec.Name, string(filter.Value))
+		return filter.MatchStringFunc(rls.Spec.Name, strings.Contains)
 	case query.FieldStatus:
-		return strings.Contains(rls.Status.State, string(filter.Value))
+		return filter.MatchStringFunc(rls.Status.State, strings.Contains)
 	default:
 		return v1alpha3.DefaultObjectMetaFilter(rls.ObjectMeta, filter)
 	}
@@ -55,7 +55,7 @@ func (d *reposGetter) List(_ string, query *query.Query) (*api.ListResult, error
 		result = append(result, user)
 	}
 
-	return v1alpha3.DefaultList(result, query, d.compare, d.filter), nil
+	return v1alpha3.DefaultList(result, query, d.compare, d.filter)
 }
 
 func (d *reposGetter) compare(left runtime.Object, right runtime.Object, field query.Field) bool {
@@ -87,7 +87,7 @@ func (d *reposGetter) filter(object runtime.Object, filter query.Filter) bool {
 
 	switch filter.Field {
 	case query.FieldName:
-		return strings.Contains(repo.Spec.Name, string(filter.Value))
+		return filter.MatchStringFunc(repo.Spec.Name, strings.Contains)
 	default:
 		return v1alpha3.DefaultObjectMetaFilter(repo.ObjectMeta, filter)
 	}
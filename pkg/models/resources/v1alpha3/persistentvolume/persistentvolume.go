@@ -54,7 +54,7 @@ func (p *persistentVolumeGetter) List(namespace string, query *query.Query) (*ap
 	for _, pv := range all {
 		result = append(result, pv)
 	}
-	return v1alpha3.DefaultList(result, query, p.compare, p.filter), nil
+	return v1alpha3.DefaultList(result, query, p.compare, p.filter)
 }
 
 func (p *persistentVolumeGetter) compare(obj1, obj2 runtime.Object, field query.Field) bool {
@@ -76,9 +76,9 @@ func (p *persistentVolumeGetter) filter(object runtime.Object, filter query.Filt
 	}
 	switch filter.Field {
 	case query.FieldStatus:
-		return strings.EqualFold(string(pv.Status.Phase), string(filter.Value))
+		return filter.MatchStringFunc(string(pv.Status.Phase), strings.EqualFold)
 	case storageClassName:
-		return pv.Spec.StorageClassName != "" && pv.Spec.StorageClassName == string(filter.Value)
+		return pv.Spec.StorageClassName != "" && filter.MatchString(pv.Spec.StorageClassName)
 	default:
 		return v1alpha3.DefaultObjectMetaFilter(pv.ObjectMeta, filter)
 	}
@@ -22,6 +22,7 @@ import (
 
 	snapshotinformers "github.com/kubernetes-csi/external-snapshotter/client/v4/informers/externalversions"
 	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/informers"
@@ -71,7 +72,7 @@ func (p *persistentVolumeClaimGetter) List(namespace string, query *query.Query)
 		p.annotatePVC(pvc)
 		result = append(result, pvc)
 	}
-	return v1alpha3.DefaultList(result, query, p.compare, p.filter), nil
+	return v1alpha3.DefaultList(result, query, p.compare, p.filter)
 }
 
 func (p *persistentVolumeClaimGetter) compare(left, right runtime.Object, field query.Field) bool {
@@ -94,14 +95,52 @@ func (p *persistentVolumeClaimGetter) filter(object runtime.Object, filter query
 
 	switch filter.Field {
 	case query.FieldStatus:
-		return strings.EqualFold(string(pvc.Status.Phase), string(filter.Value))
+		return filter.MatchStringFunc(string(pvc.Status.Phase), strings.EqualFold)
 	case storageClassName:
-		return pvc.Spec.StorageClassName != nil && *pvc.Spec.StorageClassName == string(filter.Value)
+		return pvc.Spec.StorageClassName != nil && filter.MatchString(*pvc.Spec.StorageClassName)
 	default:
 		return v1alpha3.DefaultObjectMetaFilter(pvc.ObjectMeta, filter)
 	}
 }
 
+// TableColumns returns the columns of the Table output of persistentvolumeclaims
+func (p *persistentVolumeClaimGetter) TableColumns() []v1alpha3.TableColumn {
+	return []v1alpha3.TableColumn{
+		v1alpha3.NameColumn,
+		{
+			TableColumnDefinition: metav1.TableColumnDefinition{Name: "Status", Type: "string", Description: "The phase of the persistent volume claim."},
+			Cell: v1alpha3.Cell(func(pvc *v1.PersistentVolumeClaim) interface{} {
+				return string(pvc.Status.Phase)
+			}),
+		},
+		{
+			TableColumnDefinition: metav1.TableColumnDefinition{Name: "Volume", Type: "string", Description: "The name of the bound persistent volume."},
+			Cell: v1alpha3.Cell(func(pvc *v1.PersistentVolumeClaim) interface{} {
+				return pvc.Spec.VolumeName
+			}),
+		},
+		{
+			TableColumnDefinition: metav1.TableColumnDefinition{Name: "Capacity", Type: "string", Description: "The capacity of the bound persistent volume."},
+			Cell: v1alpha3.Cell(func(pvc *v1.PersistentVolumeClaim) interface{} {
+				if storage, ok := pvc.Status.Capacity[v1.ResourceStorage]; ok {
+					return storage.String()
+				}
+				return ""
+			}),
+		},
+		{
+			TableColumnDefinition: metav1.TableColumnDefinition{Name: "StorageClass", Type: "string", Description: "The storage class of the persistent volume claim."},
+			Cell: v1alpha3.Cell(func(pvc *v1.PersistentVolumeClaim) interface{} {
+				if pvc.Spec.StorageClassName != nil {
+					return *pvc.Spec.StorageClassName
+				}
+				return ""
+			}),
+		},
+		v1alpha3.AgeColumn,
+	}
+}
+
 func (p *persistentVolumeClaimGetter) annotatePVC(pvc *v1.PersistentVolumeClaim) {
 	inUse := p.countPods(pvc.Name, pvc.Namespace)
 	isSnapshotAllow := p.isSnapshotAllowed(pvc.GetAnnotations()[annotationStorageProvisioner])
@@ -21,8 +21,10 @@ import (
 	"strings"
 
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
 	"k8s.io/client-go/informers"
 
 	"kubesphere.io/kubesphere/pkg/api"
@@ -68,7 +70,11 @@ func (p *podsGetter) List(namespace string, query *query.Query) (*api.ListResult
 		result = append(result, pod)
 	}
 
-	return v1alpha3.DefaultList(result, query, p.compare, p.filter), nil
+	return v1alpha3.DefaultList(result, query, p.compare, p.filter)
+}
+
+func (p *podsGetter) Watch(namespace string, query *query.Query, resourceVersion string) (watch.Interface, error) {
+	return v1alpha3.DefaultWatch(p.informer.Core().V1().Pods().Informer(), namespace, query, resourceVersion, p.filter)
 }
 
 func (p *podsGetter) compare(left runtime.Object, right runtime.Object, field query.Field) bool {
@@ -94,16 +100,16 @@ func (p *podsGetter) filter(object runtime.Object, filter query.Filter) bool {
 	}
 	switch filter.Field {
 	case fieldNodeName:
-		return pod.Spec.NodeName == string(filter.Value)
+		return filter.MatchString(pod.Spec.NodeName)
 	case fieldPVCName:
 		return p.podBindPVC(pod, string(filter.Value))
 	case fieldServiceName:
 		return p.podBelongToService(pod, string(filter.Value))
 	case fieldStatus:
 		_, statusType := p.getPodStatus(pod)
-		return statusType == string(filter.Value)
+		return filter.MatchString(statusType)
 	case fieldPhase:
-		return string(pod.Status.Phase) == string(filter.Value)
+		return filter.MatchString(string(pod.Status.Phase))
 	case fieldPodIP:
 		return p.podWithIP(pod, string(filter.Value))
 	default:
@@ -111,6 +117,49 @@ func (p *podsGetter) filter(object runtime.Object, filter query.Filter) bool {
 	}
 }
 
+// TableColumns returns the columns of the Table output of pods
+func (p *podsGetter) TableColumns() []v1alpha3.TableColumn {
+	return []v1alpha3.TableColumn{
+		v1alpha3.NameColumn,
+		{
+			TableColumnDefinition: metav1.TableColumnDefinition{Name: "Ready", Type: "string", Description: "The number of ready containers out of the containers of the pod."},
+			Cell: v1alpha3.Cell(func(pod *corev1.Pod) interface{} {
+				ready := 0
+				for _, status := range pod.Status.ContainerStatuses {
+					if status.Ready {
+						ready++
+					}
+				}
+				return fmt.Sprintf("%d/%d", ready, len(pod.Spec.Containers))
+			}),
+		},
+		{
+			TableColumnDefinition: metav1.TableColumnDefinition{Name: "Status", Type: "string", Description: "The status of the pod, refer to kubectl get pods."},
+			Cell: v1alpha3.Cell(func(pod *corev1.Pod) interface{} {
+				reason, _ := p.getPodStatus(pod)
+				return reason
+			}),
+		},
+		{
+			TableColumnDefinition: metav1.TableColumnDefinition{Name: "Restarts", Type: "integer", Description: "The number of restarts of the containers of the pod."},
+			Cell: v1alpha3.Cell(func(pod *corev1.Pod) interface{} {
+				var restarts int64
+				for _, status := range pod.Status.ContainerStatuses {
+					restarts += int64(status.RestartCount)
+				}
+				return restarts
+			}),
+		},
+		{
+			TableColumnDefinition: metav1.TableColumnDefinition{Name: "Node", Type: "string", Description: "The node the pod is scheduled to."},
+			Cell: v1alpha3.Cell(func(pod *corev1.Pod) interface{} {
+				return pod.Spec.NodeName
+			}),
+		},
+		v1alpha3.AgeColumn,
+	}
+}
+
 func (p *podsGetter) podWithIP(item *corev1.Pod, ipAddress string) bool {
 	for _, ip := range item.Status.PodIPs {
 		if strings.Contains(ip.String(), ipAddress) {
@@ -178,3 +178,29 @@ func prepare() v1alpha3.Interface {
 
 	return New(informer)
 }
+
+func TestPodTableColumns(t *testing.T) {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "default"},
+		Spec: corev1.PodSpec{
+			NodeName:   "node1",
+			Containers: []corev1.Container{{Name: "app"}, {Name: "sidecar"}},
+		},
+		Status: corev1.PodStatus{
+			Phase: corev1.PodRunning,
+			ContainerStatuses: []corev1.ContainerStatus{
+				{Name: "app", Ready: true, RestartCount: 2, State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{}}},
+				{Name: "sidecar", RestartCount: 1, State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{}}},
+			},
+		},
+	}
+
+	table, err := v1alpha3.DefaultTable(&api.ListResult{Items: []interface{}{pod}, TotalItems: 1}, prepare().(v1alpha3.TableInterface).TableColumns())
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []interface{}{"foo", "1/2", "Running", int64(3), "node1", "0001-01-01T00:00:00Z"}
+	if diff := cmp.Diff(table.Rows[0].Cells, expected); diff != "" {
+		t.Errorf("%T differ (-got, +want): %s", expected, diff)
+	}
+}
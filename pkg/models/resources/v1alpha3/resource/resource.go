@@ -29,9 +29,11 @@ import (
 	rbacv1 "k8s.io/api/rbac/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
 	monitoringdashboardv1alpha2 "kubesphere.io/monitoring-dashboard/api/v1alpha2"
 	"sigs.k8s.io/controller-runtime/pkg/cache"
 
+	appv1alpha1 "kubesphere.io/api/application/v1alpha1"
 	clusterv1alpha1 "kubesphere.io/api/cluster/v1alpha1"
 	devopsv1alpha3 "kubesphere.io/api/devops/v1alpha3"
 	iamv1alpha2 "kubesphere.io/api/iam/v1alpha2"
@@ -77,6 +79,7 @@ import (
 	"kubesphere.io/kubesphere/pkg/models/resources/v1alpha3/networkpolicy"
 	"kubesphere.io/kubesphere/pkg/models/resources/v1alpha3/node"
 	"kubesphere.io/kubesphere/pkg/models/resources/v1alpha3/notification"
+	"kubesphere.io/kubesphere/pkg/models/resources/v1alpha3/openpitrix/helmrelease"
 	"kubesphere.io/kubesphere/pkg/models/resources/v1alpha3/persistentvolumeclaim"
 	"kubesphere.io/kubesphere/pkg/models/resources/v1alpha3/pod"
 	"kubesphere.io/kubesphere/pkg/models/resources/v1alpha3/role"
@@ -94,10 +97,12 @@ import (
 )
 
 var ErrResourceNotSupported = errors.New("resource is not supported")
+var ErrWatchNotSupported = errors.New("watch is not supported for the resource")
 
 type ResourceGetter struct {
 	clusterResourceGetters    map[schema.GroupVersionResource]v1alpha3.Interface
 	namespacedResourceGetters map[schema.GroupVersionResource]v1alpha3.Interface
+	searchResourceGetters     map[schema.GroupVersionResource]v1alpha3.Interface
 }
 
 func NewResourceGetter(factory informers.InformerFactory, cache cache.Cache) *ResourceGetter {
@@ -162,9 +167,19 @@ func NewResourceGetter(factory informers.InformerFactory, cache cache.Cache) *Re
 	namespacedResourceGetters[typesv1beta1.SchemeGroupVersion.WithResource(typesv1beta1.ResourcePluralFederatedIngress)] = federatedingress.New(factory.KubeSphereSharedInformerFactory())
 	namespacedResourceGetters[monitoringdashboardv1alpha2.GroupVersion.WithResource("dashboards")] = dashboard.New(cache)
 
+	searchResourceGetters := make(map[schema.GroupVersionResource]v1alpha3.Interface)
+	for _, gvr := range searchResources {
+		if getter, ok := namespacedResourceGetters[gvr]; ok {
+			searchResourceGetters[gvr] = getter
+		}
+	}
+	// helm releases are only searchable, they are listed by the openpitrix APIs
+	searchResourceGetters[appv1alpha1.SchemeGroupVersion.WithResource(appv1alpha1.ResourcePluralHelmRelease)] = helmrelease.New(factory.KubeSphereSharedInformerFactory())
+
 	return &ResourceGetter{
 		namespacedResourceGetters: namespacedResourceGetters,
 		clusterResourceGetters:    clusterResourceGetters,
+		searchResourceGetters:     searchResourceGetters,
 	}
 }
 
@@ -203,3 +218,26 @@ func (r *ResourceGetter) List(resource, namespace string, query *query.Query) (*
 	}
 	return getter.List(namespace, query)
 }
+
+func (r *ResourceGetter) Watch(resource, namespace string, query *query.Query, resourceVersion string) (watch.Interface, error) {
+	clusterScope := namespace == ""
+	getter := r.TryResource(clusterScope, resource)
+	if getter == nil {
+		return nil, ErrResourceNotSupported
+	}
+	watcher, ok := getter.(v1alpha3.WatchInterface)
+	if !ok {
+		return nil, ErrWatchNotSupported
+	}
+	return watcher.Watch(namespace, query, resourceVersion)
+}
+
+// TableColumns returns the columns of the Table output of the resource,
+// v1alpha3.DefaultTableColumns if the resource getter has no columns of its own.
+func (r *ResourceGetter) TableColumns(resource, namespace string) []v1alpha3.TableColumn {
+	clusterScope := namespace == ""
+	if table, ok := r.TryResource(clusterScope, resource).(v1alpha3.TableInterface); ok {
+		return table.TableColumns()
+	}
+	return v1alpha3.DefaultTableColumns
+}
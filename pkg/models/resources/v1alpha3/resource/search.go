@@ -0,0 +1,236 @@
+/*
+Copyright 2022 KubeSphere Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resource
+
+import (
+	"sort"
+	"strings"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/klog/v2"
+
+	appv1alpha1 "kubesphere.io/api/application/v1alpha1"
+
+	"kubesphere.io/kubesphere/pkg/apiserver/query"
+	"kubesphere.io/kubesphere/pkg/constants"
+	"kubesphere.io/kubesphere/pkg/utils/sliceutil"
+)
+
+const (
+	scoreExactName    = 100
+	scoreNamePrefix   = 80
+	scoreNameContains = 60
+	scoreAlias        = 40
+	scoreLabel        = 20
+
+	// maxSearchedObjects is the number of the newest objects searched of each resource
+	maxSearchedObjects = 10000
+)
+
+// searchResources are the resources included in the global search
+var searchResources = []schema.GroupVersionResource{
+	{Group: "apps", Version: "v1", Resource: "deployments"},
+	{Group: "apps", Version: "v1", Resource: "statefulsets"},
+	{Group: "apps", Version: "v1", Resource: "daemonsets"},
+	{Group: "batch", Version: "v1", Resource: "jobs"},
+	{Group: "", Version: "v1", Resource: "services"},
+	{Group: "", Version: "v1", Resource: "configmaps"},
+	{Group: "", Version: "v1", Resource: "secrets"},
+	{Group: "networking.k8s.io", Version: "v1", Resource: "ingresses"},
+	appv1alpha1.SchemeGroupVersion.WithResource(appv1alpha1.ResourcePluralHelmRelease),
+}
+
+// SearchHit is a resource matching the keyword of a search, only the metadata of the resource is returned
+type SearchHit struct {
+	Group             string      `json:"group"`
+	Version           string      `json:"version"`
+	Resource          string      `json:"resource"`
+	Namespace         string      `json:"namespace,omitempty"`
+	Name              string      `json:"name"`
+	Alias             string      `json:"alias,omitempty"`
+	CreationTimestamp metav1.Time `json:"creationTimestamp"`
+	// Score is the match quality of the hit, the higher the better
+	Score int `json:"score"`
+}
+
+// SearchFailure is a resource failed to be searched
+type SearchFailure struct {
+	Group    string `json:"group"`
+	Version  string `json:"version"`
+	Resource string `json:"resource"`
+	Message  string `json:"message"`
+}
+
+type SearchResult struct {
+	Items      []SearchHit `json:"items"`
+	TotalItems int         `json:"totalItems"`
+	// Failures are the resources failed to be searched, the hits of the other resources are still returned
+	Failures []SearchFailure `json:"failures,omitempty"`
+	// Truncated are the resources having more objects than searched, only the newest objects are searched
+	Truncated []string `json:"truncated,omitempty"`
+}
+
+// Search searches the resources for the keyword in the informer caches, the hits are ranked by an exact name
+// match, a name prefix match, a name substring match, an alias match and a label value match, in that order.
+// Only the hits in the namespaces where the resource is allowed are returned. All the searchable resources are
+// searched if resources is empty, at most limit hits are returned if limit is positive. At most maxSearchedObjects
+// objects are searched of each resource, the resources failed to be searched are reported in the result.
+func (r *ResourceGetter) Search(keyword string, resources []string, limit int, allowed func(resource schema.GroupVersionResource, namespace string) bool) (*SearchResult, error) {
+	var names []string
+	for _, gvr := range searchResources {
+		names = append(names, gvr.Resource)
+	}
+	for _, resource := range resources {
+		if !sliceutil.HasString(names, resource) {
+			return nil, ErrResourceNotSupported
+		}
+	}
+	var gvrs []schema.GroupVersionResource
+	for _, gvr := range searchResources {
+		if len(resources) == 0 || sliceutil.HasString(resources, gvr.Resource) {
+			gvrs = append(gvrs, gvr)
+		}
+	}
+
+	keyword = strings.ToLower(keyword)
+	var lock sync.Mutex
+	var wg sync.WaitGroup
+	var hits []SearchHit
+	failures := make([]*SearchFailure, len(gvrs))
+	truncated := make([]bool, len(gvrs))
+	for i, gvr := range gvrs {
+		getter := r.searchResourceGetters[gvr]
+		if getter == nil {
+			continue
+		}
+		wg.Add(1)
+		go func(i int, gvr schema.GroupVersionResource) {
+			defer wg.Done()
+			q := query.New()
+			q.Pagination = &query.Pagination{Limit: maxSearchedObjects}
+			result, err := getter.List("", q)
+			if err != nil {
+				klog.Error(err)
+				failures[i] = &SearchFailure{Group: gvr.Group, Version: gvr.Version, Resource: gvr.Resource, Message: err.Error()}
+				return
+			}
+			truncated[i] = result.TotalItems > len(result.Items)
+			var matched []SearchHit
+			for _, item := range result.Items {
+				object, ok := item.(runtime.Object)
+				if !ok {
+					continue
+				}
+				if hit, ok := searchHit(gvr, object, keyword); ok {
+					matched = append(matched, hit)
+				}
+			}
+			lock.Lock()
+			hits = append(hits, matched...)
+			lock.Unlock()
+		}(i, gvr)
+	}
+	wg.Wait()
+
+	// the decisions are made after matching, so that the authorizer is only asked for the namespaces having hits
+	decisions := make(map[string]bool)
+	visible := make([]SearchHit, 0, len(hits))
+	for _, hit := range hits {
+		gvr := schema.GroupVersionResource{Group: hit.Group, Version: hit.Version, Resource: hit.Resource}
+		key := gvr.String() + "/" + hit.Namespace
+		decision, ok := decisions[key]
+		if !ok {
+			decision = allowed(gvr, hit.Namespace)
+			decisions[key] = decision
+		}
+		if decision {
+			visible = append(visible, hit)
+		}
+	}
+
+	sort.Slice(visible, func(i, j int) bool {
+		if visible[i].Score != visible[j].Score {
+			return visible[i].Score > visible[j].Score
+		}
+		if visible[i].Name != visible[j].Name {
+			return visible[i].Name < visible[j].Name
+		}
+		if visible[i].Namespace != visible[j].Namespace {
+			return visible[i].Namespace < visible[j].Namespace
+		}
+		return visible[i].Resource < visible[j].Resource
+	})
+
+	result := &SearchResult{Items: visible, TotalItems: len(visible)}
+	for i, gvr := range gvrs {
+		if failures[i] != nil {
+			result.Failures = append(result.Failures, *failures[i])
+		}
+		if truncated[i] {
+			result.Truncated = append(result.Truncated, gvr.Resource)
+		}
+	}
+	if limit > 0 && len(visible) > limit {
+		result.Items = visible[:limit]
+	}
+	return result, nil
+}
+
+func searchHit(gvr schema.GroupVersionResource, object runtime.Object, keyword string) (SearchHit, bool) {
+	accessor, err := meta.Accessor(object)
+	if err != nil {
+		return SearchHit{}, false
+	}
+	hit := SearchHit{
+		Group:             gvr.Group,
+		Version:           gvr.Version,
+		Resource:          gvr.Resource,
+		Namespace:         accessor.GetNamespace(),
+		Name:              accessor.GetName(),
+		Alias:             accessor.GetAnnotations()[constants.DisplayNameAnnotationKey],
+		CreationTimestamp: accessor.GetCreationTimestamp(),
+	}
+	// helm releases are cluster scoped, the namespace and the name of the release are kept in the labels and the spec
+	if release, ok := object.(*appv1alpha1.HelmRelease); ok {
+		hit.Namespace = release.GetRlsNamespace()
+		hit.Name = release.GetTrueName()
+	}
+
+	name := strings.ToLower(hit.Name)
+	switch {
+	case name == keyword:
+		hit.Score = scoreExactName
+	case strings.HasPrefix(name, keyword):
+		hit.Score = scoreNamePrefix
+	case strings.Contains(name, keyword):
+		hit.Score = scoreNameContains
+	case strings.Contains(strings.ToLower(hit.Alias), keyword):
+		hit.Score = scoreAlias
+	default:
+		for _, value := range accessor.GetLabels() {
+			if strings.ToLower(value) == keyword {
+				hit.Score = scoreLabel
+				break
+			}
+		}
+	}
+	return hit, hit.Score > 0
+}
@@ -0,0 +1,192 @@
+/*
+Copyright 2022 KubeSphere Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resource
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	fakesnapshot "github.com/kubernetes-csi/external-snapshotter/client/v4/clientset/versioned/fake"
+	fakeistio "istio.io/client-go/pkg/clientset/versioned/fake"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	fakeapiextensions "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset/fake"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	fakek8s "k8s.io/client-go/kubernetes/fake"
+
+	appv1alpha1 "kubesphere.io/api/application/v1alpha1"
+
+	"kubesphere.io/kubesphere/pkg/api"
+	"kubesphere.io/kubesphere/pkg/apiserver/query"
+	fakeks "kubesphere.io/kubesphere/pkg/client/clientset/versioned/fake"
+	"kubesphere.io/kubesphere/pkg/constants"
+	"kubesphere.io/kubesphere/pkg/informers"
+)
+
+func prepareSearch(t *testing.T) *ResourceGetter {
+	factory := informers.NewInformerFactories(fakek8s.NewSimpleClientset(), fakeks.NewSimpleClientset(), fakeistio.NewSimpleClientset(),
+		fakesnapshot.NewSimpleClientset(), fakeapiextensions.NewSimpleClientset(), nil)
+
+	deployments := []*appsv1.Deployment{
+		{ObjectMeta: metav1.ObjectMeta{Namespace: "team-a", Name: "payments"}},
+		{ObjectMeta: metav1.ObjectMeta{Namespace: "team-b", Name: "payments-worker"}},
+		{ObjectMeta: metav1.ObjectMeta{Namespace: "team-a", Name: "checkout", Annotations: map[string]string{constants.DisplayNameAnnotationKey: "Payments Checkout"}}},
+		{ObjectMeta: metav1.ObjectMeta{Namespace: "team-a", Name: "web", Labels: map[string]string{"app": "payments"}}},
+		{ObjectMeta: metav1.ObjectMeta{Namespace: "team-a", Name: "unrelated"}},
+	}
+	for _, deployment := range deployments {
+		if err := factory.KubernetesSharedInformerFactory().Apps().V1().Deployments().Informer().GetIndexer().Add(deployment); err != nil {
+			t.Fatal(err)
+		}
+	}
+	services := []*corev1.Service{
+		{ObjectMeta: metav1.ObjectMeta{Namespace: "team-a", Name: "legacy-payments"}},
+	}
+	for _, service := range services {
+		if err := factory.KubernetesSharedInformerFactory().Core().V1().Services().Informer().GetIndexer().Add(service); err != nil {
+			t.Fatal(err)
+		}
+	}
+	release := &appv1alpha1.HelmRelease{
+		ObjectMeta: metav1.ObjectMeta{Name: "rls-7wxw123np2nm0l", Labels: map[string]string{constants.NamespaceLabelKey: "team-b"}},
+		Spec:       appv1alpha1.HelmReleaseSpec{Name: "payments-db"},
+	}
+	if err := factory.KubeSphereSharedInformerFactory().Application().V1alpha1().HelmReleases().Informer().GetIndexer().Add(release); err != nil {
+		t.Fatal(err)
+	}
+	return NewResourceGetter(factory, nil)
+}
+
+func TestSearch(t *testing.T) {
+	getter := prepareSearch(t)
+	allowAll := func(schema.GroupVersionResource, string) bool { return true }
+
+	type hit struct {
+		Resource, Namespace, Name string
+		Score                     int
+	}
+	hits := func(result *SearchResult) []hit {
+		var got []hit
+		for _, item := range result.Items {
+			got = append(got, hit{item.Resource, item.Namespace, item.Name, item.Score})
+		}
+		return got
+	}
+
+	tests := []struct {
+		description string
+		keyword     string
+		resources   []string
+		limit       int
+		allowed     func(schema.GroupVersionResource, string) bool
+		expected    []hit
+		total       int
+	}{
+		{
+			description: "ranked by match quality",
+			keyword:     "Payments",
+			allowed:     allowAll,
+			expected: []hit{
+				{"deployments", "team-a", "payments", scoreExactName},
+				{"helmreleases", "team-b", "payments-db", scoreNamePrefix},
+				{"deployments", "team-b", "payments-worker", scoreNamePrefix},
+				{"services", "team-a", "legacy-payments", scoreNameContains},
+				{"deployments", "team-a", "checkout", scoreAlias},
+				{"deployments", "team-a", "web", scoreLabel},
+			},
+			total: 6,
+		},
+		{
+			description: "filtered by permissions",
+			keyword:     "payments",
+			allowed: func(resource schema.GroupVersionResource, namespace string) bool {
+				return namespace == "team-b" && resource.Resource == "deployments"
+			},
+			expected: []hit{{"deployments", "team-b", "payments-worker", scoreNamePrefix}},
+			total:    1,
+		},
+		{
+			description: "limited resources",
+			keyword:     "payments",
+			resources:   []string{"services"},
+			allowed:     allowAll,
+			expected:    []hit{{"services", "team-a", "legacy-payments", scoreNameContains}},
+			total:       1,
+		},
+		{
+			description: "limited hits",
+			keyword:     "payments",
+			limit:       2,
+			allowed:     allowAll,
+			expected: []hit{
+				{"deployments", "team-a", "payments", scoreExactName},
+				{"helmreleases", "team-b", "payments-db", scoreNamePrefix},
+			},
+			total: 6,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			result, err := getter.Search(test.keyword, test.resources, test.limit, test.allowed)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(hits(result), test.expected); diff != "" {
+				t.Errorf("%T differ (-got, +want): %s", test.expected, diff)
+			}
+			if result.TotalItems != test.total {
+				t.Errorf("expected %d hits in total, got %d", test.total, result.TotalItems)
+			}
+		})
+	}
+
+	if _, err := getter.Search("payments", []string{"nodes"}, 0, allowAll); err != ErrResourceNotSupported {
+		t.Errorf("expected %v, got %v", ErrResourceNotSupported, err)
+	}
+}
+
+type failedGetter struct{}
+
+func (failedGetter) Get(string, string) (runtime.Object, error) {
+	return nil, fmt.Errorf("unavailable")
+}
+
+func (failedGetter) List(string, *query.Query) (*api.ListResult, error) {
+	return nil, fmt.Errorf("unavailable")
+}
+
+func TestSearchFailures(t *testing.T) {
+	getter := prepareSearch(t)
+	getter.searchResourceGetters[schema.GroupVersionResource{Group: "", Version: "v1", Resource: "services"}] = failedGetter{}
+	allowAll := func(schema.GroupVersionResource, string) bool { return true }
+
+	result, err := getter.Search("payments", []string{"deployments", "services"}, 0, allowAll)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.TotalItems != 4 {
+		t.Errorf("expected the hits of the other resources, got %d hits", result.TotalItems)
+	}
+	expected := []SearchFailure{{Version: "v1", Resource: "services", Message: "unavailable"}}
+	if diff := cmp.Diff(result.Failures, expected); diff != "" {
+		t.Errorf("%T differ (-got, +want): %s", expected, diff)
+	}
+}
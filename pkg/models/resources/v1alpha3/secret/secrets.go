@@ -20,9 +20,11 @@ import (
 	"encoding/json"
 	"fmt"
 
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/selection"
+	"k8s.io/apimachinery/pkg/watch"
 	"k8s.io/client-go/informers"
 	"k8s.io/klog/v2"
 
@@ -57,7 +59,11 @@ func (s *secretSearcher) List(namespace string, query *query.Query) (*api.ListRe
 		result = append(result, secret)
 	}
 
-	return v1alpha3.DefaultList(result, query, s.compare, s.filter), nil
+	return v1alpha3.DefaultList(result, query, s.compare, s.filter)
+}
+
+func (s *secretSearcher) Watch(namespace string, query *query.Query, resourceVersion string) (watch.Interface, error) {
+	return v1alpha3.DefaultWatch(s.informers.Core().V1().Secrets().Informer(), namespace, query, resourceVersion, s.filter)
 }
 
 func (s *secretSearcher) compare(left runtime.Object, right runtime.Object, field query.Field) bool {
@@ -88,6 +94,26 @@ func (s *secretSearcher) filter(object runtime.Object, filter query.Filter) bool
 	return v1alpha3.DefaultObjectMetaFilter(secret.ObjectMeta, filter)
 }
 
+// TableColumns returns the columns of the Table output of secrets
+func (s *secretSearcher) TableColumns() []v1alpha3.TableColumn {
+	return []v1alpha3.TableColumn{
+		v1alpha3.NameColumn,
+		{
+			TableColumnDefinition: metav1.TableColumnDefinition{Name: "Type", Type: "string", Description: "The type of the secret."},
+			Cell: v1alpha3.Cell(func(secret *v1.Secret) interface{} {
+				return string(secret.Type)
+			}),
+		},
+		{
+			TableColumnDefinition: metav1.TableColumnDefinition{Name: "Data", Type: "integer", Description: "The number of data entries of the secret."},
+			Cell: v1alpha3.Cell(func(secret *v1.Secret) interface{} {
+				return int64(len(secret.Data))
+			}),
+		},
+		v1alpha3.AgeColumn,
+	}
+}
+
 // implement a generic query filter to support multiple field selectors with "jsonpath.JsonPathLookup"
 // https://github.com/oliveagle/jsonpath/blob/master/readme.md
 func contains(secret *v1.Secret, queryValue query.Value) bool {
@@ -17,8 +17,13 @@ limitations under the License.
 package service
 
 import (
+	"fmt"
+	"strings"
+
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
 	"k8s.io/client-go/informers"
 
 	"kubesphere.io/kubesphere/pkg/api"
@@ -50,7 +55,11 @@ func (d *servicesGetter) List(namespace string, query *query.Query) (*api.ListRe
 		result = append(result, deployment)
 	}
 
-	return v1alpha3.DefaultList(result, query, d.compare, d.filter), nil
+	return v1alpha3.DefaultList(result, query, d.compare, d.filter)
+}
+
+func (d *servicesGetter) Watch(namespace string, query *query.Query, resourceVersion string) (watch.Interface, error) {
+	return v1alpha3.DefaultWatch(d.sharedInformers.Core().V1().Services().Informer(), namespace, query, resourceVersion, d.filter)
 }
 
 func (d *servicesGetter) compare(left runtime.Object, right runtime.Object, field query.Field) bool {
@@ -76,3 +85,33 @@ func (d *servicesGetter) filter(object runtime.Object, filter query.Filter) bool
 
 	return v1alpha3.DefaultObjectMetaFilter(service.ObjectMeta, filter)
 }
+
+// TableColumns returns the columns of the Table output of services
+func (d *servicesGetter) TableColumns() []v1alpha3.TableColumn {
+	return []v1alpha3.TableColumn{
+		v1alpha3.NameColumn,
+		{
+			TableColumnDefinition: metav1.TableColumnDefinition{Name: "Type", Type: "string", Description: "The type of the service."},
+			Cell: v1alpha3.Cell(func(service *corev1.Service) interface{} {
+				return string(service.Spec.Type)
+			}),
+		},
+		{
+			TableColumnDefinition: metav1.TableColumnDefinition{Name: "Cluster-IP", Type: "string", Description: "The cluster IP of the service."},
+			Cell: v1alpha3.Cell(func(service *corev1.Service) interface{} {
+				return service.Spec.ClusterIP
+			}),
+		},
+		{
+			TableColumnDefinition: metav1.TableColumnDefinition{Name: "Ports", Type: "string", Description: "The ports of the service."},
+			Cell: v1alpha3.Cell(func(service *corev1.Service) interface{} {
+				var ports []string
+				for _, port := range service.Spec.Ports {
+					ports = append(ports, fmt.Sprintf("%d/%s", port.Port, port.Protocol))
+				}
+				return strings.Join(ports, ",")
+			}),
+		},
+		v1alpha3.AgeColumn,
+	}
+}
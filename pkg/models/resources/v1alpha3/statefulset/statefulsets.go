@@ -17,8 +17,12 @@ limitations under the License.
 package statefulset
 
 import (
+	"fmt"
+
 	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
 	"k8s.io/client-go/informers"
 
 	"kubesphere.io/kubesphere/pkg/api"
@@ -56,7 +60,11 @@ func (d *statefulSetGetter) List(namespace string, query *query.Query) (*api.Lis
 		result = append(result, deployment)
 	}
 
-	return v1alpha3.DefaultList(result, query, d.compare, d.filter), nil
+	return v1alpha3.DefaultList(result, query, d.compare, d.filter)
+}
+
+func (d *statefulSetGetter) Watch(namespace string, query *query.Query, resourceVersion string) (watch.Interface, error) {
+	return v1alpha3.DefaultWatch(d.sharedInformers.Apps().V1().StatefulSets().Informer(), namespace, query, resourceVersion, d.filter)
 }
 
 func (d *statefulSetGetter) compare(left runtime.Object, right runtime.Object, field query.Field) bool {
@@ -82,13 +90,37 @@ func (d *statefulSetGetter) filter(object runtime.Object, filter query.Filter) b
 
 	switch filter.Field {
 	case query.FieldStatus:
-		return statefulSetStatus(statefulSet) == string(filter.Value)
+		return filter.MatchString(statefulSetStatus(statefulSet))
 	default:
 		return v1alpha3.DefaultObjectMetaFilter(statefulSet.ObjectMeta, filter)
 	}
 
 }
 
+// TableColumns returns the columns of the Table output of statefulsets
+func (d *statefulSetGetter) TableColumns() []v1alpha3.TableColumn {
+	return []v1alpha3.TableColumn{
+		v1alpha3.NameColumn,
+		{
+			TableColumnDefinition: metav1.TableColumnDefinition{Name: "Ready", Type: "string", Description: "The number of ready replicas out of the desired replicas."},
+			Cell: v1alpha3.Cell(func(statefulSet *appsv1.StatefulSet) interface{} {
+				replicas := int32(1)
+				if statefulSet.Spec.Replicas != nil {
+					replicas = *statefulSet.Spec.Replicas
+				}
+				return fmt.Sprintf("%d/%d", statefulSet.Status.ReadyReplicas, replicas)
+			}),
+		},
+		{
+			TableColumnDefinition: metav1.TableColumnDefinition{Name: "Status", Type: "string", Description: "The status of the statefulset."},
+			Cell: v1alpha3.Cell(func(statefulSet *appsv1.StatefulSet) interface{} {
+				return statefulSetStatus(statefulSet)
+			}),
+		},
+		v1alpha3.AgeColumn,
+	}
+}
+
 func statefulSetStatus(item *appsv1.StatefulSet) string {
 	if item.Spec.Replicas != nil {
 		if item.Status.ReadyReplicas == 0 && *item.Spec.Replicas == 0 {
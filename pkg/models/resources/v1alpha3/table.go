@@ -0,0 +1,179 @@
+/*
+Copyright 2022 KubeSphere Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha3
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"kubesphere.io/kubesphere/pkg/api"
+)
+
+// TableColumn is a column of the Table output, Cell returns the value of the column for an object
+type TableColumn struct {
+	metav1.TableColumnDefinition
+	Cell func(object runtime.Object) interface{}
+}
+
+// TableInterface is implemented by the resource getters which define their own columns of the Table output,
+// DefaultTableColumns are used for the others.
+type TableInterface interface {
+	TableColumns() []TableColumn
+}
+
+// Cell adapts the function of the typed object to the Cell of a TableColumn, the cell of the object
+// in any other type is nil.
+func Cell[T runtime.Object](f func(T) interface{}) func(runtime.Object) interface{} {
+	return func(object runtime.Object) interface{} {
+		if typed, ok := object.(T); ok {
+			return f(typed)
+		}
+		return nil
+	}
+}
+
+var NameColumn = TableColumn{
+	TableColumnDefinition: metav1.TableColumnDefinition{Name: "Name", Type: "string", Format: "name", Description: metav1.ObjectMeta{}.SwaggerDoc()["name"]},
+	Cell: func(object runtime.Object) interface{} {
+		accessor, err := meta.Accessor(object)
+		if err != nil {
+			return nil
+		}
+		return accessor.GetName()
+	},
+}
+
+// AgeColumn is rendered as the age of the object by the clients, its cells are the creation timestamps
+var AgeColumn = TableColumn{
+	TableColumnDefinition: metav1.TableColumnDefinition{Name: "Age", Type: "date", Description: metav1.ObjectMeta{}.SwaggerDoc()["creationTimestamp"]},
+	Cell: func(object runtime.Object) interface{} {
+		accessor, err := meta.Accessor(object)
+		if err != nil {
+			return nil
+		}
+		return accessor.GetCreationTimestamp().UTC().Format(time.RFC3339)
+	},
+}
+
+var DefaultTableColumns = []TableColumn{NameColumn, AgeColumn}
+
+// DefaultTable converts the items of the list result to the rows of a Kubernetes Table, each row contains
+// the metadata of the item as a PartialObjectMetadata object without the last applied configuration.
+func DefaultTable(result *api.ListResult, columns []TableColumn) (*metav1.Table, error) {
+	table := &metav1.Table{
+		TypeMeta: metav1.TypeMeta{Kind: "Table", APIVersion: metav1.SchemeGroupVersion.String()},
+		ListMeta: metav1.ListMeta{Continue: result.Continue},
+		Rows:     []metav1.TableRow{},
+	}
+	for _, column := range columns {
+		table.ColumnDefinitions = append(table.ColumnDefinitions, column.TableColumnDefinition)
+	}
+	for _, item := range result.Items {
+		object, ok := item.(runtime.Object)
+		if !ok {
+			return nil, fmt.Errorf("unable to convert %T to table row", item)
+		}
+		row := metav1.TableRow{}
+		for _, column := range columns {
+			row.Cells = append(row.Cells, column.Cell(object))
+		}
+		if accessor, err := meta.Accessor(object); err == nil {
+			partial := &metav1.PartialObjectMetadata{
+				TypeMeta: metav1.TypeMeta{Kind: "PartialObjectMetadata", APIVersion: metav1.SchemeGroupVersion.String()},
+				ObjectMeta: metav1.ObjectMeta{
+					Name:              accessor.GetName(),
+					Namespace:         accessor.GetNamespace(),
+					UID:               accessor.GetUID(),
+					ResourceVersion:   accessor.GetResourceVersion(),
+					Generation:        accessor.GetGeneration(),
+					CreationTimestamp: accessor.GetCreationTimestamp(),
+					DeletionTimestamp: accessor.GetDeletionTimestamp(),
+					Labels:            accessor.GetLabels(),
+					Annotations:       withoutLastAppliedConfig(accessor.GetAnnotations()),
+					OwnerReferences:   accessor.GetOwnerReferences(),
+				},
+			}
+			raw, err := json.Marshal(partial)
+			if err != nil {
+				return nil, err
+			}
+			row.Object = runtime.RawExtension{Raw: raw}
+		}
+		table.Rows = append(table.Rows, row)
+	}
+	return table, nil
+}
+
+// ProjectFields keeps only the fields of the items, a field is a path of the object separated by dots,
+// e.g. metadata.name or status.phase. The fields which do not exist in an item are omitted, so is the last
+// applied configuration annotation, which contains the whole object.
+func ProjectFields(result *api.ListResult, fields []string) (*api.ListResult, error) {
+	var paths [][]string
+	for _, field := range fields {
+		path := strings.Split(field, ".")
+		for _, key := range path {
+			if key == "" {
+				return nil, errors.NewBadRequest(fmt.Sprintf("invalid field %q", field))
+			}
+		}
+		paths = append(paths, path)
+	}
+
+	projected := &api.ListResult{TotalItems: result.TotalItems, Continue: result.Continue, Items: make([]interface{}, 0, len(result.Items))}
+	for _, item := range result.Items {
+		content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(item)
+		if err != nil {
+			return nil, err
+		}
+		unstructured.RemoveNestedField(content, "metadata", "annotations", corev1.LastAppliedConfigAnnotation)
+		object := map[string]interface{}{}
+		for _, path := range paths {
+			value, found, err := unstructured.NestedFieldNoCopy(content, path...)
+			if err != nil || !found {
+				continue
+			}
+			if err := unstructured.SetNestedField(object, value, path...); err != nil {
+				return nil, err
+			}
+		}
+		projected.Items = append(projected.Items, object)
+	}
+	return projected, nil
+}
+
+// withoutLastAppliedConfig returns a copy of the annotations without the last applied configuration
+func withoutLastAppliedConfig(annotations map[string]string) map[string]string {
+	if _, ok := annotations[corev1.LastAppliedConfigAnnotation]; !ok {
+		return annotations
+	}
+	copied := make(map[string]string, len(annotations)-1)
+	for key, value := range annotations {
+		if key != corev1.LastAppliedConfigAnnotation {
+			copied[key] = value
+		}
+	}
+	return copied
+}
@@ -0,0 +1,145 @@
+/*
+Copyright 2022 KubeSphere Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha3
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"kubesphere.io/kubesphere/pkg/api"
+)
+
+func newTestPods() []interface{} {
+	return []interface{}{
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "foo",
+				Namespace: "default",
+				Labels:    map[string]string{"app": "foo"},
+				Annotations: map[string]string{
+					"kubesphere.io/alias-name":         "Foo",
+					corev1.LastAppliedConfigAnnotation: `{"kind":"Pod"}`,
+				},
+				CreationTimestamp: metav1.NewTime(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)),
+			},
+			Spec:   corev1.PodSpec{NodeName: "node1"},
+			Status: corev1.PodStatus{Phase: corev1.PodRunning},
+		},
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              "bar",
+				Namespace:         "default",
+				CreationTimestamp: metav1.NewTime(time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)),
+			},
+			Status: corev1.PodStatus{Phase: corev1.PodPending},
+		},
+	}
+}
+
+func TestProjectFields(t *testing.T) {
+	result := &api.ListResult{Items: newTestPods(), TotalItems: 2, Continue: "token"}
+
+	got, err := ProjectFields(result, []string{"metadata.name", "metadata.labels", "metadata.annotations", "status.phase", "spec.nodeName"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := &api.ListResult{
+		Items: []interface{}{
+			map[string]interface{}{
+				"metadata": map[string]interface{}{
+					"name":        "foo",
+					"labels":      map[string]interface{}{"app": "foo"},
+					"annotations": map[string]interface{}{"kubesphere.io/alias-name": "Foo"},
+				},
+				"spec":   map[string]interface{}{"nodeName": "node1"},
+				"status": map[string]interface{}{"phase": "Running"},
+			},
+			map[string]interface{}{
+				"metadata": map[string]interface{}{"name": "bar"},
+				"status":   map[string]interface{}{"phase": "Pending"},
+			},
+		},
+		TotalItems: 2,
+		Continue:   "token",
+	}
+	if diff := cmp.Diff(got, expected); diff != "" {
+		t.Errorf("%T differ (-got, +want): %s", expected, diff)
+	}
+
+	if _, err := ProjectFields(result, []string{"metadata..name"}); err == nil {
+		t.Errorf("expected error for invalid field")
+	}
+}
+
+func TestDefaultTable(t *testing.T) {
+	result := &api.ListResult{Items: newTestPods(), TotalItems: 2, Continue: "token"}
+	phaseColumn := TableColumn{
+		TableColumnDefinition: metav1.TableColumnDefinition{Name: "Phase", Type: "string"},
+		Cell: Cell(func(pod *corev1.Pod) interface{} {
+			return string(pod.Status.Phase)
+		}),
+	}
+
+	table, err := DefaultTable(result, []TableColumn{NameColumn, phaseColumn, AgeColumn})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if table.Kind != "Table" || table.Continue != "token" {
+		t.Errorf("unexpected table metadata %v %v", table.TypeMeta, table.ListMeta)
+	}
+
+	var columns []string
+	for _, column := range table.ColumnDefinitions {
+		columns = append(columns, column.Name)
+	}
+	if diff := cmp.Diff(columns, []string{"Name", "Phase", "Age"}); diff != "" {
+		t.Errorf("%T differ (-got, +want): %s", columns, diff)
+	}
+
+	var cells [][]interface{}
+	for _, row := range table.Rows {
+		cells = append(cells, row.Cells)
+	}
+	expected := [][]interface{}{
+		{"foo", "Running", "2024-01-01T00:00:00Z"},
+		{"bar", "Pending", "2024-02-01T00:00:00Z"},
+	}
+	if diff := cmp.Diff(cells, expected); diff != "" {
+		t.Errorf("%T differ (-got, +want): %s", expected, diff)
+	}
+
+	partial := &metav1.PartialObjectMetadata{}
+	if err := json.Unmarshal(table.Rows[0].Object.Raw, partial); err != nil {
+		t.Fatal(err)
+	}
+	if partial.Kind != "PartialObjectMetadata" || partial.Name != "foo" || partial.Labels["app"] != "foo" {
+		t.Errorf("unexpected row object %v", partial)
+	}
+	if diff := cmp.Diff(partial.Annotations, map[string]string{"kubesphere.io/alias-name": "Foo"}); diff != "" {
+		t.Errorf("%T differ (-got, +want): %s", partial.Annotations, diff)
+	}
+
+	// the cell of the object in another type is nil
+	if cell := phaseColumn.Cell(&corev1.Node{}); cell != nil {
+		t.Errorf("expected nil cell, got %v", cell)
+	}
+}
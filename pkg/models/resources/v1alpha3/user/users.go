@@ -82,7 +82,7 @@ func (d *usersGetter) List(_ string, query *query.Query) (*api.ListResult, error
 		result = append(result, user)
 	}
 
-	return v1alpha3.DefaultList(result, query, d.compare, d.filter), nil
+	return v1alpha3.DefaultList(result, query, d.compare, d.filter)
 }
 
 func (d *usersGetter) compare(left runtime.Object, right runtime.Object, field query.Field) bool {
@@ -109,7 +109,7 @@ func (d *usersGetter) filter(object runtime.Object, filter query.Filter) bool {
 
 	switch filter.Field {
 	case iamv1alpha2.FieldEmail:
-		return user.Spec.Email == string(filter.Value)
+		return filter.MatchString(user.Spec.Email)
 	case iamv1alpha2.InGroup:
 		return sliceutil.HasString(user.Spec.Groups, string(filter.Value))
 	case iamv1alpha2.NotInGroup:
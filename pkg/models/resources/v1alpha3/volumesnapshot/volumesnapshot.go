@@ -58,7 +58,7 @@ func (v *volumeSnapshotGetter) List(namespace string, query *query.Query) (*api.
 		result = append(result, snapshot)
 	}
 
-	return v1alpha3.DefaultList(result, query, v.compare, v.filter), nil
+	return v1alpha3.DefaultList(result, query, v.compare, v.filter)
 }
 
 func (v *volumeSnapshotGetter) compare(left, right runtime.Object, field query.Field) bool {
@@ -81,13 +81,13 @@ func (v *volumeSnapshotGetter) filter(object runtime.Object, filter query.Filter
 
 	switch filter.Field {
 	case query.FieldStatus:
-		return snapshotStatus(snapshot) == string(filter.Value)
+		return filter.MatchString(snapshotStatus(snapshot))
 	case volumeSnapshotClassName:
 		name := snapshot.Spec.VolumeSnapshotClassName
-		return name != nil && *name == string(filter.Value)
+		return name != nil && filter.MatchString(*name)
 	case persistentVolumeClaimName:
 		name := snapshot.Spec.Source.PersistentVolumeClaimName
-		return name != nil && *name == string(filter.Value)
+		return name != nil && filter.MatchString(*name)
 	default:
 		return v1alpha3.DefaultObjectMetaFilter(snapshot.ObjectMeta, filter)
 	}
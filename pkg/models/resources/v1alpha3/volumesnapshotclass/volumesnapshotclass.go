@@ -65,7 +65,7 @@ func (v *volumeSnapshotClassGetter) List(namespace string, query *query.Query) (
 		result = append(result, snapshotClass)
 	}
 
-	return v1alpha3.DefaultList(result, query, v.compare, v.filter), nil
+	return v1alpha3.DefaultList(result, query, v.compare, v.filter)
 }
 
 func (v *volumeSnapshotClassGetter) compare(left, right runtime.Object, field query.Field) bool {
@@ -88,9 +88,9 @@ func (v *volumeSnapshotClassGetter) filter(object runtime.Object, filter query.F
 
 	switch filter.Field {
 	case deletionPolicy:
-		return strings.EqualFold(string(snapshotClass.DeletionPolicy), string(filter.Value))
+		return filter.MatchStringFunc(string(snapshotClass.DeletionPolicy), strings.EqualFold)
 	case driver:
-		return strings.EqualFold(snapshotClass.Driver, string(filter.Value))
+		return filter.MatchStringFunc(snapshotClass.Driver, strings.EqualFold)
 	default:
 		return v1alpha3.DefaultObjectMetaFilter(snapshotClass.ObjectMeta, filter)
 	}
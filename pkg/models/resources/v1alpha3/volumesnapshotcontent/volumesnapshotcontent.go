@@ -57,7 +57,7 @@ func (v *volumesnapshotcontentGetter) List(namespace string, query *query.Query)
 		result = append(result, snapshotContent)
 	}
 
-	return v1alpha3.DefaultList(result, query, v.compare, v.filter), nil
+	return v1alpha3.DefaultList(result, query, v.compare, v.filter)
 }
 
 func (v *volumesnapshotcontentGetter) compare(left, right runtime.Object, field query.Field) bool {
@@ -80,13 +80,13 @@ func (v *volumesnapshotcontentGetter) filter(object runtime.Object, filter query
 
 	switch filter.Field {
 	case volumeSnapshotClassName:
-		return strings.EqualFold(*snapshotcontent.Spec.VolumeSnapshotClassName, string(filter.Value))
+		return filter.MatchStringFunc(*snapshotcontent.Spec.VolumeSnapshotClassName, strings.EqualFold)
 	case volumeSnapshotName:
-		return strings.EqualFold(snapshotcontent.Spec.VolumeSnapshotRef.Name, string(filter.Value))
+		return filter.MatchStringFunc(snapshotcontent.Spec.VolumeSnapshotRef.Name, strings.EqualFold)
 	case volumeSnapshotNameSpace:
-		return strings.EqualFold(snapshotcontent.Spec.VolumeSnapshotRef.Namespace, string(filter.Value))
+		return filter.MatchStringFunc(snapshotcontent.Spec.VolumeSnapshotRef.Namespace, strings.EqualFold)
 	case readyToUse:
-		return strings.EqualFold(strconv.FormatBool(*snapshotcontent.Status.ReadyToUse), string(filter.Value))
+		return filter.MatchStringFunc(strconv.FormatBool(*snapshotcontent.Status.ReadyToUse), strings.EqualFold)
 	default:
 		return v1alpha3.DefaultObjectMetaFilter(snapshotcontent.ObjectMeta, filter)
 	}
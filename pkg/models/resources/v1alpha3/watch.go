@@ -0,0 +1,271 @@
+/*
+Copyright 2022 KubeSphere Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha3
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+
+	"kubesphere.io/kubesphere/pkg/apiserver/query"
+)
+
+// DefaultWatchBufferSize is the number of events buffered for each watcher in addition to the matching objects
+// replayed when the watcher starts, the watcher is terminated once the consumer falls behind and the buffer is full.
+var DefaultWatchBufferSize = 100
+
+type informerWatcher struct {
+	informer        cache.SharedIndexInformer
+	registration    cache.ResourceEventHandlerRegistration
+	namespace       string
+	query           *query.Query
+	selector        labels.Selector
+	resourceVersion uint64
+	filterFunc      FilterFunc
+	transformFuncs  []TransformFunc
+	bufferSize      int
+	// the resource versions of the objects replayed when the watcher starts,
+	// the events no newer than them have been reflected by the replay
+	replayed map[string]uint64
+
+	lock       sync.Mutex
+	stopped    bool
+	result     chan watch.Event
+	removeOnce sync.Once
+}
+
+// DefaultWatch watches the objects of the informer within the namespace, the events are filtered by the same
+// query filters as DefaultList. Updates making an object match or no longer match the query are sent as
+// Added or Deleted events. The objects no newer than the resourceVersion are skipped if it is specified.
+func DefaultWatch(informer cache.SharedIndexInformer, namespace string, q *query.Query, resourceVersion string, filterFunc FilterFunc, transformFuncs ...TransformFunc) (watch.Interface, error) {
+	w := &informerWatcher{
+		informer:       informer,
+		namespace:      namespace,
+		query:          q,
+		selector:       q.Selector(),
+		filterFunc:     filterFunc,
+		transformFuncs: transformFuncs,
+		replayed:       make(map[string]uint64),
+	}
+
+	if resourceVersion != "" {
+		rv, err := strconv.ParseUint(resourceVersion, 10, 64)
+		if err != nil {
+			return nil, errors.NewBadRequest(fmt.Sprintf("invalid resource version %q", resourceVersion))
+		}
+		w.resourceVersion = rv
+	}
+
+	// the events are held until the existing objects are replayed, the handler is added before
+	// listing the objects, so that none of the changes in between is missed
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	registration, err := informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			w.handle(nil, obj)
+		},
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			w.handle(oldObj, newObj)
+		},
+		DeleteFunc: func(obj interface{}) {
+			if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+				obj = tombstone.Obj
+			}
+			if object, ok := obj.(runtime.Object); ok && w.matches(object) {
+				w.send(watch.Event{Type: watch.Deleted, Object: w.transform(object)})
+			}
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	w.registration = registration
+	if err = w.replay(); err != nil {
+		w.stopped = true
+		w.removeHandler()
+		return nil, err
+	}
+	return w, nil
+}
+
+// replay sends the existing objects matching the query as the Added events, the buffer
+// is sized to the matching objects, so that they never overflow the buffer.
+func (w *informerWatcher) replay() error {
+	var matches []runtime.Object
+	appendFunc := func(obj interface{}) {
+		object, ok := obj.(runtime.Object)
+		if !ok {
+			return
+		}
+		key, err := cache.MetaNamespaceKeyFunc(obj)
+		if err != nil {
+			return
+		}
+		resourceVersion := objectResourceVersion(object)
+		w.replayed[key] = resourceVersion
+		if w.resourceVersion > 0 && resourceVersion <= w.resourceVersion {
+			return
+		}
+		if w.matches(object) {
+			matches = append(matches, object)
+		}
+	}
+	var err error
+	if w.namespace != "" {
+		err = cache.ListAllByNamespace(w.informer.GetIndexer(), w.namespace, w.selector, appendFunc)
+	} else {
+		err = cache.ListAll(w.informer.GetIndexer(), w.selector, appendFunc)
+	}
+	if err != nil {
+		klog.Error(err)
+		return err
+	}
+
+	w.bufferSize = DefaultWatchBufferSize + len(matches)
+	// reserve one more event for the error that terminates the watcher
+	w.result = make(chan watch.Event, w.bufferSize+1)
+	for _, object := range matches {
+		w.result <- watch.Event{Type: watch.Added, Object: w.transform(object)}
+	}
+	return nil
+}
+
+func (w *informerWatcher) handle(oldObj, newObj interface{}) {
+	object, ok := newObj.(runtime.Object)
+	if !ok {
+		return
+	}
+	resourceVersion := objectResourceVersion(object)
+	if w.resourceVersion > 0 && resourceVersion <= w.resourceVersion {
+		return
+	}
+	if w.isReplayed(object, resourceVersion) {
+		return
+	}
+
+	oldMatches := false
+	if old, ok := oldObj.(runtime.Object); ok {
+		// periodic resyncs deliver the same object
+		if objectResourceVersion(old) == resourceVersion {
+			return
+		}
+		oldMatches = w.matches(old)
+	}
+	newMatches := w.matches(object)
+
+	switch {
+	case oldMatches && newMatches:
+		w.send(watch.Event{Type: watch.Modified, Object: w.transform(object)})
+	case newMatches:
+		w.send(watch.Event{Type: watch.Added, Object: w.transform(object)})
+	case oldMatches:
+		w.send(watch.Event{Type: watch.Deleted, Object: w.transform(object)})
+	}
+}
+
+// isReplayed returns whether the version of the object has been sent when the watcher starts.
+func (w *informerWatcher) isReplayed(object runtime.Object, resourceVersion uint64) bool {
+	key, err := cache.MetaNamespaceKeyFunc(object)
+	if err != nil {
+		return false
+	}
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	replayed, ok := w.replayed[key]
+	return ok && resourceVersion <= replayed
+}
+
+func (w *informerWatcher) matches(object runtime.Object) bool {
+	accessor, err := meta.Accessor(object)
+	if err != nil {
+		return false
+	}
+	if w.namespace != "" && accessor.GetNamespace() != w.namespace {
+		return false
+	}
+	if !w.selector.Matches(labels.Set(accessor.GetLabels())) {
+		return false
+	}
+	return w.query.Matches(object, w.filterFunc)
+}
+
+func (w *informerWatcher) transform(object runtime.Object) runtime.Object {
+	for _, transform := range w.transformFuncs {
+		object = transform(object)
+	}
+	return object
+}
+
+func (w *informerWatcher) send(event watch.Event) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	if w.stopped {
+		return
+	}
+	if len(w.result) >= w.bufferSize {
+		klog.V(4).Infof("watcher falls behind with %d events buffered, terminating", len(w.result))
+		status := errors.NewResourceExpired("the watcher falls behind and the events are dropped").Status()
+		w.result <- watch.Event{Type: watch.Error, Object: &status}
+		w.stopped = true
+		close(w.result)
+		// the handler can not be removed within itself
+		go w.removeHandler()
+		return
+	}
+	w.result <- event
+}
+
+func (w *informerWatcher) removeHandler() {
+	w.removeOnce.Do(func() {
+		if err := w.informer.RemoveEventHandler(w.registration); err != nil {
+			klog.Error(err)
+		}
+	})
+}
+
+// Stop implements watch.Interface
+func (w *informerWatcher) Stop() {
+	w.lock.Lock()
+	if !w.stopped {
+		w.stopped = true
+		close(w.result)
+	}
+	w.lock.Unlock()
+	w.removeHandler()
+}
+
+// ResultChan implements watch.Interface
+func (w *informerWatcher) ResultChan() <-chan watch.Event {
+	return w.result
+}
+
+func objectResourceVersion(object runtime.Object) uint64 {
+	accessor, err := meta.Accessor(object)
+	if err != nil {
+		return 0
+	}
+	resourceVersion, _ := strconv.ParseUint(accessor.GetResourceVersion(), 10, 64)
+	return resourceVersion
+}
@@ -0,0 +1,258 @@
+/*
+Copyright 2022 KubeSphere Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha3
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/cache"
+
+	"kubesphere.io/kubesphere/pkg/apiserver/query"
+)
+
+func newConfigMap(namespace, name, resourceVersion string, labels map[string]string) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:       namespace,
+			Name:            name,
+			ResourceVersion: resourceVersion,
+			Labels:          labels,
+		},
+	}
+}
+
+func configMapFilter(object runtime.Object, filter query.Filter) bool {
+	configMap, ok := object.(*corev1.ConfigMap)
+	if !ok {
+		return false
+	}
+	return DefaultObjectMetaFilter(configMap.ObjectMeta, filter)
+}
+
+func nextEvent(t *testing.T, watcher watch.Interface) (watch.Event, bool) {
+	select {
+	case event, ok := <-watcher.ResultChan():
+		return event, ok
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the watch event")
+	}
+	return watch.Event{}, false
+}
+
+func expectEvent(t *testing.T, watcher watch.Interface, eventType watch.EventType, name string) {
+	event, ok := nextEvent(t, watcher)
+	if !ok {
+		t.Fatalf("expected %s event of %s, got closed channel", eventType, name)
+	}
+	configMap, _ := event.Object.(*corev1.ConfigMap)
+	if event.Type != eventType || configMap == nil || configMap.Name != name {
+		t.Fatalf("expected %s event of %s, got %s event of %#v", eventType, name, event.Type, event.Object)
+	}
+}
+
+func expectNoEvent(t *testing.T, watcher watch.Interface) {
+	select {
+	case event := <-watcher.ResultChan():
+		t.Fatalf("unexpected %s event of %#v", event.Type, event.Object)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func newConfigMapInformer(t *testing.T, objects ...runtime.Object) (*fake.Clientset, cache.SharedIndexInformer) {
+	client := fake.NewSimpleClientset(objects...)
+	informer := informers.NewSharedInformerFactory(client, 0).Core().V1().ConfigMaps().Informer()
+	stopCh := make(chan struct{})
+	t.Cleanup(func() { close(stopCh) })
+	go informer.Run(stopCh)
+	if !cache.WaitForCacheSync(stopCh, informer.HasSynced) {
+		t.Fatal("failed to sync informer")
+	}
+	return client, informer
+}
+
+func TestDefaultWatch(t *testing.T) {
+	client, informer := newConfigMapInformer(t,
+		newConfigMap("default", "foo", "1", map[string]string{"app": "foo"}),
+		newConfigMap("default", "bar", "2", map[string]string{"app": "bar"}),
+		newConfigMap("kube-system", "foo", "3", map[string]string{"app": "foo"}),
+	)
+
+	q := query.New()
+	q.LabelSelector = "app=foo"
+	q.Filters[query.FieldName] = "foo"
+	watcher, err := DefaultWatch(informer, "default", q, "", configMapFilter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer watcher.Stop()
+
+	// the existing objects matching the query are sent first
+	expectEvent(t, watcher, watch.Added, "foo")
+	expectNoEvent(t, watcher)
+
+	ctx := context.Background()
+	updated := newConfigMap("default", "foo", "4", map[string]string{"app": "foo", "version": "v2"})
+	if _, err = client.CoreV1().ConfigMaps("default").Update(ctx, updated, metav1.UpdateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	expectEvent(t, watcher, watch.Modified, "foo")
+
+	// the object no longer matching the query is deleted from the view of the watcher
+	updated = newConfigMap("default", "foo", "5", map[string]string{"app": "bar"})
+	if _, err = client.CoreV1().ConfigMaps("default").Update(ctx, updated, metav1.UpdateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	expectEvent(t, watcher, watch.Deleted, "foo")
+
+	// the object matching the query again is added to the view of the watcher
+	updated = newConfigMap("default", "foo", "6", map[string]string{"app": "foo"})
+	if _, err = client.CoreV1().ConfigMaps("default").Update(ctx, updated, metav1.UpdateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	expectEvent(t, watcher, watch.Added, "foo")
+
+	// the name filter is applied to the events as well
+	updated = newConfigMap("default", "bar", "7", map[string]string{"app": "foo"})
+	if _, err = client.CoreV1().ConfigMaps("default").Update(ctx, updated, metav1.UpdateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	expectNoEvent(t, watcher)
+
+	created := newConfigMap("default", "foo-2", "8", map[string]string{"app": "foo"})
+	if _, err = client.CoreV1().ConfigMaps("default").Create(ctx, created, metav1.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	expectEvent(t, watcher, watch.Added, "foo-2")
+
+	if err = client.CoreV1().ConfigMaps("default").Delete(ctx, "foo-2", metav1.DeleteOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	expectEvent(t, watcher, watch.Deleted, "foo-2")
+
+	watcher.Stop()
+	if _, ok := nextEvent(t, watcher); ok {
+		t.Fatal("expected the result channel to be closed")
+	}
+}
+
+func TestDefaultWatchResourceVersion(t *testing.T) {
+	_, informer := newConfigMapInformer(t,
+		newConfigMap("default", "foo", "1", nil),
+		newConfigMap("default", "bar", "3", nil),
+	)
+
+	if _, err := DefaultWatch(informer, "default", query.New(), "invalid", configMapFilter); err == nil {
+		t.Fatal("expected error of invalid resource version")
+	}
+
+	watcher, err := DefaultWatch(informer, "default", query.New(), "2", configMapFilter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer watcher.Stop()
+
+	expectEvent(t, watcher, watch.Added, "bar")
+	expectNoEvent(t, watcher)
+}
+
+func TestDefaultWatchBufferOverflow(t *testing.T) {
+	bufferSize := DefaultWatchBufferSize
+	DefaultWatchBufferSize = 1
+	defer func() { DefaultWatchBufferSize = bufferSize }()
+
+	client, informer := newConfigMapInformer(t,
+		newConfigMap("default", "foo", "1", nil),
+		newConfigMap("default", "bar", "2", nil),
+		newConfigMap("default", "baz", "3", nil),
+	)
+
+	watcher, err := DefaultWatch(informer, "default", query.New(), "", configMapFilter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer watcher.Stop()
+
+	// the replayed objects do not overflow the buffer
+	time.Sleep(100 * time.Millisecond)
+	ctx := context.Background()
+	for _, name := range []string{"qux", "quux"} {
+		if _, err = client.CoreV1().ConfigMaps("default").Create(ctx, newConfigMap("default", name, "", nil), metav1.CreateOptions{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// wait until the buffer overflows
+	time.Sleep(100 * time.Millisecond)
+
+	for i := 0; i < 4; i++ {
+		if event, ok := nextEvent(t, watcher); !ok || event.Type != watch.Added {
+			t.Fatalf("expected Added event, got %s", event.Type)
+		}
+	}
+	event, ok := nextEvent(t, watcher)
+	if !ok || event.Type != watch.Error {
+		t.Fatalf("expected Error event, got %s", event.Type)
+	}
+	if status, ok := event.Object.(*metav1.Status); !ok || status.Reason != metav1.StatusReasonExpired {
+		t.Fatalf("expected expired status, got %#v", event.Object)
+	}
+	if _, ok := nextEvent(t, watcher); ok {
+		t.Fatal("expected the result channel to be closed")
+	}
+}
+
+func TestDefaultWatchReplayMatches(t *testing.T) {
+	bufferSize := DefaultWatchBufferSize
+	DefaultWatchBufferSize = 1
+	defer func() { DefaultWatchBufferSize = bufferSize }()
+
+	client, informer := newConfigMapInformer(t,
+		newConfigMap("default", "foo", "1", map[string]string{"app": "foo"}),
+		newConfigMap("default", "bar", "2", map[string]string{"app": "bar"}),
+		newConfigMap("kube-system", "foo", "3", map[string]string{"app": "foo"}),
+		newConfigMap("kube-system", "bar", "4", map[string]string{"app": "foo"}),
+	)
+
+	q := query.New()
+	q.LabelSelector = "app=foo"
+	watcher, err := DefaultWatch(informer, "default", q, "", configMapFilter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer watcher.Stop()
+
+	// the buffer is only enlarged by the objects matching the query
+	if got := cap(watcher.(*informerWatcher).result); got != 3 {
+		t.Fatalf("expected buffer of 3 events, got %d", got)
+	}
+	// the replayed objects are not sent again by the handler
+	expectEvent(t, watcher, watch.Added, "foo")
+	expectNoEvent(t, watcher)
+
+	updated := newConfigMap("default", "foo", "5", map[string]string{"app": "foo", "version": "v2"})
+	if _, err = client.CoreV1().ConfigMaps("default").Update(context.Background(), updated, metav1.UpdateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	expectEvent(t, watcher, watch.Modified, "foo")
+}
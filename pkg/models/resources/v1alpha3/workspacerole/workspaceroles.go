@@ -65,7 +65,7 @@ func (d *workspacerolesGetter) List(_ string, queryParam *query.Query) (*api.Lis
 		result = append(result, role)
 	}
 
-	return v1alpha3.DefaultList(result, queryParam, d.compare, d.filter), nil
+	return v1alpha3.DefaultList(result, queryParam, d.compare, d.filter)
 }
 
 func (d *workspacerolesGetter) compare(left runtime.Object, right runtime.Object, field query.Field) bool {
@@ -92,7 +92,7 @@ func (d *workspacerolesGetter) filter(object runtime.Object, filter query.Filter
 
 	switch filter.Field {
 	case iamv1alpha2.ScopeWorkspace:
-		return role.Labels[tenantv1alpha1.WorkspaceLabel] == string(filter.Value)
+		return filter.MatchString(role.Labels[tenantv1alpha1.WorkspaceLabel])
 	default:
 		return v1alpha3.DefaultObjectMetaFilter(role.ObjectMeta, filter)
 	}
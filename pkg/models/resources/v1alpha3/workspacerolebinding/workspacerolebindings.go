@@ -54,7 +54,7 @@ func (d *workspacerolebindingsGetter) List(_ string, query *query.Query) (*api.L
 		result = append(result, globalRoleBinding)
 	}
 
-	return v1alpha3.DefaultList(result, query, d.compare, d.filter), nil
+	return v1alpha3.DefaultList(result, query, d.compare, d.filter)
 }
 
 func (d *workspacerolebindingsGetter) compare(left runtime.Object, right runtime.Object, field query.Field) bool {
@@ -80,7 +80,7 @@ func (d *workspacerolebindingsGetter) filter(object runtime.Object, filter query
 	}
 	switch filter.Field {
 	case RoleName:
-		return role.RoleRef.Name == string(filter.Value)
+		return filter.MatchString(role.RoleRef.Name)
 	default:
 		return v1alpha3.DefaultObjectMetaFilter(role.ObjectMeta, filter)
 	}
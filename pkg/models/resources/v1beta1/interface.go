@@ -4,7 +4,6 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"sort"
 	"strings"
 
 	"github.com/oliveagle/jsonpath"
@@ -45,20 +44,12 @@ type FilterFunc func(runtime.Object, query.Filter) bool
 
 type TransformFunc func(runtime.Object) runtime.Object
 
-func DefaultList(objects []runtime.Object, q *query.Query, compareFunc CompareFunc, filterFunc FilterFunc, transformFuncs ...TransformFunc) ([]runtime.Object, *int64) {
+func DefaultList(objects []runtime.Object, q *query.Query, compareFunc CompareFunc, filterFunc FilterFunc, transformFuncs ...TransformFunc) ([]runtime.Object, *int64, string, error) {
 	// selected matched ones
 	var filtered []runtime.Object
 	if len(q.Filters) != 0 {
 		for _, object := range objects {
-			selected := true
-			for field, value := range q.Filters {
-				if !filterFunc(object, query.Filter{Field: field, Value: value}) {
-					selected = false
-					break
-				}
-			}
-
-			if selected {
+			if q.Matches(object, filterFunc) {
 				for _, transform := range transformFuncs {
 					object = transform(object)
 				}
@@ -69,24 +60,19 @@ func DefaultList(objects []runtime.Object, q *query.Query, compareFunc CompareFu
 		filtered = objects
 	}
 
-	// sort by sortBy field
-	sort.Slice(filtered, func(i, j int) bool {
+	// sort by sortBy field, and paginate by page or continue token
+	page, remaining, continueToken, err := q.Paginate(filtered, func(left, right runtime.Object) bool {
 		if !q.Ascending {
-			return compareFunc(filtered[i], filtered[j], q.SortBy)
+			return compareFunc(left, right, q.SortBy)
 		}
-		return !compareFunc(filtered[i], filtered[j], q.SortBy)
+		return compareFunc(right, left, q.SortBy)
 	})
-
-	total := len(filtered)
-
-	if q.Pagination == nil {
-		q.Pagination = query.NoPagination
+	if err != nil {
+		return nil, nil, "", err
 	}
+	remainingItemCount := int64(remaining)
 
-	start, end := q.Pagination.GetValidPagination(total)
-	remainingItemCount := int64(total - end)
-
-	return filtered[start:end], &remainingItemCount
+	return page, &remainingItemCount, continueToken, nil
 }
 
 // DefaultObjectMetaCompare return true is left greater than right
@@ -123,17 +109,17 @@ func DefaultObjectMetaFilter(item metav1.Object, filter query.Filter) bool {
 		return false
 	// /namespaces?page=1&limit=10&name=default
 	case query.FieldName:
-		return strings.Contains(item.GetName(), string(filter.Value))
+		return filter.MatchStringFunc(item.GetName(), strings.Contains)
 		// /namespaces?page=1&limit=10&uid=a8a8d6cf-f6a5-4fea-9c1b-e57610115706
 	case query.FieldUID:
-		return strings.Compare(string(item.GetUID()), string(filter.Value)) == 0
+		return filter.MatchString(string(item.GetUID()))
 		// /deployments?page=1&limit=10&namespace=kubesphere-system
 	case query.FieldNamespace:
-		return strings.Compare(item.GetNamespace(), string(filter.Value)) == 0
+		return filter.MatchString(item.GetNamespace())
 		// /namespaces?page=1&limit=10&ownerReference=a8a8d6cf-f6a5-4fea-9c1b-e57610115706
 	case query.FieldOwnerReference:
 		for _, ownerReference := range item.GetOwnerReferences() {
-			if strings.Compare(string(ownerReference.UID), string(filter.Value)) == 0 {
+			if filter.MatchString(string(ownerReference.UID)) {
 				return true
 			}
 		}
@@ -141,11 +127,14 @@ func DefaultObjectMetaFilter(item metav1.Object, filter query.Filter) bool {
 		// /namespaces?page=1&limit=10&ownerKind=Workspace
 	case query.FieldOwnerKind:
 		for _, ownerReference := range item.GetOwnerReferences() {
-			if strings.Compare(ownerReference.Kind, string(filter.Value)) == 0 {
+			if filter.MatchString(ownerReference.Kind) {
 				return true
 			}
 		}
 		return false
+		// /namespaces?page=1&limit=10&creationTimestamp>2024-01-01
+	case query.FieldCreationTimeStamp:
+		return filter.MatchTime(item.GetCreationTimestamp().Time)
 		// /namespaces?page=1&limit=10&annotation=openpitrix_runtime
 	case query.FieldAnnotation:
 		return labelMatch(item.GetAnnotations(), string(filter.Value))
@@ -213,8 +213,12 @@ func (h *resourceManager) List(ctx context.Context, namespace string, query *que
 		return err
 	}
 
-	filtered, remainingItemCount := DefaultList(extractList, query, compare, filter)
+	filtered, remainingItemCount, continueToken, err := DefaultList(extractList, query, compare, filter)
+	if err != nil {
+		return err
+	}
 	list.SetRemainingItemCount(remainingItemCount)
+	list.SetContinue(continueToken)
 	if err := meta.SetList(list, filtered); err != nil {
 		return err
 	}
@@ -108,11 +108,10 @@ func (t *tenantOperator) ListDevOpsProjects(user user.Info, workspace string, qu
 	}
 
 	// devops project filtering
-	result := resources.DefaultList(devopsProjects, queryParam, func(left runtime.Object, right runtime.Object, field query.Field) bool {
+	return resources.DefaultList(devopsProjects, queryParam, func(left runtime.Object, right runtime.Object, field query.Field) bool {
 		return resources.DefaultObjectMetaCompare(left.(*devopsv1alpha3.DevOpsProject).ObjectMeta, right.(*devopsv1alpha3.DevOpsProject).ObjectMeta, field)
 	}, func(object runtime.Object, filter query.Filter) bool {
 		devopsProject := object.(*devopsv1alpha3.DevOpsProject)
 		return resources.DefaultObjectMetaFilter(devopsProject.ObjectMeta, filter)
 	})
-	return result, nil
 }
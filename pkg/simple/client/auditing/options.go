@@ -17,9 +17,11 @@ limitations under the License.
 package auditing
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/spf13/pflag"
+	"k8s.io/client-go/util/keyutil"
 
 	"kubesphere.io/kubesphere/pkg/utils/reflectutils"
 )
@@ -27,6 +29,10 @@ import (
 type Options struct {
 	Enable     bool   `json:"enable" yaml:"enable"`
 	WebhookUrl string `json:"webhookUrl" yaml:"webhookUrl"`
+	// The CA bundle verifying the certificate of the auditing webhook, the in-cluster service CA is used if empty.
+	WebhookCAFile string `json:"webhookCAFile,omitempty" yaml:"webhookCAFile,omitempty"`
+	// Skip verifying the certificate of the auditing webhook, it should only be enabled for testing.
+	WebhookInsecureSkipVerify bool `json:"webhookInsecureSkipVerify,omitempty" yaml:"webhookInsecureSkipVerify,omitempty"`
 	// The maximum concurrent senders which send auditing events to the auditing webhook.
 	EventSendersNum int `json:"eventSendersNum" yaml:"eventSendersNum"`
 	// The batch size of auditing events.
@@ -39,6 +45,91 @@ type Options struct {
 	Password           string        `json:"password" yaml:"password"`
 	IndexPrefix        string        `json:"indexPrefix,omitempty" yaml:"indexPrefix,omitempty"`
 	Version            string        `json:"version" yaml:"version"`
+	// The directory auditing events are spooled to until they are delivered, the spool is disabled if empty.
+	SpoolDir string `json:"spoolDir,omitempty" yaml:"spoolDir,omitempty"`
+	// The maximum size in bytes of the spool, the oldest events are dropped when it is exceeded.
+	SpoolMaxSize int64 `json:"spoolMaxSize,omitempty" yaml:"spoolMaxSize,omitempty"`
+	// The maximum time events are kept in the spool.
+	SpoolMaxAge time.Duration `json:"spoolMaxAge,omitempty" yaml:"spoolMaxAge,omitempty"`
+	// The outputs auditing events are sent to, they run independently of each other.
+	// The auditing webhook is used if no sink is configured.
+	Sinks []SinkOptions `json:"sinks,omitempty" yaml:"sinks,omitempty"`
+	// Link each auditing event to the previous one by hash, so that modified or deleted events can be detected.
+	HashChainEnabled bool `json:"hashChainEnabled,omitempty" yaml:"hashChainEnabled,omitempty"`
+	// The PEM encoded RSA or ECDSA private key signing the checkpoints of the hash chain,
+	// no checkpoint is emitted if empty.
+	CheckpointSigningKeyFile string `json:"checkpointSigningKeyFile,omitempty" yaml:"checkpointSigningKeyFile,omitempty"`
+	// The interval between the signed checkpoints of the hash chain.
+	CheckpointInterval time.Duration `json:"checkpointInterval,omitempty" yaml:"checkpointInterval,omitempty"`
+}
+
+const (
+	SinkTypeFile    = "file"
+	SinkTypeSyslog  = "syslog"
+	SinkTypeWebhook = "webhook"
+)
+
+type SinkOptions struct {
+	// Name identifies the sink in logs, metrics and the spool, it must be unique.
+	Name string `json:"name" yaml:"name"`
+	// Type of the sink, one of file, syslog or webhook.
+	Type string `json:"type" yaml:"type"`
+	// The maximum level of detail sent to the sink, one of None, Metadata, Request and RequestResponse.
+	// Events recorded at a higher level are stripped down to it, nothing is sent at level None.
+	// Defaults to RequestResponse.
+	Level   string              `json:"level,omitempty" yaml:"level,omitempty"`
+	File    *FileSinkOptions    `json:"file,omitempty" yaml:"file,omitempty"`
+	Syslog  *SyslogSinkOptions  `json:"syslog,omitempty" yaml:"syslog,omitempty"`
+	Webhook *WebhookSinkOptions `json:"webhook,omitempty" yaml:"webhook,omitempty"`
+}
+
+// FileSinkOptions writes the events as JSON lines to a file which is rotated by size.
+type FileSinkOptions struct {
+	Path string `json:"path" yaml:"path"`
+	// The maximum size in megabytes of the file before it gets rotated, defaults to 100 megabytes.
+	MaxSize int `json:"maxSize,omitempty" yaml:"maxSize,omitempty"`
+	// The maximum number of rotated files to retain, all of them are retained if 0.
+	MaxBackups int `json:"maxBackups,omitempty" yaml:"maxBackups,omitempty"`
+	// The maximum number of days to retain the rotated files, they are not removed by age if 0.
+	MaxAge   int  `json:"maxAge,omitempty" yaml:"maxAge,omitempty"`
+	Compress bool `json:"compress,omitempty" yaml:"compress,omitempty"`
+}
+
+// SyslogSinkOptions sends the events as RFC 5424 messages to a syslog server.
+type SyslogSinkOptions struct {
+	// Address of the syslog server in the form host:port.
+	Address string `json:"address" yaml:"address"`
+	// Network is either tcp or tls, defaults to tcp.
+	Network string `json:"network,omitempty" yaml:"network,omitempty"`
+	// Facility of the messages, defaults to 13 (log audit).
+	Facility *int `json:"facility,omitempty" yaml:"facility,omitempty"`
+	// AppName of the messages, defaults to kubesphere.
+	AppName string      `json:"appName,omitempty" yaml:"appName,omitempty"`
+	TLS     *TLSOptions `json:"tls,omitempty" yaml:"tls,omitempty"`
+}
+
+const (
+	WebhookFormatEventList = "EventList"
+	WebhookFormatKafka     = "Kafka"
+)
+
+// WebhookSinkOptions posts the events to an HTTP endpoint.
+type WebhookSinkOptions struct {
+	URL string `json:"url" yaml:"url"`
+	// Format of the request body, EventList posts the events as a v1alpha1.EventList,
+	// Kafka posts them as records accepted by the Kafka REST proxy. Defaults to EventList.
+	Format  string        `json:"format,omitempty" yaml:"format,omitempty"`
+	Timeout time.Duration `json:"timeout,omitempty" yaml:"timeout,omitempty"`
+	TLS     *TLSOptions   `json:"tls,omitempty" yaml:"tls,omitempty"`
+}
+
+type TLSOptions struct {
+	// The CA bundle verifying the server certificate, the system roots are used if empty.
+	CAFile string `json:"caFile,omitempty" yaml:"caFile,omitempty"`
+	// The client certificate and key for mutual TLS.
+	CertFile           string `json:"certFile,omitempty" yaml:"certFile,omitempty"`
+	KeyFile            string `json:"keyFile,omitempty" yaml:"keyFile,omitempty"`
+	InsecureSkipVerify bool   `json:"insecureSkipVerify,omitempty" yaml:"insecureSkipVerify,omitempty"`
 }
 
 func NewAuditingOptions() *Options {
@@ -57,6 +148,52 @@ func (s *Options) ApplyTo(options *Options) {
 
 func (s *Options) Validate() []error {
 	errs := make([]error, 0)
+	if s.HashChainEnabled && s.CheckpointSigningKeyFile != "" {
+		if _, err := keyutil.PrivateKeyFromFile(s.CheckpointSigningKeyFile); err != nil {
+			errs = append(errs, fmt.Errorf("invalid auditing checkpoint signing key, %s", err))
+		}
+	}
+	names := make(map[string]bool)
+	for _, sink := range s.Sinks {
+		if sink.Name == "" {
+			errs = append(errs, fmt.Errorf("auditing sink name must not be empty"))
+		} else if names[sink.Name] {
+			errs = append(errs, fmt.Errorf("duplicate auditing sink %s", sink.Name))
+		}
+		names[sink.Name] = true
+
+		switch sink.Level {
+		case "", "None", "Metadata", "Request", "RequestResponse":
+		default:
+			errs = append(errs, fmt.Errorf("invalid level %s of auditing sink %s", sink.Level, sink.Name))
+		}
+
+		switch {
+		case sink.Type == SinkTypeFile && sink.File != nil:
+			if sink.File.Path == "" {
+				errs = append(errs, fmt.Errorf("path of auditing sink %s must not be empty", sink.Name))
+			}
+		case sink.Type == SinkTypeSyslog && sink.Syslog != nil:
+			if sink.Syslog.Address == "" {
+				errs = append(errs, fmt.Errorf("address of auditing sink %s must not be empty", sink.Name))
+			}
+			if network := sink.Syslog.Network; network != "" && network != "tcp" && network != "tls" {
+				errs = append(errs, fmt.Errorf("invalid network %s of auditing sink %s", network, sink.Name))
+			}
+			if facility := sink.Syslog.Facility; facility != nil && (*facility < 0 || *facility > 23) {
+				errs = append(errs, fmt.Errorf("invalid facility %d of auditing sink %s", *facility, sink.Name))
+			}
+		case sink.Type == SinkTypeWebhook && sink.Webhook != nil:
+			if sink.Webhook.URL == "" {
+				errs = append(errs, fmt.Errorf("url of auditing sink %s must not be empty", sink.Name))
+			}
+			if format := sink.Webhook.Format; format != "" && format != WebhookFormatEventList && format != WebhookFormatKafka {
+				errs = append(errs, fmt.Errorf("invalid format %s of auditing sink %s", format, sink.Name))
+			}
+		default:
+			errs = append(errs, fmt.Errorf("auditing sink %s must configure the options of its type %s", sink.Name, sink.Type))
+		}
+	}
 	return errs
 }
 
@@ -64,6 +201,11 @@ func (s *Options) AddFlags(fs *pflag.FlagSet, c *Options) {
 	fs.BoolVar(&s.Enable, "auditing-enabled", c.Enable, "Enable auditing component or not. ")
 
 	fs.StringVar(&s.WebhookUrl, "auditing-webhook-url", c.WebhookUrl, "Auditing wehook url")
+	fs.StringVar(&s.WebhookCAFile, "auditing-webhook-ca-file", c.WebhookCAFile, ""+
+		"The CA bundle verifying the certificate of the auditing webhook. "+
+		"The in-cluster service CA is used if left blank.")
+	fs.BoolVar(&s.WebhookInsecureSkipVerify, "auditing-webhook-insecure-skip-verify", c.WebhookInsecureSkipVerify, ""+
+		"Skip verifying the certificate of the auditing webhook, it should only be enabled for testing.")
 
 	fs.BoolVar(&s.BasicAuth, "auditing-elasticsearch-basicAuth", c.BasicAuth, ""+
 		"Elasticsearch auditing service basic auth enabled. KubeSphere is using elastic as auditing store, "+
@@ -85,6 +227,21 @@ func (s *Options) AddFlags(fs *pflag.FlagSet, c *Options) {
 		"The batch size of auditing events.")
 	fs.DurationVar(&s.EventBatchInterval, "auditing-event-batch-interval", c.EventBatchInterval,
 		"The batch interval of auditing events.")
+	fs.StringVar(&s.SpoolDir, "auditing-spool-dir", c.SpoolDir, ""+
+		"The directory auditing events are spooled to until they are delivered, so that they survive "+
+		"outages of the sinks and restarts. Each configured sink spools into a subdirectory named after it. "+
+		"The spool is disabled if left blank.")
+	fs.Int64Var(&s.SpoolMaxSize, "auditing-spool-max-size", c.SpoolMaxSize,
+		"The maximum size in bytes of the auditing spool, the oldest events are dropped when it is exceeded.")
+	fs.DurationVar(&s.SpoolMaxAge, "auditing-spool-max-age", c.SpoolMaxAge,
+		"The maximum time auditing events are kept in the spool before they are dropped.")
+	fs.BoolVar(&s.HashChainEnabled, "auditing-hash-chain-enabled", c.HashChainEnabled, ""+
+		"Link each auditing event to the previous one by hash, so that modified or deleted events can be detected.")
+	fs.StringVar(&s.CheckpointSigningKeyFile, "auditing-checkpoint-signing-key-file", c.CheckpointSigningKeyFile, ""+
+		"The PEM encoded RSA or ECDSA private key signing the checkpoints of the auditing hash chain. "+
+		"No checkpoint is emitted if left blank.")
+	fs.DurationVar(&s.CheckpointInterval, "auditing-checkpoint-interval", c.CheckpointInterval,
+		"The interval between the signed checkpoints of the auditing hash chain.")
 
 	fs.StringVar(&s.Host, "auditing-elasticsearch-host", c.Host, ""+
 		"Elasticsearch service host. KubeSphere is using elastic as auditing store, "+
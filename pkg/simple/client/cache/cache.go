@@ -39,6 +39,21 @@ type Interface interface {
 	// Set sets the value and living duration of the given key, zero duration means never expire
 	Set(key string, value string, duration time.Duration) error
 
+	// SetNX sets the value and living duration of the given key only if the key doesn't exist,
+	// returns whether the key was set, zero duration means never expire
+	SetNX(key string, value string, duration time.Duration) (bool, error)
+
+	// AddToWindow records an event at the given time in the sliding window of the given key and returns the number
+	// of the events in the window, the events at or before the time minus the window are discarded. The key expires
+	// once the window has elapsed since the latest event.
+	AddToWindow(key string, at time.Time, window time.Duration) (int64, error)
+
+	// CountWindow returns the number of the events after the given time minus the window in the sliding window of the given key
+	CountWindow(key string, at time.Time, window time.Duration) (int64, error)
+
+	// RemoveFromWindow removes the latest event from the sliding window of the given key, no error returned if the window is empty
+	RemoveFromWindow(key string) error
+
 	// Del deletes the given key, no error returned if the key doesn't exist
 	Del(keys ...string) error
 
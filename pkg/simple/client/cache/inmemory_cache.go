@@ -18,7 +18,9 @@ package cache
 
 import (
 	"regexp"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"kubesphere.io/kubesphere/pkg/server/options"
@@ -40,6 +42,8 @@ type simpleObject struct {
 	value       string
 	neverExpire bool
 	expiredAt   time.Time
+	// events are the times of the events in the sliding window in ascending order
+	events []time.Time
 }
 
 func (so *simpleObject) IsExpired() bool {
@@ -62,6 +66,7 @@ type InMemoryCacheOptions struct {
 
 // imMemoryCache implements cache.Interface use memory objects, it should be used only for testing
 type inMemoryCache struct {
+	mutex sync.Mutex
 	store map[string]simpleObject
 }
 
@@ -82,6 +87,8 @@ func NewInMemoryCache(options *InMemoryCacheOptions, stopCh <-chan struct{}) (In
 }
 
 func (s *inMemoryCache) cleanInvalidToken() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
 	for k, v := range s.store {
 		if v.IsExpired() {
 			delete(s.store, k)
@@ -98,6 +105,8 @@ func (s *inMemoryCache) Keys(pattern string) ([]string, error) {
 	if err != nil {
 		return nil, err
 	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
 	var keys []string
 	for k := range s.store {
 		if re.MatchString(k) {
@@ -109,6 +118,66 @@ func (s *inMemoryCache) Keys(pattern string) ([]string, error) {
 }
 
 func (s *inMemoryCache) Set(key string, value string, duration time.Duration) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.set(key, value, duration)
+	return nil
+}
+
+func (s *inMemoryCache) SetNX(key string, value string, duration time.Duration) (bool, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if _, ok := s.get(key); ok {
+		return false, nil
+	}
+	s.set(key, value, duration)
+	return true, nil
+}
+
+func (s *inMemoryCache) AddToWindow(key string, at time.Time, window time.Duration) (int64, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	events := s.window(key, at, window)
+	i := sort.Search(len(events), func(i int) bool { return events[i].After(at) })
+	events = append(events, time.Time{})
+	copy(events[i+1:], events[i:])
+	events[i] = at
+	s.store[key] = simpleObject{events: events, expiredAt: time.Now().Add(window)}
+	return int64(len(events)), nil
+}
+
+func (s *inMemoryCache) CountWindow(key string, at time.Time, window time.Duration) (int64, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return int64(len(s.window(key, at, window))), nil
+}
+
+func (s *inMemoryCache) RemoveFromWindow(key string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if _, ok := s.get(key); !ok {
+		return nil
+	}
+	sobject := s.store[key]
+	if len(sobject.events) > 0 {
+		sobject.events = sobject.events[:len(sobject.events)-1]
+		s.store[key] = sobject
+	}
+	return nil
+}
+
+// window returns a copy of the events after the time minus the window
+func (s *inMemoryCache) window(key string, at time.Time, window time.Duration) []time.Time {
+	if _, ok := s.get(key); !ok {
+		return nil
+	}
+	events := s.store[key].events
+	start := at.Add(-window)
+	i := sort.Search(len(events), func(i int) bool { return events[i].After(start) })
+	return append([]time.Time(nil), events[i:]...)
+}
+
+func (s *inMemoryCache) set(key string, value string, duration time.Duration) {
 	sobject := simpleObject{
 		value:       value,
 		neverExpire: false,
@@ -120,10 +189,11 @@ func (s *inMemoryCache) Set(key string, value string, duration time.Duration) er
 	}
 
 	s.store[key] = sobject
-	return nil
 }
 
 func (s *inMemoryCache) Del(keys ...string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
 	for _, key := range keys {
 		delete(s.store, key)
 	}
@@ -131,16 +201,26 @@ func (s *inMemoryCache) Del(keys ...string) error {
 }
 
 func (s *inMemoryCache) Get(key string) (string, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if value, ok := s.get(key); ok {
+		return value, nil
+	}
+	return "", ErrNoSuchKey
+}
+
+func (s *inMemoryCache) get(key string) (string, bool) {
 	if sobject, ok := s.store[key]; ok {
 		if sobject.neverExpire || time.Now().Before(sobject.expiredAt) {
-			return sobject.value, nil
+			return sobject.value, true
 		}
 	}
-
-	return "", ErrNoSuchKey
+	return "", false
 }
 
 func (s *inMemoryCache) Exists(keys ...string) (bool, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
 	for _, key := range keys {
 		if _, ok := s.store[key]; !ok {
 			return false, nil
@@ -151,22 +231,13 @@ func (s *inMemoryCache) Exists(keys ...string) (bool, error) {
 }
 
 func (s *inMemoryCache) Expire(key string, duration time.Duration) error {
-	value, err := s.Get(key)
-	if err != nil {
-		return err
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	value, ok := s.get(key)
+	if !ok {
+		return ErrNoSuchKey
 	}
-
-	sobject := simpleObject{
-		value:       value,
-		neverExpire: false,
-		expiredAt:   time.Now().Add(duration),
-	}
-
-	if duration == NeverExpire {
-		sobject.neverExpire = true
-	}
-
-	s.store[key] = sobject
+	s.set(key, value, duration)
 	return nil
 }
 
@@ -138,3 +138,55 @@ func TestDeleteAndExpireCache(t *testing.T) {
 		})
 	}
 }
+
+func TestSetNX(t *testing.T) {
+	cacheClient, _ := NewInMemoryCache(nil, nil)
+
+	if ok, err := cacheClient.SetNX("foo", "val1", time.Millisecond*500); err != nil || !ok {
+		t.Fatalf("expected the key to be set, got %v, %v", ok, err)
+	}
+	if ok, err := cacheClient.SetNX("foo", "val2", NeverExpire); err != nil || ok {
+		t.Fatalf("expected the existing key not to be set, got %v, %v", ok, err)
+	}
+	if val, _ := cacheClient.Get("foo"); val != "val1" {
+		t.Errorf("expected val1, got %s", val)
+	}
+
+	time.Sleep(time.Millisecond * 500)
+	if ok, err := cacheClient.SetNX("foo", "val2", NeverExpire); err != nil || !ok {
+		t.Fatalf("expected the expired key to be set, got %v, %v", ok, err)
+	}
+	if val, _ := cacheClient.Get("foo"); val != "val2" {
+		t.Errorf("expected val2, got %s", val)
+	}
+}
+
+func TestSlidingWindow(t *testing.T) {
+	cacheClient, _ := NewInMemoryCache(nil, nil)
+	now := time.Now()
+
+	for i, expected := range []int64{1, 2, 3} {
+		if count, err := cacheClient.AddToWindow("foo", now.Add(time.Duration(i)*time.Minute), 2*time.Minute); err != nil || count != expected {
+			t.Fatalf("expected %d events, got %v, %v", expected, count, err)
+		}
+	}
+	// the first event is out of the window
+	if count, err := cacheClient.AddToWindow("foo", now.Add(2*time.Minute), 2*time.Minute); err != nil || count != 3 {
+		t.Fatalf("expected 3 events, got %v, %v", count, err)
+	}
+	if count, err := cacheClient.CountWindow("foo", now.Add(3*time.Minute+time.Second), 2*time.Minute); err != nil || count != 2 {
+		t.Fatalf("expected 2 events, got %v, %v", count, err)
+	}
+	if err := cacheClient.RemoveFromWindow("foo"); err != nil {
+		t.Fatal(err)
+	}
+	if count, err := cacheClient.CountWindow("foo", now.Add(2*time.Minute), 2*time.Minute); err != nil || count != 2 {
+		t.Fatalf("expected 2 events, got %v, %v", count, err)
+	}
+	if err := cacheClient.RemoveFromWindow("bar"); err != nil {
+		t.Fatal(err)
+	}
+	if count, err := cacheClient.CountWindow("bar", now, time.Minute); err != nil || count != 0 {
+		t.Fatalf("expected no events, got %v, %v", count, err)
+	}
+}
@@ -19,9 +19,11 @@ package cache
 import (
 	"errors"
 	"fmt"
+	"strconv"
 	"time"
 
 	"github.com/go-redis/redis"
+	"github.com/google/uuid"
 	"github.com/mitchellh/mapstructure"
 	"k8s.io/klog/v2"
 
@@ -30,6 +32,15 @@ import (
 
 const typeRedis = "redis"
 
+// addToWindowScript discards the events out of the window, adds the event to the sorted set scored by
+// the time in milliseconds and returns the number of the events in the window
+var addToWindowScript = redis.NewScript(`
+redis.call("ZREMRANGEBYSCORE", KEYS[1], "-inf", ARGV[1])
+redis.call("ZADD", KEYS[1], ARGV[2], ARGV[3])
+redis.call("PEXPIRE", KEYS[1], ARGV[4])
+return redis.call("ZCARD", KEYS[1])
+`)
+
 type redisClient struct {
 	client *redis.Client
 }
@@ -76,7 +87,11 @@ func NewRedisClient(option *redisOptions, stopCh <-chan struct{}) (Interface, er
 }
 
 func (r *redisClient) Get(key string) (string, error) {
-	return r.client.Get(key).Result()
+	value, err := r.client.Get(key).Result()
+	if err == redis.Nil {
+		return "", ErrNoSuchKey
+	}
+	return value, err
 }
 
 func (r *redisClient) Keys(pattern string) ([]string, error) {
@@ -87,6 +102,24 @@ func (r *redisClient) Set(key string, value string, duration time.Duration) erro
 	return r.client.Set(key, value, duration).Err()
 }
 
+func (r *redisClient) SetNX(key string, value string, duration time.Duration) (bool, error) {
+	return r.client.SetNX(key, value, duration).Result()
+}
+
+func (r *redisClient) AddToWindow(key string, at time.Time, window time.Duration) (int64, error) {
+	// the member is unique, so that the events at the same time are counted separately
+	member := strconv.FormatInt(at.UnixNano(), 10) + "-" + uuid.New().String()
+	return addToWindowScript.Run(r.client, []string{key}, at.Add(-window).UnixMilli(), at.UnixMilli(), member, window.Milliseconds()).Int64()
+}
+
+func (r *redisClient) CountWindow(key string, at time.Time, window time.Duration) (int64, error) {
+	return r.client.ZCount(key, "("+strconv.FormatInt(at.Add(-window).UnixMilli(), 10), "+inf").Result()
+}
+
+func (r *redisClient) RemoveFromWindow(key string) error {
+	return r.client.ZRemRangeByRank(key, -1, -1).Err()
+}
+
 func (r *redisClient) Del(keys ...string) error {
 	return r.client.Del(keys...).Err()
 }
@@ -19,7 +19,6 @@ package elasticsearch
 import (
 	"bytes"
 	"encoding/json"
-	"fmt"
 	"io"
 	"time"
 
@@ -30,12 +29,6 @@ import (
 	"kubesphere.io/kubesphere/pkg/utils/stringutils"
 )
 
-const (
-	podNameMaxLength          = 63
-	podNameSuffixLength       = 6  // 5 characters + 1 hyphen
-	replicaSetSuffixMaxLength = 11 // max 10 characters + 1 hyphen
-)
-
 type Source struct {
 	Log        string `json:"log"`
 	Time       string `json:"time"`
@@ -243,7 +236,7 @@ func parseToQueryPart(sf logging.SearchFilter) *query.Query {
 	if sf.WorkloadFilter != nil {
 		bi := query.NewBool().WithMinimumShouldMatch(mini)
 		for _, wk := range sf.WorkloadFilter {
-			bi.AppendShould(query.NewRegex("kubernetes.pod_name.keyword", podNameRegex(wk)))
+			bi.AppendShould(query.NewRegex("kubernetes.pod_name.keyword", logging.PodNameRegex(wk)))
 		}
 
 		b.AppendFilter(bi)
@@ -286,28 +279,3 @@ func parseToQueryPart(sf logging.SearchFilter) *query.Query {
 
 	return query.NewQuery().WithBool(b)
 }
-
-func podNameRegex(workloadName string) string {
-	var regex string
-	if len(workloadName) <= podNameMaxLength-replicaSetSuffixMaxLength-podNameSuffixLength {
-		// match deployment pods, eg. <deploy>-579dfbcddd-24znw
-		// replicaset rand string is limited to vowels
-		// https://github.com/kubernetes/kubernetes/blob/master/staging/src/k8s.io/apimachinery/pkg/util/rand/rand.go#L83
-		regex += workloadName + "-[bcdfghjklmnpqrstvwxz2456789]{1,10}-[a-z0-9]{5}|"
-		// match statefulset pods, eg. <sts>-0
-		regex += workloadName + "-[0-9]+|"
-		// match pods of daemonset or job, eg. <ds>-29tdk, <job>-5xqvl
-		regex += workloadName + "-[a-z0-9]{5}"
-	} else if len(workloadName) <= podNameMaxLength-podNameSuffixLength {
-		replicaSetSuffixLength := podNameMaxLength - podNameSuffixLength - len(workloadName)
-		regex += fmt.Sprintf("%s%d%s", workloadName+"-[bcdfghjklmnpqrstvwxz2456789]{", replicaSetSuffixLength, "}[a-z0-9]{5}|")
-		regex += workloadName + "-[0-9]+|"
-		regex += workloadName + "-[a-z0-9]{5}"
-	} else {
-		// Rand suffix may overwrites the workload name if the name is too long
-		// This won't happen for StatefulSet because long name will cause ReplicaSet fails during StatefulSet creation.
-		regex += workloadName[:podNameMaxLength-podNameSuffixLength+1] + "[a-z0-9]{5}|"
-		regex += workloadName + "-[0-9]+"
-	}
-	return regex
-}
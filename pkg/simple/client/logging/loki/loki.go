@@ -0,0 +1,691 @@
+/*
+Copyright 2022 KubeSphere Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package loki
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"kubesphere.io/kubesphere/pkg/simple/client/logging"
+	"kubesphere.io/kubesphere/pkg/utils/stringutils"
+)
+
+const (
+	queryPath      = "/loki/api/v1/query"
+	queryRangePath = "/loki/api/v1/query_range"
+	tenantHeader   = "X-Scope-OrgID"
+
+	directionForward  = "forward"
+	directionBackward = "backward"
+
+	pageSize = 1000
+	// the max number of points of a range query, which is the limit of loki
+	maxQueryPoints = 11000
+	// how long the total of a search is cached, so that paging through the logs does not count them again
+	totalCacheTTL = 30 * time.Second
+	// the search range if the start time is not given, which is the default max query length of loki
+	defaultLookback = 721 * time.Hour
+
+	defaultNamespaceLabel = "namespace"
+	defaultPodLabel       = "pod"
+	defaultContainerLabel = "container"
+)
+
+// Loki implement logging interface
+type client struct {
+	host            string
+	basicAuth       bool
+	username        string
+	password        string
+	tenantID        string
+	namespaceLabel  string
+	podLabel        string
+	containerLabel  string
+	exportLogsLimit int
+	client          *http.Client
+	now             func() time.Time
+
+	// totals are the cached totals of the searches keyed by the search filter
+	totalsMutex sync.Mutex
+	totals      map[string]cachedTotal
+}
+
+type cachedTotal struct {
+	logs    int64
+	expires time.Time
+}
+
+func NewClient(options *logging.Options) (logging.Client, error) {
+	if _, err := url.Parse(options.Host); err != nil {
+		return nil, err
+	}
+	c := &client{
+		host:            strings.TrimSuffix(options.Host, "/"),
+		basicAuth:       options.BasicAuth,
+		username:        options.Username,
+		password:        options.Password,
+		namespaceLabel:  defaultNamespaceLabel,
+		podLabel:        defaultPodLabel,
+		containerLabel:  defaultContainerLabel,
+		exportLogsLimit: options.ExportLogsLimit,
+		client:          &http.Client{Timeout: time.Minute},
+		now:             time.Now,
+		totals:          make(map[string]cachedTotal),
+	}
+	if loki := options.Loki; loki != nil {
+		c.tenantID = loki.TenantID
+		if loki.NamespaceLabel != "" {
+			c.namespaceLabel = loki.NamespaceLabel
+		}
+		if loki.PodLabel != "" {
+			c.podLabel = loki.PodLabel
+		}
+		if loki.ContainerLabel != "" {
+			c.containerLabel = loki.ContainerLabel
+		}
+	}
+	return c, nil
+}
+
+// stream is a log query over a time range.
+type stream struct {
+	query string
+	start time.Time
+	end   time.Time
+}
+
+// streams translates the search filter into log queries, the namespaces created after the start
+// of the search are queried from their creation time, so that the archived logs of a reopened
+// namespace are not disclosed. The namespaces sharing the same start are queried together.
+func (c *client) streams(sf logging.SearchFilter) []stream {
+	end := sf.Endtime
+	if end.IsZero() {
+		end = c.now()
+	}
+	start := sf.Starttime
+	if start.IsZero() {
+		start = end.Add(-defaultLookback)
+	}
+
+	groups := make(map[time.Time][]string)
+	for ns, creationTime := range sf.NamespaceFilter {
+		nsStart := start
+		if creationTime != nil && creationTime.After(start) {
+			nsStart = *creationTime
+		}
+		if nsStart.After(end) {
+			continue
+		}
+		groups[nsStart] = append(groups[nsStart], ns)
+	}
+
+	var streams []stream
+	for nsStart, namespaces := range groups {
+		sort.Strings(namespaces)
+		streams = append(streams, stream{query: c.logQuery(namespaces, sf), start: nsStart, end: end})
+	}
+	sort.Slice(streams, func(i, j int) bool {
+		return streams[i].start.Before(streams[j].start)
+	})
+	return streams
+}
+
+// logQuery returns the LogQL selecting the logs of the namespaces matching the search filter.
+// The filters match the pods and containers exactly while the searches match them fuzzily,
+// which is a case-insensitive substring match.
+func (c *client) logQuery(namespaces []string, sf logging.SearchFilter) string {
+	matchers := []string{matcher(c.namespaceLabel, exactly(namespaces))}
+
+	if len(sf.WorkloadFilter) > 0 {
+		var regexes []string
+		for _, workload := range sf.WorkloadFilter {
+			regexes = append(regexes, "("+logging.PodNameRegex(workload)+")")
+		}
+		matchers = append(matchers, matcher(c.podLabel, strings.Join(regexes, "|")))
+	}
+	if len(sf.PodFilter) > 0 {
+		matchers = append(matchers, matcher(c.podLabel, exactly(sf.PodFilter)))
+	}
+	if len(sf.ContainerFilter) > 0 {
+		matchers = append(matchers, matcher(c.containerLabel, exactly(sf.ContainerFilter)))
+	}
+	if len(sf.WorkloadSearch) > 0 {
+		matchers = append(matchers, matcher(c.podLabel, ".*"+fuzzily(sf.WorkloadSearch)+".*"))
+	}
+	if len(sf.PodSearch) > 0 {
+		matchers = append(matchers, matcher(c.podLabel, ".*"+fuzzily(sf.PodSearch)+".*"))
+	}
+	if len(sf.ContainerSearch) > 0 {
+		matchers = append(matchers, matcher(c.containerLabel, ".*"+fuzzily(sf.ContainerSearch)+".*"))
+	}
+
+	query := "{" + strings.Join(matchers, ", ") + "}"
+	if len(sf.LogSearch) > 0 {
+		query += " |~ " + strconv.Quote(fuzzily(sf.LogSearch))
+	}
+	return query
+}
+
+func matcher(label, regex string) string {
+	return label + "=~" + strconv.Quote(regex)
+}
+
+func exactly(values []string) string {
+	quoted := make([]string, 0, len(values))
+	for _, v := range values {
+		quoted = append(quoted, regexp.QuoteMeta(v))
+	}
+	return strings.Join(quoted, "|")
+}
+
+func fuzzily(values []string) string {
+	return "(?i)(" + exactly(values) + ")"
+}
+
+func (c *client) GetCurrentStats(sf logging.SearchFilter) (logging.Statistics, error) {
+	var stats logging.Statistics
+	for _, s := range c.streams(sf) {
+		counter := fmt.Sprintf("count_over_time(%s[%s])", s.query, duration(s.end.Sub(s.start)))
+
+		logs, err := c.instant("sum("+counter+")", s.end)
+		if err != nil {
+			return logging.Statistics{}, err
+		}
+		containers, err := c.instant(fmt.Sprintf("count(sum by (%s, %s, %s) (%s))",
+			c.namespaceLabel, c.podLabel, c.containerLabel, counter), s.end)
+		if err != nil {
+			return logging.Statistics{}, err
+		}
+		stats.Logs += logs
+		stats.Containers += containers
+	}
+	return stats, nil
+}
+
+func (c *client) CountLogsByInterval(sf logging.SearchFilter, interval string) (logging.Histogram, error) {
+	step, err := parseInterval(interval)
+	if err != nil {
+		return logging.Histogram{}, err
+	}
+
+	buckets := make(map[int64]int64)
+	for _, s := range c.streams(sf) {
+		if err := c.histogram(s, step, buckets); err != nil {
+			return logging.Histogram{}, err
+		}
+	}
+
+	h := logging.Histogram{}
+	for key, count := range buckets {
+		h.Total += count
+		h.Buckets = append(h.Buckets, logging.Bucket{Time: key, Count: count})
+	}
+	sort.Slice(h.Buckets, func(i, j int) bool {
+		return h.Buckets[i].Time < h.Buckets[j].Time
+	})
+	return h, nil
+}
+
+// histogram counts the logs of the stream into the buckets keyed by their start in milliseconds.
+// The buckets are aligned to the interval, the partial buckets at both ends are counted
+// separately, so that no log outside the time range of the stream is counted.
+func (c *client) histogram(s stream, step time.Duration, buckets map[int64]int64) error {
+	stepMillis := step.Milliseconds()
+	first := s.start.UnixMilli() / stepMillis * stepMillis
+	firstBoundary := time.UnixMilli(first + stepMillis)
+	last := s.end.UnixMilli() / stepMillis * stepMillis
+	lastBoundary := time.UnixMilli(last)
+
+	count := func(key int64, start, end time.Time) error {
+		if !end.After(start) {
+			return nil
+		}
+		n, err := c.instant(fmt.Sprintf("sum(count_over_time(%s[%s]))", s.query, duration(end.Sub(start))), end)
+		if n > 0 {
+			buckets[key] += n
+		}
+		return err
+	}
+
+	if !firstBoundary.Before(s.end) {
+		return count(first, s.start, s.end)
+	}
+	if err := count(first, s.start, firstBoundary); err != nil {
+		return err
+	}
+	// the range is queried in chunks, each of which has at most maxQueryPoints points
+	for chunkStart := firstBoundary.Add(step); !chunkStart.After(lastBoundary); {
+		chunkEnd := lastBoundary
+		if lastBoundary.Sub(chunkStart)/step >= maxQueryPoints {
+			chunkEnd = chunkStart.Add(step * (maxQueryPoints - 1))
+		}
+		params := url.Values{}
+		params.Set("query", fmt.Sprintf("sum(count_over_time(%s[%s]))", s.query, duration(step)))
+		params.Set("start", nanoseconds(chunkStart))
+		params.Set("end", nanoseconds(chunkEnd))
+		params.Set("step", duration(step))
+		var samples []sample
+		if err := c.query(queryRangePath, params, "matrix", &samples); err != nil {
+			return err
+		}
+		for _, sample := range samples {
+			for _, point := range sample.Values {
+				ts, value, err := point.parse()
+				if err != nil {
+					return err
+				}
+				buckets[ts.UnixMilli()-stepMillis] += int64(value)
+			}
+		}
+		chunkStart = chunkEnd.Add(step)
+	}
+	return count(last, lastBoundary, s.end)
+}
+
+func (c *client) SearchLogs(sf logging.SearchFilter, from, size int64, order string) (logging.Logs, error) {
+	total, err := c.total(sf)
+	if err != nil {
+		return logging.Logs{}, err
+	}
+	l := logging.Logs{Total: total}
+
+	direction := directionBackward
+	if order == "asc" {
+		direction = directionForward
+	}
+	var skipped int64
+	err = c.entries(sf, direction, func(e entry) bool {
+		if skipped < from {
+			skipped++
+			return true
+		}
+		l.Records = append(l.Records, logging.Record{
+			Log:       e.line,
+			Time:      time.Unix(0, e.ts).UTC().Format(time.RFC3339Nano),
+			Namespace: e.labels[c.namespaceLabel],
+			Pod:       e.labels[c.podLabel],
+			Container: e.labels[c.containerLabel],
+		})
+		return int64(len(l.Records)) < size
+	})
+	if err != nil {
+		return logging.Logs{}, err
+	}
+	return l, nil
+}
+
+// total returns the number of the logs matching the search filter, which is cached for totalCacheTTL.
+func (c *client) total(sf logging.SearchFilter) (int64, error) {
+	data, err := json.Marshal(sf)
+	if err != nil {
+		return 0, err
+	}
+	key := string(data)
+
+	c.totalsMutex.Lock()
+	cached, ok := c.totals[key]
+	c.totalsMutex.Unlock()
+	if ok && c.now().Before(cached.expires) {
+		return cached.logs, nil
+	}
+
+	stats, err := c.GetCurrentStats(sf)
+	if err != nil {
+		return 0, err
+	}
+
+	now := c.now()
+	c.totalsMutex.Lock()
+	defer c.totalsMutex.Unlock()
+	for k, v := range c.totals {
+		if !now.Before(v.expires) {
+			delete(c.totals, k)
+		}
+	}
+	c.totals[key] = cachedTotal{logs: stats.Logs, expires: now.Add(totalCacheTTL)}
+	return stats.Logs, nil
+}
+
+func (c *client) ExportLogs(sf logging.SearchFilter, w io.Writer) error {
+	var size int
+	var writeErr error
+	err := c.entries(sf, directionBackward, func(e entry) bool {
+		line := stringutils.StripAnsi(e.line)
+		if !strings.HasSuffix(line, "\n") {
+			line += "\n"
+		}
+		if _, writeErr = io.WriteString(w, line); writeErr != nil {
+			return false
+		}
+		size++
+		return size < c.exportLogsLimit
+	})
+	if err != nil {
+		return err
+	}
+	return writeErr
+}
+
+// entries iterates the log entries of all the streams in the direction until fn returns false.
+func (c *client) entries(sf logging.SearchFilter, direction string, fn func(entry) bool) error {
+	var pagers []*pager
+	for _, s := range c.streams(sf) {
+		pagers = append(pagers, newPager(c, s, direction))
+	}
+
+	for {
+		var head *pager
+		for _, p := range pagers {
+			e, err := p.peek()
+			if err != nil {
+				return err
+			}
+			if e == nil {
+				continue
+			}
+			if head == nil {
+				head = p
+				continue
+			}
+			if current, _ := head.peek(); direction == directionForward && e.ts < current.ts ||
+				direction == directionBackward && e.ts > current.ts {
+				head = p
+			}
+		}
+		if head == nil {
+			return nil
+		}
+		e, _ := head.peek()
+		head.pop()
+		if !fn(*e) {
+			return nil
+		}
+	}
+}
+
+type entry struct {
+	ts     int64
+	line   string
+	labels map[string]string
+	// key orders the entries with the same timestamp
+	key string
+}
+
+// pager pages through the log entries of a stream in the direction, the next page
+// starts at the timestamp of the last entry of the previous page, the entries at
+// that timestamp which were already returned are skipped.
+type pager struct {
+	c         *client
+	stream    stream
+	direction string
+	// the remaining time range in nanoseconds
+	start int64
+	end   int64
+	// the timestamp of the last entry returned and the number of entries returned at it
+	boundary int64
+	seen     int
+	buf      []entry
+	done     bool
+}
+
+func newPager(c *client, s stream, direction string) *pager {
+	return &pager{
+		c:         c,
+		stream:    s,
+		direction: direction,
+		start:     s.start.UnixNano(),
+		end:       s.end.UnixNano() + 1,
+		boundary:  -1,
+	}
+}
+
+func (p *pager) peek() (*entry, error) {
+	for len(p.buf) == 0 && !p.done {
+		if err := p.fetch(); err != nil {
+			return nil, err
+		}
+	}
+	if len(p.buf) == 0 {
+		return nil, nil
+	}
+	return &p.buf[0], nil
+}
+
+func (p *pager) pop() {
+	e := p.buf[0]
+	p.buf = p.buf[1:]
+	if e.ts == p.boundary {
+		p.seen++
+	} else {
+		p.boundary, p.seen = e.ts, 1
+	}
+}
+
+func (p *pager) fetch() error {
+	params := url.Values{}
+	params.Set("query", p.stream.query)
+	params.Set("start", strconv.FormatInt(p.start, 10))
+	params.Set("end", strconv.FormatInt(p.end, 10))
+	params.Set("limit", strconv.Itoa(pageSize))
+	params.Set("direction", p.direction)
+	var streams []streamValues
+	if err := p.c.query(queryRangePath, params, "streams", &streams); err != nil {
+		return err
+	}
+
+	var entries []entry
+	for _, s := range streams {
+		key := labelsKey(s.Stream)
+		for _, value := range s.Values {
+			ts, err := strconv.ParseInt(value[0], 10, 64)
+			if err != nil {
+				return err
+			}
+			entries = append(entries, entry{ts: ts, line: value[1], labels: s.Stream, key: key})
+		}
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		if entries[i].ts != entries[j].ts {
+			return entries[i].ts < entries[j].ts == (p.direction == directionForward)
+		}
+		return entries[i].key < entries[j].key
+	})
+	if len(entries) < pageSize {
+		p.done = true
+	}
+
+	skip := 0
+	for _, e := range entries {
+		beyond := p.direction == directionForward && e.ts < p.boundary ||
+			p.direction == directionBackward && p.boundary >= 0 && e.ts > p.boundary
+		if beyond || e.ts == p.boundary && skip < p.seen {
+			if !beyond {
+				skip++
+			}
+			continue
+		}
+		p.buf = append(p.buf, e)
+	}
+
+	if len(entries) > 0 {
+		last := entries[len(entries)-1].ts
+		if p.direction == directionForward {
+			p.start = last
+		} else {
+			p.end = last + 1
+		}
+		if len(p.buf) == 0 && !p.done {
+			// every entry of the page was returned already
+			if p.direction == directionForward {
+				p.start = last + 1
+			} else {
+				p.end = last
+			}
+		}
+	}
+	return nil
+}
+
+func labelsKey(labels map[string]string) string {
+	keys := make([]string, 0, len(labels))
+	for k, v := range labels {
+		keys = append(keys, k+"="+v)
+	}
+	sort.Strings(keys)
+	return strings.Join(keys, ",")
+}
+
+type response struct {
+	Status string `json:"status"`
+	Data   struct {
+		ResultType string          `json:"resultType"`
+		Result     json.RawMessage `json:"result"`
+	} `json:"data"`
+}
+
+type streamValues struct {
+	Stream map[string]string `json:"stream"`
+	Values [][2]string       `json:"values"`
+}
+
+type sample struct {
+	Metric map[string]string `json:"metric"`
+	Value  point             `json:"value"`
+	Values []point           `json:"values"`
+}
+
+// point is a pair of unix timestamp in seconds and value.
+type point [2]interface{}
+
+func (p point) parse() (time.Time, float64, error) {
+	seconds, ok := p[0].(float64)
+	if !ok {
+		return time.Time{}, 0, fmt.Errorf("invalid sample timestamp %v", p[0])
+	}
+	s, ok := p[1].(string)
+	if !ok {
+		return time.Time{}, 0, fmt.Errorf("invalid sample value %v", p[1])
+	}
+	value, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return time.Time{}, 0, err
+	}
+	return time.UnixMilli(int64(seconds * 1000)), value, nil
+}
+
+// instant returns the sum of the values of an instant metric query.
+func (c *client) instant(expr string, at time.Time) (int64, error) {
+	params := url.Values{}
+	params.Set("query", expr)
+	params.Set("time", nanoseconds(at))
+	var samples []sample
+	if err := c.query(queryPath, params, "vector", &samples); err != nil {
+		return 0, err
+	}
+	var sum float64
+	for _, s := range samples {
+		_, value, err := s.Value.parse()
+		if err != nil {
+			return 0, err
+		}
+		sum += value
+	}
+	return int64(sum), nil
+}
+
+func (c *client) query(path string, params url.Values, resultType string, result interface{}) error {
+	req, err := http.NewRequest(http.MethodGet, c.host+path+"?"+params.Encode(), nil)
+	if err != nil {
+		return err
+	}
+	if c.basicAuth {
+		req.SetBasicAuth(c.username, c.password)
+	}
+	if c.tenantID != "" {
+		req.Header.Set(tenantHeader, c.tenantID)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("loki: %s", strings.TrimSpace(string(body)))
+	}
+
+	r := &response{}
+	if err := json.Unmarshal(body, r); err != nil {
+		return err
+	}
+	if r.Data.ResultType != resultType {
+		return fmt.Errorf("loki: unexpected result type %s", r.Data.ResultType)
+	}
+	return json.Unmarshal(r.Data.Result, result)
+}
+
+func nanoseconds(t time.Time) string {
+	return strconv.FormatInt(t.UnixNano(), 10)
+}
+
+func duration(d time.Duration) string {
+	if d < time.Millisecond {
+		d = time.Millisecond
+	}
+	return strconv.FormatInt(d.Milliseconds(), 10) + "ms"
+}
+
+var intervalPattern = regexp.MustCompile(`^([0-9]+)([smhdwMqy])$`)
+
+// parseInterval parses the interval of the histogram, the months, quarters and years
+// are approximated by 30, 90 and 365 days as the buckets must have a fixed length.
+func parseInterval(interval string) (time.Duration, error) {
+	matches := intervalPattern.FindStringSubmatch(interval)
+	if matches == nil {
+		return 0, fmt.Errorf("invalid interval %s", interval)
+	}
+	n, err := strconv.Atoi(matches[1])
+	if err != nil || n == 0 {
+		return 0, fmt.Errorf("invalid interval %s", interval)
+	}
+	day := 24 * time.Hour
+	unit := map[string]time.Duration{
+		"s": time.Second,
+		"m": time.Minute,
+		"h": time.Hour,
+		"d": day,
+		"w": 7 * day,
+		"M": 30 * day,
+		"q": 90 * day,
+		"y": 365 * day,
+	}[matches[2]]
+	return time.Duration(n) * unit, nil
+}
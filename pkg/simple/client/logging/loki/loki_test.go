@@ -0,0 +1,413 @@
+/*
+Copyright 2022 KubeSphere Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package loki
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	"kubesphere.io/kubesphere/pkg/simple/client/logging"
+)
+
+func TestLogQuery(t *testing.T) {
+	now := time.Unix(1660000200, 0)
+	start := time.Unix(1660000000, 0)
+	created := time.Unix(1660000100, 0)
+	later := time.Unix(1660000300, 0)
+
+	var tests = []struct {
+		filter   logging.SearchFilter
+		expected []stream
+	}{
+		{
+			filter: logging.SearchFilter{
+				NamespaceFilter: map[string]*time.Time{"kubesphere-system": nil, "default": nil},
+				Starttime:       start,
+			},
+			expected: []stream{
+				{query: `{namespace=~"default|kubesphere-system"}`, start: start, end: now},
+			},
+		},
+		{
+			filter: logging.SearchFilter{
+				NamespaceFilter: map[string]*time.Time{"old": &start, "new": &created, "future": &later},
+				Starttime:       start,
+			},
+			expected: []stream{
+				{query: `{namespace=~"old"}`, start: start, end: now},
+				{query: `{namespace=~"new"}`, start: created, end: now},
+			},
+		},
+		{
+			filter: logging.SearchFilter{
+				NamespaceFilter: map[string]*time.Time{"default": nil},
+				WorkloadFilter:  []string{"nginx"},
+				PodFilter:       []string{"nginx.1"},
+				ContainerFilter: []string{"nginx", "sidecar"},
+				Endtime:         now,
+			},
+			expected: []stream{
+				{
+					query: `{namespace=~"default", pod=~"(nginx-[bcdfghjklmnpqrstvwxz2456789]{1,10}-[a-z0-9]{5}|nginx-[0-9]+|nginx-[a-z0-9]{5})", pod=~"nginx\\.1", container=~"nginx|sidecar"}`,
+					start: now.Add(-defaultLookback),
+					end:   now,
+				},
+			},
+		},
+		{
+			filter: logging.SearchFilter{
+				NamespaceFilter: map[string]*time.Time{"default": nil},
+				WorkloadSearch:  []string{"ngi"},
+				PodSearch:       []string{"x"},
+				ContainerSearch: []string{"side"},
+				LogSearch:       []string{"error", `"quoted"`},
+				Starttime:       start,
+				Endtime:         now,
+			},
+			expected: []stream{
+				{
+					query: `{namespace=~"default", pod=~".*(?i)(ngi).*", pod=~".*(?i)(x).*", container=~".*(?i)(side).*"} |~ "(?i)(error|\"quoted\")"`,
+					start: start,
+					end:   now,
+				},
+			},
+		},
+	}
+
+	c := newTestClient("")
+	c.now = func() time.Time { return now }
+	for i, test := range tests {
+		streams := c.streams(test.filter)
+		if diff := cmp.Diff(streams, test.expected, cmp.AllowUnexported(stream{})); diff != "" {
+			t.Errorf("case %d: %T differ (-got, +want): %s", i, test.expected, diff)
+		}
+	}
+}
+
+func TestGetCurrentStats(t *testing.T) {
+	srv, queries := mockLokiService(t)
+	defer srv.Close()
+
+	c := newTestClient(srv.URL)
+	stats, err := c.GetCurrentStats(logging.SearchFilter{
+		NamespaceFilter: map[string]*time.Time{"kubesphere-system": nil},
+		Starttime:       time.Unix(1660000000, 0),
+		Endtime:         time.Unix(1660000200, 0),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(stats, logging.Statistics{Containers: 2, Logs: 4}); diff != "" {
+		t.Errorf("%T differ (-got, +want): %s", stats, diff)
+	}
+
+	expected := []url.Values{
+		{
+			"query": {`sum(count_over_time({namespace=~"kubesphere-system"}[200000ms]))`},
+			"time":  {"1660000200000000000"},
+		},
+		{
+			"query": {`count(sum by (namespace, pod, container) (count_over_time({namespace=~"kubesphere-system"}[200000ms])))`},
+			"time":  {"1660000200000000000"},
+		},
+	}
+	if diff := cmp.Diff(*queries, expected); diff != "" {
+		t.Errorf("%T differ (-got, +want): %s", expected, diff)
+	}
+}
+
+func TestCountLogsByInterval(t *testing.T) {
+	srv, queries := mockLokiService(t)
+	defer srv.Close()
+
+	c := newTestClient(srv.URL)
+	histogram, err := c.CountLogsByInterval(logging.SearchFilter{
+		NamespaceFilter: map[string]*time.Time{"kubesphere-system": nil},
+		Starttime:       time.Unix(1660000000, 0),
+		Endtime:         time.Unix(1660000200, 0),
+	}, "1m")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := logging.Histogram{
+		Total: 13,
+		Buckets: []logging.Bucket{
+			{Time: 1659999960000, Count: 4},
+			{Time: 1660000020000, Count: 3},
+			{Time: 1660000080000, Count: 5},
+			{Time: 1660000140000, Count: 1},
+		},
+	}
+	if diff := cmp.Diff(histogram, expected); diff != "" {
+		t.Errorf("%T differ (-got, +want): %s", expected, diff)
+	}
+
+	expectedQueries := []url.Values{
+		{
+			"query": {`sum(count_over_time({namespace=~"kubesphere-system"}[20000ms]))`},
+			"time":  {"1660000020000000000"},
+		},
+		{
+			"query": {`sum(count_over_time({namespace=~"kubesphere-system"}[60000ms]))`},
+			"start": {"1660000080000000000"},
+			"end":   {"1660000200000000000"},
+			"step":  {"60000ms"},
+		},
+	}
+	if diff := cmp.Diff(*queries, expectedQueries); diff != "" {
+		t.Errorf("%T differ (-got, +want): %s", expectedQueries, diff)
+	}
+
+	if _, err := c.CountLogsByInterval(logging.SearchFilter{}, "1x"); err == nil {
+		t.Error("expected error for invalid interval")
+	}
+}
+
+func TestCountLogsByIntervalChunks(t *testing.T) {
+	srv, queries := mockLokiService(t)
+	defer srv.Close()
+
+	c := newTestClient(srv.URL)
+	_, err := c.CountLogsByInterval(logging.SearchFilter{
+		NamespaceFilter: map[string]*time.Time{"kubesphere-system": nil},
+		Starttime:       time.Unix(1660000000, 0),
+		Endtime:         time.Unix(1660025000, 0),
+	}, "1s")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// each chunk has at most maxQueryPoints points
+	var ranges [][2]string
+	for _, query := range *queries {
+		if query.Has("step") {
+			ranges = append(ranges, [2]string{query.Get("start"), query.Get("end")})
+		}
+	}
+	expected := [][2]string{
+		{"1660000002000000000", "1660011001000000000"},
+		{"1660011002000000000", "1660022001000000000"},
+		{"1660022002000000000", "1660025000000000000"},
+	}
+	if diff := cmp.Diff(ranges, expected); diff != "" {
+		t.Errorf("%T differ (-got, +want): %s", expected, diff)
+	}
+}
+
+func TestSearchLogs(t *testing.T) {
+	srv, queries := mockLokiService(t)
+	defer srv.Close()
+
+	c := newTestClient(srv.URL)
+	sf := logging.SearchFilter{
+		NamespaceFilter: map[string]*time.Time{"kubesphere-system": nil},
+		Starttime:       time.Unix(1660000000, 0),
+		Endtime:         time.Unix(1660000200, 0),
+	}
+	logs, err := c.SearchLogs(sf, 1, 2, "desc")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := logging.Logs{
+		Total: 4,
+		Records: []logging.Record{
+			{
+				Log:       "I0808 23:06:42.000000       1 leaderelection.go:248] attempting to acquire leader lease",
+				Time:      "2022-08-08T23:06:42Z",
+				Namespace: "kubesphere-system",
+				Pod:       "ks-controller-manager-7d9f6c8b9-tq4x8",
+				Container: "ks-controller-manager",
+			},
+			{
+				Log:       "W0808 23:06:41.000000       1 client_config.go:615] Neither --kubeconfig nor --master was specified.\n",
+				Time:      "2022-08-08T23:06:41Z",
+				Namespace: "kubesphere-system",
+				Pod:       "ks-apiserver-6f7b5c5d7-mk2jd",
+				Container: "ks-apiserver",
+			},
+		},
+	}
+	if diff := cmp.Diff(logs, expected); diff != "" {
+		t.Errorf("%T differ (-got, +want): %s", expected, diff)
+	}
+
+	rangeQuery := (*queries)[len(*queries)-1]
+	expectedQuery := url.Values{
+		"query":     {`{namespace=~"kubesphere-system"}`},
+		"start":     {"1660000000000000000"},
+		"end":       {"1660000200000000001"},
+		"limit":     {"1000"},
+		"direction": {"backward"},
+	}
+	if diff := cmp.Diff(rangeQuery, expectedQuery); diff != "" {
+		t.Errorf("%T differ (-got, +want): %s", expectedQuery, diff)
+	}
+
+	// the total is cached while paging through the logs
+	instantQueries := func() int {
+		var n int
+		for _, query := range *queries {
+			if query.Has("time") {
+				n++
+			}
+		}
+		return n
+	}
+	counted := instantQueries()
+	logs, err = c.SearchLogs(sf, 3, 2, "desc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if logs.Total != 4 {
+		t.Errorf("expected the cached total 4, got %d", logs.Total)
+	}
+	if n := instantQueries(); n != counted {
+		t.Errorf("expected the logs not to be counted again, got %d more queries", n-counted)
+	}
+}
+
+func TestExportLogs(t *testing.T) {
+	srv, _ := mockLokiService(t)
+	defer srv.Close()
+
+	c := newTestClient(srv.URL)
+	c.exportLogsLimit = 3
+	var buf bytes.Buffer
+	err := c.ExportLogs(logging.SearchFilter{
+		NamespaceFilter: map[string]*time.Time{"kubesphere-system": nil},
+		Starttime:       time.Unix(1660000000, 0),
+		Endtime:         time.Unix(1660000200, 0),
+	}, &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `I0808 23:06:43.000000       1 apiserver.go:428] Start listening on :9090
+I0808 23:06:42.000000       1 leaderelection.go:248] attempting to acquire leader lease
+W0808 23:06:41.000000       1 client_config.go:615] Neither --kubeconfig nor --master was specified.
+`
+	if diff := cmp.Diff(buf.String(), expected); diff != "" {
+		t.Errorf("%T differ (-got, +want): %s", expected, diff)
+	}
+}
+
+func TestPager(t *testing.T) {
+	// the first page ends in the middle of the entries at the same timestamp
+	var pages [][]string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		end := r.URL.Query().Get("end")
+		pages = append(pages, []string{r.URL.Query().Get("start"), end})
+		var values []string
+		switch end {
+		case "3":
+			for i := 0; i < pageSize-1; i++ {
+				values = append(values, `["2","a"]`)
+			}
+			values = append(values, `["1","b"]`)
+		case "2":
+			values = append(values, `["1","b"]`, `["1","c"]`, `["0","d"]`)
+		}
+		_, _ = w.Write([]byte(`{"status":"success","data":{"resultType":"streams","result":[{"stream":{},"values":[` +
+			strings.Join(values, ",") + `]}]}}`))
+	}))
+	defer srv.Close()
+
+	c := newTestClient(srv.URL)
+	p := newPager(c, stream{query: "{}", start: time.Unix(0, 0), end: time.Unix(0, 2)}, directionBackward)
+	var lines []string
+	for {
+		e, err := p.peek()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if e == nil {
+			break
+		}
+		lines = append(lines, e.line)
+		p.pop()
+	}
+
+	if diff := cmp.Diff(pages, [][]string{{"0", "3"}, {"0", "2"}}); diff != "" {
+		t.Errorf("pages differ (-got, +want): %s", diff)
+	}
+	if diff := cmp.Diff(strings.Join(lines, ""), strings.Repeat("a", pageSize-1)+"bcd"); diff != "" {
+		t.Errorf("lines differ (-got, +want): %s", diff)
+	}
+}
+
+func newTestClient(host string) *client {
+	c, _ := NewClient(&logging.Options{
+		Host:            host,
+		BasicAuth:       true,
+		Username:        "admin",
+		Password:        "P@88w0rd",
+		ExportLogsLimit: 10000,
+		Loki:            &logging.LokiOptions{TenantID: "kubesphere"},
+	})
+	return c.(*client)
+}
+
+// mockLokiService serves the recorded responses and records the queries received.
+func mockLokiService(t *testing.T) (*httptest.Server, *[]url.Values) {
+	var mu sync.Mutex
+	var queries []url.Values
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(tenantHeader) != "kubesphere" {
+			t.Errorf("unexpected tenant %s", r.Header.Get(tenantHeader))
+		}
+		if username, password, ok := r.BasicAuth(); !ok || username != "admin" || password != "P@88w0rd" {
+			t.Error("unexpected basic auth")
+		}
+
+		mu.Lock()
+		queries = append(queries, r.URL.Query())
+		mu.Unlock()
+
+		query := r.URL.Query().Get("query")
+		var file string
+		switch {
+		case r.URL.Path == queryPath && strings.HasPrefix(query, "count("):
+			file = "query_containers.json"
+		case r.URL.Path == queryPath:
+			file = "query_logs.json"
+		case r.URL.Path == queryRangePath && strings.HasPrefix(query, "sum("):
+			file = "query_range_matrix.json"
+		case r.URL.Path == queryRangePath:
+			file = "query_range_streams.json"
+		default:
+			http.NotFound(w, r)
+			return
+		}
+		body, err := os.ReadFile("./testdata/" + file)
+		if err != nil {
+			t.Error(err)
+		}
+		_, _ = w.Write(body)
+	}))
+	return srv, &queries
+}
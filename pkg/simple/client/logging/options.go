@@ -17,6 +17,8 @@ limitations under the License.
 package logging
 
 import (
+	"fmt"
+
 	"github.com/spf13/pflag"
 
 	"kubesphere.io/kubesphere/pkg/utils/reflectutils"
@@ -24,9 +26,14 @@ import (
 
 const (
 	exportLogsLimitDefault = 100000
+
+	BackendElasticsearch = "elasticsearch"
+	BackendLoki          = "loki"
 )
 
 type Options struct {
+	// The log store, elasticsearch or loki, defaults to elasticsearch.
+	Backend         string `json:"backend,omitempty" yaml:"backend,omitempty"`
 	Host            string `json:"host" yaml:"host"`
 	BasicAuth       bool   `json:"basicAuth" yaml:"basicAuth"`
 	Username        string `json:"username" yaml:"username"`
@@ -34,6 +41,18 @@ type Options struct {
 	IndexPrefix     string `json:"indexPrefix,omitempty" yaml:"indexPrefix,omitempty"`
 	Version         string `json:"version" yaml:"version"`
 	ExportLogsLimit int    `json:"exportLogsLimit" yaml:"exportLogsLimit"`
+	// Loki options, only used when the backend is loki.
+	Loki *LokiOptions `json:"loki,omitempty" yaml:"loki,omitempty"`
+}
+
+type LokiOptions struct {
+	// The tenant sent in the X-Scope-OrgID header when Loki runs in multi-tenant mode.
+	TenantID string `json:"tenantID,omitempty" yaml:"tenantID,omitempty"`
+	// The stream labels of the namespace, pod and container,
+	// defaults to namespace, pod and container as set by promtail.
+	NamespaceLabel string `json:"namespaceLabel,omitempty" yaml:"namespaceLabel,omitempty"`
+	PodLabel       string `json:"podLabel,omitempty" yaml:"podLabel,omitempty"`
+	ContainerLabel string `json:"containerLabel,omitempty" yaml:"containerLabel,omitempty"`
 }
 
 func NewLoggingOptions() *Options {
@@ -53,10 +72,17 @@ func (s *Options) ApplyTo(options *Options) {
 
 func (s *Options) Validate() []error {
 	errs := make([]error, 0)
+	if s.Backend != "" && s.Backend != BackendElasticsearch && s.Backend != BackendLoki {
+		errs = append(errs, fmt.Errorf("invalid logging backend %s", s.Backend))
+	}
 	return errs
 }
 
 func (s *Options) AddFlags(fs *pflag.FlagSet, c *Options) {
+	fs.StringVar(&s.Backend, "logging-backend", c.Backend, ""+
+		"The log store, elasticsearch or loki. For loki, logging-elasticsearch-host is the url of loki, "+
+		"and the basic auth options are also used to connect to loki.")
+
 	fs.StringVar(&s.Host, "logging-elasticsearch-host", c.Host, ""+
 		"Elasticsearch logging service host. KubeSphere is using elastic as log store, "+
 		"if this filed left blank, KubeSphere will use kubernetes builtin log API instead, and"+
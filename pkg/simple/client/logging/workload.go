@@ -0,0 +1,52 @@
+/*
+Copyright 2022 KubeSphere Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logging
+
+import "fmt"
+
+const (
+	podNameMaxLength          = 63
+	podNameSuffixLength       = 6  // 5 characters + 1 hyphen
+	replicaSetSuffixMaxLength = 11 // max 10 characters + 1 hyphen
+)
+
+// PodNameRegex returns the regular expression matching the names of the pods of the workload,
+// it is supported by both Elasticsearch and RE2.
+func PodNameRegex(workloadName string) string {
+	var regex string
+	if len(workloadName) <= podNameMaxLength-replicaSetSuffixMaxLength-podNameSuffixLength {
+		// match deployment pods, eg. <deploy>-579dfbcddd-24znw
+		// replicaset rand string is limited to vowels
+		// https://github.com/kubernetes/kubernetes/blob/master/staging/src/k8s.io/apimachinery/pkg/util/rand/rand.go#L83
+		regex += workloadName + "-[bcdfghjklmnpqrstvwxz2456789]{1,10}-[a-z0-9]{5}|"
+		// match statefulset pods, eg. <sts>-0
+		regex += workloadName + "-[0-9]+|"
+		// match pods of daemonset or job, eg. <ds>-29tdk, <job>-5xqvl
+		regex += workloadName + "-[a-z0-9]{5}"
+	} else if len(workloadName) <= podNameMaxLength-podNameSuffixLength {
+		replicaSetSuffixLength := podNameMaxLength - podNameSuffixLength - len(workloadName)
+		regex += fmt.Sprintf("%s%d%s", workloadName+"-[bcdfghjklmnpqrstvwxz2456789]{", replicaSetSuffixLength, "}[a-z0-9]{5}|")
+		regex += workloadName + "-[0-9]+|"
+		regex += workloadName + "-[a-z0-9]{5}"
+	} else {
+		// Rand suffix may overwrites the workload name if the name is too long
+		// This won't happen for StatefulSet because long name will cause ReplicaSet fails during StatefulSet creation.
+		regex += workloadName[:podNameMaxLength-podNameSuffixLength+1] + "[a-z0-9]{5}|"
+		regex += workloadName + "-[0-9]+"
+	}
+	return regex
+}
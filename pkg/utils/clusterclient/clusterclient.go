@@ -22,6 +22,7 @@ import (
 	"reflect"
 	"sync"
 
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/cache"
@@ -54,6 +55,7 @@ type ClusterClients interface {
 	IsClusterReady(cluster *clusterv1alpha1.Cluster) bool
 	GetClusterKubeconfig(string) (string, error)
 	Get(string) (*clusterv1alpha1.Cluster, error)
+	List() ([]*clusterv1alpha1.Cluster, error)
 	GetInnerCluster(string) *innerCluster
 	GetKubernetesClientSet(string) (*kubernetes.Clientset, error)
 	GetKubeSphereClientSet(string) (*kubesphere.Clientset, error)
@@ -143,6 +145,10 @@ func (c *clusterClients) Get(clusterName string) (*clusterv1alpha1.Cluster, erro
 	return c.clusterLister.Get(clusterName)
 }
 
+func (c *clusterClients) List() ([]*clusterv1alpha1.Cluster, error) {
+	return c.clusterLister.List(labels.Everything())
+}
+
 func (c *clusterClients) GetClusterKubeconfig(clusterName string) (string, error) {
 	cluster, err := c.clusterLister.Get(clusterName)
 	if err != nil {
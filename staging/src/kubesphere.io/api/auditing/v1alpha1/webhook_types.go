@@ -145,6 +145,40 @@ type WebhookSpec struct {
 	AuditLevel Level `json:"auditLevel" protobuf:"bytes,1,opt,name=auditLevel"`
 	// K8s auditing is enabled or not.
 	K8sAuditingEnabled bool `json:"k8sAuditingEnabled,omitempty" protobuf:"bytes,8,opt,name=priority"`
+	// Redaction rules replacing the sensitive fields of the request and response objects recorded
+	// in the audit events. They are applied in addition to the built-in rules, which redact the data
+	// of secrets, the passwords of users and the tokens.
+	// +optional
+	RedactionRules []RedactionRule `json:"redactionRules,omitempty"`
+}
+
+type RedactionMethod string
+
+const (
+	// RedactionMethodMask replaces the field with "***".
+	RedactionMethodMask RedactionMethod = "Mask"
+	// RedactionMethodHash replaces the field with the SHA-256 hash of its JSON encoding,
+	// so that changes of the field can still be told apart.
+	RedactionMethodHash RedactionMethod = "Hash"
+)
+
+// RedactionRule redacts fields of the request and response objects of the matching events.
+type RedactionRule struct {
+	// Resources the rule applies to, e.g. secrets, or users/kubeconfig for the subresource only.
+	// The rule applies to all resources if empty.
+	// +optional
+	Resources []string `json:"resources,omitempty"`
+	// Verbs the rule applies to, e.g. create. The rule applies to all verbs if empty.
+	// +optional
+	Verbs []string `json:"verbs,omitempty"`
+	// JSONPath expressions of the fields to redact, e.g. {.data}, .items[*].data or ..token.
+	// Children, wildcards, array indices, quoted keys and recursive descent are supported,
+	// $ redacts the whole object, including the object which is not JSON.
+	Paths []string `json:"paths"`
+	// Method of redaction, Mask or Hash.
+	// default: Mask
+	// +optional
+	Method RedactionMethod `json:"method,omitempty"`
 }
 
 type WebhookClientConfig struct {
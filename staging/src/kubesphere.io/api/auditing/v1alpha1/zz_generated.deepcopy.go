@@ -113,6 +113,36 @@ func (in *Receiver) DeepCopy() *Receiver {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RedactionRule) DeepCopyInto(out *RedactionRule) {
+	*out = *in
+	if in.Resources != nil {
+		in, out := &in.Resources, &out.Resources
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Verbs != nil {
+		in, out := &in.Verbs, &out.Verbs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Paths != nil {
+		in, out := &in.Paths, &out.Paths
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RedactionRule.
+func (in *RedactionRule) DeepCopy() *RedactionRule {
+	if in == nil {
+		return nil
+	}
+	out := new(RedactionRule)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Rule) DeepCopyInto(out *Rule) {
 	*out = *in
@@ -322,6 +352,13 @@ func (in *WebhookSpec) DeepCopyInto(out *WebhookSpec) {
 		*out = new(AuditSinkPolicy)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.RedactionRules != nil {
+		in, out := &in.RedactionRules, &out.RedactionRules
+		*out = make([]RedactionRule, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WebhookSpec.
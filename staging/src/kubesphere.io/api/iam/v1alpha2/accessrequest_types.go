@@ -0,0 +1,106 @@
+/*
+Copyright 2022 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha2
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	ResourceKindAccessRequest      = "AccessRequest"
+	ResourcesSingularAccessRequest = "accessrequest"
+	ResourcesPluralAccessRequest   = "accessrequests"
+
+	// AccessRequestReferenceLabel references the access request which grants the role binding.
+	AccessRequestReferenceLabel = "iam.kubesphere.io/accessrequest-ref"
+	// ExpiresAtAnnotation is the time in RFC3339 when the role binding is revoked.
+	ExpiresAtAnnotation = "iam.kubesphere.io/expires-at"
+)
+
+type AccessRequestState string
+
+const (
+	// AccessRequestPending means the access request is waiting for approval.
+	AccessRequestPending AccessRequestState = "Pending"
+	// AccessRequestApproved means the access request is approved, the role binding will be created.
+	AccessRequestApproved AccessRequestState = "Approved"
+	// AccessRequestDenied means the access request is denied.
+	AccessRequestDenied AccessRequestState = "Denied"
+	// AccessRequestActive means the role binding is created and not expired yet.
+	AccessRequestActive AccessRequestState = "Active"
+	// AccessRequestExpired means the role binding is revoked.
+	AccessRequestExpired AccessRequestState = "Expired"
+)
+
+// AccessRequestSpec defines the temporary role binding requested.
+// The role is a cluster role if neither the workspace nor the namespace is specified,
+// a workspace role if the workspace is specified, otherwise a role in the namespace.
+type AccessRequestSpec struct {
+	// The user who requests the access.
+	User      string `json:"user"`
+	Workspace string `json:"workspace,omitempty"`
+	Namespace string `json:"namespace,omitempty"`
+	// The name of the role to be bound.
+	Role string `json:"role"`
+	// How long the access is granted after the approval.
+	Duration metav1.Duration `json:"duration"`
+	// Why the access is required.
+	Reason string `json:"reason,omitempty"`
+}
+
+type AccessRequestStatus struct {
+	// +optional
+	State AccessRequestState `json:"state,omitempty"`
+	// The user who approves or denies the access request.
+	// +optional
+	Approver string `json:"approver,omitempty"`
+	// +optional
+	ApprovedAt *metav1.Time `json:"approvedAt,omitempty"`
+	// +optional
+	ExpiresAt *metav1.Time `json:"expiresAt,omitempty"`
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="User",type="string",JSONPath=".spec.user"
+// +kubebuilder:printcolumn:name="Role",type="string",JSONPath=".spec.role"
+// +kubebuilder:printcolumn:name="State",type="string",JSONPath=".status.state"
+// +kubebuilder:printcolumn:name="Expires",type="date",JSONPath=".status.expiresAt"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+// +kubebuilder:resource:categories="iam",scope="Cluster"
+
+// AccessRequest is the Schema for the accessrequests API, a request for temporary access
+// which is granted once approved and revoked when it expires.
+type AccessRequest struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec AccessRequestSpec `json:"spec"`
+	// +optional
+	Status AccessRequestStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// AccessRequestList contains a list of AccessRequest
+type AccessRequestList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []AccessRequest `json:"items"`
+}
@@ -54,6 +54,7 @@ const (
 	ResourcesSingularRole                 = "role"
 	ResourcesPluralRole                   = "roles"
 	RegoOverrideAnnotation                = "iam.kubesphere.io/rego-override"
+	DenyRulesAnnotation                   = "iam.kubesphere.io/deny-rules"
 	AggregationRolesAnnotation            = "iam.kubesphere.io/aggregation-roles"
 	GlobalRoleAnnotation                  = "iam.kubesphere.io/globalrole"
 	WorkspaceRoleAnnotation               = "iam.kubesphere.io/workspacerole"
@@ -61,6 +62,10 @@ const (
 	GrantedClustersAnnotation             = "iam.kubesphere.io/granted-clusters"
 	UninitializedAnnotation               = "iam.kubesphere.io/uninitialized"
 	LastPasswordChangeTimeAnnotation      = "iam.kubesphere.io/last-password-change-time"
+	TOTPSecretAnnotation                  = "iam.kubesphere.io/totp-secret"
+	TOTPEnabledAnnotation                 = "iam.kubesphere.io/totp-enabled"
+	TOTPRecoveryCodesAnnotation           = "iam.kubesphere.io/totp-recovery-codes"
+	PasswordHistoryAnnotation             = "iam.kubesphere.io/password-history"
 	RoleAnnotation                        = "iam.kubesphere.io/role"
 	RoleTemplateLabel                     = "iam.kubesphere.io/role-template"
 	ScopeLabelFormat                      = "scope.kubesphere.io/%s"
@@ -75,6 +80,11 @@ const (
 	ExtraUsername                         = "username"
 	ExtraDisplayName                      = "displayName"
 	ExtraUninitialized                    = "uninitialized"
+	ExtraAccessToken                      = "accesstoken"
+	ExtraAccessTokenVerbs                 = "accesstoken.verbs"
+	ExtraAccessTokenAPIGroups             = "accesstoken.apigroups"
+	ExtraAccessTokenWorkspaces            = "accesstoken.workspaces"
+	ExtraPasswordExpired                  = "passwordexpired"
 	InGroup                               = "ingroup"
 	NotInGroup                            = "notingroup"
 	AggregateTo                           = "aggregateTo"
@@ -157,6 +167,8 @@ const (
 	UserDisabled UserState = "Disabled"
 	// UserAuthLimitExceeded means restrict user login.
 	UserAuthLimitExceeded UserState = "AuthLimitExceeded"
+	// UserPasswordExpired means the user must change the password on next login.
+	UserPasswordExpired UserState = "PasswordExpired"
 
 	AuthenticatedSuccessfully = "authenticated successfully"
 )
@@ -186,6 +198,18 @@ type UserList struct {
 	Items           []User `json:"items"`
 }
 
+// DenyRule denies the requests matching the PolicyRule. The DenyRules of the GlobalRoles and WorkspaceRoles,
+// or the JSON encoded DenyRules in the DenyRulesAnnotation of the ClusterRoles and Roles, are evaluated
+// before any rule allowing the request.
+type DenyRule struct {
+	rbacv1.PolicyRule `json:",inline"`
+
+	// ObjectSelector restricts the rule to the requests of the named objects whose labels match the selector.
+	// The labels are only resolved for namespaces, the rule applies to any object of the other resources.
+	// +optional
+	ObjectSelector *metav1.LabelSelector `json:"objectSelector,omitempty"`
+}
+
 // +genclient
 // +genclient:nonNamespaced
 // +kubebuilder:object:root=true
@@ -199,6 +223,11 @@ type GlobalRole struct {
 	// Rules holds all the PolicyRules for this GlobalRole
 	// +optional
 	Rules []rbacv1.PolicyRule `json:"rules" protobuf:"bytes,2,rep,name=rules"`
+
+	// DenyRules holds the rules denied for this GlobalRole, a request matching any deny rule of the roles
+	// bound to the user is denied regardless of the rules allowing it in any scope
+	// +optional
+	DenyRules []DenyRule `json:"denyRules,omitempty"`
 }
 
 // +kubebuilder:object:root=true
@@ -256,6 +285,11 @@ type WorkspaceRole struct {
 	// Rules holds all the PolicyRules for this WorkspaceRole
 	// +optional
 	Rules []rbacv1.PolicyRule `json:"rules" protobuf:"bytes,2,rep,name=rules"`
+
+	// DenyRules holds the rules denied for this WorkspaceRole, a request matching any deny rule of the roles
+	// bound to the user is denied regardless of the rules allowing it in any scope
+	// +optional
+	DenyRules []DenyRule `json:"denyRules,omitempty"`
 }
 
 // +kubebuilder:object:root=true
@@ -348,6 +382,9 @@ type LoginRecordSpec struct {
 	Success bool `json:"success"`
 	// States failed login attempt reason
 	Reason string `json:"reason"`
+	// Whether the second factor is verified in the login attempt
+	// +optional
+	MultiFactor bool `json:"multiFactor,omitempty"`
 }
 
 type LoginType string
@@ -0,0 +1,96 @@
+/*
+Copyright 2022 The KubeSphere Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// audit-verify checks the hash chains of an exported stream of auditing events,
+// reporting missing and modified events and invalid checkpoint signatures.
+//
+//	audit-verify --public-key=checkpoint.pub audit.log
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"k8s.io/client-go/util/keyutil"
+
+	"kubesphere.io/kubesphere/pkg/apiserver/auditing"
+	auditv1alpha1 "kubesphere.io/kubesphere/pkg/apiserver/auditing/v1alpha1"
+)
+
+var publicKeyFile string
+
+func init() {
+	flag.StringVar(&publicKeyFile, "public-key", "", "The PEM encoded public key verifying the checkpoint signatures, "+
+		"the signatures are not verified if left blank.")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [--public-key=FILE] [FILE...]\n\n"+
+			"Reads the auditing events from the files, or from the standard input if no file is given.\n\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+}
+
+func main() {
+	flag.Parse()
+
+	var publicKeys []interface{}
+	if publicKeyFile != "" {
+		keys, err := keyutil.PublicKeysFromFile(publicKeyFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to load public key: %s\n", err)
+			os.Exit(2)
+		}
+		publicKeys = keys
+	}
+
+	var events []auditv1alpha1.Event
+	readEvents := func(name string, r io.Reader) {
+		items, err := auditing.ReadEvents(r)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to read events from %s: %s\n", name, err)
+			os.Exit(2)
+		}
+		events = append(events, items...)
+	}
+	if flag.NArg() == 0 {
+		readEvents("stdin", os.Stdin)
+	}
+	for _, name := range flag.Args() {
+		f, err := os.Open(name)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to open %s: %s\n", name, err)
+			os.Exit(2)
+		}
+		readEvents(name, f)
+		f.Close()
+	}
+
+	report := auditing.VerifyChains(events, publicKeys)
+	for _, chain := range report.Chains {
+		fmt.Printf("chain %s: %d events from %d to %d, %d checkpoints, last valid checkpoint at %d\n",
+			chain.ID, chain.Events, chain.FirstSequence, chain.LastSequence, chain.Checkpoints, chain.LastCheckpoint)
+	}
+	if report.Unchained > 0 {
+		fmt.Printf("%d events are not chained\n", report.Unchained)
+	}
+	for _, problem := range report.Problems {
+		fmt.Println(problem)
+	}
+	if len(report.Problems) > 0 {
+		os.Exit(1)
+	}
+}